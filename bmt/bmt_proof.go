@@ -0,0 +1,101 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package bmt
+
+import (
+	"bytes"
+	"errors"
+)
+
+/*
+Inclusion proofs are given for full chunks, i.e. chunks of a power of two
+segments, whose BMT is balanced. The proof of a segment consists of its sibling
+segment followed by the sibling node of every level above, up to but not
+including the root:
+
+	root = H(... H(H(segment|sibling segment)|sibling node 1) ... |sibling node n)
+
+where the operands are swapped whenever the proved node is a right child.
+*/
+
+var (
+	errProofChunkSize = errors.New("bmt: proofs require a full chunk of a power of two segments")
+	errProofIndex     = errors.New("bmt: segment index out of range")
+)
+
+// Proof returns the BMT inclusion proof of segment i of the full chunk data.
+func Proof(hasher BaseHasher, data []byte, i int) ([][]byte, error) {
+	h := hasher()
+	size := h.Size()
+	count := len(data) / size
+	if count < 2 || count&(count-1) != 0 || count*size != len(data) {
+		return nil, errProofChunkSize
+	}
+	if i < 0 || i >= count {
+		return nil, errProofIndex
+	}
+	level := make([][]byte, count)
+	for j := range level {
+		level[j] = data[j*size : (j+1)*size]
+	}
+	var proof [][]byte
+	for ; len(level) > 1; i /= 2 {
+		proof = append(proof, level[i^1])
+		next := make([][]byte, len(level)/2)
+		for j := range next {
+			h.Reset()
+			h.Write(level[2*j])
+			h.Write(level[2*j+1])
+			next[j] = h.Sum(nil)
+		}
+		level = next
+	}
+	return proof, nil
+}
+
+// ProofRoot returns the BMT root of the chunk in which segment is segment i
+// according to proof, nil if the proof is malformed.
+func ProofRoot(hasher BaseHasher, segment []byte, i int, proof [][]byte) []byte {
+	h := hasher()
+	if len(segment) != h.Size() || len(proof) == 0 || len(proof) >= 64 || i < 0 || i>>uint(len(proof)) != 0 {
+		return nil
+	}
+	node := segment
+	for _, sibling := range proof {
+		if len(sibling) != h.Size() {
+			return nil
+		}
+		h.Reset()
+		if i%2 == 0 {
+			h.Write(node)
+			h.Write(sibling)
+		} else {
+			h.Write(sibling)
+			h.Write(node)
+		}
+		node = h.Sum(nil)
+		i /= 2
+	}
+	return node
+}
+
+// VerifyProof returns whether proof proves that segment is segment i of the
+// chunk with the BMT root.
+func VerifyProof(hasher BaseHasher, root []byte, segment []byte, i int, proof [][]byte) bool {
+	proved := ProofRoot(hasher, segment, i, proof)
+	return proved != nil && bytes.Equal(proved, root)
+}
@@ -0,0 +1,68 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package bmt
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/GenaroNetwork/GenaroCore/crypto/sha3"
+)
+
+// Tests that the inclusion proofs of all segments of a full chunk prove the
+// BMT hash computed by the hashers, and that tampered proofs are rejected.
+func TestProof(t *testing.T) {
+	hasher := sha3.NewKeccak256
+	count := DefaultSegmentCount
+	data := make([]byte, count*32)
+	io.ReadFull(testDataReader(len(data)), data)
+
+	root := NewRefHasher(hasher, count).Hash(data)
+	pool := NewTreePool(hasher, count, 1)
+	defer pool.Drain(0)
+	bmt := New(pool)
+	bmt.Reset()
+	bmt.Write(data)
+	if sum := bmt.Sum(nil); !bytes.Equal(sum, root) {
+		t.Fatalf("hasher mismatch: have %x, want %x", sum, root)
+	}
+	for i := 0; i < count; i++ {
+		proof, err := Proof(hasher, data, i)
+		if err != nil {
+			t.Fatalf("segment %d: failed to create proof: %v", i, err)
+		}
+		segment := data[i*32 : (i+1)*32]
+		if !VerifyProof(hasher, root, segment, i, proof) {
+			t.Fatalf("segment %d: valid proof rejected", i)
+		}
+		if VerifyProof(hasher, root, segment, i^1, proof) {
+			t.Fatalf("segment %d: proof accepted for the wrong index", i)
+		}
+		tampered := append([]byte{}, segment...)
+		tampered[0]++
+		if VerifyProof(hasher, root, tampered, i, proof) {
+			t.Fatalf("segment %d: proof accepted for tampered segment", i)
+		}
+	}
+	if _, err := Proof(hasher, data[:100], 0); err != errProofChunkSize {
+		t.Errorf("partial chunk: have %v, want %v", err, errProofChunkSize)
+	}
+	if _, err := Proof(hasher, data, count); err != errProofIndex {
+		t.Errorf("index out of range: have %v, want %v", err, errProofIndex)
+	}
+}
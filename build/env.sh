@@ -22,6 +22,10 @@ fi
 GOPATH="$workspace"
 export GOPATH
 
+# The workspace is a GOPATH one, the vendor directory holds the dependencies.
+GO111MODULE=off
+export GO111MODULE
+
 # Run the command inside the workspace.
 cd "$ethdir/GenaroCore"
 PWD="$ethdir/GenaroCore"
@@ -299,7 +299,7 @@ func main() {
 	extra := new(genaro.ExtraData)
 	var candidateInfos state.CandidateInfos
 	candidateInfos = GenesisAllocToCandidateInfos(genesis.Alloc)
-	extra.CommitteeRank, extra.Proportion = state.RankWithLenth(candidateInfos, int(genaroConfig.Genaro.CommitteeMaxSize), uint64(common.CommitteeMinStake))
+	extra.CommitteeRank, extra.Proportion = genaro.ElectionStrategyAt(genaroConfig.Genaro, common.Big0).Elect(candidateInfos, int(genaroConfig.Genaro.CommitteeMaxSize), uint64(common.CommitteeMinStake))
 	extraByte, _ := json.Marshal(extra)
 	genesis.ExtraData = extraByte
 
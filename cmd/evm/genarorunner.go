@@ -0,0 +1,210 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/GenaroNetwork/GenaroCore/common"
+	"github.com/GenaroNetwork/GenaroCore/common/hexutil"
+	"github.com/GenaroNetwork/GenaroCore/core"
+	"github.com/GenaroNetwork/GenaroCore/core/types"
+	"github.com/GenaroNetwork/GenaroCore/core/vm"
+	"github.com/GenaroNetwork/GenaroCore/log"
+	"github.com/GenaroNetwork/GenaroCore/rlp"
+	"github.com/GenaroNetwork/GenaroCore/rpc"
+	"github.com/GenaroNetwork/GenaroCore/tests"
+
+	cli "gopkg.in/urfave/cli.v1"
+)
+
+var (
+	RPCFlag = cli.StringFlag{
+		Name:  "rpc",
+		Usage: "endpoint of the node to record from",
+		Value: "http://localhost:8545",
+	}
+	LastBlockFlag = cli.Uint64Flag{
+		Name:  "last",
+		Usage: "last block to record (0 = current head)",
+	}
+	AccountsFlag = cli.StringFlag{
+		Name:  "accounts",
+		Usage: "comma separated list of additional accounts to record in the post state",
+	}
+	NameFlag = cli.StringFlag{
+		Name:  "name",
+		Usage: "name of the recorded test",
+		Value: "recorded",
+	}
+)
+
+var genaroTestCommand = cli.Command{
+	Action:    genaroTestCmd,
+	Name:      "genarotest",
+	Usage:     "executes the given Genaro state and block tests",
+	ArgsUsage: "<file>",
+	Subcommands: []cli.Command{
+		{
+			Action:    genaroRecordCmd,
+			Name:      "record",
+			Usage:     "records a Genaro block test from a running node",
+			ArgsUsage: "<genesis.json>",
+			Flags:     []cli.Flag{RPCFlag, LastBlockFlag, AccountsFlag, NameFlag},
+			Description: `
+The record command fetches the blocks of a chain started from the given genesis
+from a node with the eth and debug APIs enabled, and prints them together with
+the post state as a Genaro block test.`,
+		},
+	},
+}
+
+func genaroTestCmd(ctx *cli.Context) error {
+	if len(ctx.Args().First()) == 0 {
+		return errors.New("path-to-test argument required")
+	}
+	// Configure the go-ethereum logger
+	glogger := log.NewGlogHandler(log.StreamHandler(os.Stderr, log.TerminalFormat(false)))
+	glogger.Verbosity(log.Lvl(ctx.GlobalInt(VerbosityFlag.Name)))
+	log.Root().SetHandler(glogger)
+
+	// Load the test content from the input file
+	src, err := ioutil.ReadFile(ctx.Args().First())
+	if err != nil {
+		return err
+	}
+	var tests map[string]tests.GenaroTest
+	if err = json.Unmarshal(src, &tests); err != nil {
+		return err
+	}
+	// Iterate over all the tests, run them and aggregate the results
+	results := make([]StatetestResult, 0, len(tests))
+	for key, test := range tests {
+		result := &StatetestResult{Name: key, Fork: "Genaro", Pass: true}
+		state, err := test.Run(vm.Config{})
+		if err != nil {
+			// Test failed, mark as so and dump any state to aid debugging
+			result.Pass, result.Error = false, err.Error()
+			if ctx.GlobalBool(DumpFlag.Name) && state != nil {
+				dump := state.RawDump()
+				result.State = &dump
+			}
+		}
+		results = append(results, *result)
+	}
+	out, _ := json.MarshalIndent(results, "", "  ")
+	fmt.Println(string(out))
+	return nil
+}
+
+func genaroRecordCmd(ctx *cli.Context) error {
+	if len(ctx.Args().First()) == 0 {
+		return errors.New("path-to-genesis argument required")
+	}
+	src, err := ioutil.ReadFile(ctx.Args().First())
+	if err != nil {
+		return err
+	}
+	genesis := new(core.Genesis)
+	if err := json.Unmarshal(src, genesis); err != nil {
+		return err
+	}
+	var accounts []common.Address
+	if list := ctx.String(AccountsFlag.Name); list != "" {
+		for _, account := range strings.Split(list, ",") {
+			if !common.IsHexAddress(account) {
+				return fmt.Errorf("invalid account %q", account)
+			}
+			accounts = append(accounts, common.HexToAddress(account))
+		}
+	}
+	client, err := rpc.Dial(ctx.String(RPCFlag.Name))
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	last := ctx.Uint64(LastBlockFlag.Name)
+	if last == 0 {
+		var head hexutil.Uint64
+		if err := client.Call(&head, "eth_blockNumber"); err != nil {
+			return err
+		}
+		last = uint64(head)
+	}
+	test, err := tests.RecordGenaroBlockTest(rpcSource{client}, genesis, last, accounts)
+	if err != nil {
+		return err
+	}
+	out, err := json.MarshalIndent(map[string]*tests.GenaroTest{ctx.String(NameFlag.Name): test}, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(out))
+	return nil
+}
+
+// rpcSource records Genaro block tests from a node over RPC.
+type rpcSource struct {
+	client *rpc.Client
+}
+
+func (s rpcSource) BlockByNumber(number uint64) (*types.Block, error) {
+	var enc string
+	if err := s.client.Call(&enc, "debug_getBlockRlp", number); err != nil {
+		return nil, err
+	}
+	blob, err := hex.DecodeString(enc)
+	if err != nil {
+		return nil, err
+	}
+	block := new(types.Block)
+	if err := rlp.DecodeBytes(blob, block); err != nil {
+		return nil, err
+	}
+	return block, nil
+}
+
+func (s rpcSource) Account(addr common.Address, number uint64) (tests.GenaroAccount, error) {
+	var (
+		blockNr  = hexutil.EncodeUint64(number)
+		balance  hexutil.Big
+		nonce    hexutil.Uint64
+		code     hexutil.Bytes
+		codeHash string
+	)
+	if err := s.client.Call(&balance, "eth_getBalance", addr, blockNr); err != nil {
+		return tests.GenaroAccount{}, err
+	}
+	if err := s.client.Call(&nonce, "eth_getTransactionCount", addr, blockNr); err != nil {
+		return tests.GenaroAccount{}, err
+	}
+	if err := s.client.Call(&code, "eth_getCode", addr, blockNr); err != nil {
+		return tests.GenaroAccount{}, err
+	}
+	if err := s.client.Call(&codeHash, "eth_getGenaroCodeHash", addr, blockNr); err != nil {
+		return tests.GenaroAccount{}, err
+	}
+	hash, _ := hexutil.Decode(codeHash)
+	return tests.NewGenaroAccount(balance.ToInt(), uint64(nonce), code, hash), nil
+}
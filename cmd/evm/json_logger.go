@@ -23,6 +23,7 @@ import (
 	"time"
 
 	"github.com/GenaroNetwork/GenaroCore/common"
+	"github.com/GenaroNetwork/GenaroCore/common/hexutil"
 	"github.com/GenaroNetwork/GenaroCore/common/math"
 	"github.com/GenaroNetwork/GenaroCore/core/vm"
 )
@@ -66,6 +67,17 @@ func (l *JSONLogger) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cos
 	return nil
 }
 
+// CaptureSpecialTx outputs the cost of a special transaction and the changes it
+// made to the state.
+func (l *JSONLogger) CaptureSpecialTx(cost *big.Int, errorCode uint64, diff map[common.Address]*accountDiff) error {
+	type specialLog struct {
+		SpecialCost *hexutil.Big                    `json:"specialCost"`
+		ErrorCode   uint64                          `json:"errorCode,omitempty"`
+		StateDiff   map[common.Address]*accountDiff `json:"stateDiff"`
+	}
+	return l.encoder.Encode(specialLog{(*hexutil.Big)(cost), errorCode, diff})
+}
+
 // CaptureEnd is triggered at end of execution.
 func (l *JSONLogger) CaptureEnd(output []byte, gasUsed uint64, t time.Duration, err error) error {
 	type endLog struct {
@@ -139,6 +139,8 @@ func init() {
 		disasmCommand,
 		runCommand,
 		stateTestCommand,
+		genaroTestCommand,
+		specialCommand,
 	}
 }
 
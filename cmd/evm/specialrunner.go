@@ -0,0 +1,231 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/GenaroNetwork/GenaroCore/cmd/utils"
+	"github.com/GenaroNetwork/GenaroCore/common"
+	"github.com/GenaroNetwork/GenaroCore/common/hexutil"
+	"github.com/GenaroNetwork/GenaroCore/common/math"
+	"github.com/GenaroNetwork/GenaroCore/core"
+	"github.com/GenaroNetwork/GenaroCore/core/state"
+	"github.com/GenaroNetwork/GenaroCore/core/types"
+	"github.com/GenaroNetwork/GenaroCore/core/vm"
+	"github.com/GenaroNetwork/GenaroCore/ethdb"
+	"github.com/GenaroNetwork/GenaroCore/log"
+	"github.com/GenaroNetwork/GenaroCore/params"
+	"github.com/GenaroNetwork/GenaroCore/tests"
+
+	cli "gopkg.in/urfave/cli.v1"
+)
+
+var specialCommand = cli.Command{
+	Action:    specialCmd,
+	Name:      "special",
+	Usage:     "executes a special transaction against a prestate",
+	ArgsUsage: "<prestate.json> <input>",
+	Description: `
+The special command sends a special transaction from --sender to the special
+sync address, with the given SpecialTxInput as data, and prints its special cost
+and the changes it made to the state. The input is either inline JSON or the
+path of a JSON file.
+
+The prestate holds the config, env and pre sections of a Genaro state test: the
+chain config, the block context and the accounts, system addresses included,
+with their Genaro data as plain JSON.`,
+}
+
+// specialPrestate is the state and block context a special transaction is
+// executed in.
+type specialPrestate struct {
+	Config *params.ChainConfig                    `json:"config"`
+	Env    specialEnv                             `json:"env"`
+	Pre    map[common.Address]tests.GenaroAccount `json:"pre"`
+}
+
+type specialEnv struct {
+	Coinbase   common.UnprefixedAddress `json:"currentCoinbase"`
+	Difficulty *math.HexOrDecimal256    `json:"currentDifficulty"`
+	GasLimit   math.HexOrDecimal64      `json:"currentGasLimit"`
+	Number     math.HexOrDecimal64      `json:"currentNumber"`
+	Timestamp  math.HexOrDecimal64      `json:"currentTimestamp"`
+}
+
+// accountDiff is the change of an account by a special transaction. Unchanged
+// fields are left out.
+type accountDiff struct {
+	Balance    *valueDiff            `json:"balance,omitempty"`
+	Nonce      *valueDiff            `json:"nonce,omitempty"`
+	GenaroData *valueDiff            `json:"genaroData,omitempty"`
+	Storage    map[string]*valueDiff `json:"storage,omitempty"`
+}
+
+type valueDiff struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+func specialCmd(ctx *cli.Context) error {
+	if ctx.NArg() != 2 {
+		return errors.New("prestate and input arguments required")
+	}
+	glogger := log.NewGlogHandler(log.StreamHandler(os.Stderr, log.TerminalFormat(false)))
+	glogger.Verbosity(log.Lvl(ctx.GlobalInt(VerbosityFlag.Name)))
+	log.Root().SetHandler(glogger)
+
+	src, err := ioutil.ReadFile(ctx.Args().Get(0))
+	if err != nil {
+		return err
+	}
+	var pre specialPrestate
+	if err := json.Unmarshal(src, &pre); err != nil {
+		return err
+	}
+	if pre.Config == nil || pre.Config.Genaro == nil {
+		return errors.New("prestate lacks a Genaro chain config")
+	}
+	input := []byte(ctx.Args().Get(1))
+	if !strings.HasPrefix(strings.TrimSpace(string(input)), "{") {
+		if input, err = ioutil.ReadFile(string(input)); err != nil {
+			return err
+		}
+	}
+	var s types.SpecialTxInput
+	if err := json.Unmarshal(input, &s); err != nil {
+		return err
+	}
+	buf := new(bytes.Buffer)
+	json.Compact(buf, input)
+	input = buf.Bytes()
+
+	statedb := tests.MakeGenaroPreState(ethdb.NewMemDatabase(), pre.Pre)
+	before := statedb.RawDump()
+
+	sender := common.StringToAddress("sender")
+	if ctx.GlobalString(SenderFlag.Name) != "" {
+		sender = common.HexToAddress(ctx.GlobalString(SenderFlag.Name))
+	}
+	var cost *big.Int
+	if s.Type != nil {
+		specialCost := s.SpecialCost(statedb.GetGenaroPrice(), vm.SpecialTxBuckets(s, statedb), pre.Config.Genaro, new(big.Int).SetUint64(uint64(pre.Env.Number)))
+		cost = &specialCost
+	}
+	header := &types.Header{
+		Coinbase:   common.Address(pre.Env.Coinbase),
+		Difficulty: new(big.Int),
+		GasLimit:   uint64(pre.Env.GasLimit),
+		Number:     new(big.Int).SetUint64(uint64(pre.Env.Number)),
+		Time:       new(big.Int).SetUint64(uint64(pre.Env.Timestamp)),
+	}
+	if pre.Env.Difficulty != nil {
+		header.Difficulty = (*big.Int)(pre.Env.Difficulty)
+	}
+	logger := NewJSONLogger(&vm.LogConfig{DisableMemory: ctx.GlobalBool(DisableMemoryFlag.Name), DisableStack: ctx.GlobalBool(DisableStackFlag.Name)}, os.Stdout)
+	gas := ctx.GlobalUint64(GasFlag.Name)
+	msg := types.NewMessage(sender, &common.SpecialSyncAddress, statedb.GetNonce(sender), utils.GlobalBig(ctx, ValueFlag.Name), gas, utils.GlobalBig(ctx, PriceFlag.Name), input, false)
+	context := core.NewEVMContext(msg, header, nil, &header.Coinbase)
+	context.GetHash = func(uint64) common.Hash { return common.Hash{} }
+	evm := vm.NewEVM(context, statedb, pre.Config, vm.Config{Tracer: logger, Debug: ctx.GlobalBool(MachineFlag.Name)})
+
+	tstart := time.Now()
+	_, gasUsed, err := core.ApplyMessageWithError(evm, msg, new(core.GasPool).AddGas(gas))
+	execTime := time.Since(tstart)
+	if vmerr, ok := err.(*core.ExecutionError); ok {
+		err = vmerr.Err
+	}
+	statedb.IntermediateRoot(pre.Config.IsEIP158(header.Number))
+
+	logger.CaptureSpecialTx(cost, vm.SpecialTxErrorCode(err), diffDumps(before, statedb.RawDump()))
+	logger.CaptureEnd(nil, gasUsed, execTime, err)
+
+	if ctx.GlobalBool(DebugFlag.Name) {
+		vm.WriteLogs(os.Stderr, statedb.Logs())
+	}
+	return nil
+}
+
+// diffDumps returns the changes of the accounts between two state dumps.
+// Accounts missing from a dump are diffed as null.
+func diffDumps(before, after state.Dump) map[common.Address]*accountDiff {
+	diffs := make(map[common.Address]*accountDiff)
+	for key, a := range after.Accounts {
+		if diff := diffAccounts(before.Accounts[key], a); diff != nil {
+			diffs[common.HexToAddress(key)] = diff
+		}
+	}
+	for key, b := range before.Accounts {
+		if _, ok := after.Accounts[key]; !ok {
+			diffs[common.HexToAddress(key)] = diffAccounts(b, state.DumpAccount{})
+		}
+	}
+	return diffs
+}
+
+// diffAccounts returns the changes between two dumps of an account, nil if
+// there are none.
+func diffAccounts(b, a state.DumpAccount) *accountDiff {
+	value := func(account state.DumpAccount, v interface{}) interface{} {
+		if account.CodeHash == "" {
+			return nil // missing account
+		}
+		return v
+	}
+	diff := new(accountDiff)
+	if a.Balance != b.Balance {
+		diff.Balance = &valueDiff{value(b, b.Balance), value(a, a.Balance)}
+	}
+	if a.Nonce != b.Nonce {
+		diff.Nonce = &valueDiff{value(b, b.Nonce), value(a, a.Nonce)}
+	}
+	if a.CodeHash != b.CodeHash {
+		diff.GenaroData = &valueDiff{value(b, dumpGenaroData(b.CodeHash)), value(a, dumpGenaroData(a.CodeHash))}
+	}
+	for k, v := range a.Storage {
+		if prev := b.Storage[k]; prev != v {
+			if diff.Storage == nil {
+				diff.Storage = make(map[string]*valueDiff)
+			}
+			diff.Storage[k] = &valueDiff{prev, v}
+		}
+	}
+	if diff.Balance == nil && diff.Nonce == nil && diff.GenaroData == nil && diff.Storage == nil {
+		return nil
+	}
+	return diff
+}
+
+// dumpGenaroData returns the Genaro data held by the code hash of an account in
+// a state dump, or the code hash itself if it is one.
+func dumpGenaroData(codeHash string) interface{} {
+	hash, err := hexutil.Decode("0x" + codeHash)
+	if err != nil {
+		return nil
+	}
+	if account := tests.NewGenaroAccount(nil, 0, nil, hash); account.GenaroData != nil {
+		return account.GenaroData
+	}
+	return codeHash
+}
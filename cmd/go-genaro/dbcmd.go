@@ -0,0 +1,123 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/GenaroNetwork/GenaroCore/cmd/utils"
+	"github.com/GenaroNetwork/GenaroCore/common"
+	"github.com/GenaroNetwork/GenaroCore/ethdb"
+	"github.com/GenaroNetwork/GenaroCore/log"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var dbCommand = cli.Command{
+	Name:      "db",
+	Usage:     "Manage the engine of the chain database",
+	ArgsUsage: "",
+	Category:  "BLOCKCHAIN COMMANDS",
+	Description: `
+The db commands operate on the chain database of a stopped node.`,
+	Subcommands: []cli.Command{
+		{
+			Action:    utils.MigrateFlags(migrateDatabase),
+			Name:      "migrate",
+			Usage:     "Move the chain database to another engine",
+			ArgsUsage: "<leveldb|pebble>",
+			Flags: []cli.Flag{
+				utils.DataDirFlag,
+				utils.CacheFlag,
+				utils.TestnetFlag,
+				utils.RinkebyFlag,
+				utils.LightModeFlag,
+			},
+			Description: `
+    go-genaro db migrate pebble
+
+Copies every entry of the chain database into a new database stored with the
+given engine, which then replaces it. The node must be stopped. The previous
+database is kept next to the new one, suffixed with the name of its engine,
+and can be deleted once the node runs fine on the new one.`,
+		},
+	},
+}
+
+// migrateDatabase copies the chain database into a database of another engine
+// and swaps the two.
+func migrateDatabase(ctx *cli.Context) error {
+	if len(ctx.Args()) != 1 {
+		utils.Fatalf("This command requires the target engine as argument")
+	}
+	engine := ctx.Args().First()
+	if engine != ethdb.EngineLevelDB && engine != ethdb.EnginePebble {
+		utils.Fatalf("Unknown database engine %q, must be either '%s' or '%s'", engine, ethdb.EngineLevelDB, ethdb.EnginePebble)
+	}
+	stack, _ := makeConfigNode(ctx)
+	name := "chaindata"
+	if ctx.GlobalBool(utils.LightModeFlag.Name) {
+		name = "lightchaindata"
+	}
+	path := stack.ResolvePath(name)
+	current, err := ethdb.DatabaseEngine(path)
+	if err != nil {
+		utils.Fatalf("Failed to read the database engine: %v", err)
+	}
+	switch current {
+	case "":
+		utils.Fatalf("No database found at %s", path)
+	case engine:
+		utils.Fatalf("Database %s is already stored with %s", path, engine)
+	}
+	cache := ctx.GlobalInt(utils.CacheFlag.Name)
+	src, err := ethdb.NewDatabase(path, current, cache/2, 256)
+	if err != nil {
+		utils.Fatalf("Failed to open the database: %v", err)
+	}
+	// Copy into a fresh database, dropping the leftovers of an interrupted
+	// migration
+	migrating := path + ".migrating"
+	if err := os.RemoveAll(migrating); err != nil {
+		utils.Fatalf("Failed to remove %s: %v", migrating, err)
+	}
+	dst, err := ethdb.NewDatabase(migrating, engine, cache/2, 256)
+	if err != nil {
+		utils.Fatalf("Failed to create the database: %v", err)
+	}
+	log.Info("Migrating database", "path", path, "from", current, "to", engine)
+
+	start := time.Now()
+	entries, size, err := ethdb.CopyDatabase(dst, src)
+	src.Close()
+	dst.Close()
+	if err != nil {
+		utils.Fatalf("Database migration failed: %v", err)
+	}
+	// Swap the databases, keeping the previous one aside
+	backup := path + "." + current
+	if err := os.Rename(path, backup); err != nil {
+		utils.Fatalf("Failed to move the previous database: %v", err)
+	}
+	if err := os.Rename(migrating, path); err != nil {
+		utils.Fatalf("Failed to move the migrated database: %v", err)
+	}
+	log.Info("Database migrated", "entries", entries, "size", common.StorageSize(size), "elapsed", common.PrettyDuration(time.Since(start)))
+	fmt.Printf("The %s database was kept at %s, delete it once the node runs fine.\n", current, backup)
+	return nil
+}
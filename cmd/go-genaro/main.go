@@ -79,10 +79,17 @@ var (
 		utils.TxPoolAccountQueueFlag,
 		utils.TxPoolGlobalQueueFlag,
 		utils.TxPoolLifetimeFlag,
+		utils.TxPoolSpecialSlotsFlag,
+		utils.TxPoolSpecialAccountSlotsFlag,
+		utils.TxPoolSpecialRateLimitsFlag,
+		utils.TxPoolSystemSendersFlag,
 		utils.FastSyncFlag,
 		utils.LightModeFlag,
 		utils.SyncModeFlag,
+		utils.DBEngineFlag,
 		utils.GCModeFlag,
+		utils.GenaroHistoryFlag,
+		utils.AddressIndexFlag,
 		utils.LightServFlag,
 		utils.LightPeersFlag,
 		utils.LightKDFFlag,
@@ -158,6 +165,10 @@ func init() {
 		copydbCommand,
 		removedbCommand,
 		dumpCommand,
+		// See snapshotcmd.go:
+		snapshotCommand,
+		// See dbcmd.go:
+		dbCommand,
 		// See monitorcmd.go:
 		monitorCommand,
 		// See accountcmd.go:
@@ -0,0 +1,111 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/GenaroNetwork/GenaroCore/cmd/utils"
+	"github.com/GenaroNetwork/GenaroCore/common"
+	"github.com/GenaroNetwork/GenaroCore/core"
+	"github.com/GenaroNetwork/GenaroCore/core/state"
+	"github.com/GenaroNetwork/GenaroCore/eth"
+	"github.com/GenaroNetwork/GenaroCore/ethdb"
+	"github.com/GenaroNetwork/GenaroCore/log"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	pruneDryRunFlag = cli.BoolFlag{
+		Name:  "dry-run",
+		Usage: "Report the reclaimable space without deleting anything",
+	}
+	pruneBloomSizeFlag = cli.Uint64Flag{
+		Name:  "bloomfilter.size",
+		Usage: "Megabytes of memory allocated to the bloom filter marking the state to keep",
+		Value: 2048,
+	}
+	snapshotCommand = cli.Command{
+		Name:      "snapshot",
+		Usage:     "Manage the state stored in the chain database",
+		ArgsUsage: "",
+		Category:  "BLOCKCHAIN COMMANDS",
+		Description: `
+The snapshot commands operate on the chain database of a stopped node.`,
+		Subcommands: []cli.Command{
+			{
+				Action:    utils.MigrateFlags(pruneState),
+				Name:      "prune-state",
+				Usage:     "Delete the state not needed by the head block",
+				ArgsUsage: " ",
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.CacheFlag,
+					utils.TestnetFlag,
+					utils.RinkebyFlag,
+					pruneDryRunFlag,
+					pruneBloomSizeFlag,
+				},
+				Description: `
+    go-genaro snapshot prune-state [--dry-run] [--bloomfilter.size <MB>]
+
+Deletes every trie node and contract code of the chain database that is not
+reachable from the state of the head block, then compacts the database. The
+node must be stopped.
+
+The state to keep is marked in a bloom filter, a larger one keeps less of the
+unreachable state around.
+
+With --dry-run the database is left untouched and the reclaimable space is
+reported instead.`,
+			},
+		},
+	}
+)
+
+// pruneState deletes the state of the chain database the head block does not
+// need.
+func pruneState(ctx *cli.Context) error {
+	stack, _ := makeConfigNode(ctx)
+	chainDb := utils.MakeChainDatabase(ctx, stack)
+	defer chainDb.Close()
+
+	db, ok := chainDb.(ethdb.DiskDatabase)
+	if !ok {
+		utils.Fatalf("State pruning is only supported on disk databases")
+	}
+	head := core.GetHeadBlockHash(chainDb)
+	header := core.GetHeader(chainDb, head, core.GetBlockNumber(chainDb, head))
+	if header == nil {
+		utils.Fatalf("Head block %x not found", head)
+	}
+	dryRun := ctx.Bool(pruneDryRunFlag.Name)
+	log.Info("Pruning state", "head", header.Number, "root", header.Root, "dryrun", dryRun)
+
+	start := time.Now()
+	stats, err := state.PruneState(db, []common.Hash{header.Root}, eth.IndexPrefixes, ctx.Uint64(pruneBloomSizeFlag.Name)*1024*1024, dryRun)
+	if err != nil {
+		utils.Fatalf("State pruning failed: %v", err)
+	}
+	if dryRun {
+		fmt.Printf("Reachable entries: %d\nReclaimable entries: %d\nReclaimable size: %v\n", stats.Reachable, stats.Pruned, stats.Size)
+		return nil
+	}
+	log.Info("State pruned", "kept", stats.Reachable, "deleted", stats.Pruned, "size", stats.Size, "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
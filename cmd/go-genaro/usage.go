@@ -75,7 +75,10 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.TestnetFlag,
 			utils.RinkebyFlag,
 			utils.SyncModeFlag,
+			utils.DBEngineFlag,
 			utils.GCModeFlag,
+			utils.GenaroHistoryFlag,
+			utils.AddressIndexFlag,
 			utils.EthStatsURLFlag,
 			utils.IdentityFlag,
 			utils.LightServFlag,
@@ -123,6 +126,10 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.TxPoolAccountQueueFlag,
 			utils.TxPoolGlobalQueueFlag,
 			utils.TxPoolLifetimeFlag,
+			utils.TxPoolSpecialSlotsFlag,
+			utils.TxPoolSpecialAccountSlotsFlag,
+			utils.TxPoolSpecialRateLimitsFlag,
+			utils.TxPoolSystemSendersFlag,
 		},
 	},
 	{
@@ -65,6 +65,7 @@ const (
 	SWARM_ENV_NETWORK_ID      = "SWARM_NETWORK_ID"
 	SWARM_ENV_SWAP_ENABLE     = "SWARM_SWAP_ENABLE"
 	SWARM_ENV_SWAP_API        = "SWARM_SWAP_API"
+	SWARM_ENV_BUCKET_API      = "SWARM_BUCKET_API"
 	SWARM_ENV_SYNC_ENABLE     = "SWARM_SYNC_ENABLE"
 	SWARM_ENV_ENS_API         = "SWARM_ENS_API"
 	SWARM_ENV_ENS_ADDR        = "SWARM_ENS_ADDR"
@@ -200,6 +201,10 @@ func cmdLineOverride(currentConfig *bzzapi.Config, ctx *cli.Context) *bzzapi.Con
 		utils.Fatalf(SWARM_ERR_SWAP_SET_NO_API)
 	}
 
+	if bucketapi := ctx.GlobalString(SwarmBucketAPIFlag.Name); bucketapi != "" {
+		currentConfig.BucketApi = bucketapi
+	}
+
 	if ctx.GlobalIsSet(EnsAPIFlag.Name) {
 		ensAPIs := ctx.GlobalStringSlice(EnsAPIFlag.Name)
 		// preserve backward compatibility to disable ENS with --ens-api=""
@@ -276,6 +281,10 @@ func envVarsOverride(currentConfig *bzzapi.Config) (config *bzzapi.Config) {
 		utils.Fatalf(SWARM_ERR_SWAP_SET_NO_API)
 	}
 
+	if bucketapi := os.Getenv(SWARM_ENV_BUCKET_API); bucketapi != "" {
+		currentConfig.BucketApi = bucketapi
+	}
+
 	if ensapi := os.Getenv(SWARM_ENV_ENS_API); ensapi != "" {
 		currentConfig.EnsAPIs = strings.Split(ensapi, ",")
 	}
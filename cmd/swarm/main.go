@@ -101,6 +101,11 @@ var (
 		Usage:  "URL of the Ethereum API provider to use to settle SWAP payments",
 		EnvVar: SWARM_ENV_SWAP_API,
 	}
+	SwarmBucketAPIFlag = cli.StringFlag{
+		Name:   "bucket-api",
+		Usage:  "URL of the Genaro API provider to check uploads against the buckets of their accounts and report bucket usage (disabled if empty)",
+		EnvVar: SWARM_ENV_BUCKET_API,
+	}
 	SwarmSyncEnabledFlag = cli.BoolTFlag{
 		Name:   "sync",
 		Usage:  "Swarm Syncing enabled (default true)",
@@ -342,6 +347,7 @@ DEPRECATED: use 'swarm db clean'.
 		SwarmConfigPathFlag,
 		SwarmSwapEnabledFlag,
 		SwarmSwapAPIFlag,
+		SwarmBucketAPIFlag,
 		SwarmSyncEnabledFlag,
 		SwarmListenAddrFlag,
 		SwarmPortFlag,
@@ -170,11 +170,23 @@ var (
 		Usage: `Blockchain sync mode ("fast", "full", or "light")`,
 		Value: &defaultSyncMode,
 	}
+	DBEngineFlag = cli.StringFlag{
+		Name:  "dbengine",
+		Usage: `Engine of new databases ("leveldb" or "pebble"), existing ones only open with their own ("go-genaro db migrate" to switch)`,
+	}
 	GCModeFlag = cli.StringFlag{
 		Name:  "gcmode",
 		Usage: `Blockchain garbage collection mode ("full", "archive")`,
 		Value: "full",
 	}
+	GenaroHistoryFlag = cli.BoolFlag{
+		Name:  "genarohistory",
+		Usage: "Index the stake, heft, traffic and bucket history of the accounts (requires --gcmode=archive)",
+	}
+	AddressIndexFlag = cli.BoolFlag{
+		Name:  "addressindex",
+		Usage: "Index the transactions of the accounts, including the special transactions acting on them",
+	}
 	LightServFlag = cli.IntFlag{
 		Name:  "lightserv",
 		Usage: "Maximum percentage of time allowed for serving LES requests (0-90)",
@@ -289,6 +301,24 @@ var (
 		Usage: "Maximum amount of time non-executable transaction are queued",
 		Value: eth.DefaultConfig.TxPool.Lifetime,
 	}
+	TxPoolSpecialSlotsFlag = cli.Uint64Flag{
+		Name:  "txpool.specialslots",
+		Usage: "Maximum number of special transaction slots, besides the global ones",
+		Value: eth.DefaultConfig.TxPool.SpecialSlots,
+	}
+	TxPoolSpecialAccountSlotsFlag = cli.Uint64Flag{
+		Name:  "txpool.specialaccountslots",
+		Usage: "Maximum number of special transaction slots permitted per account",
+		Value: eth.DefaultConfig.TxPool.SpecialAccountSlots,
+	}
+	TxPoolSpecialRateLimitsFlag = cli.StringFlag{
+		Name:  "txpool.specialratelimits",
+		Usage: "Maximum number of special transactions admitted per block by type (type:limit, comma separated)",
+	}
+	TxPoolSystemSendersFlag = cli.StringFlag{
+		Name:  "txpool.systemsenders",
+		Usage: "Senders whose special transactions bypass the lane limits and are mined first (comma separated)",
+	}
 	// Performance tuning settings
 	CacheFlag = cli.IntFlag{
 		Name:  "cache",
@@ -887,6 +917,14 @@ func SetNodeConfig(ctx *cli.Context, cfg *node.Config) {
 		cfg.DataDir = filepath.Join(node.DefaultDataDir(), "rinkeby")
 	}
 
+	if ctx.GlobalIsSet(DBEngineFlag.Name) {
+		switch engine := ctx.GlobalString(DBEngineFlag.Name); engine {
+		case ethdb.EngineLevelDB, ethdb.EnginePebble:
+			cfg.DBEngine = engine
+		default:
+			Fatalf("--%s must be either '%s' or '%s'", DBEngineFlag.Name, ethdb.EngineLevelDB, ethdb.EnginePebble)
+		}
+	}
 	if ctx.GlobalIsSet(KeyStoreDirFlag.Name) {
 		cfg.KeyStoreDir = ctx.GlobalString(KeyStoreDirFlag.Name)
 	}
@@ -938,6 +976,39 @@ func setTxPool(ctx *cli.Context, cfg *core.TxPoolConfig) {
 	if ctx.GlobalIsSet(TxPoolLifetimeFlag.Name) {
 		cfg.Lifetime = ctx.GlobalDuration(TxPoolLifetimeFlag.Name)
 	}
+	if ctx.GlobalIsSet(TxPoolSpecialSlotsFlag.Name) {
+		cfg.SpecialSlots = ctx.GlobalUint64(TxPoolSpecialSlotsFlag.Name)
+	}
+	if ctx.GlobalIsSet(TxPoolSpecialAccountSlotsFlag.Name) {
+		cfg.SpecialAccountSlots = ctx.GlobalUint64(TxPoolSpecialAccountSlotsFlag.Name)
+	}
+	if ctx.GlobalIsSet(TxPoolSpecialRateLimitsFlag.Name) {
+		cfg.SpecialRateLimits = nil
+		for _, entry := range strings.Split(ctx.GlobalString(TxPoolSpecialRateLimitsFlag.Name), ",") {
+			parts := strings.Split(strings.TrimSpace(entry), ":")
+			if len(parts) != 2 {
+				Fatalf("Invalid special rate limit %q, want type:limit", entry)
+			}
+			txType, err := strconv.ParseUint(parts[0], 10, 64)
+			if err != nil {
+				Fatalf("Invalid special rate limit %q: %v", entry, err)
+			}
+			limit, err := strconv.ParseUint(parts[1], 10, 64)
+			if err != nil {
+				Fatalf("Invalid special rate limit %q: %v", entry, err)
+			}
+			cfg.SpecialRateLimits = append(cfg.SpecialRateLimits, core.SpecialRateLimit{Type: txType, Limit: limit})
+		}
+	}
+	if ctx.GlobalIsSet(TxPoolSystemSendersFlag.Name) {
+		cfg.SystemSenders = nil
+		for _, sender := range strings.Split(ctx.GlobalString(TxPoolSystemSendersFlag.Name), ",") {
+			if sender = strings.TrimSpace(sender); !common.IsHexAddress(sender) {
+				Fatalf("Invalid system sender %q", sender)
+			}
+			cfg.SystemSenders = append(cfg.SystemSenders, common.HexToAddress(sender))
+		}
+	}
 }
 
 func setEthash(ctx *cli.Context, cfg *eth.Config) {
@@ -1050,6 +1121,11 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *eth.Config) {
 		Fatalf("--%s must be either 'full' or 'archive'", GCModeFlag.Name)
 	}
 	cfg.NoPruning = ctx.GlobalString(GCModeFlag.Name) == "archive"
+	cfg.GenaroHistory = ctx.GlobalBool(GenaroHistoryFlag.Name)
+	if cfg.GenaroHistory && !cfg.NoPruning {
+		Fatalf("--%s requires --%s=archive", GenaroHistoryFlag.Name, GCModeFlag.Name)
+	}
+	cfg.AddressIndex = ctx.GlobalBool(AddressIndexFlag.Name)
 
 	if ctx.GlobalIsSet(CacheFlag.Name) || ctx.GlobalIsSet(CacheGCFlag.Name) {
 		cfg.TrieCache = ctx.GlobalInt(CacheFlag.Name) * ctx.GlobalInt(CacheGCFlag.Name) / 100
@@ -56,6 +56,12 @@ var (
 	OptionTxBeginSaveAddress Address = HexToAddress("0xa000000000000000000000000000000000000000")
 
 	NameSpaceSaveAddress Address = HexToAddress("0xb000000000000000000000000000000000000000")
+
+	HeftOracleSaveAddress Address = HexToAddress("0xc000000000000000000000000000000000000000")
+
+	StorageChallengeSaveAddress Address = HexToAddress("0xd000000000000000000000000000000000000000")
+
+	MortgageSaveAddress Address = HexToAddress("0xe000000000000000000000000000000000000000")
 )
 
 var SpecialAddressList = []Address{CandidateSaveAddress, BackStakeAddress, LastSynStateSaveAddress, StakeNode2StakeAddress, GenaroPriceAddress, SpecialSyncAddress, RewardsSaveAddress, BindingSaveAddress, ForbidBackStakeSaveAddress, NameSpaceSaveAddress}
@@ -111,6 +117,12 @@ var (
 
 	SpecialTxUnsubscribeName = big.NewInt(25)
 
+	SpecialTxCancelShareKey = big.NewInt(26)
+
+	SpecialTxRejectShareKey = big.NewInt(27)
+
+	SpecialTxRevokeFileSharePublicKey = big.NewInt(28)
+
 	SpecialTxWithdrawCash = big.NewInt(30)
 
 	SpecialTxRevoke = big.NewInt(31)
@@ -127,12 +139,36 @@ var (
 
 	SpecialTxBucketSupplement = big.NewInt(41)
 
+	SpecialTxAddHeftReporter = big.NewInt(42)
+
+	SpecialTxDelHeftReporter = big.NewInt(43)
+
+	SpecialTxReportHeft = big.NewInt(44)
+
+	SpecialTxBatch = big.NewInt(45)
+
+	SpecialTxSponsored = big.NewInt(46)
+
+	SpecialTxVestingCreate = big.NewInt(47)
+
+	SpecialTxVestingWithdraw = big.NewInt(48)
+
+	SpecialTxStorageCommit = big.NewInt(49)
+
 	// 设置收益账号
 	SpecialTxSetProfitAccount = big.NewInt(50)
 
 	// 设置影子账号
 	SpecialTxSetShadowAccount = big.NewInt(51)
 
+	SpecialTxStorageChallenge = big.NewInt(52)
+
+	SpecialTxStorageProof = big.NewInt(53)
+
+	SpecialTxBucketUsage = big.NewInt(54)
+
+	SpecialTxBucketRootCommit = big.NewInt(55)
+
 )
 
 var ReadWrite int = 0
@@ -157,4 +193,16 @@ var (
 	StorageRewardsRatio = uint64(1)
 	RatioPerYear        = uint64(2)
 	BlockLogLenth       = uint64(500000)
+	ShareKeyRetention   = uint64(100000) // blocks a finished share key offer is kept before it is collected
+	HeftReportTolerance = uint64(10000)  // deviation from the aggregated heft, in Base units, a heft report may have
+	HeftReportStrikes   = uint64(3)      // consecutive epochs of disagreeing reports after which a reporter is flagged
+	MaxStorageRoots     = uint64(64)     // chunks a storage node can commit to, and a bucket owner can commit to per bucket
+	StorageProofWindow  = uint64(100)    // blocks a storage node has to answer a challenge
+	StoragePunishment   = uint64(100)    // stake, in GNX, taken for every unanswered challenge
+	MortgageHistory     = uint64(16)     // sidechain statuses kept with a mortgage
+	MortgageSettlements = 16             // expired mortgages settled by the consensus engine per block
+	MinVestingTotal     = uint64(1000)   // GNX a vesting must lock at least, so a grant can't cheaply block the beneficiary
 )
+
+// BucketSizeUnit is the number of bytes of a unit of bucket size.
+var BucketSizeUnit = uint64(1 << 30)
@@ -1,8 +1,14 @@
 package genaro
 
 import (
+	"context"
+	"reflect"
+
 	"github.com/GenaroNetwork/GenaroCore/common"
 	"github.com/GenaroNetwork/GenaroCore/consensus"
+	"github.com/GenaroNetwork/GenaroCore/event"
+	"github.com/GenaroNetwork/GenaroCore/log"
+	"github.com/GenaroNetwork/GenaroCore/rpc"
 )
 
 // API is a user facing RPC API to allow controlling the signer and voting
@@ -31,3 +37,98 @@ func (api *API) GetBlockCommittee(epochNumber uint64) []common.Address {
 	commitee, _ := GetHeaderCommitteeRankList(api.chain.GetHeaderByNumber(writeNo))
 	return commitee
 }
+
+// PublicEventAPI offers the committee and epoch lifecycle events of the engine
+// as subscriptions in the eth namespace.
+type PublicEventAPI struct {
+	genaro *Genaro
+}
+
+const (
+	// eventChanSize is the size of the channel a subscription receives the
+	// events of the engine on.
+	eventChanSize = 16
+	// eventQueueSize is the number of events queued for a client before its
+	// subscription starts dropping them.
+	eventQueueSize = 256
+)
+
+// CommitteeElected creates a subscription that fires for every committee elected at an epoch boundary.
+func (api *PublicEventAPI) CommitteeElected(ctx context.Context) (*rpc.Subscription, error) {
+	events := make(chan CommitteeElectedEvent, eventChanSize)
+	return subscribe(ctx, events, func() event.Subscription { return api.genaro.SubscribeCommitteeElected(events) })
+}
+
+// CommitteeEffective creates a subscription that fires for every committee taking effect at the start of its turn.
+func (api *PublicEventAPI) CommitteeEffective(ctx context.Context) (*rpc.Subscription, error) {
+	events := make(chan CommitteeEffectiveEvent, eventChanSize)
+	return subscribe(ctx, events, func() event.Subscription { return api.genaro.SubscribeCommitteeEffective(events) })
+}
+
+// InturnSigner creates a subscription that fires with the in-turn and the actual signer of every block.
+func (api *PublicEventAPI) InturnSigner(ctx context.Context) (*rpc.Subscription, error) {
+	events := make(chan InturnSignerEvent, eventChanSize)
+	return subscribe(ctx, events, func() event.Subscription { return api.genaro.SubscribeInturnSigner(events) })
+}
+
+// BackStakes creates a subscription that fires for every stake paid back to its owner.
+func (api *PublicEventAPI) BackStakes(ctx context.Context) (*rpc.Subscription, error) {
+	events := make(chan BackStakeEvent, eventChanSize)
+	return subscribe(ctx, events, func() event.Subscription { return api.genaro.SubscribeBackStake(events) })
+}
+
+// Rewards creates a subscription that fires with the rewards settled in every block.
+func (api *PublicEventAPI) Rewards(ctx context.Context) (*rpc.Subscription, error) {
+	events := make(chan RewardsEvent, eventChanSize)
+	return subscribe(ctx, events, func() event.Subscription { return api.genaro.SubscribeRewards(events) })
+}
+
+// subscribe creates a subscription notifying the client of the events received
+// on events, the channel subscribed to a feed of the engine by sub.
+func subscribe(ctx context.Context, events interface{}, sub func() event.Subscription) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	rpcSub := notifier.CreateSubscription()
+	eventsSub := sub()
+	go func() {
+		forwardEvents(events, eventQueueSize, rpcSub.Err(), notifier.Closed(), func(ev interface{}) {
+			notifier.Notify(rpcSub.ID, ev)
+		})
+		eventsSub.Unsubscribe()
+	}()
+	return rpcSub, nil
+}
+
+// forwardEvents passes the events received on the channel events to notify
+// until err or closed fires. The feeds of the engine block until every
+// subscriber took the event, so events are queued up to queueSize and dropped
+// once the client falls behind. A slow client thus never stalls the posting of
+// the events, and with it block import.
+func forwardEvents(events interface{}, queueSize int, err <-chan error, closed <-chan interface{}, notify func(interface{})) {
+	queue := make(chan interface{}, queueSize)
+	defer close(queue)
+	go func() {
+		for ev := range queue {
+			notify(ev)
+		}
+	}()
+
+	cases := []reflect.SelectCase{
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(events)},
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(err)},
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(closed)},
+	}
+	for {
+		chosen, ev, _ := reflect.Select(cases)
+		if chosen != 0 {
+			return
+		}
+		select {
+		case queue <- ev.Interface():
+		default:
+			log.Warn("Dropping consensus event for slow subscriber", "type", ev.Type())
+		}
+	}
+}
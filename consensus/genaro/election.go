@@ -0,0 +1,240 @@
+package genaro
+
+import (
+	"bytes"
+	"math/big"
+	"sort"
+
+	"github.com/GenaroNetwork/GenaroCore/common"
+	"github.com/GenaroNetwork/GenaroCore/core/state"
+	"github.com/GenaroNetwork/GenaroCore/params"
+)
+
+// Names of the election strategies that can be selected with
+// GenaroConfig.ElectionStrategy.
+const (
+	StakeElection     = "stake"     // rank and weight by stake
+	StakeHeftElection = "stakeHeft" // rank and weight by stake times heft
+	CappedElection    = "capped"    // rank and weight by stake, no member above ProportionCap
+)
+
+// defaultProportionCap is used by the capped strategy if
+// GenaroConfig.ProportionCap is not set.
+var defaultProportionCap = common.Base / 5
+
+// ElectionStrategy elects the committee of an epoch out of the candidates. It
+// returns at most size signers in committee order together with their
+// proportion in common.Base units. Candidates staking less than minStake are
+// not eligible.
+type ElectionStrategy interface {
+	Elect(candidates state.CandidateInfos, size int, minStake uint64) ([]common.Address, []uint64)
+}
+
+// ElectionStrategyAt returns the election strategy in use at number. Before
+// GenaroConfig.ElectionBlock, or if ElectionStrategy is not a known name, the
+// committee is elected with state.RankWithLenth.
+func ElectionStrategyAt(config *params.GenaroConfig, number *big.Int) ElectionStrategy {
+	if !config.IsElection(number) {
+		return legacyElection{}
+	}
+	switch config.ElectionStrategy {
+	case StakeElection:
+		return stakeElection{}
+	case StakeHeftElection:
+		return stakeHeftElection{}
+	case CappedElection:
+		proportionCap := config.ProportionCap
+		if proportionCap == 0 {
+			proportionCap = defaultProportionCap
+		}
+		return cappedElection{proportionCap}
+	}
+	return legacyElection{}
+}
+
+// legacyElection ranks by the point of CandidateInfos.Apply and weights by stake.
+type legacyElection struct{}
+
+func (legacyElection) Elect(candidates state.CandidateInfos, size int, minStake uint64) ([]common.Address, []uint64) {
+	return state.RankWithLenth(candidates, size, minStake)
+}
+
+type stakeElection struct{}
+
+func (stakeElection) Elect(candidates state.CandidateInfos, size int, minStake uint64) ([]common.Address, []uint64) {
+	rank, weights := electByWeight(candidates, size, minStake, stakeWeight)
+	return rank, proportions(weights)
+}
+
+// stakeHeftElection weights a candidate by its stake times its heft. If none of
+// the elected has any heft the proportions fall back to their stake.
+type stakeHeftElection struct{}
+
+func (stakeHeftElection) Elect(candidates state.CandidateInfos, size int, minStake uint64) ([]common.Address, []uint64) {
+	rank, weights := electByWeight(candidates, size, minStake, func(c state.CandidateInfo) *big.Int {
+		weight := new(big.Int).SetUint64(c.Stake)
+		return weight.Mul(weight, new(big.Int).SetUint64(c.Heft))
+	})
+	if sumWeights(weights).Sign() == 0 {
+		return stakeElection{}.Elect(candidates, size, minStake)
+	}
+	return rank, proportions(weights)
+}
+
+// cappedElection elects like stakeElection, but no member gets a proportion
+// above proportionCap. The excess is shared among the other members by stake.
+type cappedElection struct {
+	proportionCap uint64
+}
+
+func (e cappedElection) Elect(candidates state.CandidateInfos, size int, minStake uint64) ([]common.Address, []uint64) {
+	rank, weights := electByWeight(candidates, size, minStake, stakeWeight)
+	proportion := proportions(weights)
+	capped := make([]bool, len(proportion))
+	for {
+		excess := uint64(0)
+		for i := range proportion {
+			if !capped[i] && proportion[i] > e.proportionCap {
+				excess += proportion[i] - e.proportionCap
+				proportion[i] = e.proportionCap
+				capped[i] = true
+			}
+		}
+		rest := new(big.Int)
+		for i, weight := range weights {
+			if !capped[i] {
+				rest.Add(rest, weight)
+			}
+		}
+		if excess == 0 || rest.Sign() == 0 {
+			return rank, proportion
+		}
+		for i, weight := range weights {
+			if !capped[i] {
+				share := new(big.Int).Mul(new(big.Int).SetUint64(excess), weight)
+				proportion[i] += share.Div(share, rest).Uint64()
+			}
+		}
+	}
+}
+
+func stakeWeight(c state.CandidateInfo) *big.Int {
+	return new(big.Int).SetUint64(c.Stake)
+}
+
+// electByWeight ranks the eligible candidates by descending weight, ties broken
+// by ascending address, and returns the first size of them with their weight.
+func electByWeight(candidates state.CandidateInfos, size int, minStake uint64, weight func(state.CandidateInfo) *big.Int) ([]common.Address, []*big.Int) {
+	type weighted struct {
+		signer common.Address
+		weight *big.Int
+	}
+	eligible := make([]weighted, 0, len(candidates))
+	for _, c := range candidates {
+		if c.Stake >= minStake {
+			eligible = append(eligible, weighted{c.Signer, weight(c)})
+		}
+	}
+	sort.Slice(eligible, func(i, j int) bool {
+		if cmp := eligible[i].weight.Cmp(eligible[j].weight); cmp != 0 {
+			return cmp > 0
+		}
+		return bytes.Compare(eligible[i].signer[:], eligible[j].signer[:]) < 0
+	})
+	if len(eligible) > size {
+		eligible = eligible[:size]
+	}
+	rank := make([]common.Address, len(eligible))
+	weights := make([]*big.Int, len(eligible))
+	for i, e := range eligible {
+		rank[i], weights[i] = e.signer, e.weight
+	}
+	return rank, weights
+}
+
+func sumWeights(weights []*big.Int) *big.Int {
+	total := new(big.Int)
+	for _, weight := range weights {
+		total.Add(total, weight)
+	}
+	return total
+}
+
+// proportions converts weights into proportions in common.Base units.
+func proportions(weights []*big.Int) []uint64 {
+	proportion := make([]uint64, len(weights))
+	total := sumWeights(weights)
+	if total.Sign() == 0 {
+		return proportion
+	}
+	for i, weight := range weights {
+		share := new(big.Int).Mul(weight, new(big.Int).SetUint64(common.Base))
+		proportion[i] = share.Div(share, total).Uint64()
+	}
+	return proportion
+}
+
+// CandidateDelta is a hypothetical change of a candidate. Stake and Heft are
+// added to the candidate, and the stake and heft of SubAccounts are counted as
+// if they were bound to it. An account that is not a candidate yet becomes one.
+type CandidateDelta struct {
+	Candidate   common.Address   `json:"candidate"`
+	Stake       int64            `json:"stake"`
+	Heft        int64            `json:"heft"`
+	SubAccounts []common.Address `json:"subAccounts"`
+}
+
+// ProjectElection runs the election of the epoch boundary number against the
+// candidates of statedb changed by deltas. It returns the changed candidates
+// together with the elected committee and its proportions.
+func ProjectElection(config *params.GenaroConfig, statedb *state.StateDB, number *big.Int, deltas []CandidateDelta) (state.CandidateInfos, []common.Address, []uint64) {
+	candidates := state.CandidateInfos(statedb.GetCandidatesInfoWithAllSubAccounts())
+	find := func(addr common.Address) int {
+		for i, c := range candidates {
+			if c.Signer == addr {
+				return i
+			}
+		}
+		return -1
+	}
+	for _, delta := range deltas {
+		i := find(delta.Candidate)
+		if i < 0 {
+			candidates = append(candidates, statedb.GetCandidateInfoWithAllSubAccounts(delta.Candidate))
+			i = len(candidates) - 1
+		}
+		c := &candidates[i]
+		c.Stake = addDelta(c.Stake, delta.Stake)
+		c.Heft = addDelta(c.Heft, delta.Heft)
+		for _, sub := range delta.SubAccounts {
+			stake, _ := statedb.GetStake(sub)
+			heft, _ := statedb.GetHeft(sub)
+			c.Stake += stake
+			c.Heft += heft
+		}
+		// A bound sub-account no longer runs on its own.
+		for _, sub := range delta.SubAccounts {
+			if j := find(sub); j >= 0 && sub != delta.Candidate {
+				candidates = append(candidates[:j], candidates[j+1:]...)
+			}
+		}
+	}
+	elected := make(state.CandidateInfos, len(candidates))
+	copy(elected, candidates)
+	genaroPrice := statedb.GetGenaroPrice()
+	rank, proportion := ElectionStrategyAt(config, number).Elect(elected, int(config.CommitteeMaxSize), genaroPrice.CommitteeMinStake)
+	if uint64(len(rank)) > config.CommitteeMaxSize {
+		rank, proportion = rank[:config.CommitteeMaxSize], proportion[:config.CommitteeMaxSize]
+	}
+	return candidates, rank, proportion
+}
+
+func addDelta(value uint64, delta int64) uint64 {
+	if delta < 0 {
+		if uint64(-delta) > value {
+			return 0
+		}
+		return value - uint64(-delta)
+	}
+	return value + uint64(delta)
+}
@@ -0,0 +1,111 @@
+package genaro
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/GenaroNetwork/GenaroCore/common"
+	"github.com/GenaroNetwork/GenaroCore/core/state"
+	"github.com/GenaroNetwork/GenaroCore/params"
+)
+
+// electionTest is a recorded candidate set together with the committee every
+// strategy is expected to elect out of it. The legacy strategy is only
+// recorded for sets without point ties, as state.RankWithLenth does not break
+// them.
+type electionTest struct {
+	Name          string                    `json:"name"`
+	Size          int                       `json:"size"`
+	MinStake      uint64                    `json:"minStake"`
+	ProportionCap uint64                    `json:"proportionCap"`
+	Candidates    state.CandidateInfos      `json:"candidates"`
+	Results       map[string]electionResult `json:"results"`
+}
+
+type electionResult struct {
+	Rank       []common.Address `json:"rank"`
+	Proportion []uint64         `json:"proportion"`
+}
+
+func TestElectionStrategies(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/election.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tests []electionTest
+	if err := json.Unmarshal(data, &tests); err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range tests {
+		for name, want := range test.Results {
+			config := &params.GenaroConfig{ElectionBlock: big.NewInt(0), ElectionStrategy: name, ProportionCap: test.ProportionCap}
+			if name == "legacy" {
+				config.ElectionBlock = nil
+			}
+			candidates := make(state.CandidateInfos, len(test.Candidates))
+			copy(candidates, test.Candidates)
+
+			rank, proportion := ElectionStrategyAt(config, big.NewInt(1)).Elect(candidates, test.Size, test.MinStake)
+			if !reflect.DeepEqual(rank, want.Rank) {
+				t.Errorf("%s, %s: rank mismatch: have %x, want %x", test.Name, name, rank, want.Rank)
+			}
+			if !reflect.DeepEqual(proportion, want.Proportion) {
+				t.Errorf("%s, %s: proportion mismatch: have %v, want %v", test.Name, name, proportion, want.Proportion)
+			}
+		}
+	}
+}
+
+func TestElectionStrategyAt(t *testing.T) {
+	config := &params.GenaroConfig{ElectionBlock: big.NewInt(10), ElectionStrategy: StakeHeftElection}
+	if _, ok := ElectionStrategyAt(config, big.NewInt(9)).(legacyElection); !ok {
+		t.Error("election strategy changed before the fork")
+	}
+	if _, ok := ElectionStrategyAt(config, big.NewInt(10)).(stakeHeftElection); !ok {
+		t.Error("election strategy not changed at the fork")
+	}
+	config.ElectionStrategy = "unknown"
+	if _, ok := ElectionStrategyAt(config, big.NewInt(10)).(legacyElection); !ok {
+		t.Error("unknown election strategy not ignored")
+	}
+}
+
+func TestProjectElection(t *testing.T) {
+	var (
+		a = common.HexToAddress("0x0a")
+		b = common.HexToAddress("0x0b")
+		c = common.HexToAddress("0x0c")
+	)
+	statedb := newTestStateDB()
+	for addr, stake := range map[common.Address]uint64{a: 10, b: 5, c: 3} {
+		statedb.UpdateStake(addr, stake, 1)
+		statedb.AddCandidate(addr)
+	}
+	price := statedb.GetGenaroPrice()
+	price.CommitteeMinStake = 1
+	statedb.SetGenaroPrice(*price)
+
+	config := &params.GenaroConfig{ElectionBlock: big.NewInt(0), ElectionStrategy: StakeElection, CommitteeMaxSize: 2}
+	tests := []struct {
+		deltas     []CandidateDelta
+		candidates int
+		rank       []common.Address
+	}{
+		{nil, 3, []common.Address{a, b}},
+		{[]CandidateDelta{{Candidate: c, Stake: 8}}, 3, []common.Address{c, a}},
+		{[]CandidateDelta{{Candidate: a, Stake: -20}}, 3, []common.Address{b, c}},
+		{[]CandidateDelta{{Candidate: c, SubAccounts: []common.Address{b}}}, 2, []common.Address{a, c}},
+	}
+	for i, test := range tests {
+		candidates, rank, _ := ProjectElection(config, statedb, big.NewInt(10), test.deltas)
+		if len(candidates) != test.candidates {
+			t.Errorf("test %d: candidate count mismatch: have %d, want %d", i, len(candidates), test.candidates)
+		}
+		if !reflect.DeepEqual(rank, test.rank) {
+			t.Errorf("test %d: rank mismatch: have %x, want %x", i, rank, test.rank)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package genaro
+
+import (
+	"github.com/GenaroNetwork/GenaroCore/common"
+	"github.com/GenaroNetwork/GenaroCore/common/hexutil"
+	"github.com/GenaroNetwork/GenaroCore/core/types"
+	"github.com/GenaroNetwork/GenaroCore/event"
+)
+
+// CommitteeElectedEvent is posted when a committee is elected at an epoch
+// boundary. The committee seals the blocks from the first block of
+// EffectiveTurn on.
+type CommitteeElectedEvent struct {
+	BlockNumber   uint64           `json:"blockNumber"`
+	BlockHash     common.Hash      `json:"blockHash"`
+	EffectiveTurn uint64           `json:"effectiveTurn"`
+	CommitteeRank []common.Address `json:"committeeRank"`
+	Proportion    []uint64         `json:"proportion"`
+}
+
+// CommitteeEffectiveEvent is posted at the first block of every turn with the
+// committee sealing the turn.
+type CommitteeEffectiveEvent struct {
+	BlockNumber   uint64           `json:"blockNumber"`
+	BlockHash     common.Hash      `json:"blockHash"`
+	Turn          uint64           `json:"turn"`
+	CommitteeRank []common.Address `json:"committeeRank"`
+	Proportion    []uint64         `json:"proportion"`
+}
+
+// InturnSignerEvent is posted for every block with the committee member whose
+// turn it was and the account that actually sealed the block.
+type InturnSignerEvent struct {
+	BlockNumber  uint64         `json:"blockNumber"`
+	BlockHash    common.Hash    `json:"blockHash"`
+	Index        uint64         `json:"index"`
+	InturnSigner common.Address `json:"inturnSigner"`
+	Signer       common.Address `json:"signer"`
+}
+
+// BackStakeEvent is posted for every stake paid back to its owner.
+type BackStakeEvent struct {
+	BlockNumber      uint64         `json:"blockNumber"`
+	BlockHash        common.Hash    `json:"blockHash"`
+	Account          common.Address `json:"account"`
+	ApplyBlockNumber uint64         `json:"applyBlockNumber"`
+}
+
+// RewardsEvent is posted for every block with the rewards settled in it. The
+// storage rewards are only settled at epoch boundaries.
+type RewardsEvent struct {
+	BlockNumber    uint64         `json:"blockNumber"`
+	BlockHash      common.Hash    `json:"blockHash"`
+	Coinbase       common.Address `json:"coinbase"`
+	CoinRewards    *hexutil.Big   `json:"coinRewards"`
+	StorageRewards *hexutil.Big   `json:"storageRewards"`
+}
+
+// blockEvents are the events of a finalized block, kept until the block is
+// inserted into the chain.
+type blockEvents struct {
+	elected    *CommitteeElectedEvent
+	effective  *CommitteeEffectiveEvent
+	inturn     *InturnSignerEvent
+	backStakes []*BackStakeEvent
+	rewards    *RewardsEvent
+}
+
+// blockEventsKey identifies a finalized block before it is sealed and its hash
+// is known.
+type blockEventsKey struct {
+	number uint64
+	root   common.Hash
+}
+
+// storeBlockEvents keeps the events of the block finalized with header.
+func (g *Genaro) storeBlockEvents(header *types.Header, events *blockEvents) {
+	g.pendingEvents.Add(blockEventsKey{header.Number.Uint64(), header.Root}, events)
+}
+
+// PostBlockEvents posts the events of a block that was inserted into the
+// chain to the subscribers. Blocks that were not finalized by this engine
+// instance have no events.
+func (g *Genaro) PostBlockEvents(header *types.Header) {
+	key := blockEventsKey{header.Number.Uint64(), header.Root}
+	cached, ok := g.pendingEvents.Get(key)
+	if !ok {
+		return
+	}
+	g.pendingEvents.Remove(key)
+
+	events := cached.(*blockEvents)
+	hash := header.Hash()
+	if events.elected != nil {
+		ev := *events.elected
+		ev.BlockHash = hash
+		g.committeeElectedFeed.Send(ev)
+	}
+	if events.effective != nil {
+		ev := *events.effective
+		ev.BlockHash = hash
+		g.committeeEffectiveFeed.Send(ev)
+	}
+	if events.inturn != nil {
+		ev := *events.inturn
+		ev.BlockHash = hash
+		g.inturnSignerFeed.Send(ev)
+	}
+	for _, backStake := range events.backStakes {
+		ev := *backStake
+		ev.BlockHash = hash
+		g.backStakeFeed.Send(ev)
+	}
+	if events.rewards != nil {
+		ev := *events.rewards
+		ev.BlockHash = hash
+		g.rewardsFeed.Send(ev)
+	}
+}
+
+// SubscribeCommitteeElected registers a subscription of CommitteeElectedEvent.
+func (g *Genaro) SubscribeCommitteeElected(ch chan<- CommitteeElectedEvent) event.Subscription {
+	return g.scope.Track(g.committeeElectedFeed.Subscribe(ch))
+}
+
+// SubscribeCommitteeEffective registers a subscription of CommitteeEffectiveEvent.
+func (g *Genaro) SubscribeCommitteeEffective(ch chan<- CommitteeEffectiveEvent) event.Subscription {
+	return g.scope.Track(g.committeeEffectiveFeed.Subscribe(ch))
+}
+
+// SubscribeInturnSigner registers a subscription of InturnSignerEvent.
+func (g *Genaro) SubscribeInturnSigner(ch chan<- InturnSignerEvent) event.Subscription {
+	return g.scope.Track(g.inturnSignerFeed.Subscribe(ch))
+}
+
+// SubscribeBackStake registers a subscription of BackStakeEvent.
+func (g *Genaro) SubscribeBackStake(ch chan<- BackStakeEvent) event.Subscription {
+	return g.scope.Track(g.backStakeFeed.Subscribe(ch))
+}
+
+// SubscribeRewards registers a subscription of RewardsEvent.
+func (g *Genaro) SubscribeRewards(ch chan<- RewardsEvent) event.Subscription {
+	return g.scope.Track(g.rewardsFeed.Subscribe(ch))
+}
@@ -0,0 +1,100 @@
+package genaro
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/GenaroNetwork/GenaroCore/common"
+	"github.com/GenaroNetwork/GenaroCore/core/types"
+	"github.com/GenaroNetwork/GenaroCore/ethdb"
+	"github.com/GenaroNetwork/GenaroCore/event"
+	"github.com/GenaroNetwork/GenaroCore/params"
+)
+
+func TestPostBlockEvents(t *testing.T) {
+	g := New(&params.GenaroConfig{Epoch: 10}, ethdb.NewMemDatabase())
+
+	backStakes := make(chan BackStakeEvent, 2)
+	sub := g.SubscribeBackStake(backStakes)
+	defer sub.Unsubscribe()
+	rewards := make(chan RewardsEvent, 1)
+	rewardsSub := g.SubscribeRewards(rewards)
+	defer rewardsSub.Unsubscribe()
+
+	header := &types.Header{Number: big.NewInt(20), Root: common.HexToHash("0x01")}
+	g.storeBlockEvents(header, &blockEvents{
+		backStakes: []*BackStakeEvent{
+			{BlockNumber: 20, Account: common.HexToAddress("0x01"), ApplyBlockNumber: 3},
+			{BlockNumber: 20, Account: common.HexToAddress("0x02"), ApplyBlockNumber: 4},
+		},
+	})
+
+	// A block with another root was not finalized with these events.
+	g.PostBlockEvents(&types.Header{Number: big.NewInt(20), Root: common.HexToHash("0x02")})
+	if len(backStakes) != 0 {
+		t.Fatalf("events posted for unknown block")
+	}
+
+	g.PostBlockEvents(header)
+	for i, account := range []common.Address{common.HexToAddress("0x01"), common.HexToAddress("0x02")} {
+		ev := <-backStakes
+		if ev.Account != account || ev.BlockHash != header.Hash() {
+			t.Errorf("back stake %d: have %x in %x, want %x in %x", i, ev.Account, ev.BlockHash, account, header.Hash())
+		}
+	}
+	if len(rewards) != 0 {
+		t.Errorf("missing event posted")
+	}
+
+	// Events are posted only once.
+	g.PostBlockEvents(header)
+	if len(backStakes) != 0 {
+		t.Errorf("events posted twice")
+	}
+}
+
+func TestForwardEventsSlowClient(t *testing.T) {
+	var feed event.Feed
+	events := make(chan int, 1)
+	sub := feed.Subscribe(events)
+	defer sub.Unsubscribe()
+
+	release := make(chan struct{})
+	notified := make(chan interface{}, 10)
+	closed := make(chan interface{})
+	done := make(chan struct{})
+	go func() {
+		forwardEvents(events, 2, nil, closed, func(ev interface{}) {
+			<-release
+			notified <- ev
+		})
+		close(done)
+	}()
+
+	// A client that does not take its notifications must not block the feed.
+	sent := make(chan struct{})
+	go func() {
+		for i := 0; i < 10; i++ {
+			feed.Send(i)
+		}
+		close(sent)
+	}()
+	select {
+	case <-sent:
+	case <-time.After(time.Second):
+		t.Fatal("feed blocked by slow client")
+	}
+	close(release)
+	close(closed)
+	<-done
+
+	// The first event is being delivered, two are queued and the rest dropped.
+	time.Sleep(10 * time.Millisecond)
+	if len(notified) > 4 || len(notified) == 0 {
+		t.Errorf("notified events mismatch: have %d", len(notified))
+	}
+	if ev := <-notified; ev != 0 {
+		t.Errorf("first event mismatch: have %v, want 0", ev)
+	}
+}
@@ -14,6 +14,8 @@ type ExtraData struct {
 	Signature               []byte                              `json:"signature"` // the signature of block broadcaster
 	Proportion              []uint64                            `json:"ratio"`
 	CommitteeAccountBinding map[common.Address][]common.Address `json:"CommitteeAccountBinding"` // 委员会账号的绑定信息
+	Seed                    *common.Hash                        `json:"seed,omitempty"`          // in-turn seed, see seed.go
+	SeedReveal              []byte                              `json:"seedReveal,omitempty"`    // the signer's contribution to Seed
 }
 
 func UnmarshalToExtra(header *types.Header) *ExtraData {
@@ -1,20 +1,24 @@
 package genaro
 
 import (
+	"bytes"
 	"errors"
 	"math/big"
+	"sort"
 	"sync"
 	"time"
 
 	"encoding/json"
 	"github.com/GenaroNetwork/GenaroCore/accounts"
 	"github.com/GenaroNetwork/GenaroCore/common"
+	"github.com/GenaroNetwork/GenaroCore/common/hexutil"
 	"github.com/GenaroNetwork/GenaroCore/consensus"
 	"github.com/GenaroNetwork/GenaroCore/core/state"
 	"github.com/GenaroNetwork/GenaroCore/core/types"
 	"github.com/GenaroNetwork/GenaroCore/crypto"
 	"github.com/GenaroNetwork/GenaroCore/crypto/sha3"
 	"github.com/GenaroNetwork/GenaroCore/ethdb"
+	"github.com/GenaroNetwork/GenaroCore/event"
 	"github.com/GenaroNetwork/GenaroCore/log"
 	"github.com/GenaroNetwork/GenaroCore/params"
 	"github.com/GenaroNetwork/GenaroCore/rlp"
@@ -23,9 +27,10 @@ import (
 )
 
 const (
-	inmemorySnapshots = 128          // Number of recent snapshots to keep in memory
-	epochLength       = uint64(5000) // Default number of blocks a turn
-	minDistance       = uint64(500)
+	inmemorySnapshots   = 128          // Number of recent snapshots to keep in memory
+	inmemoryBlockEvents = 128          // Number of finalized blocks to keep the events of
+	epochLength         = uint64(5000) // Default number of blocks a turn
+	minDistance         = uint64(500)
 )
 
 var (
@@ -119,6 +124,14 @@ type Genaro struct {
 	signer  common.Address       // Ethereum address of the signing key
 	lock    sync.RWMutex         // Protects the signer fields
 	signFn  SignerFn             // sign function
+
+	pendingEvents          *lru.ARCCache // events of finalized blocks not yet in the chain
+	committeeElectedFeed   event.Feed
+	committeeEffectiveFeed event.Feed
+	inturnSignerFeed       event.Feed
+	backStakeFeed          event.Feed
+	rewardsFeed            event.Feed
+	scope                  event.SubscriptionScope
 }
 
 // New creates a Genaro consensus engine
@@ -130,11 +143,13 @@ func New(config *params.GenaroConfig, snapshotDb ethdb.Database) *Genaro {
 	}
 	// Allocate the snapshot caches and create the engine
 	recents, _ := lru.NewARC(inmemorySnapshots)
+	pendingEvents, _ := lru.NewARC(inmemoryBlockEvents)
 
 	return &Genaro{
-		config:  &conf,
-		db:      snapshotDb,
-		recents: recents,
+		config:        &conf,
+		db:            snapshotDb,
+		recents:       recents,
+		pendingEvents: pendingEvents,
 	}
 }
 
@@ -155,7 +170,7 @@ func (g *Genaro) Prepare(chain consensus.ChainReader, header *types.Header) erro
 	header.Nonce = types.BlockNonce{}
 	number := header.Number.Uint64()
 
-	snap, err := g.snapshot(chain, GetTurnOfCommiteeByBlockNumber(g.config, number), nil)
+	snap, err := g.turnSnapshot(chain, number, nil)
 	if err != nil {
 		return err
 	}
@@ -167,6 +182,21 @@ func (g *Genaro) Prepare(chain consensus.ChainReader, header *types.Header) erro
 	if parent == nil {
 		return consensus.ErrUnknownAncestor
 	}
+	// Contribute to the in-turn seed
+	g.lock.RLock()
+	signer, signFn := g.signer, g.signFn
+	g.lock.RUnlock()
+	if g.config.IsInturnSeed(header.Number) && signFn != nil {
+		// A block without seed is rejected by the peers, so don't seal one.
+		parentSeed := seedOf(parent)
+		reveal, err := signFn(accounts.Account{Address: signer}, revealHash(parentSeed).Bytes())
+		if err != nil {
+			return err
+		}
+		if err := SetHeaderSeed(header, nextSeed(parentSeed, reveal), reveal); err != nil {
+			return err
+		}
+	}
 	delayTime := snap.getDelayTime(header)
 	header.Time = new(big.Int).Add(parent.Time, new(big.Int).SetUint64(g.config.Period+delayTime))
 	if header.Time.Int64() < time.Now().Unix() {
@@ -213,9 +243,8 @@ func (g *Genaro) Seal(chain consensus.ChainReader, block *types.Block, stop <-ch
 func (g *Genaro) CalcDifficulty(chain consensus.ChainReader, time uint64, parent *types.Header) *big.Int {
 	log.Info("CalcDifficulty:" + parent.Number.String())
 	blockNumber := parent.Number.Uint64() + 1
-	dependEpoch := GetTurnOfCommiteeByBlockNumber(g.config, blockNumber)
 
-	snap, err := g.snapshot(chain, dependEpoch, nil)
+	snap, err := g.turnSnapshot(chain, blockNumber, nil)
 	if err != nil {
 		return nil
 	}
@@ -266,6 +295,8 @@ func (g *Genaro) snapshot(chain consensus.ChainReader, epollNumber uint64, paren
 	// If an in-memory snapshot was found, use that
 	if s, ok := g.recents.Get(epollNumber); ok {
 		snap = s.(*CommitteeSnapshot)
+	} else if s := g.storedSnapshot(chain, epollNumber, parents); s != nil {
+		snap = s
 	} else if epollNumber < g.config.ValidPeriod+g.config.ElectionPeriod {
 
 		h := chain.GetHeaderByNumber(0)
@@ -306,6 +337,28 @@ func (g *Genaro) snapshot(chain consensus.ChainReader, epollNumber uint64, paren
 	return snap, nil
 }
 
+// storedSnapshot loads the snapshot of the turn from the database, nil if it is
+// missing or was written by a block no longer in the canonical chain.
+//
+// Snapshots are stored when first computed rather than when the election block
+// is finalized: they are built from the committee the election block carries in
+// its extra data, never from its state, so a missing one is rebuilt from the
+// header alone, whatever the age of the block.
+func (g *Genaro) storedSnapshot(chain consensus.ChainReader, epollNumber uint64, parents []*types.Header) *CommitteeSnapshot {
+	if epollNumber < g.config.ValidPeriod+g.config.ElectionPeriod {
+		return nil
+	}
+	snap, err := loadSnapshot(g.config, g.db, epollNumber-g.config.ValidPeriod-g.config.ElectionPeriod)
+	if err != nil {
+		return nil
+	}
+	h := headerByNumber(chain, snap.WriteBlockNumber, parents)
+	if h == nil || h.Hash() != snap.WriteBlockHash {
+		return nil
+	}
+	return snap
+}
+
 // VerifySeal implements consensus.Engine, checking whether the signature contained
 // in the header satisfies the consensus protocol requirements.
 func (g *Genaro) VerifySeal(chain consensus.ChainReader, header *types.Header) error {
@@ -338,7 +391,7 @@ func (g *Genaro) verifySeal(chain consensus.ChainReader, header *types.Header, p
 		}
 	}
 	// get current committee snapshot
-	snap, err := g.snapshot(chain, GetTurnOfCommiteeByBlockNumber(g.config, blockNumber), parents)
+	snap, err := g.turnSnapshot(chain, blockNumber, parents)
 	if err != nil {
 		return err
 	}
@@ -365,6 +418,11 @@ func (g *Genaro) verifySeal(chain consensus.ChainReader, header *types.Header, p
 	if header.Time.Uint64() < parent.Time.Uint64() {
 		return errUnknownBlock
 	}
+	if g.config.IsInturnSeed(header.Number) {
+		if err := verifySeed(header, parent, signer); err != nil {
+			return err
+		}
+	}
 	// Ensure that difficulty corresponds to the turn of the signer
 	diffcult := CalcDifficulty(snap, signer, blockNumber)
 	if header.Difficulty.Cmp(diffcult) != 0 {
@@ -551,10 +609,22 @@ func updateCoinRewardsRatio(thisstate *state.StateDB, commiteeRank []common.Addr
 
 func updateSpecialBlock(config *params.GenaroConfig, header *types.Header, thisstate *state.StateDB) {
 	blockNumber := header.Number.Uint64()
+	if config.IsStorageProof(header.Number) {
+		expireStorageChallenges(config, thisstate, blockNumber)
+	}
+	if config.IsMortgage(header.Number) {
+		settleExpiredMortgages(thisstate, header)
+	}
 	if blockNumber%config.Epoch == 0 {
+		if config.IsHeftOracle(header.Number) {
+			aggregateHeftReports(thisstate, blockNumber)
+		}
+		if config.IsStorageProof(header.Number) {
+			penalizeStorageFailures(thisstate, blockNumber)
+		}
 		candidateInfos := thisstate.GetCandidatesInfoWithAllSubAccounts()
 		genaroPrice := thisstate.GetGenaroPrice()
-		commiteeRank, proportion := state.RankWithLenth(candidateInfos, int(config.CommitteeMaxSize), genaroPrice.CommitteeMinStake)
+		commiteeRank, proportion := ElectionStrategyAt(config, header.Number).Elect(candidateInfos, int(config.CommitteeMaxSize), genaroPrice.CommitteeMinStake)
 
 		var committeeAccountBinding map[common.Address][]common.Address
 		if uint64(len(candidateInfos)) <= config.CommitteeMaxSize {
@@ -577,18 +647,118 @@ func updateSpecialBlock(config *params.GenaroConfig, header *types.Header, thiss
 	}
 }
 
-func handleAlreadyBackStakeList(config *params.GenaroConfig, header *types.Header, thisstate *state.StateDB) {
+// aggregateHeftReports closes the heft reports of the epoch and writes the
+// aggregated heft of every account with enough reports, see
+// types.HeftOracle.Aggregate.
+func aggregateHeftReports(thisstate *state.StateDB, blockNumber uint64) {
+	oracle := thisstate.GetHeftOracle()
+	if len(oracle.Reporters) == 0 {
+		return
+	}
+	hefts := oracle.Aggregate(common.HeftReportTolerance)
+	accounts := make([]common.Address, 0, len(hefts))
+	for account := range hefts {
+		accounts = append(accounts, account)
+	}
+	sort.Slice(accounts, func(i, j int) bool { return bytes.Compare(accounts[i][:], accounts[j][:]) < 0 })
+	for _, account := range accounts {
+		thisstate.UpdateHeft(account, hefts[account], blockNumber)
+	}
+	thisstate.SetHeftOracle(oracle)
+}
+
+// expireStorageChallenges punishes the stakers of the storage nodes that did not
+// answer their challenge in time, as a punishment special transaction of the
+// official account would.
+func expireStorageChallenges(config *params.GenaroConfig, thisstate *state.StateDB, blockNumber uint64) {
+	challenges := thisstate.GetStorageChallenges()
+	expired := challenges.Expire(blockNumber)
+	if len(expired) == 0 {
+		return
+	}
+	official := common.HexToAddress(config.OfficialAddress)
+	for _, challenge := range expired {
+		_, punishment := thisstate.DeleteStake(challenge.Account, common.StoragePunishment, blockNumber)
+		thisstate.AddBalance(official, new(big.Int).Mul(common.BaseCompany, new(big.Int).SetUint64(punishment)))
+		log.Info("Storage challenge expired", "node", challenge.NodeID, "account", challenge.Account, "root", challenge.Root, "punishment", punishment)
+	}
+	thisstate.SetStorageChallenges(challenges)
+}
+
+// settleExpiredMortgages settles the mortgages expired at the time of header
+// from their last synced sidechain status, as a mortgage terminate special
+// transaction would. At most common.MortgageSettlements are settled per
+// block, the others are left to the following blocks. An expiry whose
+// mortgage can't be settled is dropped, so it doesn't hold a slot forever.
+func settleExpiredMortgages(thisstate *state.StateDB, header *types.Header) {
+	expiries := thisstate.GetMortgageExpiries()
+	for n := 0; n < len(expiries) && n < common.MortgageSettlements; n++ {
+		expiry := expiries[n]
+		if expiry.EndTime > header.Time.Int64() {
+			break
+		}
+		payouts, ok := thisstate.SettleMortgage(expiry.FromAccount, expiry.FileID, header.Number.Uint64())
+		if !ok {
+			stale := thisstate.GetMortgageExpiries()
+			if stale.Remove(expiry.FromAccount, expiry.FileID) {
+				thisstate.SetMortgageExpiries(stale)
+			}
+			log.Warn("Dropped unsettleable mortgage expiry", "account", expiry.FromAccount, "file", expiry.FileID)
+			continue
+		}
+		accounts := make([]common.Address, 0, len(payouts))
+		for account := range payouts {
+			accounts = append(accounts, account)
+		}
+		sort.Slice(accounts, func(i, j int) bool { return bytes.Compare(accounts[i][:], accounts[j][:]) < 0 })
+		for _, account := range accounts {
+			thisstate.AddBalance(account, payouts[account])
+		}
+		log.Info("Mortgage expired", "account", expiry.FromAccount, "file", expiry.FileID, "payouts", len(payouts))
+	}
+}
+
+// penalizeStorageFailures halves the heft of a staker for every storage
+// challenge it failed in the epoch.
+func penalizeStorageFailures(thisstate *state.StateDB, blockNumber uint64) {
+	challenges := thisstate.GetStorageChallenges()
+	failures := challenges.TakeFailures()
+	if len(failures) == 0 {
+		return
+	}
+	accounts := make([]common.Address, 0, len(failures))
+	for account := range failures {
+		accounts = append(accounts, account)
+	}
+	sort.Slice(accounts, func(i, j int) bool { return bytes.Compare(accounts[i][:], accounts[j][:]) < 0 })
+	for _, account := range accounts {
+		heft, _ := thisstate.GetHeft(account)
+		if failures[account] >= 64 {
+			heft = 0
+		} else {
+			heft >>= failures[account]
+		}
+		thisstate.UpdateHeft(account, heft, blockNumber)
+	}
+	thisstate.SetStorageChallenges(challenges)
+}
+
+// handleAlreadyBackStakeList pays back the stakes due at header and returns them.
+func handleAlreadyBackStakeList(config *params.GenaroConfig, header *types.Header, thisstate *state.StateDB) []common.AlreadyBackStake {
 	blockNumber := header.Number.Uint64()
 	_, backlist := thisstate.GetAlreadyBackStakeList()
+	var backed []common.AlreadyBackStake
 	for i := 0; i < len(backlist); i++ {
 		back := backlist[i]
 		if IsBackStakeBlockNumber(config, back.BackBlockNumber, blockNumber) {
 			thisstate.BackStake(back.Addr, blockNumber)
+			backed = append(backed, back)
 			backlist = append(backlist[:i], backlist[i+1:]...)
 			i--
 		}
 	}
 	thisstate.SetAlreadyBackStakeList(backlist)
+	return backed
 }
 
 // Finalize implements consensus.Engine, ensuring no uncles are set, nor block
@@ -612,7 +782,7 @@ func (g *Genaro) Finalize(chain consensus.ChainReader, header *types.Header, sta
 	}
 
 	// 出块委员会的快照获取
-	snap, err := g.snapshot(chain, GetTurnOfCommiteeByBlockNumber(g.config, header.Number.Uint64()), nil)
+	snap, err := g.turnSnapshot(chain, blockNumber, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -631,16 +801,59 @@ func (g *Genaro) Finalize(chain consensus.ChainReader, header *types.Header, sta
 	}
 
 	//  coin interest reward
-	accumulateInterestRewards(g.config, state, header, proportion, blockNumber, snap.CommitteeSize, snap.CommitteeAccountBinding)
+	coinRewards := accumulateInterestRewards(g.config, state, header, proportion, blockNumber, snap.CommitteeSize, snap.CommitteeAccountBinding)
 	// storage reward
-	accumulateStorageRewards(g.config, state, blockNumber, snap.CommitteeSize)
+	storageRewards := accumulateStorageRewards(g.config, state, blockNumber, snap.CommitteeSize)
 
 	//handle already back stake list
-	handleAlreadyBackStakeList(g.config, header, state)
+	backed := handleAlreadyBackStakeList(g.config, header, state)
 
 	header.Root = state.IntermediateRoot(chain.Config().IsEIP158(header.Number))
 	header.UncleHash = types.CalcUncleHash(nil)
 
+	events := &blockEvents{
+		inturn: &InturnSignerEvent{
+			BlockNumber:  blockNumber,
+			Index:        index,
+			InturnSigner: snap.CommitteeRank[index],
+			Signer:       header.Coinbase,
+		},
+		rewards: &RewardsEvent{
+			BlockNumber:    blockNumber,
+			Coinbase:       header.Coinbase,
+			CoinRewards:    (*hexutil.Big)(coinRewards),
+			StorageRewards: (*hexutil.Big)(storageRewards),
+		},
+	}
+	if blockNumber%g.config.Epoch == 0 {
+		turn := GetTurnOfCommiteeByBlockNumber(g.config, blockNumber)
+		rank, proportion := GetHeaderCommitteeRankList(header)
+		events.elected = &CommitteeElectedEvent{
+			BlockNumber:   blockNumber,
+			EffectiveTurn: turn + g.config.ValidPeriod + g.config.ElectionPeriod - 1,
+			CommitteeRank: rank,
+			Proportion:    proportion,
+		}
+		effective := &CommitteeEffectiveEvent{
+			BlockNumber:   blockNumber,
+			Turn:          turn,
+			CommitteeRank: snap.CommitteeRank,
+			Proportion:    make([]uint64, len(snap.CommitteeRank)),
+		}
+		for i, member := range snap.CommitteeRank {
+			effective.Proportion[i] = snap.Committee[member]
+		}
+		events.effective = effective
+	}
+	for _, back := range backed {
+		events.backStakes = append(events.backStakes, &BackStakeEvent{
+			BlockNumber:      blockNumber,
+			Account:          back.Addr,
+			ApplyBlockNumber: back.BackBlockNumber,
+		})
+	}
+	g.storeBlockEvents(header, events)
+
 	// Assemble and return the final block for sealing
 	return types.NewBlock(header, txs, nil, receipts), nil
 }
@@ -718,9 +931,17 @@ func settleInterestRewards(state *state.StateDB, coinbase common.Address, reward
 	AddProfit(state, coinbase, surplusReward)
 }
 
-// AccumulateInterestRewards credits the reward to the block author by coin  interest
-func accumulateInterestRewards(config *params.GenaroConfig, state *state.StateDB, header *types.Header, proportion uint64,
-	blockNumber uint64, committeeSize uint64, committeeAccountBinding map[common.Address][]common.Address) error {
+// EpochInterestRewards returns the coin interest reward a committee member with
+// proportion earns over an epoch starting at blockNumber.
+func EpochInterestRewards(config *params.GenaroConfig, state *state.StateDB, blockNumber uint64, proportion uint64) *big.Int {
+	planRewards := planInterestRewards(config, state, blockNumber)
+	planRewards.Mul(planRewards, new(big.Int).SetUint64(proportion))
+	return planRewards.Div(planRewards, big.NewInt(int64(common.Base)))
+}
+
+// planInterestRewards returns the coin interest reward of the whole committee
+// for the epoch of blockNumber.
+func planInterestRewards(config *params.GenaroConfig, state *state.StateDB, blockNumber uint64) *big.Int {
 	preCoinRewards := GetPreCoinActualRewards(state)
 	preSurplusRewards := big.NewInt(0)
 	//when now is the start of year, preSurplusRewards should get "Pre + SurplusCoinAddress"
@@ -748,6 +969,14 @@ func accumulateInterestRewards(config *params.GenaroConfig, state *state.StateDB
 	planRewards.Mul(planRewards, big.NewInt(int64(coefficient)))
 	planRewards.Div(planRewards, big.NewInt(int64(common.Base)))
 	//fmt.Printf("Plan rewards this epoch %v(after adjustment), coefficient %v\n", planRewards.String(), coefficient)
+	return planRewards
+}
+
+// AccumulateInterestRewards credits the reward to the block author by coin  interest
+// and returns the reward
+func accumulateInterestRewards(config *params.GenaroConfig, state *state.StateDB, header *types.Header, proportion uint64,
+	blockNumber uint64, committeeSize uint64, committeeAccountBinding map[common.Address][]common.Address) *big.Int {
+	planRewards := planInterestRewards(config, state, blockNumber)
 	//this addr should get
 	planRewards.Mul(planRewards, big.NewInt(int64(proportion)))
 	planRewards.Div(planRewards, big.NewInt(int64(common.Base)))
@@ -765,7 +994,7 @@ func accumulateInterestRewards(config *params.GenaroConfig, state *state.StateDB
 		AddProfit(state, header.Coinbase, reward)
 	}
 	AddCoinActualRewards(state, reward)
-	return nil
+	return reward
 }
 
 // 统一的收益函数
@@ -778,10 +1007,11 @@ func AddProfit(state *state.StateDB, saddr common.Address, reward *big.Int) {
 	}
 }
 
-// AccumulateStorageRewards credits the reward to the sentinel owner
-func accumulateStorageRewards(config *params.GenaroConfig, state *state.StateDB, blockNumber uint64, committeeSize uint64) error {
+// AccumulateStorageRewards credits the reward to the sentinel owner and returns
+// the total reward
+func accumulateStorageRewards(config *params.GenaroConfig, state *state.StateDB, blockNumber uint64, committeeSize uint64) *big.Int {
 	if blockNumber%config.Epoch != 0 {
-		return nil
+		return new(big.Int)
 	}
 	preStorageRewards := GetPreStorageActualRewards(state)
 	preSurplusRewards := big.NewInt(0)
@@ -822,17 +1052,19 @@ func accumulateStorageRewards(config *params.GenaroConfig, state *state.StateDB,
 		total += contributes[i]
 	}
 	if total == 0 {
-		return nil
+		return new(big.Int)
 	}
 
+	rewards := new(big.Int)
 	for i, c := range cs {
 		reward := big.NewInt(0)
 		reward.Mul(planRewards, big.NewInt(int64(contributes[i])))
 		reward.Div(planRewards, big.NewInt(int64(total)))
 		AddProfit(state, c, reward)
 		AddStorageActualRewards(state, reward)
+		rewards.Add(rewards, reward)
 	}
-	return nil
+	return rewards
 }
 
 // VerifyHeader checks whether a header conforms to the consensus rules of a
@@ -875,5 +1107,10 @@ func (g *Genaro) APIs(chain consensus.ChainReader) []rpc.API {
 		Version:   "1.0",
 		Service:   &API{chain: chain, genaro: g},
 		Public:    false,
+	}, {
+		Namespace: "eth",
+		Version:   "1.0",
+		Service:   &PublicEventAPI{genaro: g},
+		Public:    true,
 	}}
 }
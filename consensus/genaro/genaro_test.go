@@ -121,6 +121,97 @@ func TestUpdateSpecialBlock(t *testing.T) {
 	updateSpecialBlock(genaroConfig, header, newTestStateDB())
 }
 
+// Tests that unanswered storage challenges are punished once expired and halve
+// the heft of their staker at the end of the epoch.
+func TestExpireStorageChallenges(t *testing.T) {
+	official := common.HexToAddress("0x1000000000000000000000000000000000000001")
+	staker := common.HexToAddress("0x1000000000000000000000000000000000000002")
+	genaroConfig := &params.GenaroConfig{OfficialAddress: official.Hex()}
+
+	statedb := newTestStateDB()
+	statedb.UpdateStake(staker, common.StoragePunishment*3, 1)
+	statedb.UpdateHeft(staker, 100, 1)
+	statedb.AddStorageChallenge(types.StorageChallenge{NodeID: "a", Account: staker, Deadline: 10})
+	statedb.AddStorageChallenge(types.StorageChallenge{NodeID: "b", Account: staker, Deadline: 20})
+
+	expireStorageChallenges(genaroConfig, statedb, 10)
+	if stake, _ := statedb.GetStake(staker); stake != common.StoragePunishment*3 {
+		t.Errorf("challenge punished before its deadline: stake %d", stake)
+	}
+	expireStorageChallenges(genaroConfig, statedb, 11)
+	if stake, _ := statedb.GetStake(staker); stake != common.StoragePunishment*2 {
+		t.Errorf("stake mismatch: have %d, want %d", stake, common.StoragePunishment*2)
+	}
+	want := new(big.Int).Mul(common.BaseCompany, new(big.Int).SetUint64(common.StoragePunishment))
+	if statedb.GetBalance(official).Cmp(want) != 0 {
+		t.Errorf("official balance mismatch: have %v, want %v", statedb.GetBalance(official), want)
+	}
+	challenges := statedb.GetStorageChallenges()
+	if len(challenges.Pending) != 1 || challenges.Pending[0].NodeID != "b" || challenges.Failures[staker] != 1 {
+		t.Errorf("challenges mismatch: %+v", challenges)
+	}
+
+	penalizeStorageFailures(statedb, 5000)
+	if heft, _ := statedb.GetHeft(staker); heft != 50 {
+		t.Errorf("heft mismatch: have %d, want 50", heft)
+	}
+	if challenges := statedb.GetStorageChallenges(); len(challenges.Failures) != 0 {
+		t.Errorf("failures not reset: %v", challenges.Failures)
+	}
+}
+
+func TestSettleExpiredMortgages(t *testing.T) {
+	writer := common.HexToAddress("0x1000000000000000000000000000000000000001")
+	statedb := newTestStateDB()
+	for i := 0; i < common.MortgageSettlements+2; i++ {
+		from := common.BigToAddress(big.NewInt(int64(0x100 + i)))
+		statedb.OpenMortgage(types.SpecialTxTypeMortgageInit{
+			MortgageTable:  map[common.Address]*hexutil.Big{writer: (*hexutil.Big)(big.NewInt(100))},
+			AuthorityTable: map[common.Address]int{writer: common.Write},
+			FileID:         "file",
+			MortgagTotal:   big.NewInt(100),
+			EndTime:        int64(1000 + i),
+			FromAccount:    from,
+		})
+		statedb.SyncMortgageStatus(from, "file", "v1", types.Sidechain{writer: (*hexutil.Big)(big.NewInt(10))})
+	}
+
+	settleExpiredMortgages(statedb, &types.Header{Number: big.NewInt(5), Time: big.NewInt(999)})
+	if len(statedb.GetMortgageExpiries()) != common.MortgageSettlements+2 {
+		t.Fatalf("mortgage settled before it expired")
+	}
+	// The expired mortgages are settled from their last status, a bounded
+	// number per block
+	settleExpiredMortgages(statedb, &types.Header{Number: big.NewInt(6), Time: big.NewInt(5000)})
+	if expiries := statedb.GetMortgageExpiries(); len(expiries) != 2 {
+		t.Fatalf("expiries mismatch: have %d, want 2", len(expiries))
+	}
+	if have, want := statedb.GetBalance(writer).Int64(), int64(10*common.MortgageSettlements); have != want {
+		t.Errorf("writer balance mismatch: have %d, want %d", have, want)
+	}
+	first := common.BigToAddress(big.NewInt(0x100))
+	if settled, _ := statedb.GetMortgage(first, "file"); !settled.Terminate || settled.SettleBlock != 6 || statedb.GetBalance(first).Int64() != 90 {
+		t.Errorf("settled mortgage mismatch: %+v, refund %v", settled, statedb.GetBalance(first))
+	}
+	settleExpiredMortgages(statedb, &types.Header{Number: big.NewInt(7), Time: big.NewInt(5000)})
+	if expiries := statedb.GetMortgageExpiries(); len(expiries) != 0 {
+		t.Errorf("expired mortgages left: %+v", expiries)
+	}
+
+	// An expiry without an open mortgage is dropped instead of blocking a slot
+	stale := statedb.GetMortgageExpiries()
+	stale.Add(types.MortgageExpiry{FromAccount: first, FileID: "file", EndTime: 1})
+	stale.Add(types.MortgageExpiry{FromAccount: writer, FileID: "none", EndTime: 2})
+	statedb.SetMortgageExpiries(stale)
+	settleExpiredMortgages(statedb, &types.Header{Number: big.NewInt(8), Time: big.NewInt(5000)})
+	if expiries := statedb.GetMortgageExpiries(); len(expiries) != 0 {
+		t.Errorf("stale expiries left: %+v", expiries)
+	}
+	if settled, _ := statedb.GetMortgage(first, "file"); settled.SettleBlock != 6 {
+		t.Errorf("settled mortgage settled again at block %d", settled.SettleBlock)
+	}
+}
+
 func TestCandidateInfos(t *testing.T) {
 	var candidateInfos state.CandidateInfos
 	candidateInfos = make([]state.CandidateInfo, 4)
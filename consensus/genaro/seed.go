@@ -0,0 +1,157 @@
+package genaro
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"math/big"
+
+	"github.com/GenaroNetwork/GenaroCore/common"
+	"github.com/GenaroNetwork/GenaroCore/consensus"
+	"github.com/GenaroNetwork/GenaroCore/core/types"
+	"github.com/GenaroNetwork/GenaroCore/crypto"
+)
+
+// From GenaroConfig.InturnSeedBlock on every block carries a seed in its
+// ExtraData. The signer of a block signs the seed of the parent block, and the
+// seed of the block is the hash of the parent seed and that signature, so every
+// signer mixes an unpredictable but verifiable contribution into the chain of
+// seeds. The seed of the last block before a turn shuffles the in-turn order of
+// the turn, which is therefore unknown until the turn is about to start.
+//
+// Honest signers sign deterministically (RFC 6979), so a signer cannot choose
+// between several contributions without running a modified client. The signer
+// of the last block before a turn can still withhold its block.
+
+var errInvalidSeed = errors.New("invalid in-turn seed")
+
+// seedOf returns the in-turn seed carried by header, the zero hash if it has
+// none.
+func seedOf(header *types.Header) common.Hash {
+	seed := UnmarshalToExtra(header).Seed
+	if seed == nil {
+		return common.Hash{}
+	}
+	return *seed
+}
+
+// revealHash returns the hash the signer of a block signs to contribute to the
+// seed of the block.
+func revealHash(parentSeed common.Hash) common.Hash {
+	return crypto.Keccak256Hash([]byte("genaro-seed"), parentSeed[:])
+}
+
+// nextSeed mixes the contribution of a signer into the parent seed.
+func nextSeed(parentSeed common.Hash, reveal []byte) common.Hash {
+	return crypto.Keccak256Hash(parentSeed[:], reveal)
+}
+
+// SetHeaderSeed stores the seed of a block and the contribution of its signer
+// in the header.
+func SetHeaderSeed(header *types.Header, seed common.Hash, reveal []byte) error {
+	extraData := UnmarshalToExtra(header)
+	extraData.Seed = &seed
+	extraData.SeedReveal = make([]byte, len(reveal))
+	copy(extraData.SeedReveal, reveal)
+	extraByte, err := json.Marshal(extraData)
+	if err != nil {
+		return err
+	}
+	header.Extra = make([]byte, len(extraByte))
+	copy(header.Extra, extraByte)
+	return nil
+}
+
+// verifySeed checks that the seed of header was derived from the seed of parent
+// with a contribution of signer.
+func verifySeed(header *types.Header, parent *types.Header, signer common.Address) error {
+	extraData := UnmarshalToExtra(header)
+	if extraData.Seed == nil {
+		return errInvalidSeed
+	}
+	parentSeed := seedOf(parent)
+	pubkey, err := crypto.Ecrecover(revealHash(parentSeed).Bytes(), extraData.SeedReveal)
+	if err != nil {
+		return errInvalidSeed
+	}
+	var revealer common.Address
+	copy(revealer[:], crypto.Keccak256(pubkey[1:])[12:])
+	if revealer != signer || *extraData.Seed != nextSeed(parentSeed, extraData.SeedReveal) {
+		return errInvalidSeed
+	}
+	return nil
+}
+
+// shuffleCommittee orders the committee by drawing the members one at a time
+// without replacement, each with a chance proportional to its proportion. The
+// draws are taken from seed.
+func shuffleCommittee(rank []common.Address, committee map[common.Address]uint64, seed common.Hash) []common.Address {
+	remaining := make([]common.Address, len(rank))
+	copy(remaining, rank)
+	order := make([]common.Address, 0, len(rank))
+
+	for draw := uint64(0); len(remaining) > 0; draw++ {
+		total := uint64(0)
+		for _, member := range remaining {
+			total += committee[member]
+		}
+		var b [8]byte
+		binary.BigEndian.PutUint64(b[:], draw)
+		r := new(big.Int).SetBytes(crypto.Keccak256(seed[:], b[:]))
+
+		pick := 0
+		if total == 0 {
+			pick = int(r.Mod(r, big.NewInt(int64(len(remaining)))).Int64())
+		} else {
+			point := r.Mod(r, new(big.Int).SetUint64(total)).Uint64()
+			for i, member := range remaining {
+				if point < committee[member] {
+					pick = i
+					break
+				}
+				point -= committee[member]
+			}
+		}
+		order = append(order, remaining[pick])
+		remaining = append(remaining[:pick], remaining[pick+1:]...)
+	}
+	return order
+}
+
+// turnSnapshot returns the snapshot of the committee sealing the block number,
+// with the committee in the in-turn order of the turn.
+func (g *Genaro) turnSnapshot(chain consensus.ChainReader, number uint64, parents []*types.Header) (*CommitteeSnapshot, error) {
+	turn := GetTurnOfCommiteeByBlockNumber(g.config, number)
+	snap, err := g.snapshot(chain, turn, parents)
+	if err != nil {
+		return nil, err
+	}
+	start := GetFirstBlockNumberOfEpoch(g.config, turn)
+	if start == 0 || !g.config.IsInturnSeed(new(big.Int).SetUint64(start)) {
+		return snap, nil
+	}
+	header := headerByNumber(chain, start-1, parents)
+	if header == nil {
+		return nil, consensus.ErrUnknownAncestor
+	}
+	// The turn the fork activates in starts after a block without seed.
+	seed := seedOf(header)
+	if seed == (common.Hash{}) {
+		return snap, nil
+	}
+	shuffled := snap.copy()
+	shuffled.CommitteeRank = shuffleCommittee(snap.CommitteeRank, snap.Committee, seed)
+	return shuffled, nil
+}
+
+// headerByNumber retrieves the header number out of the batch of parents being
+// verified, or else out of the chain.
+func headerByNumber(chain consensus.ChainReader, number uint64, parents []*types.Header) *types.Header {
+	if len(parents) > 0 {
+		first := parents[0].Number.Uint64()
+		if number >= first && number-first < uint64(len(parents)) {
+			return parents[number-first]
+		}
+	}
+	return chain.GetHeaderByNumber(number)
+}
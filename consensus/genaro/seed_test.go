@@ -0,0 +1,75 @@
+package genaro
+
+import (
+	"math/big"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/GenaroNetwork/GenaroCore/common"
+	"github.com/GenaroNetwork/GenaroCore/core/types"
+	"github.com/GenaroNetwork/GenaroCore/crypto"
+)
+
+func TestVerifySeed(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	signer := crypto.PubkeyToAddress(key.PublicKey)
+
+	parent := &types.Header{Number: big.NewInt(1)}
+	SetHeaderSeed(parent, common.HexToHash("0x01"), nil)
+	header := &types.Header{Number: big.NewInt(2)}
+	reveal, err := crypto.Sign(revealHash(seedOf(parent)).Bytes(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetHeaderSeed(header, nextSeed(seedOf(parent), reveal), reveal)
+
+	if err := verifySeed(header, parent, signer); err != nil {
+		t.Errorf("valid seed rejected: %v", err)
+	}
+	if err := verifySeed(header, parent, common.HexToAddress("0x01")); err != errInvalidSeed {
+		t.Errorf("seed of another signer: have %v, want %v", err, errInvalidSeed)
+	}
+	if err := verifySeed(header, &types.Header{Number: big.NewInt(1)}, signer); err != errInvalidSeed {
+		t.Errorf("seed of another parent: have %v, want %v", err, errInvalidSeed)
+	}
+	SetHeaderSeed(header, common.HexToHash("0x02"), reveal)
+	if err := verifySeed(header, parent, signer); err != errInvalidSeed {
+		t.Errorf("forged seed: have %v, want %v", err, errInvalidSeed)
+	}
+	if err := verifySeed(&types.Header{Number: big.NewInt(2)}, parent, signer); err != errInvalidSeed {
+		t.Errorf("missing seed: have %v, want %v", err, errInvalidSeed)
+	}
+}
+
+func TestShuffleCommittee(t *testing.T) {
+	var (
+		a = common.HexToAddress("0x0a")
+		b = common.HexToAddress("0x0b")
+		c = common.HexToAddress("0x0c")
+	)
+	rank := []common.Address{a, b, c}
+	committee := map[common.Address]uint64{a: 8000, b: 1000, c: 1000}
+
+	first := make(map[common.Address]int)
+	for i := 0; i < 1000; i++ {
+		seed := crypto.Keccak256Hash(big.NewInt(int64(i)).Bytes())
+		order := shuffleCommittee(rank, committee, seed)
+		if !reflect.DeepEqual(order, shuffleCommittee(rank, committee, seed)) {
+			t.Fatalf("seed %x: order not deterministic", seed)
+		}
+		sorted := append([]common.Address{}, order...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i].Big().Cmp(sorted[j].Big()) < 0 })
+		if !reflect.DeepEqual(sorted, rank) {
+			t.Fatalf("seed %x: order %x is not a permutation of %x", seed, order, rank)
+		}
+		first[order[0]]++
+	}
+	// a holds 80% of the proportion and should lead about 800 of the turns.
+	if first[a] < 700 || first[a] > 900 || first[b] == 0 || first[c] == 0 {
+		t.Errorf("first in turn not weighted by proportion: %v", first)
+	}
+	if !reflect.DeepEqual(rank, []common.Address{a, b, c}) {
+		t.Errorf("rank modified: %x", rank)
+	}
+}
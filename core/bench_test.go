@@ -26,6 +26,7 @@ import (
 	"github.com/GenaroNetwork/GenaroCore/common"
 	"github.com/GenaroNetwork/GenaroCore/common/math"
 	"github.com/GenaroNetwork/GenaroCore/consensus/ethash"
+	"github.com/GenaroNetwork/GenaroCore/core/state"
 	"github.com/GenaroNetwork/GenaroCore/core/types"
 	"github.com/GenaroNetwork/GenaroCore/core/vm"
 	"github.com/GenaroNetwork/GenaroCore/crypto"
@@ -34,40 +35,58 @@ import (
 )
 
 func BenchmarkInsertChain_empty_memdb(b *testing.B) {
-	benchInsertChain(b, false, nil)
+	benchInsertChain(b, "", nil)
 }
 func BenchmarkInsertChain_empty_diskdb(b *testing.B) {
-	benchInsertChain(b, true, nil)
+	benchInsertChain(b, ethdb.EngineLevelDB, nil)
+}
+func BenchmarkInsertChain_empty_pebbledb(b *testing.B) {
+	benchInsertChain(b, ethdb.EnginePebble, nil)
 }
 func BenchmarkInsertChain_valueTx_memdb(b *testing.B) {
-	benchInsertChain(b, false, genValueTx(0))
+	benchInsertChain(b, "", genValueTx(0))
 }
 func BenchmarkInsertChain_valueTx_diskdb(b *testing.B) {
-	benchInsertChain(b, true, genValueTx(0))
+	benchInsertChain(b, ethdb.EngineLevelDB, genValueTx(0))
+}
+func BenchmarkInsertChain_valueTx_pebbledb(b *testing.B) {
+	benchInsertChain(b, ethdb.EnginePebble, genValueTx(0))
 }
 func BenchmarkInsertChain_valueTx_100kB_memdb(b *testing.B) {
-	benchInsertChain(b, false, genValueTx(100*1024))
+	benchInsertChain(b, "", genValueTx(100*1024))
 }
 func BenchmarkInsertChain_valueTx_100kB_diskdb(b *testing.B) {
-	benchInsertChain(b, true, genValueTx(100*1024))
+	benchInsertChain(b, ethdb.EngineLevelDB, genValueTx(100*1024))
+}
+func BenchmarkInsertChain_valueTx_100kB_pebbledb(b *testing.B) {
+	benchInsertChain(b, ethdb.EnginePebble, genValueTx(100*1024))
 }
 func BenchmarkInsertChain_uncles_memdb(b *testing.B) {
-	benchInsertChain(b, false, genUncles)
+	benchInsertChain(b, "", genUncles)
 }
 func BenchmarkInsertChain_uncles_diskdb(b *testing.B) {
-	benchInsertChain(b, true, genUncles)
+	benchInsertChain(b, ethdb.EngineLevelDB, genUncles)
+}
+func BenchmarkInsertChain_uncles_pebbledb(b *testing.B) {
+	benchInsertChain(b, ethdb.EnginePebble, genUncles)
 }
 func BenchmarkInsertChain_ring200_memdb(b *testing.B) {
-	benchInsertChain(b, false, genTxRing(200))
+	benchInsertChain(b, "", genTxRing(200))
 }
 func BenchmarkInsertChain_ring200_diskdb(b *testing.B) {
-	benchInsertChain(b, true, genTxRing(200))
+	benchInsertChain(b, ethdb.EngineLevelDB, genTxRing(200))
+}
+func BenchmarkInsertChain_ring200_pebbledb(b *testing.B) {
+	benchInsertChain(b, ethdb.EnginePebble, genTxRing(200))
 }
 func BenchmarkInsertChain_ring1000_memdb(b *testing.B) {
-	benchInsertChain(b, false, genTxRing(1000))
+	benchInsertChain(b, "", genTxRing(1000))
 }
 func BenchmarkInsertChain_ring1000_diskdb(b *testing.B) {
-	benchInsertChain(b, true, genTxRing(1000))
+	benchInsertChain(b, ethdb.EngineLevelDB, genTxRing(1000))
+}
+func BenchmarkInsertChain_ring1000_pebbledb(b *testing.B) {
+	benchInsertChain(b, ethdb.EnginePebble, genTxRing(1000))
 }
 
 var (
@@ -144,10 +163,10 @@ func genUncles(i int, gen *BlockGen) {
 	}
 }
 
-func benchInsertChain(b *testing.B, disk bool, gen func(int, *BlockGen)) {
+func benchInsertChain(b *testing.B, engine string, gen func(int, *BlockGen)) {
 	// Create the database in memory or in a temporary directory.
 	var db ethdb.Database
-	if !disk {
+	if engine == "" {
 		db = ethdb.NewMemDatabase()
 	} else {
 		dir, err := ioutil.TempDir("", "eth-core-bench")
@@ -155,7 +174,10 @@ func benchInsertChain(b *testing.B, disk bool, gen func(int, *BlockGen)) {
 			b.Fatalf("cannot create temporary directory: %v", err)
 		}
 		defer os.RemoveAll(dir)
-		db, err = ethdb.NewLDBDatabase(dir, 128, 128)
+		db, err = ethdb.NewDatabase(dir, engine, 128, 128)
+		if err == ethdb.ErrNoPebble {
+			b.Skip(err)
+		}
 		if err != nil {
 			b.Fatalf("cannot create temporary database: %v", err)
 		}
@@ -183,40 +205,64 @@ func benchInsertChain(b *testing.B, disk bool, gen func(int, *BlockGen)) {
 }
 
 func BenchmarkChainRead_header_10k(b *testing.B) {
-	benchReadChain(b, false, 10000)
+	benchReadChain(b, ethdb.EngineLevelDB, false, 10000)
+}
+func BenchmarkChainRead_header_10k_pebbledb(b *testing.B) {
+	benchReadChain(b, ethdb.EnginePebble, false, 10000)
 }
 func BenchmarkChainRead_full_10k(b *testing.B) {
-	benchReadChain(b, true, 10000)
+	benchReadChain(b, ethdb.EngineLevelDB, true, 10000)
+}
+func BenchmarkChainRead_full_10k_pebbledb(b *testing.B) {
+	benchReadChain(b, ethdb.EnginePebble, true, 10000)
 }
 func BenchmarkChainRead_header_100k(b *testing.B) {
-	benchReadChain(b, false, 100000)
+	benchReadChain(b, ethdb.EngineLevelDB, false, 100000)
+}
+func BenchmarkChainRead_header_100k_pebbledb(b *testing.B) {
+	benchReadChain(b, ethdb.EnginePebble, false, 100000)
 }
 func BenchmarkChainRead_full_100k(b *testing.B) {
-	benchReadChain(b, true, 100000)
+	benchReadChain(b, ethdb.EngineLevelDB, true, 100000)
+}
+func BenchmarkChainRead_full_100k_pebbledb(b *testing.B) {
+	benchReadChain(b, ethdb.EnginePebble, true, 100000)
 }
 func BenchmarkChainRead_header_500k(b *testing.B) {
-	benchReadChain(b, false, 500000)
+	benchReadChain(b, ethdb.EngineLevelDB, false, 500000)
 }
 func BenchmarkChainRead_full_500k(b *testing.B) {
-	benchReadChain(b, true, 500000)
+	benchReadChain(b, ethdb.EngineLevelDB, true, 500000)
 }
 func BenchmarkChainWrite_header_10k(b *testing.B) {
-	benchWriteChain(b, false, 10000)
+	benchWriteChain(b, ethdb.EngineLevelDB, false, 10000)
+}
+func BenchmarkChainWrite_header_10k_pebbledb(b *testing.B) {
+	benchWriteChain(b, ethdb.EnginePebble, false, 10000)
 }
 func BenchmarkChainWrite_full_10k(b *testing.B) {
-	benchWriteChain(b, true, 10000)
+	benchWriteChain(b, ethdb.EngineLevelDB, true, 10000)
+}
+func BenchmarkChainWrite_full_10k_pebbledb(b *testing.B) {
+	benchWriteChain(b, ethdb.EnginePebble, true, 10000)
 }
 func BenchmarkChainWrite_header_100k(b *testing.B) {
-	benchWriteChain(b, false, 100000)
+	benchWriteChain(b, ethdb.EngineLevelDB, false, 100000)
+}
+func BenchmarkChainWrite_header_100k_pebbledb(b *testing.B) {
+	benchWriteChain(b, ethdb.EnginePebble, false, 100000)
 }
 func BenchmarkChainWrite_full_100k(b *testing.B) {
-	benchWriteChain(b, true, 100000)
+	benchWriteChain(b, ethdb.EngineLevelDB, true, 100000)
+}
+func BenchmarkChainWrite_full_100k_pebbledb(b *testing.B) {
+	benchWriteChain(b, ethdb.EnginePebble, true, 100000)
 }
 func BenchmarkChainWrite_header_500k(b *testing.B) {
-	benchWriteChain(b, false, 500000)
+	benchWriteChain(b, ethdb.EngineLevelDB, false, 500000)
 }
 func BenchmarkChainWrite_full_500k(b *testing.B) {
-	benchWriteChain(b, true, 500000)
+	benchWriteChain(b, ethdb.EngineLevelDB, true, 500000)
 }
 
 // makeChainForBench writes a given number of headers or empty blocks/receipts
@@ -245,13 +291,16 @@ func makeChainForBench(db ethdb.Database, full bool, count uint64) {
 	}
 }
 
-func benchWriteChain(b *testing.B, full bool, count uint64) {
+func benchWriteChain(b *testing.B, engine string, full bool, count uint64) {
 	for i := 0; i < b.N; i++ {
 		dir, err := ioutil.TempDir("", "eth-chain-bench")
 		if err != nil {
 			b.Fatalf("cannot create temporary directory: %v", err)
 		}
-		db, err := ethdb.NewLDBDatabase(dir, 128, 1024)
+		db, err := ethdb.NewDatabase(dir, engine, 128, 1024)
+		if err == ethdb.ErrNoPebble {
+			b.Skip(err)
+		}
 		if err != nil {
 			b.Fatalf("error opening database at %v: %v", dir, err)
 		}
@@ -261,14 +310,17 @@ func benchWriteChain(b *testing.B, full bool, count uint64) {
 	}
 }
 
-func benchReadChain(b *testing.B, full bool, count uint64) {
+func benchReadChain(b *testing.B, engine string, full bool, count uint64) {
 	dir, err := ioutil.TempDir("", "eth-chain-bench")
 	if err != nil {
 		b.Fatalf("cannot create temporary directory: %v", err)
 	}
 	defer os.RemoveAll(dir)
 
-	db, err := ethdb.NewLDBDatabase(dir, 128, 1024)
+	db, err := ethdb.NewDatabase(dir, engine, 128, 1024)
+	if err == ethdb.ErrNoPebble {
+		b.Skip(err)
+	}
 	if err != nil {
 		b.Fatalf("error opening database at %v: %v", dir, err)
 	}
@@ -279,7 +331,10 @@ func benchReadChain(b *testing.B, full bool, count uint64) {
 	b.ResetTimer()
 
 	for i := 0; i < b.N; i++ {
-		db, err := ethdb.NewLDBDatabase(dir, 128, 1024)
+		db, err := ethdb.NewDatabase(dir, engine, 128, 1024)
+		if err == ethdb.ErrNoPebble {
+			b.Skip(err)
+		}
 		if err != nil {
 			b.Fatalf("error opening database at %v: %v", dir, err)
 		}
@@ -301,3 +356,53 @@ func benchReadChain(b *testing.B, full bool, count uint64) {
 		db.Close()
 	}
 }
+
+func BenchmarkGenaroState_diskdb(b *testing.B) {
+	benchGenaroState(b, ethdb.EngineLevelDB)
+}
+func BenchmarkGenaroState_pebbledb(b *testing.B) {
+	benchGenaroState(b, ethdb.EnginePebble)
+}
+
+// benchGenaroState writes b.N block states to disk, each rewriting the Genaro
+// data of 200 accounts the way the stake and heft special transactions do.
+func benchGenaroState(b *testing.B, engine string) {
+	dir, err := ioutil.TempDir("", "eth-genaro-bench")
+	if err != nil {
+		b.Fatalf("cannot create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := ethdb.NewDatabase(dir, engine, 128, 1024)
+	if err == ethdb.ErrNoPebble {
+		b.Skip(err)
+	}
+	if err != nil {
+		b.Fatalf("error opening database at %v: %v", dir, err)
+	}
+	defer db.Close()
+	sdb := state.NewDatabase(db)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	var root common.Hash
+	for i := 0; i < b.N; i++ {
+		statedb, err := state.New(root, sdb)
+		if err != nil {
+			b.Fatalf("error opening state: %v", err)
+		}
+		for j, addr := range ringAddrs[:200] {
+			statedb.UpdateStake(addr, 1, uint64(i))
+			if j%4 == 0 {
+				statedb.UpdateHeft(addr, 1, uint64(i))
+			}
+		}
+		if root, err = statedb.Commit(true); err != nil {
+			b.Fatalf("error committing state: %v", err)
+		}
+		if err := sdb.TrieDB().Commit(root, false); err != nil {
+			b.Fatalf("error writing state: %v", err)
+		}
+	}
+}
@@ -86,6 +86,7 @@ type GenesisAccount struct {
 	CodeHash   []byte                      `json:"CodeHash,omitempty"` // genaro data
 	Nonce      uint64                      `json:"nonce,omitempty"`
 	PrivateKey []byte                      `json:"secretKey,omitempty"` // for tests
+	Vesting    *types.Vesting              `json:"vesting,omitempty"`   // GNX locked on top of the balance
 }
 
 // field type overrides for gencodec
@@ -169,7 +170,10 @@ func SetupGenesisBlock(db ethdb.Database, genesis *Genesis) (*params.ChainConfig
 			log.Info("Writing custom genesis block")
 		}
 		block, err := genesis.Commit(db)
-		return genesis.Config, block.Hash(), err
+		if err != nil {
+			return genesis.Config, common.Hash{}, err
+		}
+		return genesis.Config, block.Hash(), nil
 	}
 
 	// Check whether the genesis block is already written.
@@ -251,6 +255,9 @@ func (g *Genesis) ToBlock(db ethdb.Database) *types.Block {
 		} else {
 			statedb.SetCode(addr, account.Code)
 		}
+		if account.Vesting != nil {
+			statedb.SetVesting(addr, *account.Vesting)
+		}
 
 		statedb.SetNonce(addr, account.Nonce)
 		for key, value := range account.Storage {
@@ -286,6 +293,11 @@ func (g *Genesis) ToBlock(db ethdb.Database) *types.Block {
 // Commit writes the block and state of a genesis specification to the database.
 // The block is committed as the canonical head block.
 func (g *Genesis) Commit(db ethdb.Database) (*types.Block, error) {
+	for addr, account := range g.Alloc {
+		if account.Vesting != nil && !account.Vesting.Valid() {
+			return nil, fmt.Errorf("invalid vesting of genesis account %x", addr)
+		}
+	}
 	block := g.ToBlock(db)
 	if block.Number().Sign() != 0 {
 		return nil, fmt.Errorf("can't commit genesis block with number > 0")
@@ -22,7 +22,9 @@ import (
 	"testing"
 
 	"github.com/GenaroNetwork/GenaroCore/common"
+	"github.com/GenaroNetwork/GenaroCore/common/hexutil"
 	"github.com/GenaroNetwork/GenaroCore/consensus/ethash"
+	"github.com/GenaroNetwork/GenaroCore/core/types"
 	"github.com/GenaroNetwork/GenaroCore/core/vm"
 	"github.com/GenaroNetwork/GenaroCore/ethdb"
 	"github.com/GenaroNetwork/GenaroCore/params"
@@ -169,3 +171,27 @@ func TestSetupGenesis(t *testing.T) {
 		}
 	}
 }
+
+// Tests that a genesis with an invalid vesting allocation is not committed.
+func TestGenesisInvalidVesting(t *testing.T) {
+	addr := common.HexToAddress("0x1000000000000000000000000000000000000001")
+	for i, vesting := range []*types.Vesting{
+		{Start: 0, Cliff: 10, End: 20},
+		{Total: (*hexutil.Big)(big.NewInt(1)), Start: 20, Cliff: 10, End: 30},
+		{Total: (*hexutil.Big)(big.NewInt(1)), Start: 10, Cliff: 10, End: 10},
+	} {
+		genesis := &Genesis{Config: params.TestChainConfig, Alloc: GenesisAlloc{addr: {Balance: big.NewInt(1), Vesting: vesting}}}
+		db := ethdb.NewMemDatabase()
+		if _, _, err := SetupGenesisBlock(db, genesis); err == nil {
+			t.Errorf("test %d: invalid vesting committed", i)
+		}
+		if stored := GetCanonicalHash(db, 0); stored != (common.Hash{}) {
+			t.Errorf("test %d: genesis block written: %x", i, stored)
+		}
+	}
+	valid := &types.Vesting{Total: (*hexutil.Big)(big.NewInt(1)), Start: 10, Cliff: 10, End: 20}
+	genesis := &Genesis{Config: params.TestChainConfig, Alloc: GenesisAlloc{addr: {Balance: big.NewInt(1), Vesting: valid}}}
+	if _, err := genesis.Commit(ethdb.NewMemDatabase()); err != nil {
+		t.Errorf("valid vesting rejected: %v", err)
+	}
+}
@@ -59,6 +59,10 @@ type (
 		account            *common.Address
 		prevcode, prevhash []byte
 	}
+	genaroDataChange struct {
+		account            *common.Address
+		prevcode, prevdata []byte
+	}
 
 	// Changes to other state values.
 	refundChange struct {
@@ -117,6 +121,13 @@ func (ch codeChange) undo(s *StateDB) {
 	s.getStateObject(*ch.account).setCode(common.BytesToHash(ch.prevhash), ch.prevcode)
 }
 
+func (ch genaroDataChange) undo(s *StateDB) {
+	obj := s.getStateObject(*ch.account)
+	obj.code = ch.prevcode
+	obj.data.CodeHash = ch.prevdata
+	obj.dirtyCode = true
+}
+
 func (ch storageChange) undo(s *StateDB) {
 	s.getStateObject(*ch.account).setState(ch.key, ch.prevalue)
 }
@@ -0,0 +1,157 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	"github.com/GenaroNetwork/GenaroCore/common"
+	"github.com/GenaroNetwork/GenaroCore/ethdb"
+	"github.com/GenaroNetwork/GenaroCore/log"
+)
+
+// PruneStats reports the outcome of PruneState.
+type PruneStats struct {
+	Reachable int                // trie nodes and contract codes kept
+	Pruned    int                // trie nodes and contract codes deleted, deletable on a dry run
+	Size      common.StorageSize // bytes deleted, deletable on a dry run
+}
+
+// stateBloom is a bloom filter marking the trie nodes and contract codes to
+// keep. As the entries are keyed by their hash, slices of the key are used as
+// the hash functions.
+type stateBloom []uint64
+
+// newStateBloom creates a bloom filter of size bytes.
+func newStateBloom(size uint64) stateBloom {
+	if size < 8 {
+		size = 8
+	}
+	return make(stateBloom, size/8)
+}
+
+// add marks the hash in the filter.
+func (b stateBloom) add(hash common.Hash) {
+	bits := uint64(len(b)) * 64
+	for i := 0; i < common.HashLength/8; i++ {
+		bit := binary.BigEndian.Uint64(hash[i*8:]) % bits
+		b[bit/64] |= 1 << (bit % 64)
+	}
+}
+
+// contains reports whether the hash may have been marked, false positives are
+// possible but false negatives are not.
+func (b stateBloom) contains(hash common.Hash) bool {
+	bits := uint64(len(b)) * 64
+	for i := 0; i < common.HashLength/8; i++ {
+		bit := binary.BigEndian.Uint64(hash[i*8:]) % bits
+		if b[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// PruneState deletes the trie nodes and contract codes of the database that
+// are not reachable from any of the state roots, then compacts the database.
+// On a dry run the database is left untouched and only the stats are reported.
+//
+// Trie nodes and contract codes are keyed by their 32 byte hash, the other
+// entries of the chain database are prefixed. Since some of the prefixed
+// entries are 32 bytes long too, the entries starting with one of the keep
+// prefixes are never deleted; a few unreachable trie nodes whose hash happens
+// to start with one of them are kept around. The reachable entries are marked
+// in a bloom filter of bloomSize bytes, so that memory use does not grow with
+// the state; its false positives only keep a few more of them.
+func PruneState(db ethdb.DiskDatabase, roots []common.Hash, keep [][]byte, bloomSize uint64, dryRun bool) (*PruneStats, error) {
+	// Mark the entries reachable from the roots, failing before anything is
+	// deleted if a state is incomplete
+	reachable := newStateBloom(bloomSize)
+	statedb := NewDatabase(db)
+	for _, root := range roots {
+		state, err := New(root, statedb)
+		if err != nil {
+			return nil, err
+		}
+		marked := 0
+		it := NewNodeIterator(state)
+		for it.Next() {
+			if it.Hash != (common.Hash{}) {
+				reachable.add(it.Hash)
+				marked++
+			}
+		}
+		if it.Error != nil {
+			return nil, it.Error
+		}
+		log.Info("Marked reachable state", "root", root, "entries", marked)
+	}
+	// Sweep the rest
+	stats := new(PruneStats)
+	batch := db.NewBatch()
+	it := db.NewIteratorWithPrefix(nil)
+	for it.Next() {
+		key := it.Key()
+		if len(key) != common.HashLength || hasPrefix(key, keep) {
+			continue
+		}
+		if reachable.contains(common.BytesToHash(key)) {
+			stats.Reachable++
+			continue
+		}
+		stats.Pruned++
+		stats.Size += common.StorageSize(len(key) + len(it.Value()))
+		if dryRun {
+			continue
+		}
+		batch.Delete(common.CopyBytes(key))
+		if batch.ValueSize() >= ethdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				it.Release()
+				return nil, err
+			}
+			batch.Reset()
+		}
+	}
+	it.Release()
+	if err := it.Error(); err != nil {
+		return nil, err
+	}
+	if dryRun {
+		return stats, nil
+	}
+	if err := batch.Write(); err != nil {
+		return nil, err
+	}
+	log.Info("Deleted unreachable state", "entries", stats.Pruned, "size", stats.Size)
+
+	if err := db.Compact(nil, nil); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// hasPrefix reports whether the key starts with one of the prefixes.
+func hasPrefix(key []byte, prefixes [][]byte) bool {
+	for _, prefix := range prefixes {
+		if bytes.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
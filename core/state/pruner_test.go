@@ -0,0 +1,143 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/GenaroNetwork/GenaroCore/common"
+	"github.com/GenaroNetwork/GenaroCore/crypto"
+	"github.com/GenaroNetwork/GenaroCore/ethdb"
+)
+
+// checkStateComplete iterates over the whole state, failing if any entry is
+// missing.
+func checkStateComplete(db ethdb.Database, root common.Hash) error {
+	state, err := New(root, NewDatabase(db))
+	if err != nil {
+		return err
+	}
+	it := NewNodeIterator(state)
+	for it.Next() {
+	}
+	return it.Error
+}
+
+func TestPruneState(t *testing.T)       { testPruneState(t, ethdb.EngineLevelDB) }
+func TestPruneStatePebble(t *testing.T) { testPruneState(t, ethdb.EnginePebble) }
+
+func testPruneState(t *testing.T, engine string) {
+	dir, err := ioutil.TempDir("", "pruner")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	diskdb, err := ethdb.NewDatabase(dir, engine, 0, 0)
+	if err == ethdb.ErrNoPebble {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer diskdb.Close()
+
+	// Write two states on disk, the second updating the storage and code of
+	// some of the accounts of the first
+	db := NewDatabase(diskdb)
+	state, _ := New(common.Hash{}, db)
+	for i := byte(0); i < 64; i++ {
+		addr := common.BytesToAddress([]byte{i})
+		state.AddBalance(addr, big.NewInt(int64(i)+1))
+		state.SetState(addr, common.Hash{i}, common.Hash{i})
+		if i%4 == 0 {
+			state.SetCode(addr, []byte{i, i})
+		}
+	}
+	old, _ := state.Commit(false)
+	if err := db.TrieDB().Commit(old, false); err != nil {
+		t.Fatal(err)
+	}
+	state, _ = New(old, db)
+	for i := byte(0); i < 64; i += 8 {
+		addr := common.BytesToAddress([]byte{i})
+		state.SetState(addr, common.Hash{i}, common.Hash{i, 1})
+		state.SetCode(addr, []byte{i, i, 1})
+	}
+	root, _ := state.Commit(false)
+	if err := db.TrieDB().Commit(root, false); err != nil {
+		t.Fatal(err)
+	}
+	diskdb.Put([]byte("genaro-test"), []byte{1})
+
+	// A prefixed entry as long as a hash is kept if its prefix is
+	keep := [][]byte{[]byte("iX")}
+	indexKey := append([]byte("iX"), make([]byte, common.HashLength-2)...)
+	diskdb.Put(indexKey, []byte{1})
+
+	// A dry run reports the unreachable entries without deleting them
+	stats, err := PruneState(diskdb, []common.Hash{root}, keep, 1024*1024, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Pruned == 0 || stats.Size == 0 {
+		t.Fatalf("nothing to prune reported: %+v", stats)
+	}
+	if err := checkStateComplete(diskdb, old); err != nil {
+		t.Fatalf("dry run deleted the old state: %v", err)
+	}
+
+	// Pruning deletes the old state only
+	pruned, err := PruneState(diskdb, []common.Hash{root}, keep, 1024*1024, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *pruned != *stats {
+		t.Errorf("pruned %+v, dry run reported %+v", pruned, stats)
+	}
+	if err := checkStateComplete(diskdb, root); err != nil {
+		t.Fatalf("kept state incomplete: %v", err)
+	}
+	if err := checkStateComplete(diskdb, old); err == nil {
+		t.Fatalf("old state not pruned")
+	}
+	if ok, _ := diskdb.Has(crypto.Keccak256([]byte{4, 4})); !ok {
+		t.Errorf("code still reachable pruned")
+	}
+	if ok, _ := diskdb.Has(crypto.Keccak256([]byte{8, 8})); ok {
+		t.Errorf("unreachable code kept")
+	}
+	if ok, _ := diskdb.Has([]byte("genaro-test")); !ok {
+		t.Errorf("entry other than state pruned")
+	}
+	if ok, _ := diskdb.Has(indexKey); !ok {
+		t.Errorf("entry with a kept prefix pruned")
+	}
+
+	// Pruning again finds nothing, and a missing root aborts before deleting
+	if stats, err := PruneState(diskdb, []common.Hash{root}, keep, 1024*1024, false); err != nil || stats.Pruned != 0 {
+		t.Errorf("second prune: %+v, %v", stats, err)
+	}
+	if _, err := PruneState(diskdb, []common.Hash{root, old}, keep, 1024*1024, false); err == nil {
+		t.Errorf("pruned with an incomplete state")
+	}
+	if err := checkStateComplete(diskdb, root); err != nil {
+		t.Fatalf("failed prune deleted state: %v", err)
+	}
+}
@@ -490,6 +490,20 @@ func (self *stateObject) SetCodeHash(codeHash []byte) {
 	}
 }
 
+// journalGenaroData records the Genaro data of the account before it is
+// overwritten, so reverting to an earlier snapshot restores it. Nothing is
+// recorded unless the state journals its Genaro data.
+func (self *stateObject) journalGenaroData() {
+	if !self.db.genaroJournal {
+		return
+	}
+	self.db.journal = append(self.db.journal, genaroDataChange{
+		account:  &self.address,
+		prevcode: self.code,
+		prevdata: self.data.CodeHash,
+	})
+}
+
 func (self *stateObject) setCode(codeHash common.Hash, code []byte) {
 	self.code = code
 	self.data.CodeHash = codeHash[:]
@@ -559,6 +573,7 @@ func (self *stateObject) UpdateHeft(heft uint64, blockNumber uint64) {
 	}
 
 	b, _ := json.Marshal(genaroData)
+	self.journalGenaroData()
 	self.code = nil
 	self.data.CodeHash = b[:]
 	self.dirtyCode = true
@@ -622,6 +637,7 @@ func (self *stateObject) UpdateStake(stake uint64, blockNumber uint64) {
 	}
 
 	b, _ := json.Marshal(genaroData)
+	self.journalGenaroData()
 	self.code = nil
 	self.data.CodeHash = b[:]
 	self.dirtyCode = true
@@ -651,6 +667,7 @@ func (self *stateObject) DeleteStake(stake uint64, blockNumber uint64) uint64 {
 		genaroData.StakeLog.Add(newLog)
 
 		b, _ := json.Marshal(genaroData)
+		self.journalGenaroData()
 		self.code = nil
 		self.data.CodeHash = b[:]
 		self.dirtyCode = true
@@ -702,6 +719,7 @@ func (self *stateObject) AddCandidate(candidate common.Address) {
 	if !candidates.isExist(candidate) {
 		candidates = append(candidates, candidate)
 		b, _ := json.Marshal(candidates)
+		self.journalGenaroData()
 		self.code = nil
 		self.data.CodeHash = b[:]
 		self.dirtyCode = true
@@ -734,6 +752,7 @@ func (self *stateObject) DelCandidate(candidate common.Address) {
 	if candidates.isExist(candidate) {
 		candidates.DelCandidate(candidate)
 		b, _ := json.Marshal(candidates)
+		self.journalGenaroData()
 		self.code = nil
 		self.data.CodeHash = b[:]
 		self.dirtyCode = true
@@ -763,6 +782,7 @@ func (self *stateObject) AddAccountInForbidBackStakeList(addr common.Address) {
 	if !forbidList.IsExist(addr) {
 		forbidList.Add(addr)
 		b, _ := json.Marshal(forbidList)
+		self.journalGenaroData()
 		self.code = nil
 		self.data.CodeHash = b[:]
 		self.dirtyCode = true
@@ -795,6 +815,7 @@ func (self *stateObject) DelAccountInForbidBackStakeList(addr common.Address) {
 	if forbidList.IsExist(addr) {
 		forbidList.Del(addr)
 		b, _ := json.Marshal(forbidList)
+		self.journalGenaroData()
 		self.code = nil
 		self.data.CodeHash = b[:]
 		self.dirtyCode = true
@@ -814,6 +835,66 @@ func (self *stateObject) GetForbidBackStakeList() types.ForbidBackStakeList {
 	return nil
 }
 
+func (self *stateObject) GetHeftOracle() types.HeftOracle {
+	var oracle types.HeftOracle
+	if self.data.CodeHash != nil {
+		json.Unmarshal(self.data.CodeHash, &oracle)
+	}
+	return oracle
+}
+
+func (self *stateObject) SetHeftOracle(oracle types.HeftOracle) {
+	b, _ := json.Marshal(oracle)
+	self.journalGenaroData()
+	self.code = nil
+	self.data.CodeHash = b[:]
+	self.dirtyCode = true
+	if self.onDirty != nil {
+		self.onDirty(self.Address())
+		self.onDirty = nil
+	}
+}
+
+func (self *stateObject) GetMortgageExpiries() types.MortgageExpiries {
+	var expiries types.MortgageExpiries
+	if self.data.CodeHash != nil {
+		json.Unmarshal(self.data.CodeHash, &expiries)
+	}
+	return expiries
+}
+
+func (self *stateObject) SetMortgageExpiries(expiries types.MortgageExpiries) {
+	b, _ := json.Marshal(expiries)
+	self.journalGenaroData()
+	self.code = nil
+	self.data.CodeHash = b[:]
+	self.dirtyCode = true
+	if self.onDirty != nil {
+		self.onDirty(self.Address())
+		self.onDirty = nil
+	}
+}
+
+func (self *stateObject) GetStorageChallenges() types.StorageChallenges {
+	var challenges types.StorageChallenges
+	if self.data.CodeHash != nil {
+		json.Unmarshal(self.data.CodeHash, &challenges)
+	}
+	return challenges
+}
+
+func (self *stateObject) SetStorageChallenges(challenges types.StorageChallenges) {
+	b, _ := json.Marshal(challenges)
+	self.journalGenaroData()
+	self.code = nil
+	self.data.CodeHash = b[:]
+	self.dirtyCode = true
+	if self.onDirty != nil {
+		self.onDirty(self.Address())
+		self.onDirty = nil
+	}
+}
+
 func (self *stateObject) AddAlreadyBackStack(backStake common.AlreadyBackStake) {
 	var backStakes common.BackStakeList
 	if self.data.CodeHash == nil {
@@ -825,6 +906,7 @@ func (self *stateObject) AddAlreadyBackStack(backStake common.AlreadyBackStake)
 		backStakes = append(backStakes, backStake)
 
 		b, _ := json.Marshal(backStakes)
+		self.journalGenaroData()
 		self.code = nil
 		self.data.CodeHash = b[:]
 		self.dirtyCode = true
@@ -846,6 +928,7 @@ func (self *stateObject) GetAlreadyBackStakeList() common.BackStakeList {
 
 func (self *stateObject) SetAlreadyBackStakeList(backStakes common.BackStakeList) {
 	b, _ := json.Marshal(backStakes)
+	self.journalGenaroData()
 	self.code = nil
 	self.data.CodeHash = b[:]
 	self.dirtyCode = true
@@ -890,6 +973,7 @@ func (self *stateObject) UpdateBucketProperties(buckid string, szie uint64, back
 	}
 
 	b, _ := json.Marshal(genaroData)
+	self.journalGenaroData()
 	self.code = nil
 	self.data.CodeHash = b[:]
 	self.dirtyCode = true
@@ -912,6 +996,8 @@ func (self *stateObject) UpdateBucket(bucket types.BucketPropertie) bool {
 					bp.TimeStart = bucket.TimeStart
 					bp.Size = bucket.Size
 					bp.Backup = bucket.Backup
+					bp.Used = bucket.Used
+					bp.UsageReports = bucket.UsageReports
 					genaroData.Buckets[k] = bp
 					break
 				}
@@ -919,6 +1005,7 @@ func (self *stateObject) UpdateBucket(bucket types.BucketPropertie) bool {
 		}
 
 		b, _ := json.Marshal(genaroData)
+		self.journalGenaroData()
 		self.code = nil
 		self.data.CodeHash = b[:]
 		self.dirtyCode = true
@@ -987,6 +1074,7 @@ func (self *stateObject) UpdateTraffic(traffic uint64) {
 	}
 
 	b, _ := json.Marshal(genaroData)
+	self.journalGenaroData()
 	self.code = nil
 	self.data.CodeHash = b[:]
 	self.dirtyCode = true
@@ -1058,6 +1146,7 @@ func (self *stateObject) SpecialTxTypeMortgageInit(specialTxTypeMortgageInit typ
 	}
 	genaroData.SpecialTxTypeMortgageInit = types.SpecialTxTypeMortgageInit{}
 	b, _ := json.Marshal(genaroData)
+	self.journalGenaroData()
 	self.code = nil
 	self.data.CodeHash = b[:]
 	self.dirtyCode = true
@@ -1125,6 +1214,7 @@ func (self *stateObject) SpecialTxTypeSyncSidechainStatus(SpecialTxTypeSyncSidec
 	}
 	genaroData.SpecialTxTypeMortgageInit = types.SpecialTxTypeMortgageInit{}
 	b, _ := json.Marshal(genaroData)
+	self.journalGenaroData()
 	self.code = nil
 	self.data.CodeHash = b[:]
 	self.dirtyCode = true
@@ -1150,6 +1240,7 @@ func (self *stateObject) TxLogBydataVersionUpdate(fileID string) (types.SpecialT
 		resultTmp.LogSwitch = true
 		genaroData.SpecialTxTypeMortgageInitArr[fileID] = resultTmp
 		b, _ := json.Marshal(genaroData)
+		self.journalGenaroData()
 		self.code = nil
 		self.data.CodeHash = b[:]
 		self.dirtyCode = true
@@ -1185,6 +1276,7 @@ func (self *stateObject) SyncStakeNode(s string) error {
 		json.Unmarshal(self.data.CodeHash, &genaroData)
 		genaroData.Node = append(genaroData.Node, s)
 		b, _ := json.Marshal(genaroData)
+		self.journalGenaroData()
 		self.code = nil
 		self.data.CodeHash = b[:]
 		self.dirtyCode = true
@@ -1206,6 +1298,7 @@ func (self *stateObject) SyncNode2Address(s string, address string) error {
 		d[s] = address
 	}
 	b, _ := json.Marshal(d)
+	self.journalGenaroData()
 	self.code = nil
 	self.data.CodeHash = b[:]
 	self.dirtyCode = true
@@ -1249,6 +1342,7 @@ func (self *stateObject) SynchronizeShareKey(synchronizeShareKey types.Synchroni
 	}
 	genaroData.SynchronizeShareKey = types.SynchronizeShareKey{}
 	b, _ := json.Marshal(genaroData)
+	self.journalGenaroData()
 	self.code = nil
 	self.data.CodeHash = b[:]
 	self.dirtyCode = true
@@ -1271,6 +1365,7 @@ func (self *stateObject) UpdateFileSharePublicKey(publicKey string) {
 	}
 
 	b, _ := json.Marshal(genaroData)
+	self.journalGenaroData()
 	self.code = nil
 	self.data.CodeHash = b[:]
 	self.dirtyCode = true
@@ -1293,6 +1388,67 @@ func (self *stateObject) GetFileSharePublicKey() string {
 	return genaroData.FileSharePublicKey
 }
 
+func (self *stateObject) GetFileSharePublicKeys() []types.FileSharePublicKeyVersion {
+	if self.data.CodeHash == nil {
+		return nil
+	}
+	var genaroData types.GenaroData
+	if err := json.Unmarshal(self.data.CodeHash, &genaroData); err != nil {
+		return nil
+	}
+	return genaroData.FileSharePublicKeys
+}
+
+// SetFileSharePublicKeys replaces the public key history and the current
+// public key.
+func (self *stateObject) SetFileSharePublicKeys(versions []types.FileSharePublicKeyVersion, publicKey string) {
+	var genaroData types.GenaroData
+	if self.data.CodeHash != nil {
+		json.Unmarshal(self.data.CodeHash, &genaroData)
+	}
+	genaroData.FileSharePublicKeys = versions
+	genaroData.FileSharePublicKey = publicKey
+
+	b, _ := json.Marshal(genaroData)
+	self.journalGenaroData()
+	self.code = nil
+	self.data.CodeHash = b[:]
+	self.dirtyCode = true
+	if self.onDirty != nil {
+		self.onDirty(self.Address())
+		self.onDirty = nil
+	}
+}
+
+func (self *stateObject) GetVesting() *types.Vesting {
+	if self.data.CodeHash == nil {
+		return nil
+	}
+	var genaroData types.GenaroData
+	if err := json.Unmarshal(self.data.CodeHash, &genaroData); err != nil {
+		return nil
+	}
+	return genaroData.Vesting
+}
+
+func (self *stateObject) SetVesting(vesting *types.Vesting) {
+	var genaroData types.GenaroData
+	if self.data.CodeHash != nil {
+		json.Unmarshal(self.data.CodeHash, &genaroData)
+	}
+	genaroData.Vesting = vesting
+
+	b, _ := json.Marshal(genaroData)
+	self.journalGenaroData()
+	self.code = nil
+	self.data.CodeHash = b[:]
+	self.dirtyCode = true
+	if self.onDirty != nil {
+		self.onDirty(self.Address())
+		self.onDirty = nil
+	}
+}
+
 func (self *stateObject) UnlockSharedKey(shareKeyId string) types.SynchronizeShareKey {
 	var genaroData types.GenaroData
 	var synchronizeShareKey types.SynchronizeShareKey
@@ -1314,6 +1470,7 @@ func (self *stateObject) UnlockSharedKey(shareKeyId string) types.SynchronizeSha
 	}
 	genaroData.SynchronizeShareKey = types.SynchronizeShareKey{}
 	b, _ := json.Marshal(genaroData)
+	self.journalGenaroData()
 	self.code = nil
 	self.data.CodeHash = b[:]
 	self.dirtyCode = true
@@ -1344,6 +1501,96 @@ func (self *stateObject) CheckUnlockSharedKey(shareKeyId string) bool {
 	return false
 }
 
+func (self *stateObject) GetSynchronizeShareKeys() map[string]types.SynchronizeShareKey {
+	if self.data.CodeHash == nil {
+		return nil
+	}
+	var genaroData types.GenaroData
+	if err := json.Unmarshal(self.data.CodeHash, &genaroData); err != nil {
+		return nil
+	}
+	return genaroData.SynchronizeShareKeyArr
+}
+
+func (self *stateObject) SetSynchronizeShareKeys(shareKeys map[string]types.SynchronizeShareKey) {
+	var genaroData types.GenaroData
+	if self.data.CodeHash != nil {
+		json.Unmarshal(self.data.CodeHash, &genaroData)
+	}
+	genaroData.SynchronizeShareKeyArr = shareKeys
+
+	b, _ := json.Marshal(genaroData)
+	self.journalGenaroData()
+	self.code = nil
+	self.data.CodeHash = b[:]
+	self.dirtyCode = true
+	if self.onDirty != nil {
+		self.onDirty(self.Address())
+		self.onDirty = nil
+	}
+}
+
+func (self *stateObject) GetOutgoingShareKeys() []types.ShareKeyRef {
+	if self.data.CodeHash == nil {
+		return nil
+	}
+	var genaroData types.GenaroData
+	if err := json.Unmarshal(self.data.CodeHash, &genaroData); err != nil {
+		return nil
+	}
+	return genaroData.OutgoingShareKeys
+}
+
+func (self *stateObject) SetOutgoingShareKeys(refs []types.ShareKeyRef) {
+	var genaroData types.GenaroData
+	if self.data.CodeHash != nil {
+		json.Unmarshal(self.data.CodeHash, &genaroData)
+	}
+	genaroData.OutgoingShareKeys = refs
+
+	b, _ := json.Marshal(genaroData)
+	self.journalGenaroData()
+	self.code = nil
+	self.data.CodeHash = b[:]
+	self.dirtyCode = true
+	if self.onDirty != nil {
+		self.onDirty(self.Address())
+		self.onDirty = nil
+	}
+}
+
+func (self *stateObject) GetMortgages() map[string]types.SpecialTxTypeMortgageInit {
+	if self.data.CodeHash == nil {
+		return nil
+	}
+	var genaroData types.GenaroData
+	if err := json.Unmarshal(self.data.CodeHash, &genaroData); err != nil {
+		return nil
+	}
+	return genaroData.SpecialTxTypeMortgageInitArr
+}
+
+func (self *stateObject) SetMortgage(mortgage types.SpecialTxTypeMortgageInit) {
+	var genaroData types.GenaroData
+	if self.data.CodeHash != nil {
+		json.Unmarshal(self.data.CodeHash, &genaroData)
+	}
+	if genaroData.SpecialTxTypeMortgageInitArr == nil {
+		genaroData.SpecialTxTypeMortgageInitArr = make(map[string]types.SpecialTxTypeMortgageInit)
+	}
+	genaroData.SpecialTxTypeMortgageInitArr[mortgage.FileID] = mortgage
+
+	b, _ := json.Marshal(genaroData)
+	self.journalGenaroData()
+	self.code = nil
+	self.data.CodeHash = b[:]
+	self.dirtyCode = true
+	if self.onDirty != nil {
+		self.onDirty(self.Address())
+		self.onDirty = nil
+	}
+}
+
 func (self *stateObject) UpdateBucketApplyPrice(price *hexutil.Big) {
 	var genaroPrice types.GenaroPrice
 	if self.data.CodeHash == nil {
@@ -1356,6 +1603,7 @@ func (self *stateObject) UpdateBucketApplyPrice(price *hexutil.Big) {
 	}
 
 	b, _ := json.Marshal(genaroPrice)
+	self.journalGenaroData()
 	self.code = nil
 	self.data.CodeHash = b[:]
 	self.dirtyCode = true
@@ -1389,6 +1637,7 @@ func (self *stateObject) UpdateTrafficApplyPrice(price *hexutil.Big) {
 	}
 
 	b, _ := json.Marshal(genaroPrice)
+	self.journalGenaroData()
 	self.code = nil
 	self.data.CodeHash = b[:]
 	self.dirtyCode = true
@@ -1412,6 +1661,7 @@ func (self *stateObject) AddLastRootState(statehash common.Hash, blockNumber uin
 	lastSynState.AddLastSynState(statehash, blockNumber)
 
 	b, _ := json.Marshal(lastSynState)
+	self.journalGenaroData()
 	self.code = nil
 	self.data.CodeHash = b[:]
 	self.dirtyCode = true
@@ -1436,6 +1686,7 @@ func (self *stateObject) UpdateAccountBinding(mainAccount common.Address, subAcc
 	bindingTable.UpdateBinding(mainAccount, subAccount)
 
 	b, _ := json.Marshal(bindingTable)
+	self.journalGenaroData()
 	self.code = nil
 	self.data.CodeHash = b[:]
 	self.dirtyCode = true
@@ -1457,6 +1708,7 @@ func (self *stateObject) DelSubAccountBinding(subAccount common.Address) bool {
 		bindingTable.DelSubAccount(subAccount)
 
 		b, _ := json.Marshal(bindingTable)
+		self.journalGenaroData()
 		self.code = nil
 		self.data.CodeHash = b[:]
 		self.dirtyCode = true
@@ -1481,6 +1733,7 @@ func (self *stateObject) DelMainAccountBinding(mainAccount common.Address) []com
 		subAccounts := bindingTable.DelMainAccount(mainAccount)
 
 		b, _ := json.Marshal(bindingTable)
+		self.journalGenaroData()
 		self.code = nil
 		self.data.CodeHash = b[:]
 		self.dirtyCode = true
@@ -1601,6 +1854,7 @@ func (self *stateObject) UpdateStakePerNodePrice(price *hexutil.Big) {
 	}
 
 	b, _ := json.Marshal(genaroPrice)
+	self.journalGenaroData()
 	self.code = nil
 	self.data.CodeHash = b[:]
 	self.dirtyCode = true
@@ -1625,6 +1879,7 @@ func (self *stateObject) SetLastSynBlock(blockNumber uint64, blockHash common.Ha
 	lastsynState.LastSynBlockHash = blockHash
 
 	b, _ := json.Marshal(lastsynState)
+	self.journalGenaroData()
 	self.code = nil
 	self.data.CodeHash = b[:]
 	self.dirtyCode = true
@@ -1657,6 +1912,7 @@ func (self *stateObject) GetGenaroPrice() *types.GenaroPrice {
 
 func (self *stateObject) SetGenaroPrice(genaroPrice types.GenaroPrice) {
 	b, _ := json.Marshal(genaroPrice)
+	self.journalGenaroData()
 	self.code = nil
 	self.data.CodeHash = b[:]
 	self.dirtyCode = true
@@ -1678,6 +1934,7 @@ func (self *stateObject) UpdateOneDayGesCost(price *hexutil.Big) {
 	}
 
 	b, _ := json.Marshal(genaroPrice)
+	self.journalGenaroData()
 	self.code = nil
 	self.data.CodeHash = b[:]
 	self.dirtyCode = true
@@ -1699,6 +1956,7 @@ func (self *stateObject) UpdateOneDaySyncLogGsaCost(price *hexutil.Big) {
 	}
 
 	b, _ := json.Marshal(genaroPrice)
+	self.journalGenaroData()
 	self.code = nil
 	self.data.CodeHash = b[:]
 	self.dirtyCode = true
@@ -1754,6 +2012,7 @@ func (self *stateObject) UnbindNode(nodeId string) error {
 		}
 		genaroData.Node = a
 		b, _ := json.Marshal(genaroData)
+		self.journalGenaroData()
 		self.code = nil
 		self.data.CodeHash = b[:]
 		self.dirtyCode = true
@@ -1775,6 +2034,7 @@ func (self *stateObject) UbindNode2Address(nodeId string) error {
 		delete(d, nodeId)
 	}
 	b, _ := json.Marshal(d)
+	self.journalGenaroData()
 	self.code = nil
 	self.data.CodeHash = b[:]
 	self.dirtyCode = true
@@ -1797,6 +2057,7 @@ func (self *stateObject) GetRewardsValues() *types.RewardsValues {
 
 func (self *stateObject) SetRewardsValues(rewardsValues types.RewardsValues) {
 	b, _ := json.Marshal(rewardsValues)
+	self.journalGenaroData()
 	self.code = nil
 	self.data.CodeHash = b[:]
 	self.dirtyCode = true
@@ -1818,6 +2079,7 @@ func (self *stateObject) PromissoryNotesWithdrawCash(blockNumber uint64) uint64
 			return promissoryNotesNum
 		}
 		b, _ := json.Marshal(genaroData)
+		self.journalGenaroData()
 		self.code = nil
 		self.data.CodeHash = b[:]
 		self.dirtyCode = true
@@ -1864,6 +2126,7 @@ func (self *stateObject) AddPromissoryNote(promissoryNote types.PromissoryNote)
 	}
 
 	b, _ := json.Marshal(genaroData)
+	self.journalGenaroData()
 	self.code = nil
 	self.data.CodeHash = b[:]
 	self.dirtyCode = true
@@ -1886,6 +2149,7 @@ func (self *stateObject) DelPromissoryNote(promissoryNote types.PromissoryNote)
 	}
 	genaroData.PromissoryNotes = promissoryNotes
 	b, _ := json.Marshal(genaroData)
+	self.journalGenaroData()
 	self.code = nil
 	self.data.CodeHash = b[:]
 	self.dirtyCode = true
@@ -1934,6 +2198,7 @@ func (self *stateObject) DelTxInOptionTxTable(hash common.Hash) {
 	if _, ok := optionTxTable[hash]; ok {
 		delete(optionTxTable, hash)
 		b, _ := json.Marshal(optionTxTable)
+		self.journalGenaroData()
 		self.code = nil
 		self.data.CodeHash = b[:]
 		self.dirtyCode = true
@@ -1953,6 +2218,7 @@ func (self *stateObject) AddTxInOptionTxTable(hash common.Hash, promissoryNotesO
 	if _, ok := optionTxTable[hash]; !ok {
 		optionTxTable[hash] = promissoryNotesOptionTx
 		b, _ := json.Marshal(optionTxTable)
+		self.journalGenaroData()
 		self.code = nil
 		self.data.CodeHash = b[:]
 		self.dirtyCode = true
@@ -1972,6 +2238,7 @@ func (self *stateObject) SetTxStatusInOptionTxTable(hash common.Hash, status boo
 		promissoryNotesOptionTx.IsSell = status
 		optionTxTable[hash] = promissoryNotesOptionTx
 		b, _ := json.Marshal(optionTxTable)
+		self.journalGenaroData()
 		self.code = nil
 		self.data.CodeHash = b[:]
 		self.dirtyCode = true
@@ -2007,6 +2274,7 @@ func (self *stateObject) BuyPromissoryNotes(orderId common.Hash, address common.
 	buyPromissoryNotes.OptionOwner = address
 	optionTxTable[orderId] = buyPromissoryNotes
 	b, _ := json.Marshal(optionTxTable)
+	self.journalGenaroData()
 	self.code = nil
 	self.data.CodeHash = b[:]
 	self.dirtyCode = true
@@ -2040,6 +2308,7 @@ func (self *stateObject) DeletePromissoryNotes(orderId common.Hash, address comm
 	delete(optionTxTable, orderId)
 	fmt.Println(optionTxTable)
 	b, _ := json.Marshal(optionTxTable)
+	self.journalGenaroData()
 	self.code = nil
 	self.data.CodeHash = b[:]
 	self.dirtyCode = true
@@ -2069,6 +2338,7 @@ func (self *stateObject) TurnBuyPromissoryNotes(orderId common.Hash, optionPrice
 	buyPromissoryNotes.OptionPrice = optionPrice.ToInt()
 	optionTxTable[orderId] = buyPromissoryNotes
 	b, _ := json.Marshal(optionTxTable)
+	self.journalGenaroData()
 	self.code = nil
 	self.data.CodeHash = b[:]
 	self.dirtyCode = true
@@ -2101,6 +2371,7 @@ func (self *stateObject) UpdateProfitAccount(profitAccount common.Address) bool
 	}
 
 	b, _ := json.Marshal(genaroData)
+	self.journalGenaroData()
 	self.code = nil
 	self.data.CodeHash = b[:]
 	self.dirtyCode = true
@@ -2133,6 +2404,7 @@ func (self *stateObject) UpdateShadowAccount(shadowAccount common.Address) bool
 	}
 
 	b, _ := json.Marshal(genaroData)
+	self.journalGenaroData()
 	self.code = nil
 	self.data.CodeHash = b[:]
 	self.dirtyCode = true
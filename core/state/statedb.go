@@ -85,6 +85,9 @@ type StateDB struct {
 	validRevisions []revision
 	nextRevisionId int
 
+	// genaroJournal reports whether the Genaro data changes are journaled too.
+	genaroJournal bool
+
 	lock sync.Mutex
 }
 
@@ -548,6 +551,7 @@ func (self *StateDB) Copy() *StateDB {
 		logs:              make(map[common.Hash][]*types.Log, len(self.logs)),
 		logSize:           self.logSize,
 		preimages:         make(map[common.Hash][]byte),
+		genaroJournal:     self.genaroJournal,
 	}
 	// Copy the dirty states, logs, and preimages
 	for addr := range self.stateObjectsDirty {
@@ -564,6 +568,12 @@ func (self *StateDB) Copy() *StateDB {
 	return state
 }
 
+// SetGenaroJournal sets whether the Genaro data changes are journaled, and so
+// reverted with the other changes of a failing call.
+func (self *StateDB) SetGenaroJournal(enabled bool) {
+	self.genaroJournal = enabled
+}
+
 // Snapshot returns an identifier for the current revision of the state.
 func (self *StateDB) Snapshot() int {
 	id := self.nextRevisionId
@@ -777,6 +787,7 @@ func (self *StateDB) BackStake(id common.Address, blockNumber uint64) (bool, uin
 		stateObject.DeleteStake(stake, blockNumber)
 		mount := big.NewInt(int64(stake))
 		mount.Mul(mount, big.NewInt(1000000000000000000))
+		mount = backVestingStake(stateObject, mount)
 		stateObject.AddBalance(mount)
 		return true, stake
 	}
@@ -1051,6 +1062,115 @@ func (self *StateDB) SpecialTxTypeSyncSidechainStatus(address common.Address, Sp
 	return nil, false
 }
 
+// GetMortgages returns the sidechain mortgages opened by address, keyed by
+// file id.
+func (self *StateDB) GetMortgages(address common.Address) map[string]types.SpecialTxTypeMortgageInit {
+	stateObject := self.getStateObject(address)
+	if stateObject != nil {
+		return stateObject.GetMortgages()
+	}
+	return nil
+}
+
+// GetMortgage returns the sidechain mortgage of the given file opened by
+// address.
+func (self *StateDB) GetMortgage(address common.Address, fileID string) (types.SpecialTxTypeMortgageInit, bool) {
+	mortgage, ok := self.GetMortgages(address)[fileID]
+	return mortgage, ok
+}
+
+// GetMortgageExpiries returns the open mortgages by the time they expire at.
+func (self *StateDB) GetMortgageExpiries() types.MortgageExpiries {
+	stateObject := self.getStateObject(common.MortgageSaveAddress)
+	if stateObject != nil {
+		return stateObject.GetMortgageExpiries()
+	}
+	return nil
+}
+
+func (self *StateDB) SetMortgageExpiries(expiries types.MortgageExpiries) bool {
+	stateObject := self.GetOrNewStateObject(common.MortgageSaveAddress)
+	if stateObject != nil {
+		stateObject.SetMortgageExpiries(expiries)
+		return true
+	}
+	return false
+}
+
+// OpenMortgage stores a new sidechain mortgage at its FromAccount and adds it
+// to the mortgage expiries. It fails if a mortgage for the same file already
+// exists, settled or not.
+func (self *StateDB) OpenMortgage(mortgage types.SpecialTxTypeMortgageInit) bool {
+	if _, ok := self.GetMortgage(mortgage.FromAccount, mortgage.FileID); ok {
+		return false
+	}
+	stateObject := self.GetOrNewStateObject(mortgage.FromAccount)
+	if stateObject == nil {
+		return false
+	}
+	stateObject.SetMortgage(mortgage)
+	expiries := self.GetMortgageExpiries()
+	expiries.Add(types.MortgageExpiry{FromAccount: mortgage.FromAccount, FileID: mortgage.FileID, EndTime: mortgage.EndTime})
+	return self.SetMortgageExpiries(expiries)
+}
+
+// SyncMortgageStatus records the sidechain status of an open mortgage as its
+// latest one. Only the latest common.MortgageHistory statuses are kept.
+func (self *StateDB) SyncMortgageStatus(address common.Address, fileID string, dataversion string, sidechain types.Sidechain) bool {
+	mortgage, ok := self.GetMortgage(address, fileID)
+	if !ok || mortgage.Terminate {
+		return false
+	}
+	if mortgage.SidechainStatus == nil {
+		mortgage.SidechainStatus = make(map[string]map[common.Address]*hexutil.Big)
+	}
+	mortgage.SidechainStatus[dataversion] = sidechain
+	mortgage.LastDataversion = dataversion
+	for i, synced := range mortgage.Dataversions {
+		if synced == dataversion {
+			mortgage.Dataversions = append(mortgage.Dataversions[:i], mortgage.Dataversions[i+1:]...)
+			break
+		}
+	}
+	mortgage.Dataversions = append(mortgage.Dataversions, dataversion)
+	for uint64(len(mortgage.Dataversions)) > common.MortgageHistory {
+		delete(mortgage.SidechainStatus, mortgage.Dataversions[0])
+		mortgage.Dataversions = mortgage.Dataversions[1:]
+	}
+	self.getStateObject(address).SetMortgage(mortgage)
+	return true
+}
+
+// SettleMortgage terminates an open mortgage and returns the amounts to pay
+// out of it, see SpecialTxTypeMortgageInit.Settlement. The payout is kept with
+// the settled mortgage, which leaves the mortgage expiries.
+func (self *StateDB) SettleMortgage(address common.Address, fileID string, blockNumber uint64) (map[common.Address]*big.Int, bool) {
+	mortgage, ok := self.GetMortgage(address, fileID)
+	if !ok || mortgage.Terminate {
+		return nil, false
+	}
+	payouts, refund := mortgage.Settlement()
+	if refund.Sign() > 0 {
+		if paid, ok := payouts[mortgage.FromAccount]; ok {
+			paid.Add(paid, refund)
+		} else {
+			payouts[mortgage.FromAccount] = refund
+		}
+	}
+	mortgage.Payout = make(map[common.Address]*hexutil.Big)
+	for account, amount := range payouts {
+		mortgage.Payout[account] = (*hexutil.Big)(new(big.Int).Set(amount))
+	}
+	mortgage.Terminate = true
+	mortgage.SettleBlock = blockNumber
+	self.getStateObject(address).SetMortgage(mortgage)
+	expiries := self.GetMortgageExpiries()
+	if expiries.Remove(address, fileID) {
+		self.SetMortgageExpiries(expiries)
+	}
+	return payouts, true
+}
+
 func (self *StateDB) SyncStakeNode(address common.Address, s string) error {
 	stateObject := self.GetOrNewStateObject(address)
 	var err error = nil
@@ -1130,6 +1250,132 @@ func (self *StateDB) GetFileSharePublicKey(addr common.Address) string {
 	return ""
 }
 
+// GetFileSharePublicKeys returns the public key history of addr.
+func (self *StateDB) GetFileSharePublicKeys(addr common.Address) []types.FileSharePublicKeyVersion {
+	stateObject := self.getStateObject(addr)
+	if stateObject != nil {
+		return fileSharePublicKeyHistory(stateObject)
+	}
+	return nil
+}
+
+// AddFileSharePublicKey publishes a new version of the public key of id that is
+// active from blockNumber on. A key published before versioning is kept as
+// version 1.
+func (self *StateDB) AddFileSharePublicKey(id common.Address, publicKey string, blockNumber uint64) bool {
+	stateObject := self.GetOrNewStateObject(id)
+	if stateObject == nil {
+		return false
+	}
+	versions := fileSharePublicKeyHistory(stateObject)
+	versions = append(versions, types.FileSharePublicKeyVersion{
+		Version:     uint64(len(versions)) + 1,
+		PublicKey:   publicKey,
+		ActiveBlock: blockNumber,
+	})
+	stateObject.SetFileSharePublicKeys(versions, publicKey)
+	return true
+}
+
+// RevokeFileSharePublicKey flags the given version of the public key of id as
+// revoked from blockNumber on. Revoking the current version leaves the account
+// without a public key until a new one is published.
+func (self *StateDB) RevokeFileSharePublicKey(id common.Address, version uint64, blockNumber uint64) bool {
+	stateObject := self.getStateObject(id)
+	if stateObject == nil {
+		return false
+	}
+	versions := fileSharePublicKeyHistory(stateObject)
+	if version == 0 || version > uint64(len(versions)) || versions[version-1].RevokeBlock != 0 {
+		return false
+	}
+	versions[version-1].RevokeBlock = blockNumber
+	publicKey := stateObject.GetFileSharePublicKey()
+	if version == uint64(len(versions)) {
+		publicKey = ""
+	}
+	stateObject.SetFileSharePublicKeys(versions, publicKey)
+	return true
+}
+
+// fileSharePublicKeyHistory returns the public key history of the account,
+// turning a key published before versioning into version 1.
+func fileSharePublicKeyHistory(stateObject *stateObject) []types.FileSharePublicKeyVersion {
+	versions := stateObject.GetFileSharePublicKeys()
+	if len(versions) == 0 {
+		if legacy := stateObject.GetFileSharePublicKey(); legacy != "" {
+			versions = append(versions, types.FileSharePublicKeyVersion{Version: 1, PublicKey: legacy})
+		}
+	}
+	return versions
+}
+
+// GetVesting returns the vesting of addr, nil if it has none.
+func (self *StateDB) GetVesting(addr common.Address) *types.Vesting {
+	stateObject := self.getStateObject(addr)
+	if stateObject != nil {
+		return stateObject.GetVesting()
+	}
+	return nil
+}
+
+// SetVesting grants the vesting to id. The granted GNX are locked in the
+// vesting, they are not added to the balance.
+func (self *StateDB) SetVesting(id common.Address, vesting types.Vesting) bool {
+	stateObject := self.GetOrNewStateObject(id)
+	if stateObject == nil {
+		return false
+	}
+	stateObject.SetVesting(&vesting)
+	return true
+}
+
+// StakeVesting moves amount of the GNX held by the vesting of id to its stake.
+// The amount goes back to the vesting when the stake is backed.
+func (self *StateDB) StakeVesting(id common.Address, amount *big.Int) bool {
+	stateObject := self.getStateObject(id)
+	if stateObject == nil {
+		return false
+	}
+	vesting := stateObject.GetVesting()
+	if vesting == nil || !vesting.Stake(amount) {
+		return false
+	}
+	stateObject.SetVesting(vesting)
+	return true
+}
+
+// WithdrawVesting moves the GNX of the vesting of id that are released at
+// blockNumber and not staked to its balance, and returns the amount moved.
+func (self *StateDB) WithdrawVesting(id common.Address, blockNumber uint64) *big.Int {
+	stateObject := self.getStateObject(id)
+	if stateObject == nil {
+		return new(big.Int)
+	}
+	vesting := stateObject.GetVesting()
+	if vesting == nil {
+		return new(big.Int)
+	}
+	amount := vesting.Withdraw(blockNumber)
+	if amount.Sign() > 0 {
+		stateObject.SetVesting(vesting)
+		stateObject.AddBalance(amount)
+	}
+	return amount
+}
+
+// backVestingStake returns the backed stake amount of the account to its
+// vesting first, and returns the rest.
+func backVestingStake(stateObject *stateObject, amount *big.Int) *big.Int {
+	vesting := stateObject.GetVesting()
+	if vesting == nil || vesting.Staked == nil {
+		return amount
+	}
+	rest := vesting.BackStake(amount)
+	stateObject.SetVesting(vesting)
+	return rest
+}
+
 func (self *StateDB) UnlockSharedKey(address common.Address, shareKeyId string) bool {
 	stateObject := self.GetOrNewStateObject(address)
 	if stateObject != nil {
@@ -1160,6 +1406,130 @@ func (self *StateDB) GetSharedFile(address common.Address, shareKeyId string) ty
 	return types.SynchronizeShareKey{}
 }
 
+// GetSynchronizeShareKeys returns the share key offers received by address.
+func (self *StateDB) GetSynchronizeShareKeys(address common.Address) map[string]types.SynchronizeShareKey {
+	stateObject := self.getStateObject(address)
+	if stateObject != nil {
+		return stateObject.GetSynchronizeShareKeys()
+	}
+	return nil
+}
+
+// GetSynchronizeShareKey returns the share key offer with the given id received
+// by address. Unlike GetSharedFile it leaves the offer untouched.
+func (self *StateDB) GetSynchronizeShareKey(address common.Address, shareKeyId string) (types.SynchronizeShareKey, bool) {
+	shareKey, ok := self.GetSynchronizeShareKeys(address)[shareKeyId]
+	return shareKey, ok
+}
+
+// GetOutgoingShareKeys returns the references to the share key offers made by
+// address.
+func (self *StateDB) GetOutgoingShareKeys(address common.Address) []types.ShareKeyRef {
+	stateObject := self.getStateObject(address)
+	if stateObject != nil {
+		return stateObject.GetOutgoingShareKeys()
+	}
+	return nil
+}
+
+// AddShareKey stores a pending share key offer at its recipient and indexes it
+// at the sharer. Finished offers of the recipient are collected on the way.
+func (self *StateDB) AddShareKey(shareKey types.SynchronizeShareKey, blockNumber uint64) bool {
+	stateObject := self.GetOrNewStateObject(shareKey.RecipientAddress)
+	if stateObject == nil {
+		return false
+	}
+	shareKeys := stateObject.GetSynchronizeShareKeys()
+	if shareKeys == nil {
+		shareKeys = make(map[string]types.SynchronizeShareKey)
+	}
+	collected := collectShareKeys(shareKeys, blockNumber)
+	if old, ok := shareKeys[shareKey.ShareKeyId]; ok {
+		collected = append(collected, old)
+	}
+	shareKeys[shareKey.ShareKeyId] = shareKey
+	stateObject.SetSynchronizeShareKeys(shareKeys)
+
+	self.removeOutgoingShareKeys(collected)
+	sharer := self.GetOrNewStateObject(shareKey.FromAccount)
+	refs := append(sharer.GetOutgoingShareKeys(), types.ShareKeyRef{ShareKeyId: shareKey.ShareKeyId, RecipientAddress: shareKey.RecipientAddress})
+	sharer.SetOutgoingShareKeys(refs)
+	return true
+}
+
+// SetShareKeyStatus finishes the pending share key offer with the given id
+// received by recipient. Finished offers of the recipient are collected on the
+// way.
+func (self *StateDB) SetShareKeyStatus(recipient common.Address, shareKeyId string, status int, blockNumber uint64) bool {
+	stateObject := self.getStateObject(recipient)
+	if stateObject == nil {
+		return false
+	}
+	shareKeys := stateObject.GetSynchronizeShareKeys()
+	shareKey, ok := shareKeys[shareKeyId]
+	if !ok || shareKey.Status != types.ShareKeyStatusPending {
+		return false
+	}
+	shareKey.Status = status
+	shareKey.CompleteBlock = blockNumber
+	shareKeys[shareKeyId] = shareKey
+	collected := collectShareKeys(shareKeys, blockNumber)
+	stateObject.SetSynchronizeShareKeys(shareKeys)
+
+	self.removeOutgoingShareKeys(collected)
+	return true
+}
+
+// collectShareKeys deletes the offers that finished or expired more than
+// common.ShareKeyRetention blocks ago and returns them.
+func collectShareKeys(shareKeys map[string]types.SynchronizeShareKey, blockNumber uint64) []types.SynchronizeShareKey {
+	var collected []types.SynchronizeShareKey
+	for id, shareKey := range shareKeys {
+		var finished uint64
+		switch {
+		case shareKey.Status != types.ShareKeyStatusPending:
+			finished = shareKey.CompleteBlock
+		case shareKey.ExpireBlock != 0:
+			finished = shareKey.ExpireBlock
+		default:
+			continue
+		}
+		if finished+common.ShareKeyRetention <= blockNumber {
+			collected = append(collected, shareKey)
+			delete(shareKeys, id)
+		}
+	}
+	return collected
+}
+
+// removeOutgoingShareKeys drops the references the sharers hold to the given
+// offers.
+func (self *StateDB) removeOutgoingShareKeys(shareKeys []types.SynchronizeShareKey) {
+	removed := make(map[common.Address]map[types.ShareKeyRef]bool)
+	for _, shareKey := range shareKeys {
+		if removed[shareKey.FromAccount] == nil {
+			removed[shareKey.FromAccount] = make(map[types.ShareKeyRef]bool)
+		}
+		removed[shareKey.FromAccount][types.ShareKeyRef{ShareKeyId: shareKey.ShareKeyId, RecipientAddress: shareKey.RecipientAddress}] = true
+	}
+	for sharer, refs := range removed {
+		stateObject := self.getStateObject(sharer)
+		if stateObject == nil {
+			continue
+		}
+		old := stateObject.GetOutgoingShareKeys()
+		kept := make([]types.ShareKeyRef, 0, len(old))
+		for _, ref := range old {
+			if !refs[ref] {
+				kept = append(kept, ref)
+			}
+		}
+		if len(kept) != len(old) {
+			stateObject.SetOutgoingShareKeys(kept)
+		}
+	}
+}
+
 func (self *StateDB) CheckUnlockSharedKey(address common.Address, shareKeyId string) bool {
 	stateObject := self.getStateObject(address)
 	if stateObject != nil {
@@ -1426,6 +1796,108 @@ func (self *StateDB) IsAccountExistInForbidBackStakeList(address common.Address)
 	return false
 }
 
+// GetHeftOracle returns the heft reporter set and the pending heft reports.
+func (self *StateDB) GetHeftOracle() types.HeftOracle {
+	stateObject := self.getStateObject(common.HeftOracleSaveAddress)
+	if stateObject != nil {
+		return stateObject.GetHeftOracle()
+	}
+	return types.HeftOracle{}
+}
+
+func (self *StateDB) SetHeftOracle(oracle types.HeftOracle) bool {
+	stateObject := self.GetOrNewStateObject(common.HeftOracleSaveAddress)
+	if stateObject != nil {
+		stateObject.SetHeftOracle(oracle)
+		return true
+	}
+	return false
+}
+
+func (self *StateDB) AddHeftReporter(reporter common.Address) bool {
+	oracle := self.GetHeftOracle()
+	oracle.AddReporter(reporter)
+	return self.SetHeftOracle(oracle)
+}
+
+func (self *StateDB) DelHeftReporter(reporter common.Address) bool {
+	oracle := self.GetHeftOracle()
+	oracle.DelReporter(reporter)
+	return self.SetHeftOracle(oracle)
+}
+
+// ReportHeft records the heft of account reported by reporter for the current
+// epoch.
+func (self *StateDB) ReportHeft(reporter common.Address, account common.Address, heft uint64) bool {
+	oracle := self.GetHeftOracle()
+	if !oracle.IsReporter(reporter) {
+		return false
+	}
+	oracle.Report(reporter, account, heft)
+	return self.SetHeftOracle(oracle)
+}
+
+// GetStorageChallenges returns the committed chunks of the storage nodes and
+// their pending challenges.
+func (self *StateDB) GetStorageChallenges() types.StorageChallenges {
+	stateObject := self.getStateObject(common.StorageChallengeSaveAddress)
+	if stateObject != nil {
+		return stateObject.GetStorageChallenges()
+	}
+	return types.StorageChallenges{}
+}
+
+func (self *StateDB) SetStorageChallenges(challenges types.StorageChallenges) bool {
+	stateObject := self.GetOrNewStateObject(common.StorageChallengeSaveAddress)
+	if stateObject != nil {
+		stateObject.SetStorageChallenges(challenges)
+		return true
+	}
+	return false
+}
+
+func (self *StateDB) CommitBucketRoot(owner common.Address, bucketID string, root common.Hash) bool {
+	challenges := self.GetStorageChallenges()
+	if !challenges.CommitBucket(types.BucketKey(owner, bucketID), root) {
+		return false
+	}
+	return self.SetStorageChallenges(challenges)
+}
+
+func (self *StateDB) CommitStorageRoot(nodeID string, root common.Hash) bool {
+	challenges := self.GetStorageChallenges()
+	if !challenges.Commit(nodeID, root) {
+		return false
+	}
+	return self.SetStorageChallenges(challenges)
+}
+
+func (self *StateDB) AddStorageChallenge(challenge types.StorageChallenge) bool {
+	challenges := self.GetStorageChallenges()
+	if !challenges.Challenge(challenge) {
+		return false
+	}
+	return self.SetStorageChallenges(challenges)
+}
+
+func (self *StateDB) AnswerStorageChallenge(nodeID string) bool {
+	challenges := self.GetStorageChallenges()
+	if !challenges.Answer(nodeID) {
+		return false
+	}
+	return self.SetStorageChallenges(challenges)
+}
+
+// DropStorageNode forgets the chunks committed by an unbound node.
+func (self *StateDB) DropStorageNode(nodeID string) bool {
+	challenges := self.GetStorageChallenges()
+	if len(challenges.Roots[nodeID]) == 0 {
+		return true
+	}
+	challenges.DropNode(nodeID)
+	return self.SetStorageChallenges(challenges)
+}
+
 func (self *StateDB) GetForbidBackStakeList() types.ForbidBackStakeList {
 	stateObject := self.GetOrNewStateObject(common.ForbidBackStakeSaveAddress)
 	if stateObject != nil {
@@ -31,6 +31,7 @@ import (
 	check "gopkg.in/check.v1"
 
 	"github.com/GenaroNetwork/GenaroCore/common"
+	"github.com/GenaroNetwork/GenaroCore/common/hexutil"
 	"github.com/GenaroNetwork/GenaroCore/core/types"
 	"github.com/GenaroNetwork/GenaroCore/ethdb"
 )
@@ -421,3 +422,132 @@ func (s *StateSuite) TestTouchDelete(c *check.C) {
 		c.Fatal("expected no dirty state object")
 	}
 }
+
+func TestShareKeyLifecycle(t *testing.T) {
+	state, _ := New(common.Hash{}, NewDatabase(ethdb.NewMemDatabase()))
+	sharer := common.HexToAddress("0x1100000000000000000000000000000000000000")
+	recipient := common.HexToAddress("0x1200000000000000000000000000000000000000")
+
+	offer := func(id string, expire uint64) types.SynchronizeShareKey {
+		return types.SynchronizeShareKey{ShareKeyId: id, ShareKey: "key", RecipientAddress: recipient, FromAccount: sharer, ExpireBlock: expire}
+	}
+	state.AddShareKey(offer("a", 0), 1)
+	state.AddShareKey(offer("b", 10), 1)
+	if refs := state.GetOutgoingShareKeys(sharer); len(refs) != 2 {
+		t.Fatalf("outgoing share keys mismatch: have %d, want 2", len(refs))
+	}
+	if !state.SetShareKeyStatus(recipient, "a", types.ShareKeyStatusRejected, 5) {
+		t.Fatal("failed to reject pending share key")
+	}
+	if state.SetShareKeyStatus(recipient, "a", types.ShareKeyStatusUnlocked, 6) {
+		t.Fatal("finished share key changed status")
+	}
+	if shareKey, _ := state.GetSynchronizeShareKey(recipient, "b"); shareKey.StatusName(10) != "expired" {
+		t.Fatalf("share key status mismatch: have %s, want expired", shareKey.StatusName(10))
+	}
+
+	// Both offers are collected once the retention passed
+	state.AddShareKey(offer("c", 0), 10+common.ShareKeyRetention)
+	if shareKeys := state.GetSynchronizeShareKeys(recipient); len(shareKeys) != 1 {
+		t.Fatalf("incoming share keys mismatch: have %d, want 1", len(shareKeys))
+	}
+	refs := state.GetOutgoingShareKeys(sharer)
+	if len(refs) != 1 || refs[0].ShareKeyId != "c" {
+		t.Fatalf("outgoing share keys mismatch: have %v", refs)
+	}
+}
+
+func TestFileSharePublicKeyVersions(t *testing.T) {
+	state, _ := New(common.Hash{}, NewDatabase(ethdb.NewMemDatabase()))
+	addr := common.HexToAddress("0x1100000000000000000000000000000000000000")
+
+	state.UpdateFileSharePublicKey(addr, "legacy")
+	state.AddFileSharePublicKey(addr, "second", 10)
+	versions := state.GetFileSharePublicKeys(addr)
+	if len(versions) != 2 || versions[0].PublicKey != "legacy" || versions[1].Version != 2 || versions[1].ActiveBlock != 10 {
+		t.Fatalf("public key history mismatch: %+v", versions)
+	}
+	if !state.RevokeFileSharePublicKey(addr, 2, 20) {
+		t.Fatal("failed to revoke current public key")
+	}
+	if state.RevokeFileSharePublicKey(addr, 2, 21) {
+		t.Fatal("revoked public key twice")
+	}
+	if key := state.GetFileSharePublicKey(addr); key != "" {
+		t.Fatalf("revoked public key still current: %s", key)
+	}
+}
+
+func TestMortgageSettlement(t *testing.T) {
+	state, _ := New(common.Hash{}, NewDatabase(ethdb.NewMemDatabase()))
+	from := common.HexToAddress("0x1100000000000000000000000000000000000000")
+	writer := common.HexToAddress("0x1200000000000000000000000000000000000000")
+	reader := common.HexToAddress("0x1300000000000000000000000000000000000000")
+
+	mortgage := types.SpecialTxTypeMortgageInit{
+		MortgageTable:  map[common.Address]*hexutil.Big{writer: (*hexutil.Big)(big.NewInt(100)), reader: (*hexutil.Big)(big.NewInt(50))},
+		AuthorityTable: map[common.Address]int{writer: common.Write, reader: common.ReadOnly},
+		FileID:         "file",
+		MortgagTotal:   big.NewInt(150),
+		FromAccount:    from,
+	}
+	if !state.OpenMortgage(mortgage) {
+		t.Fatal("failed to open mortgage")
+	}
+	if state.OpenMortgage(mortgage) {
+		t.Fatal("opened the same mortgage twice")
+	}
+	state.SyncMortgageStatus(from, "file", "v1", types.Sidechain{writer: (*hexutil.Big)(big.NewInt(10))})
+	state.SyncMortgageStatus(from, "file", "v2", types.Sidechain{writer: (*hexutil.Big)(big.NewInt(300)), reader: (*hexutil.Big)(big.NewInt(20))})
+
+	// The latest status is paid out, capped by the writer's mortgage, the
+	// reader is not paid and the rest goes back to the mortgage owner
+	payouts, ok := state.SettleMortgage(from, "file", 7)
+	if !ok {
+		t.Fatal("failed to settle mortgage")
+	}
+	if len(payouts) != 2 || payouts[writer].Int64() != 100 || payouts[from].Int64() != 50 {
+		t.Fatalf("payout mismatch: have %v", payouts)
+	}
+	if _, ok := state.SettleMortgage(from, "file", 8); ok {
+		t.Fatal("settled mortgage twice")
+	}
+	if state.SyncMortgageStatus(from, "file", "v3", nil) {
+		t.Fatal("synced settled mortgage")
+	}
+	settled, _ := state.GetMortgage(from, "file")
+	if !settled.Terminate || settled.SettleBlock != 7 || settled.Payout[writer].ToInt().Int64() != 100 || settled.StatusName(0) != "settled" {
+		t.Fatalf("settled mortgage mismatch: %+v", settled)
+	}
+}
+
+func TestGenaroDataRevert(t *testing.T) {
+	state, _ := New(common.Hash{}, NewDatabase(ethdb.NewMemDatabase()))
+	addr := common.HexToAddress("0x1300000000000000000000000000000000000000")
+
+	// Without the Genaro journal the data survives a revert
+	state.UpdateStake(addr, 1, 1)
+	snapshot := state.Snapshot()
+	state.UpdateStake(addr, 2, 2)
+	state.RevertToSnapshot(snapshot)
+	if stake, _ := state.GetStake(addr); stake != 3 {
+		t.Fatalf("unjournaled stake mismatch: have %d, want 3", stake)
+	}
+	state, _ = New(common.Hash{}, NewDatabase(ethdb.NewMemDatabase()))
+	state.SetGenaroJournal(true)
+
+	state.UpdateStake(addr, 1, 1)
+	snapshot = state.Snapshot()
+	state.UpdateStake(addr, 2, 2)
+	state.UpdateHeft(addr, 5, 2)
+	if stake, _ := state.GetStake(addr); stake != 3 {
+		t.Fatalf("stake mismatch: have %d, want 3", stake)
+	}
+	state.RevertToSnapshot(snapshot)
+	if stake, _ := state.GetStake(addr); stake != 1 {
+		t.Errorf("stake not reverted: have %d, want 1", stake)
+	}
+	if heft, _ := state.GetHeft(addr); heft != 0 {
+		t.Errorf("heft not reverted: have %d, want 0", heft)
+	}
+}
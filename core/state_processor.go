@@ -97,8 +97,11 @@ func ApplyTransaction(config *params.ChainConfig, bc *BlockChain, author *common
 	// about the transaction and calling mechanisms.
 	vmenv := vm.NewEVM(context, statedb, config, cfg)
 	// Apply the transaction to the current state (included in the env)
-	_, gas, failed, err := ApplyMessage(vmenv, msg, gp)
-
+	_, gas, err := ApplyMessageWithError(vmenv, msg, gp)
+	vmerr, failed := err.(*ExecutionError)
+	if failed {
+		err = nil
+	}
 	if err != nil {
 		return nil, 0, err
 	}
@@ -114,6 +117,10 @@ func ApplyTransaction(config *params.ChainConfig, bc *BlockChain, author *common
 	// Create a new receipt for the transaction, storing the intermediate root and gas used by the tx
 	// based on the eip phase, we're passing wether the root touch-delete accounts.
 	receipt := types.NewReceipt(root, failed, *usedGas)
+	if failed {
+		receipt.ErrorCode = vm.SpecialTxErrorCode(vmerr.Err)
+		receipt.ErrorReason = vmerr.Error()
+	}
 
 	//extraCost for special Tx
 	if nil != msg.To() {
@@ -124,12 +131,8 @@ func ApplyTransaction(config *params.ChainConfig, bc *BlockChain, author *common
 				if err == nil {
 					currentPrice := vmenv.StateDB.GetGenaroPrice()
 
-					bucketsMap := make(map[string]interface{})
-					if s.Type.ToInt().Uint64() == common.SpecialTxBucketSupplement.Uint64() {
-						bucketsMap, _ = vmenv.StateDB.GetBuckets(common.HexToAddress(s.Address))
-					}
-
-					costInfo := s.SpecialCost(currentPrice, bucketsMap)
+					bucketsMap := vm.SpecialTxBuckets(s, vmenv.StateDB)
+					costInfo := s.SpecialCost(currentPrice, bucketsMap, config.Genaro, header.Number)
 					receipt.ExtraInfo = costInfo.String()
 				}
 			}
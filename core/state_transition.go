@@ -17,11 +17,13 @@
 package core
 
 import (
+	"encoding/json"
 	"errors"
 	"math"
 	"math/big"
 
 	"github.com/GenaroNetwork/GenaroCore/common"
+	"github.com/GenaroNetwork/GenaroCore/core/types"
 	"github.com/GenaroNetwork/GenaroCore/core/vm"
 	"github.com/GenaroNetwork/GenaroCore/log"
 	"github.com/GenaroNetwork/GenaroCore/params"
@@ -138,6 +140,24 @@ func ApplyMessage(evm *vm.EVM, msg Message, gp *GasPool) ([]byte, uint64, bool,
 	return NewStateTransition(evm, msg, gp).TransitionDb()
 }
 
+// ApplyMessageWithError is like ApplyMessage, but instead of the failure flag it
+// returns an *ExecutionError carrying the error the EVM execution failed with,
+// so that the reason can be reported back to the user.
+func ApplyMessageWithError(evm *vm.EVM, msg Message, gp *GasPool) ([]byte, uint64, error) {
+	return NewStateTransition(evm, msg, gp).transitionDb()
+}
+
+// ExecutionError is returned by ApplyMessageWithError if the message was applied
+// but its EVM execution failed. Unlike the other errors it does not indicate a
+// consensus issue: the message is valid and its gas is consumed.
+type ExecutionError struct {
+	Err error
+}
+
+func (e *ExecutionError) Error() string {
+	return e.Err.Error()
+}
+
 func (st *StateTransition) from() vm.AccountRef {
 	f := st.msg.From()
 	if !st.state.Exist(f) {
@@ -210,6 +230,16 @@ func (st *StateTransition) preCheck() error {
 // returning the result including the the used gas. It returns an error if it
 // failed. An error indicates a consensus issue.
 func (st *StateTransition) TransitionDb() (ret []byte, usedGas uint64, failed bool, err error) {
+	ret, usedGas, err = st.transitionDb()
+	if _, ok := err.(*ExecutionError); ok {
+		return ret, usedGas, true, nil
+	}
+	return ret, usedGas, false, err
+}
+
+// transitionDb implements TransitionDb, returning an *ExecutionError instead of
+// the failure flag if the EVM execution failed.
+func (st *StateTransition) transitionDb() (ret []byte, usedGas uint64, err error) {
 	if err = st.preCheck(); err != nil {
 		return
 	}
@@ -222,10 +252,10 @@ func (st *StateTransition) TransitionDb() (ret []byte, usedGas uint64, failed bo
 	// Pay intrinsic gas
 	gas, err := IntrinsicGas(st.data, contractCreation, homestead)
 	if err != nil {
-		return nil, 0, false, err
+		return nil, 0, err
 	}
 	if err = st.useGas(gas); err != nil {
-		return nil, 0, false, err
+		return nil, 0, err
 	}
 
 	var (
@@ -240,7 +270,11 @@ func (st *StateTransition) TransitionDb() (ret []byte, usedGas uint64, failed bo
 	} else {
 		// Increment the nonce for the next transaction
 		st.state.SetNonce(sender.Address(), st.state.GetNonce(sender.Address())+1)
-		ret, st.gas, vmerr = evm.Call(sender, st.to().Address(), st.data, st.gas, st.value)
+		if s, ok := st.sponsoredInput(); ok {
+			ret, st.gas, vmerr = st.callSponsored(sender, s)
+		} else {
+			ret, st.gas, vmerr = evm.Call(sender, st.to().Address(), st.data, st.gas, st.value)
+		}
 	}
 	if vmerr != nil {
 		log.Debug("VM returned with error", "err", vmerr)
@@ -248,13 +282,56 @@ func (st *StateTransition) TransitionDb() (ret []byte, usedGas uint64, failed bo
 		// sufficient balance to make the transfer happen. The first
 		// balance transfer may never fail.
 		if vmerr == vm.ErrInsufficientBalance {
-			return nil, 0, false, vmerr
+			return nil, 0, vmerr
 		}
 	}
 	st.refundGas()
 	st.state.AddBalance(st.evm.Coinbase, new(big.Int).Mul(new(big.Int).SetUint64(st.gasUsed()), st.gasPrice))
 
-	return ret, st.gasUsed(), vmerr != nil, err
+	if vmerr != nil {
+		return ret, st.gasUsed(), &ExecutionError{Err: vmerr}
+	}
+	return ret, st.gasUsed(), nil
+}
+
+// sponsoredInput returns the special transaction input of the message if the
+// message is a sponsored special transaction.
+func (st *StateTransition) sponsoredInput() (types.SpecialTxInput, bool) {
+	var s types.SpecialTxInput
+	if st.msg.To() == nil || *st.msg.To() != common.SpecialSyncAddress || !st.evm.ChainConfig().Genaro.IsSponsored(st.evm.BlockNumber) {
+		return s, false
+	}
+	if err := json.Unmarshal(st.data, &s); err != nil || s.Type == nil || s.Type.ToInt().Cmp(common.SpecialTxSponsored) != 0 {
+		return s, false
+	}
+	return s, true
+}
+
+// callSponsored applies the payload of a sponsored special transaction as sent
+// by the signer of its authorization. The sponsor funds the special cost and
+// the value of the payload. If the payload fails, everything but the gas and
+// the nonce of the signer is reverted.
+func (st *StateTransition) callSponsored(sponsor vm.AccountRef, s types.SpecialTxInput) ([]byte, uint64, error) {
+	user, input, err := vm.CheckSponsoredTx(s, st.state, st.evm.ChainConfig().ChainId)
+	if err != nil {
+		return nil, st.gas, err
+	}
+	cost := input.SpecialCost(st.state.GetGenaroPrice(), vm.SpecialTxBuckets(input, st.state), st.evm.ChainConfig().Genaro, st.evm.BlockNumber)
+	funds := new(big.Int).Add(&cost, st.value)
+	if !st.evm.CanTransfer(st.state, sponsor.Address(), funds) {
+		return nil, st.gas, vm.ErrSpecialTxInsufficientBalance
+	}
+	// The authorization is consumed even if the payload fails, so it can't be
+	// replayed once the payload would succeed
+	st.state.SetNonce(user, st.state.GetNonce(user)+1)
+	snapshot := st.state.Snapshot()
+	st.evm.Transfer(st.state, sponsor.Address(), user, funds)
+
+	ret, gas, err := st.evm.Call(vm.AccountRef(user), common.SpecialSyncAddress, s.Sponsored.Payload, st.gas, st.value)
+	if err != nil {
+		st.state.RevertToSnapshot(snapshot)
+	}
+	return ret, gas, err
 }
 
 func (st *StateTransition) refundGas() {
@@ -0,0 +1,128 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/GenaroNetwork/GenaroCore/common"
+	"github.com/GenaroNetwork/GenaroCore/core/state"
+	"github.com/GenaroNetwork/GenaroCore/core/types"
+	"github.com/GenaroNetwork/GenaroCore/core/vm"
+	"github.com/GenaroNetwork/GenaroCore/crypto"
+	"github.com/GenaroNetwork/GenaroCore/ethdb"
+	"github.com/GenaroNetwork/GenaroCore/params"
+)
+
+// Tests that the payload of a sponsored special transaction is applied on
+// behalf of its signer at the expense of the sponsor, and can't be replayed.
+func TestSponsoredTransition(t *testing.T) {
+	var (
+		userKey, _    = crypto.GenerateKey()
+		sponsorKey, _ = crypto.GenerateKey()
+		user          = crypto.PubkeyToAddress(userKey.PublicKey)
+		sponsor       = crypto.PubkeyToAddress(sponsorKey.PublicKey)
+		config        = &params.ChainConfig{ChainId: big.NewInt(1), Genaro: &params.GenaroConfig{SponsoredBlock: big.NewInt(0)}}
+	)
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(ethdb.NewMemDatabase()))
+	statedb.AddBalance(sponsor, new(big.Int).Mul(big.NewInt(1000000), common.BaseCompany))
+
+	payload := []byte(fmt.Sprintf(`{"type":"0x4","address":"%x","traffic":10}`, user))
+	sponsored, err := types.SignSponsoredTx(payload, 0, config.ChainId, userKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	applySponsored := func(nonce uint64, sponsored *types.SponsoredTx) error {
+		tx, _ := types.NewSponsoredTransaction(nonce, sponsored, 1000000, big.NewInt(1))
+		msg := types.NewMessage(sponsor, tx.To(), nonce, tx.Value(), tx.Gas(), tx.GasPrice(), tx.Data(), true)
+		context := vm.Context{
+			CanTransfer: CanTransfer,
+			Transfer:    Transfer,
+			Origin:      sponsor,
+			BlockNumber: big.NewInt(1),
+			Time:        big.NewInt(0),
+			Difficulty:  big.NewInt(0),
+			GasPrice:    tx.GasPrice(),
+		}
+		_, _, err := ApplyMessageWithError(vm.NewEVM(context, statedb, config, vm.Config{}), msg, new(GasPool).AddGas(math.MaxUint64))
+		if vmerr, ok := err.(*ExecutionError); ok {
+			return vmerr.Err
+		}
+		if err != nil {
+			t.Fatalf("transaction %d not applied: %v", nonce, err)
+		}
+		return nil
+	}
+	apply := func(nonce uint64) error {
+		return applySponsored(nonce, sponsored)
+	}
+	before := statedb.GetBalance(sponsor)
+	if err := apply(0); err != nil {
+		t.Fatalf("sponsored transaction failed: %v", err)
+	}
+	if traffic := statedb.GetTraffic(user); traffic != 10 {
+		t.Errorf("traffic mismatch: have %d, want 10", traffic)
+	}
+	if nonce := statedb.GetNonce(user); nonce != 1 {
+		t.Errorf("user nonce mismatch: have %d, want 1", nonce)
+	}
+	if balance := statedb.GetBalance(user); balance.Sign() != 0 {
+		t.Errorf("user balance mismatch: have %v, want 0", balance)
+	}
+	input, _ := sponsored.Input()
+	cost := input.SpecialCost(statedb.GetGenaroPrice(), nil, config.Genaro, big.NewInt(1))
+	if paid := new(big.Int).Sub(before, statedb.GetBalance(sponsor)); paid.Cmp(&cost) <= 0 {
+		t.Errorf("sponsor paid %v, want the special cost %v and gas", paid, &cost)
+	}
+
+	// The authorization is bound to the nonce of the user
+	if err := apply(1); vm.SpecialTxErrorCode(err) != vm.ErrSponsoredNonce.Code {
+		t.Errorf("replay: have %v, want %v", err, vm.ErrSponsoredNonce)
+	}
+	if traffic := statedb.GetTraffic(user); traffic != 10 {
+		t.Errorf("replay applied: traffic %d, want 10", traffic)
+	}
+
+	// A failing payload still consumes the authorization, but not the funds
+	failing, err := types.SignSponsoredTx([]byte(`{"type":"0xff"}`), 1, config.ChainId, userKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := applySponsored(2, failing); err != vm.ErrSpecialTxUndefinedType {
+		t.Fatalf("failing payload: have %v, want %v", err, vm.ErrSpecialTxUndefinedType)
+	}
+	if nonce := statedb.GetNonce(user); nonce != 2 {
+		t.Errorf("user nonce mismatch after failing payload: have %d, want 2", nonce)
+	}
+	if balance := statedb.GetBalance(user); balance.Sign() != 0 {
+		t.Errorf("user balance mismatch after failing payload: have %v, want 0", balance)
+	}
+	if err := applySponsored(3, failing); vm.SpecialTxErrorCode(err) != vm.ErrSponsoredNonce.Code {
+		t.Errorf("replay of failed payload: have %v, want %v", err, vm.ErrSponsoredNonce)
+	}
+
+	// The Genaro data of a failing payload is reverted too
+	snapshot := statedb.Snapshot()
+	statedb.UpdateTraffic(user, 5)
+	statedb.RevertToSnapshot(snapshot)
+	if traffic := statedb.GetTraffic(user); traffic != 10 {
+		t.Errorf("traffic not reverted: have %d, want 10", traffic)
+	}
+}
@@ -0,0 +1,267 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/GenaroNetwork/GenaroCore/common"
+	"github.com/GenaroNetwork/GenaroCore/core/types"
+	"github.com/GenaroNetwork/GenaroCore/log"
+)
+
+// invalidSpecialTxs is the number of special transactions dropped on
+// revalidation whose reason is remembered.
+const invalidSpecialTxs = 256
+
+// Status of a special transaction as reported by TxPool.SpecialTxStatus.
+const (
+	SpecialTxPending = "pending" // executable
+	SpecialTxQueued  = "queued"  // waiting for a nonce gap to close
+	SpecialTxDropped = "dropped" // dropped as it became invalid
+)
+
+// SpecialTxStatus explains whether a special transaction in the pool is
+// executable.
+type SpecialTxStatus struct {
+	Hash   common.Hash    `json:"hash"`
+	From   common.Address `json:"from"`
+	Nonce  uint64         `json:"nonce"`
+	Type   uint64         `json:"type"`
+	Status string         `json:"status"`
+	Error  string         `json:"error,omitempty"` // why the transaction is not executable
+}
+
+// SpecialRateLimit caps the number of special transactions of one type the
+// pool admits per block.
+type SpecialRateLimit struct {
+	Type  uint64 // Special transaction type, see common.SpecialTxType*
+	Limit uint64 // Maximum number of transactions admitted per block
+}
+
+// isSpecialTx returns whether tx is a special transaction. Special transactions
+// live in their own lane of the pool: they are limited by the lane instead of
+// the global slots and are never evicted in favour of better priced ones.
+func isSpecialTx(tx *types.Transaction) bool {
+	return tx.To() != nil && *tx.To() == common.SpecialSyncAddress
+}
+
+// specialTxType returns the type of a special transaction.
+func specialTxType(tx *types.Transaction) (uint64, bool) {
+	var s types.SpecialTxInput
+	if err := json.Unmarshal(tx.Data(), &s); err != nil || s.Type == nil {
+		return 0, false
+	}
+	return s.Type.ToInt().Uint64(), true
+}
+
+// specialLane enforces the limits of the special transaction lane. Special
+// transactions of system senders bypass them.
+//
+// Note, the lane assumes the pool lock is held!
+type specialLane struct {
+	slots        uint64                      // Maximum number of special transactions in the pool
+	accountSlots uint64                      // Maximum number of special transactions per sender
+	limits       map[uint64]uint64           // Maximum number of admissions per block by type
+	system       map[common.Address]struct{} // Senders exempt from the limits and mined first
+	admitted     map[uint64]uint64           // Admissions since the last block by type
+
+	size     int                    // Number of special transactions in the pool
+	accounts map[common.Address]int // Number of special transactions in the pool by sender
+}
+
+func newSpecialLane(config TxPoolConfig) *specialLane {
+	lane := &specialLane{
+		slots:        config.SpecialSlots,
+		accountSlots: config.SpecialAccountSlots,
+		limits:       make(map[uint64]uint64),
+		system:       make(map[common.Address]struct{}),
+		admitted:     make(map[uint64]uint64),
+		accounts:     make(map[common.Address]int),
+	}
+	for _, limit := range config.SpecialRateLimits {
+		lane.limits[limit.Type] = limit.Limit
+	}
+	for _, sender := range config.SystemSenders {
+		lane.system[sender] = struct{}{}
+	}
+	return lane
+}
+
+// isSystem returns whether addr is a whitelisted system sender.
+func (l *specialLane) isSystem(addr common.Address) bool {
+	_, ok := l.system[addr]
+	return ok
+}
+
+// admit checks whether a special transaction of type txType sent by from can
+// join the lane. A replacement of a pooled special transaction does not take
+// a new slot. The admission is only counted against the rate limit once the
+// transaction is pooled, see record.
+func (l *specialLane) admit(from common.Address, txType uint64, replace bool) error {
+	if l.isSystem(from) {
+		return nil
+	}
+	if !replace && uint64(l.size) >= l.slots {
+		return ErrSpecialLaneFull
+	}
+	if !replace && uint64(l.accounts[from]) >= l.accountSlots {
+		return ErrSpecialAccountFull
+	}
+	if limit, ok := l.limits[txType]; ok && l.admitted[txType] >= limit {
+		return ErrSpecialRateLimited
+	}
+	return nil
+}
+
+// record counts a pooled special transaction against the rate limit of its type.
+func (l *specialLane) record(from common.Address, txType uint64) {
+	if !l.isSystem(from) {
+		l.admitted[txType]++
+	}
+}
+
+// track updates the number of special transactions of from in the pool by delta.
+func (l *specialLane) track(from common.Address, delta int) {
+	l.size += delta
+	if l.accounts[from] += delta; l.accounts[from] <= 0 {
+		delete(l.accounts, from)
+	}
+}
+
+// reset starts the rate limits over for a new block.
+func (l *specialLane) reset() {
+	l.admitted = make(map[uint64]uint64)
+}
+
+// storeTx inserts tx into the lookup of all pooled transactions, keeping the
+// special lane counters up to date.
+//
+// Note, this method assumes the pool lock is held!
+func (pool *TxPool) storeTx(hash common.Hash, tx *types.Transaction) {
+	if pool.all[hash] != nil {
+		pool.forgetTx(hash)
+	}
+	pool.all[hash] = tx
+	if isSpecialTx(tx) {
+		from, _ := types.Sender(pool.signer, tx) // already validated
+		pool.lane.track(from, 1)
+	}
+}
+
+// forgetTx removes a transaction from the lookup of all pooled transactions,
+// keeping the special lane counters up to date.
+//
+// Note, this method assumes the pool lock is held!
+func (pool *TxPool) forgetTx(hash common.Hash) {
+	tx, ok := pool.all[hash]
+	if !ok {
+		return
+	}
+	delete(pool.all, hash)
+	if isSpecialTx(tx) {
+		from, _ := types.Sender(pool.signer, tx) // already validated
+		pool.lane.track(from, -1)
+	}
+}
+
+// replacesSpecial returns whether the pool holds a special transaction of from
+// with the given nonce, which a new transaction would replace.
+//
+// Note, this method assumes the pool lock is held!
+func (pool *TxPool) replacesSpecial(from common.Address, nonce uint64) bool {
+	for _, list := range []*txList{pool.pending[from], pool.queue[from]} {
+		if list == nil {
+			continue
+		}
+		if old := list.txs.Get(nonce); old != nil && isSpecialTx(old) {
+			return true
+		}
+	}
+	return false
+}
+
+// revalidateSpecial runs the special transaction checks of validateTx again on
+// every special transaction in the pool and drops the ones that became invalid,
+// so they do not fail on chain. Any subsequent transactions of their senders
+// are moved back to the future queue.
+//
+// Note, this method assumes the pool lock is held!
+func (pool *TxPool) revalidateSpecial() {
+	for hash, tx := range pool.all {
+		if !isSpecialTx(tx) {
+			continue
+		}
+		from, _ := types.Sender(pool.signer, tx) // already validated
+		if err := pool.dispatchHandlerValidateTx(tx.Data(), from); err != nil {
+			log.Trace("Removed invalidated special transaction", "hash", hash, "err", err)
+			txType, _ := specialTxType(tx)
+			pool.invalid.Add(hash, SpecialTxStatus{hash, from, tx.Nonce(), txType, SpecialTxDropped, err.Error()})
+			pool.removeTx(hash)
+			specialInvalidCounter.Inc(1)
+		}
+	}
+}
+
+// SpecialTxStatus reports whether the special transactions in the pool are
+// executable, and why the recently dropped ones were not. The transactions are
+// ordered by sender and nonce, the dropped ones come last.
+func (pool *TxPool) SpecialTxStatus() []SpecialTxStatus {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	var statuses []SpecialTxStatus
+	for _, tx := range pool.all {
+		if !isSpecialTx(tx) {
+			continue
+		}
+		from, _ := types.Sender(pool.signer, tx) // already validated
+		txType, _ := specialTxType(tx)
+		status := SpecialTxStatus{Hash: tx.Hash(), From: from, Nonce: tx.Nonce(), Type: txType, Status: SpecialTxQueued}
+		if pool.pending[from] != nil && pool.pending[from].txs.Get(tx.Nonce()) == tx {
+			status.Status = SpecialTxPending
+		}
+		if err := pool.dispatchHandlerValidateTx(tx.Data(), from); err != nil {
+			status.Error = err.Error()
+		} else if status.Status == SpecialTxQueued {
+			status.Error = "nonce gap"
+		}
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		if statuses[i].From != statuses[j].From {
+			return statuses[i].From.Big().Cmp(statuses[j].From.Big()) < 0
+		}
+		return statuses[i].Nonce < statuses[j].Nonce
+	})
+	for _, hash := range pool.invalid.Keys() {
+		if status, ok := pool.invalid.Peek(hash); ok {
+			statuses = append(statuses, status.(SpecialTxStatus))
+		}
+	}
+	return statuses
+}
+
+// IsSystemSender returns whether the special transactions of addr bypass the
+// limits of the special lane and are to be mined ahead of other transactions.
+func (pool *TxPool) IsSystemSender(addr common.Address) bool {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	return pool.lane.isSystem(addr)
+}
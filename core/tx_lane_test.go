@@ -0,0 +1,167 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/GenaroNetwork/GenaroCore/common"
+	"github.com/GenaroNetwork/GenaroCore/core/types"
+	"github.com/GenaroNetwork/GenaroCore/core/vm"
+	"github.com/GenaroNetwork/GenaroCore/crypto"
+)
+
+// Tests that the special lane enforces its slots and per type rate limits, and
+// that system senders bypass both.
+func TestSpecialLaneAdmit(t *testing.T) {
+	var (
+		user     = common.HexToAddress("0x01")
+		system   = common.HexToAddress("0x02")
+		other    = common.HexToAddress("0x03")
+		synState = common.SpecialTxSynState.Uint64()
+	)
+	lane := newSpecialLane(TxPoolConfig{
+		SpecialSlots:        3,
+		SpecialAccountSlots: 2,
+		SpecialRateLimits:   []SpecialRateLimit{{Type: synState, Limit: 1}},
+		SystemSenders:       []common.Address{system},
+	})
+	if err := lane.admit(user, synState, false); err != nil {
+		t.Fatalf("first transaction rejected: %v", err)
+	}
+	lane.record(user, synState)
+	lane.track(user, 1)
+	if err := lane.admit(user, synState, false); err != ErrSpecialRateLimited {
+		t.Errorf("rate limit: have %v, want %v", err, ErrSpecialRateLimited)
+	}
+	if err := lane.admit(user, common.SpecialTxTypeStakeSync.Uint64(), false); err != nil {
+		t.Errorf("unlimited type rejected: %v", err)
+	}
+	lane.track(user, 1)
+	if err := lane.admit(user, common.SpecialTxTypeStakeSync.Uint64(), false); err != ErrSpecialAccountFull {
+		t.Errorf("full account: have %v, want %v", err, ErrSpecialAccountFull)
+	}
+	if err := lane.admit(user, common.SpecialTxTypeStakeSync.Uint64(), true); err != nil {
+		t.Errorf("replacement rejected: %v", err)
+	}
+	lane.track(system, 1)
+	if err := lane.admit(other, common.SpecialTxTypeStakeSync.Uint64(), false); err != ErrSpecialLaneFull {
+		t.Errorf("full lane: have %v, want %v", err, ErrSpecialLaneFull)
+	}
+	if err := lane.admit(system, synState, false); err != nil {
+		t.Errorf("system sender rejected: %v", err)
+	}
+	lane.track(user, -2)
+	if err := lane.admit(other, common.SpecialTxTypeStakeSync.Uint64(), false); err != nil {
+		t.Errorf("freed slot rejected: %v", err)
+	}
+	if _, ok := lane.accounts[user]; ok {
+		t.Errorf("empty account still tracked")
+	}
+	lane.reset()
+	if err := lane.admit(user, synState, false); err != nil {
+		t.Errorf("rate limit not reset: %v", err)
+	}
+}
+
+// Tests that a special transaction failing to join the pool is not counted
+// against the rate limit of its type.
+func TestSpecialLaneFailedAdd(t *testing.T) {
+	pool, key := setupTxPool()
+	defer pool.Stop()
+
+	pool.lane.limits[common.SpecialTxTypeBackStake.Uint64()] = 1
+
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	pool.currentState.AddBalance(from, big.NewInt(1000000000))
+
+	data := []byte(`{"type":"0xb"}`) // common.SpecialTxTypeBackStake
+	first, _ := types.SignTx(types.NewTransaction(0, common.SpecialSyncAddress, big.NewInt(0), 100000, big.NewInt(2), data), types.HomesteadSigner{}, key)
+	if err := pool.AddRemote(first); err != nil {
+		t.Fatalf("failed to add special transaction: %v", err)
+	}
+	pool.lockedReset(nil, nil)
+
+	// An underpriced replacement must not use up the rate limit
+	underpriced, _ := types.SignTx(types.NewTransaction(0, common.SpecialSyncAddress, big.NewInt(0), 100000, big.NewInt(2), append(data, ' ')), types.HomesteadSigner{}, key)
+	if err := pool.AddRemote(underpriced); err != ErrReplaceUnderpriced {
+		t.Fatalf("underpriced replacement: have %v, want %v", err, ErrReplaceUnderpriced)
+	}
+	second, _ := types.SignTx(types.NewTransaction(1, common.SpecialSyncAddress, big.NewInt(0), 100000, big.NewInt(1), data), types.HomesteadSigner{}, key)
+	if err := pool.AddRemote(second); err != nil {
+		t.Fatalf("failed to add special transaction after a rejected one: %v", err)
+	}
+	if pool.lane.size != 2 || pool.lane.accounts[from] != 2 {
+		t.Errorf("lane size mismatch: have %d (%d by sender), want 2", pool.lane.size, pool.lane.accounts[from])
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Errorf("pool internal state corrupted: %v", err)
+	}
+}
+
+// Tests that special transactions are never discarded in favour of better
+// priced ones.
+func TestSpecialLaneDiscard(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	special, _ := types.SignTx(types.NewTransaction(0, common.SpecialSyncAddress, big.NewInt(0), 100000, big.NewInt(1), nil), types.HomesteadSigner{}, key)
+	regular := pricedTransaction(1, 100000, big.NewInt(2), key)
+
+	all := map[common.Hash]*types.Transaction{special.Hash(): special, regular.Hash(): regular}
+	priced := newTxPricedList(&all)
+	priced.Put(special)
+	priced.Put(regular)
+
+	drop := priced.Discard(1, newAccountSet(types.HomesteadSigner{}))
+	if len(drop) != 1 || drop[0] != regular {
+		t.Errorf("discarded %v, want the regular transaction", drop)
+	}
+}
+
+// Tests that special transactions invalidated by a state change are dropped on
+// reset and reported together with the reason.
+func TestSpecialTxRevalidation(t *testing.T) {
+	pool, key := setupTxPool()
+	defer pool.Stop()
+
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	pool.currentState.AddBalance(from, big.NewInt(1000000000))
+
+	data := []byte(`{"type":"0xb"}`) // common.SpecialTxTypeBackStake
+	tx, _ := types.SignTx(types.NewTransaction(0, common.SpecialSyncAddress, big.NewInt(0), 100000, big.NewInt(1), data), types.HomesteadSigner{}, key)
+	if err := pool.AddRemote(tx); err != nil {
+		t.Fatalf("failed to add special transaction: %v", err)
+	}
+	statuses := pool.SpecialTxStatus()
+	if len(statuses) != 1 || statuses[0].Status != SpecialTxPending || statuses[0].Error != "" {
+		t.Fatalf("status mismatch: have %+v, want executable", statuses)
+	}
+	// Forbid the back stake, the transaction would fail on chain
+	pool.currentState.AddAccountInForbidBackStakeList(from)
+	pool.lockedReset(nil, nil)
+
+	if pool.Get(tx.Hash()) != nil {
+		t.Errorf("invalidated special transaction not dropped")
+	}
+	statuses = pool.SpecialTxStatus()
+	if len(statuses) != 1 || statuses[0].Status != SpecialTxDropped || statuses[0].Error != vm.ErrAccountInForbidBackStakeList.Error() {
+		t.Errorf("status mismatch: have %+v, want dropped with %v", statuses, vm.ErrAccountInForbidBackStakeList)
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Errorf("pool internal state corrupted: %v", err)
+	}
+}
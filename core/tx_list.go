@@ -352,6 +352,17 @@ func (l *txList) Len() int {
 	return l.txs.Len()
 }
 
+// Special returns the number of special transactions in the list.
+func (l *txList) Special() int {
+	special := 0
+	for _, tx := range l.txs.items {
+		if isSpecialTx(tx) {
+			special++
+		}
+	}
+	return special
+}
+
 // Empty returns whether the list of transactions is empty or not.
 func (l *txList) Empty() bool {
 	return l.Len() == 0
@@ -447,8 +458,8 @@ func (l *txPricedList) Cap(threshold *big.Int, local *accountSet) types.Transact
 			save = append(save, tx)
 			break
 		}
-		// Non stale transaction found, discard unless local
-		if local.containsTx(tx) {
+		// Non stale transaction found, discard unless local or special
+		if local.containsTx(tx) || isSpecialTx(tx) {
 			save = append(save, tx)
 		} else {
 			drop = append(drop, tx)
@@ -499,8 +510,8 @@ func (l *txPricedList) Discard(count int, local *accountSet) types.Transactions
 			l.stales--
 			continue
 		}
-		// Non stale transaction found, discard unless local
-		if local.containsTx(tx) {
+		// Non stale transaction found, discard unless local or special
+		if local.containsTx(tx) || isSpecialTx(tx) {
 			save = append(save, tx)
 		} else {
 			drop = append(drop, tx)
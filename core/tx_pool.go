@@ -34,6 +34,7 @@ import (
 	"github.com/GenaroNetwork/GenaroCore/log"
 	"github.com/GenaroNetwork/GenaroCore/metrics"
 	"github.com/GenaroNetwork/GenaroCore/params"
+	"github.com/hashicorp/golang-lru"
 	"gopkg.in/karalabe/cookiejar.v2/collections/prque"
 )
 
@@ -84,6 +85,18 @@ var (
 	// than some meaningful limit a user might use. This is not a consensus error
 	// making the transaction invalid, rather a DOS protection.
 	ErrOversizedData = errors.New("oversized data")
+
+	// ErrSpecialLaneFull is returned if the special transaction lane holds the
+	// maximum number of special transactions.
+	ErrSpecialLaneFull = errors.New("special transaction lane full")
+
+	// ErrSpecialAccountFull is returned if the sender already holds the maximum
+	// number of special transactions permitted per account.
+	ErrSpecialAccountFull = errors.New("special transaction slots of account full")
+
+	// ErrSpecialRateLimited is returned if the pool already admitted the maximum
+	// number of special transactions of the type since the last block.
+	ErrSpecialRateLimited = errors.New("special transaction type rate limited")
 )
 
 var (
@@ -107,6 +120,10 @@ var (
 	// General tx metrics
 	invalidTxCounter     = metrics.NewRegisteredCounter("txpool/invalid", nil)
 	underpricedTxCounter = metrics.NewRegisteredCounter("txpool/underpriced", nil)
+
+	// Metrics for the special transaction lane
+	specialRateLimitCounter = metrics.NewRegisteredCounter("txpool/special/ratelimit", nil) // Dropped due to lane limits
+	specialInvalidCounter   = metrics.NewRegisteredCounter("txpool/special/invalid", nil)   // Dropped as they became invalid
 )
 
 // TxStatus is the current status of a transaction as seen by the pool.
@@ -144,6 +161,11 @@ type TxPoolConfig struct {
 	GlobalQueue  uint64 // Maximum number of non-executable transaction slots for all accounts
 
 	Lifetime time.Duration // Maximum amount of time non-executable transaction are queued
+
+	SpecialSlots        uint64             // Maximum number of special transaction slots, besides the global ones
+	SpecialAccountSlots uint64             // Maximum number of special transaction slots permitted per account
+	SpecialRateLimits   []SpecialRateLimit // Maximum number of special transactions admitted per block by type
+	SystemSenders       []common.Address   // Senders whose special transactions bypass the lane limits and are mined first
 }
 
 // DefaultTxPoolConfig contains the default configurations for the transaction
@@ -161,6 +183,9 @@ var DefaultTxPoolConfig = TxPoolConfig{
 	GlobalQueue:  1024,
 
 	Lifetime: 3 * time.Hour,
+
+	SpecialSlots:        1024,
+	SpecialAccountSlots: 16,
 }
 
 // sanitize checks the provided user configurations and changes anything that's
@@ -179,6 +204,14 @@ func (config *TxPoolConfig) sanitize() TxPoolConfig {
 		log.Warn("Sanitizing invalid txpool price bump", "provided", conf.PriceBump, "updated", DefaultTxPoolConfig.PriceBump)
 		conf.PriceBump = DefaultTxPoolConfig.PriceBump
 	}
+	if conf.SpecialSlots < 1 {
+		log.Warn("Sanitizing invalid txpool special slots", "provided", conf.SpecialSlots, "updated", DefaultTxPoolConfig.SpecialSlots)
+		conf.SpecialSlots = DefaultTxPoolConfig.SpecialSlots
+	}
+	if conf.SpecialAccountSlots < 1 {
+		log.Warn("Sanitizing invalid txpool special account slots", "provided", conf.SpecialAccountSlots, "updated", DefaultTxPoolConfig.SpecialAccountSlots)
+		conf.SpecialAccountSlots = DefaultTxPoolConfig.SpecialAccountSlots
+	}
 	return conf
 }
 
@@ -204,9 +237,12 @@ type TxPool struct {
 	currentState  *state.StateDB      // Current state in the blockchain head
 	pendingState  *state.ManagedState // Pending state tracking virtual nonces
 	currentMaxGas uint64              // Current gas limit for transaction caps
+	batchState    *state.StateDB      // Scratch copy of the current state to check batches on
 
-	locals  *accountSet // Set of local transaction to exempt from eviction rules
-	journal *txJournal  // Journal of local transaction to back up to disk
+	locals  *accountSet  // Set of local transaction to exempt from eviction rules
+	journal *txJournal   // Journal of local transaction to back up to disk
+	lane    *specialLane // Limits of the special transaction lane
+	invalid *lru.Cache   // Recently invalidated special transactions and why
 
 	pending map[common.Address]*txList         // All currently processable transactions
 	queue   map[common.Address]*txList         // Queued but non-processable transactions
@@ -239,6 +275,8 @@ func NewTxPool(config TxPoolConfig, chainconfig *params.ChainConfig, chain block
 		gasPrice:    new(big.Int).SetUint64(config.PriceLimit),
 	}
 	pool.locals = newAccountSet(pool.signer)
+	pool.lane = newSpecialLane(config)
+	pool.invalid, _ = lru.New(invalidSpecialTxs)
 	pool.priced = newTxPricedList(&pool.all)
 	pool.reset(nil, chain.CurrentBlock().Header())
 
@@ -415,7 +453,9 @@ func (pool *TxPool) reset(oldHead, newHead *types.Header) {
 	}
 	pool.currentState = statedb
 	pool.pendingState = state.ManageState(statedb)
+	pool.batchState = nil
 	pool.currentMaxGas = newHead.GasLimit
+	pool.lane.reset()
 
 	// Inject any transactions discarded due to reorgs
 	log.Debug("Reinjecting stale transactions", "count", len(reinject))
@@ -427,6 +467,9 @@ func (pool *TxPool) reset(oldHead, newHead *types.Header) {
 	// higher gas price)
 	pool.demoteUnexecutables()
 
+	// Special transactions may have been invalidated by the new state too
+	pool.revalidateSpecial()
+
 	// Update all accounts to the latest known pending nonce
 	for addr, list := range pool.pending {
 		txs := list.Flatten() // Heavy but will be cached and is needed by the miner anyway
@@ -603,14 +646,12 @@ func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
 			}
 
 			json.Unmarshal(tx.Data(), &s)
-			if s.Type.ToInt().Uint64() == common.SpecialTxBucketSupplement.Uint64() {
-				bucketsMap, _ = pool.currentState.GetBuckets(common.HexToAddress(s.Address))
-			}
+			bucketsMap = vm.SpecialTxBuckets(s, pool.currentState)
 		}
 	}
 
 	currentPrice := pool.currentState.GetGenaroPrice()
-	totalCost := new(big.Int).Add(tx.Cost(), tx.SpecialCost(currentPrice, bucketsMap))
+	totalCost := new(big.Int).Add(tx.Cost(), tx.SpecialCost(currentPrice, bucketsMap, pool.chainconfig.Genaro, new(big.Int).Add(pool.chain.CurrentBlock().Number(), common.Big1)))
 	//log.Info(fmt.Sprintf("total cost:%s", totalCost.String()))
 	if pool.currentState.GetBalance(from).Cmp(totalCost) < 0 {
 		return ErrInsufficientFundsForSpecialTx
@@ -626,43 +667,168 @@ func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
 	return nil
 }
 
+// nextBlockTime returns the earliest time of the next block, which the Genaro
+// engine sets to the parent time plus the period, or to now if that has passed.
+func (pool *TxPool) nextBlockTime() int64 {
+	next := pool.chain.CurrentBlock().Time().Int64() + int64(pool.chainconfig.Genaro.Period)
+	if now := time.Now().Unix(); next < now {
+		return now
+	}
+	return next
+}
+
 func (pool *TxPool) dispatchHandlerValidateTx(input []byte, caller common.Address) error {
 	var err error
 	var s types.SpecialTxInput
 	err = json.Unmarshal(input, &s)
 	if err != nil {
-		return errors.New("special tx error： the extraData parameters of the wrong format")
+		return vm.ErrSpecialTxInvalidInput
 	}
 
 	if nil == s.Type {
-		return errors.New("special tx error: miss param [type]")
+		return vm.ErrSpecialTxTypeMissing
 	}
+	// Pooled transactions are included in the next block at the earliest, so the
+	// forks and deadlines are checked against it.
+	next := new(big.Int).Add(pool.chain.CurrentBlock().Number(), common.Big1)
 
 	switch s.Type.ToInt().Uint64() {
 	case common.SpecialTxTypeStakeSync.Uint64():
-		return vm.CheckStakeTx(s, pool.currentState, pool.chainconfig.Genaro)
+		if err := vm.CheckStakeTx(s, pool.currentState, pool.chainconfig.Genaro, next); err != nil {
+			return err
+		}
+		if s.FromVesting && pool.chainconfig.Genaro.IsVesting(next) {
+			return vm.CheckVestingStakeTx(caller, s, pool.currentState)
+		}
+		return nil
 	case common.SpecialTxTypeHeftSync.Uint64():
-		return vm.CheckSyncHeftTx(caller, s, pool.currentState, pool.chainconfig.Genaro)
+		if err := vm.CheckSyncHeftTx(caller, s, pool.currentState, pool.chainconfig.Genaro, next); err != nil {
+			return err
+		}
+		if pool.chainconfig.Genaro.IsHeftOracle(next) {
+			return vm.CheckHeftOracleInactive(pool.currentState)
+		}
+		return nil
+	case common.SpecialTxAddHeftReporter.Uint64():
+		if pool.chainconfig.Genaro.IsHeftOracle(next) {
+			return vm.CheckAddHeftReporterTx(caller, s, pool.currentState, pool.chainconfig.Genaro, next)
+		}
+	case common.SpecialTxDelHeftReporter.Uint64():
+		if pool.chainconfig.Genaro.IsHeftOracle(next) {
+			return vm.CheckDelHeftReporterTx(caller, s, pool.currentState, pool.chainconfig.Genaro)
+		}
+	case common.SpecialTxReportHeft.Uint64():
+		if pool.chainconfig.Genaro.IsHeftOracle(next) {
+			return vm.CheckReportHeftTx(caller, s, pool.currentState, pool.chainconfig.Genaro, next)
+		}
+	case common.SpecialTxBatch.Uint64():
+		if pool.chainconfig.Genaro.IsBatch(next) {
+			return pool.validateBatchTx(s, caller, common.Big0)
+		}
+	case common.SpecialTxVestingCreate.Uint64():
+		if pool.chainconfig.Genaro.IsVesting(next) {
+			return vm.CheckVestingCreateTx(caller, s, pool.currentState, pool.chainconfig.Genaro, next)
+		}
+	case common.SpecialTxVestingWithdraw.Uint64():
+		if pool.chainconfig.Genaro.IsVesting(next) {
+			return vm.CheckVestingWithdrawTx(caller, pool.currentState, next.Uint64())
+		}
+	case common.SpecialTxStorageCommit.Uint64():
+		if pool.chainconfig.Genaro.IsStorageProof(next) {
+			return vm.CheckStorageCommitTx(caller, s, pool.currentState)
+		}
+	case common.SpecialTxBucketRootCommit.Uint64():
+		if pool.chainconfig.Genaro.IsStorageProof(next) {
+			return vm.CheckBucketRootCommitTx(caller, s, pool.currentState)
+		}
+	case common.SpecialTxStorageChallenge.Uint64():
+		if pool.chainconfig.Genaro.IsStorageProof(next) {
+			return vm.CheckStorageChallengeTx(caller, s, pool.currentState, pool.chainconfig.Genaro)
+		}
+	case common.SpecialTxStorageProof.Uint64():
+		if pool.chainconfig.Genaro.IsStorageProof(next) {
+			return vm.CheckStorageProofTx(caller, s, pool.currentState, next.Uint64())
+		}
+	case common.SpecialTxBucketUsage.Uint64():
+		if pool.chainconfig.Genaro.IsBucketUsage(next) {
+			return vm.CheckBucketUsageTx(caller, s, pool.currentState, pool.chainconfig.Genaro)
+		}
+	case common.SpecialTxSponsored.Uint64():
+		if pool.chainconfig.Genaro.IsSponsored(next) {
+			user, input, err := vm.CheckSponsoredTx(s, pool.currentState, pool.chainconfig.ChainId)
+			if err != nil {
+				return err
+			}
+			// The steps of a batch are charged as they are checked, the sponsor funds them
+			if input.Type.ToInt().Cmp(common.SpecialTxBatch) == 0 && pool.chainconfig.Genaro.IsBatch(next) {
+				cost := input.SpecialCost(pool.currentState.GetGenaroPrice(), vm.SpecialTxBuckets(input, pool.currentState), pool.chainconfig.Genaro, next)
+				return pool.validateBatchTx(input, user, &cost)
+			}
+			return pool.dispatchHandlerValidateTx(s.Sponsored.Payload, user)
+		}
 	case common.SpecialTxTypeSpaceApply.Uint64():
-		return vm.CheckApplyBucketTx(s, pool.currentState, pool.chainconfig.Genaro)
+		return vm.CheckApplyBucketTx(s, pool.currentState, pool.chainconfig.Genaro, next)
 	case common.SpecialTxBucketSupplement.Uint64():
-		return vm.CheckBucketSupplement(s, pool.currentState, pool.chainconfig.Genaro)
-	//case common.SpecialTxTypeMortgageInit.Uint64():
-	//	return vm.CheckspecialTxTypeMortgageInitParameter(s, s.SpecialTxTypeMortgageInit.FromAccount)
-	//case common.SpecialTxTypeSyncSidechainStatus.Uint64():
-	//	return vm.CheckSpecialTxTypeSyncSidechainStatusParameter(s, caller, pool.currentState, pool.chainconfig.Genaro)
+		return vm.CheckBucketSupplement(s, pool.currentState, pool.chainconfig.Genaro, next)
+	case common.SpecialTxTypeMortgageInit.Uint64():
+		if pool.chainconfig.Genaro.IsMortgage(next) {
+			return vm.CheckMortgageInitTx(caller, s, pool.currentState, pool.nextBlockTime())
+		}
+	case common.SpecialTxTypeMortgageTerminate.Uint64():
+		if pool.chainconfig.Genaro.IsMortgage(next) {
+			return vm.CheckMortgageTerminateTx(caller, s, pool.currentState, pool.chainconfig.Genaro, pool.nextBlockTime())
+		}
+	case common.SpecialTxTypeSyncSidechainStatus.Uint64():
+		if pool.chainconfig.Genaro.IsMortgage(next) {
+			return vm.CheckSyncSidechainStatusTx(caller, s, pool.currentState, pool.chainconfig.Genaro, pool.nextBlockTime())
+		}
 	case common.SpecialTxTypeTrafficApply.Uint64():
-		return vm.CheckTrafficTx(s, pool.currentState, pool.chainconfig.Genaro)
+		return vm.CheckTrafficTx(s, pool.currentState, pool.chainconfig.Genaro, next)
 	case common.SpecialTxTypeSyncNode.Uint64():
 		return vm.CheckSyncNodeTx(caller, s, pool.currentState)
 	case common.SynchronizeShareKey.Uint64():
-		return vm.CheckSynchronizeShareKeyParameter(s, pool.currentState, pool.chainconfig.Genaro)
+		if !pool.chainconfig.Genaro.IsShareCommit(next) || !s.SynchronizeShareKey.IsCommitted() {
+			s.SynchronizeShareKey.Commitment = nil
+		}
+		if err := vm.CheckSynchronizeShareKeyParameter(s, pool.currentState, pool.chainconfig.Genaro, next); err != nil {
+			return err
+		}
+		if err := vm.CheckShareKeyCommitment(s); err != nil {
+			return err
+		}
+		if pool.chainconfig.Genaro.IsFileShareKey(next) {
+			if err := vm.CheckShareKeyPublicKeyVersion(s, pool.currentState); err != nil {
+				return err
+			}
+		}
+		if pool.chainconfig.Genaro.IsShareKey(next) {
+			return vm.CheckShareKeyOffer(s, pool.currentState, next)
+		}
+		return nil
 	case common.SpecialTxTypeSyncFielSharePublicKey.Uint64():
-		return vm.CheckSyncFileSharePublicKeyTx(s, pool.currentState, pool.chainconfig.Genaro)
+		return vm.CheckSyncFileSharePublicKeyTx(s, pool.currentState, pool.chainconfig.Genaro, next)
 	case common.UnlockSharedKey.Uint64():
+		if pool.chainconfig.Genaro.IsShareKey(next) {
+			if !pool.chainconfig.Genaro.IsShareCommit(next) {
+				s.SynchronizeShareKey.Commitment = nil
+			}
+			return vm.CheckUnlockShareKeyTx(caller, s, pool.currentState, next)
+		}
 		return vm.CheckUnlockSharedKeyParameter(s, pool.currentState, caller)
+	case common.SpecialTxCancelShareKey.Uint64():
+		if pool.chainconfig.Genaro.IsShareKey(next) {
+			return vm.CheckCancelShareKeyTx(caller, s, pool.currentState)
+		}
+	case common.SpecialTxRejectShareKey.Uint64():
+		if pool.chainconfig.Genaro.IsShareKey(next) {
+			return vm.CheckRejectShareKeyTx(caller, s, pool.currentState)
+		}
+	case common.SpecialTxRevokeFileSharePublicKey.Uint64():
+		if pool.chainconfig.Genaro.IsFileShareKey(next) {
+			return vm.CheckRevokeFileSharePublicKeyTx(caller, s, pool.currentState)
+		}
 	case common.SpecialTxTypePunishment.Uint64():
-		return vm.CheckPunishmentTx(caller, s, pool.currentState, pool.chainconfig.Genaro)
+		return vm.CheckPunishmentTx(caller, s, pool.currentState, pool.chainconfig.Genaro, next)
 	case common.SpecialTxTypeBackStake.Uint64():
 		return vm.CheckBackStakeTx(caller, pool.currentState)
 	case common.SpecialTxTypePriceRegulation.Uint64():
@@ -710,7 +876,41 @@ func (pool *TxPool) dispatchHandlerValidateTx(input []byte, caller common.Addres
 	case common.SpecialTxSetShadowAccount.Uint64():
 		return vm.CheckSetShadowAccount(caller, s, pool.currentState)
 	}
-	return errors.New("undefined type of special transaction")
+	return vm.ErrSpecialTxUndefinedType
+}
+
+// validateBatchTx checks a batch special transaction by applying its steps on a
+// copy of the pool state, as the checks of a step depend on the steps before it.
+// Funds are credited to the caller on the copy first, as the sponsor of a
+// sponsored batch funds its steps.
+//
+// The copy is made once per head and reverted after every check, so checking
+// all pooled batches on reset does not copy the state for each of them.
+func (pool *TxPool) validateBatchTx(s types.SpecialTxInput, caller common.Address, funds *big.Int) error {
+	if err := vm.CheckBatchTx(s); err != nil {
+		return err
+	}
+	head := pool.chain.CurrentBlock()
+	context := vm.Context{
+		CanTransfer: CanTransfer,
+		Transfer:    Transfer,
+		GetHash:     func(uint64) common.Hash { return common.Hash{} },
+		Origin:      caller,
+		BlockNumber: new(big.Int).Add(head.Number(), common.Big1),
+		Time:        head.Time(),
+		Difficulty:  head.Difficulty(),
+		GasLimit:    pool.currentMaxGas,
+		GasPrice:    new(big.Int),
+	}
+	if pool.batchState == nil {
+		pool.batchState = pool.currentState.Copy()
+	}
+	snapshot := pool.batchState.Snapshot()
+	defer pool.batchState.RevertToSnapshot(snapshot)
+
+	pool.batchState.AddBalance(caller, funds)
+	evm := vm.NewEVM(context, pool.batchState, pool.chainconfig, vm.Config{})
+	return vm.CheckBatchSteps(evm, s, caller)
 }
 
 // add validates a transaction and inserts it into the non-executable queue for
@@ -734,16 +934,28 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (bool, error) {
 		invalidTxCounter.Inc(1)
 		return false, err
 	}
-	// If the transaction pool is full, discard underpriced transactions
-	if uint64(len(pool.all)) >= pool.config.GlobalSlots+pool.config.GlobalQueue {
-		// If the new transaction is underpriced, don't accept it
+	from, _ := types.Sender(pool.signer, tx) // already validated
+	regular := len(pool.all) - pool.lane.size
+	special := isSpecialTx(tx)
+	var txType uint64
+	if special {
+		// Special transactions are only limited by their own lane
+		txType, _ = specialTxType(tx)
+		if err := pool.lane.admit(from, txType, pool.replacesSpecial(from, tx.Nonce())); err != nil {
+			log.Trace("Discarding special transaction", "hash", hash, "type", txType, "err", err)
+			specialRateLimitCounter.Inc(1)
+			return false, err
+		}
+	} else if uint64(regular) >= pool.config.GlobalSlots+pool.config.GlobalQueue {
+		// The transaction pool is full, if the new transaction is underpriced,
+		// don't accept it
 		if pool.priced.Underpriced(tx, pool.locals) {
 			log.Trace("Discarding underpriced transaction", "hash", hash, "price", tx.GasPrice())
 			underpricedTxCounter.Inc(1)
 			return false, ErrUnderpriced
 		}
 		// New transaction is better than our worse ones, make room for it
-		drop := pool.priced.Discard(len(pool.all)-int(pool.config.GlobalSlots+pool.config.GlobalQueue-1), pool.locals)
+		drop := pool.priced.Discard(regular-int(pool.config.GlobalSlots+pool.config.GlobalQueue-1), pool.locals)
 		for _, tx := range drop {
 			log.Trace("Discarding freshly underpriced transaction", "hash", tx.Hash(), "price", tx.GasPrice())
 			underpricedTxCounter.Inc(1)
@@ -751,7 +963,6 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (bool, error) {
 		}
 	}
 	// If the transaction is replacing an already pending one, do directly
-	from, _ := types.Sender(pool.signer, tx) // already validated
 	if list := pool.pending[from]; list != nil && list.Overlaps(tx) {
 		// Nonce already pending, check if required price bump is met
 		inserted, old := list.Add(tx, pool.config.PriceBump)
@@ -761,12 +972,15 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (bool, error) {
 		}
 		// New transaction is better, replace old one
 		if old != nil {
-			delete(pool.all, old.Hash())
+			pool.forgetTx(old.Hash())
 			pool.priced.Removed()
 			pendingReplaceCounter.Inc(1)
 		}
-		pool.all[tx.Hash()] = tx
+		pool.storeTx(tx.Hash(), tx)
 		pool.priced.Put(tx)
+		if special {
+			pool.lane.record(from, txType)
+		}
 		pool.journalTx(from, tx)
 
 		log.Trace("Pooled new executable transaction", "hash", hash, "from", from, "to", tx.To())
@@ -781,6 +995,9 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (bool, error) {
 	if err != nil {
 		return false, err
 	}
+	if special {
+		pool.lane.record(from, txType)
+	}
 	// Mark local addresses and journal local transactions
 	if local {
 		pool.locals.add(from)
@@ -808,11 +1025,11 @@ func (pool *TxPool) enqueueTx(hash common.Hash, tx *types.Transaction) (bool, er
 	}
 	// Discard any previous transaction and mark this
 	if old != nil {
-		delete(pool.all, old.Hash())
+		pool.forgetTx(old.Hash())
 		pool.priced.Removed()
 		queuedReplaceCounter.Inc(1)
 	}
-	pool.all[hash] = tx
+	pool.storeTx(hash, tx)
 	pool.priced.Put(tx)
 	return old != nil, nil
 }
@@ -842,7 +1059,7 @@ func (pool *TxPool) promoteTx(addr common.Address, hash common.Hash, tx *types.T
 	inserted, old := list.Add(tx, pool.config.PriceBump)
 	if !inserted {
 		// An older transaction was better, discard this
-		delete(pool.all, hash)
+		pool.forgetTx(hash)
 		pool.priced.Removed()
 
 		pendingDiscardCounter.Inc(1)
@@ -850,14 +1067,14 @@ func (pool *TxPool) promoteTx(addr common.Address, hash common.Hash, tx *types.T
 	}
 	// Otherwise discard any previous transaction and mark this
 	if old != nil {
-		delete(pool.all, old.Hash())
+		pool.forgetTx(old.Hash())
 		pool.priced.Removed()
 
 		pendingReplaceCounter.Inc(1)
 	}
 	// Failsafe to work around direct pending inserts (tests)
 	if pool.all[hash] == nil {
-		pool.all[hash] = tx
+		pool.storeTx(hash, tx)
 		pool.priced.Put(tx)
 	}
 	// Set the potentially new pending nonce and notify any subsystems of the new tx
@@ -988,7 +1205,7 @@ func (pool *TxPool) removeTx(hash common.Hash) {
 	addr, _ := types.Sender(pool.signer, tx) // already validated during insertion
 
 	// Remove it from the list of known transactions
-	delete(pool.all, hash)
+	pool.forgetTx(hash)
 	pool.priced.Removed()
 
 	// Remove the transaction from the pending lists and reset the account nonce
@@ -1040,7 +1257,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 		for _, tx := range list.Forward(pool.currentState.GetNonce(addr)) {
 			hash := tx.Hash()
 			log.Trace("Removed old queued transaction", "hash", hash)
-			delete(pool.all, hash)
+			pool.forgetTx(hash)
 			pool.priced.Removed()
 		}
 		// Drop all transactions that are too costly (low balance or out of gas)
@@ -1048,7 +1265,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 		for _, tx := range drops {
 			hash := tx.Hash()
 			log.Trace("Removed unpayable queued transaction", "hash", hash)
-			delete(pool.all, hash)
+			pool.forgetTx(hash)
 			pool.priced.Removed()
 			queuedNofundsCounter.Inc(1)
 		}
@@ -1062,7 +1279,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 		if !pool.locals.contains(addr) {
 			for _, tx := range list.Cap(int(pool.config.AccountQueue)) {
 				hash := tx.Hash()
-				delete(pool.all, hash)
+				pool.forgetTx(hash)
 				pool.priced.Removed()
 				queuedRateLimitCounter.Inc(1)
 				log.Trace("Removed cap-exceeding queued transaction", "hash", hash)
@@ -1073,10 +1290,11 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 			delete(pool.queue, addr)
 		}
 	}
-	// If the pending limit is overflown, start equalizing allowances
+	// If the pending limit is overflown, start equalizing allowances. Special
+	// transactions are limited by their own lane.
 	pending := uint64(0)
 	for _, list := range pool.pending {
-		pending += uint64(list.Len())
+		pending += uint64(list.Len() - list.Special())
 	}
 	if pending > pool.config.GlobalSlots {
 		pendingBeforeCap := pending
@@ -1084,7 +1302,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 		spammers := prque.New()
 		for addr, list := range pool.pending {
 			// Only evict transactions from high rollers
-			if !pool.locals.contains(addr) && uint64(list.Len()) > pool.config.AccountSlots {
+			if !pool.locals.contains(addr) && !pool.lane.isSystem(addr) && uint64(list.Len()) > pool.config.AccountSlots {
 				spammers.Push(addr, float32(list.Len()))
 			}
 		}
@@ -1107,7 +1325,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 						for _, tx := range list.Cap(list.Len() - 1) {
 							// Drop the transaction from the global pools too
 							hash := tx.Hash()
-							delete(pool.all, hash)
+							pool.forgetTx(hash)
 							pool.priced.Removed()
 
 							// Update the account nonce to the dropped transaction
@@ -1129,7 +1347,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 					for _, tx := range list.Cap(list.Len() - 1) {
 						// Drop the transaction from the global pools too
 						hash := tx.Hash()
-						delete(pool.all, hash)
+						pool.forgetTx(hash)
 						pool.priced.Removed()
 
 						// Update the account nonce to the dropped transaction
@@ -1147,13 +1365,13 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 	// If we've queued more transactions than the hard limit, drop oldest ones
 	queued := uint64(0)
 	for _, list := range pool.queue {
-		queued += uint64(list.Len())
+		queued += uint64(list.Len() - list.Special())
 	}
 	if queued > pool.config.GlobalQueue {
 		// Sort all accounts with queued transactions by heartbeat
 		addresses := make(addresssByHeartbeat, 0, len(pool.queue))
 		for addr := range pool.queue {
-			if !pool.locals.contains(addr) { // don't drop locals
+			if !pool.locals.contains(addr) && !pool.lane.isSystem(addr) { // don't drop locals and system senders
 				addresses = append(addresses, addressByHeartbeat{addr, pool.beats[addr]})
 			}
 		}
@@ -1198,7 +1416,7 @@ func (pool *TxPool) demoteUnexecutables() {
 		for _, tx := range list.Forward(nonce) {
 			hash := tx.Hash()
 			log.Trace("Removed old pending transaction", "hash", hash)
-			delete(pool.all, hash)
+			pool.forgetTx(hash)
 			pool.priced.Removed()
 		}
 		// Drop all transactions that are too costly (low balance or out of gas), and queue any invalids back for later
@@ -1206,7 +1424,7 @@ func (pool *TxPool) demoteUnexecutables() {
 		for _, tx := range drops {
 			hash := tx.Hash()
 			log.Trace("Removed unpayable pending transaction", "hash", hash)
-			delete(pool.all, hash)
+			pool.forgetTx(hash)
 			pool.priced.Removed()
 			pendingNofundsCounter.Inc(1)
 		}
@@ -23,12 +23,15 @@ import (
 	"math/big"
 	"math/rand"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/GenaroNetwork/GenaroCore/common"
+	"github.com/GenaroNetwork/GenaroCore/common/hexutil"
 	"github.com/GenaroNetwork/GenaroCore/core/state"
 	"github.com/GenaroNetwork/GenaroCore/core/types"
+	"github.com/GenaroNetwork/GenaroCore/core/vm"
 	"github.com/GenaroNetwork/GenaroCore/crypto"
 	"github.com/GenaroNetwork/GenaroCore/ethdb"
 	"github.com/GenaroNetwork/GenaroCore/event"
@@ -101,6 +104,24 @@ func validateTxPoolInternals(pool *TxPool) error {
 	if priced := pool.priced.items.Len() - pool.priced.stales; priced != pending+queued {
 		return fmt.Errorf("total priced transaction count %d != %d pending + %d queued", priced, pending, queued)
 	}
+	// Ensure the special lane counters match the pooled special transactions
+	specials := make(map[common.Address]int)
+	for _, tx := range pool.all {
+		if isSpecialTx(tx) {
+			from, _ := types.Sender(pool.signer, tx)
+			specials[from]++
+		}
+	}
+	size := 0
+	for addr, count := range specials {
+		if have := pool.lane.accounts[addr]; have != count {
+			return fmt.Errorf("special transaction count of %x %d != %d pooled", addr, have, count)
+		}
+		size += count
+	}
+	if pool.lane.size != size || len(pool.lane.accounts) != len(specials) {
+		return fmt.Errorf("special transaction count %d != %d pooled", pool.lane.size, size)
+	}
 	// Ensure the next nonce to assign is the correct one
 	for addr, txs := range pool.pending {
 		// Find the last transaction
@@ -1681,6 +1702,144 @@ func TestTransactionStatusCheck(t *testing.T) {
 	}
 }
 
+// headTestBlockChain is a testBlockChain whose head is at a given height.
+type headTestBlockChain struct {
+	*testBlockChain
+	number uint64
+}
+
+func (bc *headTestBlockChain) CurrentBlock() *types.Block {
+	return types.NewBlock(&types.Header{
+		Number:   new(big.Int).SetUint64(bc.number),
+		GasLimit: bc.gasLimit,
+	}, nil, nil, nil)
+}
+
+// Tests that special transactions are validated against the forks of the next
+// block, the earliest one they can be included in.
+func TestSpecialTxForkNextBlock(t *testing.T) {
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(ethdb.NewMemDatabase()))
+	for _, test := range []struct {
+		genaro params.GenaroConfig
+		txType *big.Int
+	}{
+		{params.GenaroConfig{ShareKeyBlock: big.NewInt(10)}, common.SpecialTxCancelShareKey},
+		{params.GenaroConfig{FileShareKeyBlock: big.NewInt(10)}, common.SpecialTxRevokeFileSharePublicKey},
+		{params.GenaroConfig{MortgageBlock: big.NewInt(10)}, common.SpecialTxTypeMortgageTerminate},
+		{params.GenaroConfig{HeftOracleBlock: big.NewInt(10)}, common.SpecialTxAddHeftReporter},
+		{params.GenaroConfig{BatchBlock: big.NewInt(10)}, common.SpecialTxBatch},
+		{params.GenaroConfig{SponsoredBlock: big.NewInt(10)}, common.SpecialTxSponsored},
+		{params.GenaroConfig{VestingBlock: big.NewInt(10)}, common.SpecialTxVestingWithdraw},
+		{params.GenaroConfig{StorageProofBlock: big.NewInt(10)}, common.SpecialTxStorageCommit},
+		{params.GenaroConfig{BucketUsageBlock: big.NewInt(10)}, common.SpecialTxBucketUsage},
+	} {
+		config := *params.TestChainConfig
+		config.Genaro = &test.genaro
+		input := []byte(fmt.Sprintf(`{"type":"0x%x"}`, test.txType.Uint64()))
+		for head, undefined := range map[uint64]bool{8: true, 9: false, 10: false} {
+			blockchain := &headTestBlockChain{&testBlockChain{statedb, 1000000, new(event.Feed)}, head}
+			pool := NewTxPool(testTxPoolConfig, &config, blockchain)
+			if err := pool.dispatchHandlerValidateTx(input, common.HexToAddress("0x01")); (err == vm.ErrSpecialTxUndefinedType) != undefined {
+				t.Errorf("type %v, head %d: have %v, fork at block 10", test.txType, head, err)
+			}
+			pool.Stop()
+		}
+	}
+}
+
+// Tests that the pool validates sidechain mortgage transactions against the
+// mortgages in its state, at the time of the next block.
+func TestMortgageTxValidation(t *testing.T) {
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(ethdb.NewMemDatabase()))
+	official := common.HexToAddress("0x1000000000000000000000000000000000000001")
+	from := common.HexToAddress("0x1000000000000000000000000000000000000002")
+	writer := common.HexToAddress("0x1000000000000000000000000000000000000003")
+	expired := common.HexToAddress("0x1000000000000000000000000000000000000004")
+	fileID := strings.Repeat("f", 64)
+	for account, endTime := range map[common.Address]int64{from: time.Now().Unix() + 86400, expired: 86400} {
+		statedb.OpenMortgage(types.SpecialTxTypeMortgageInit{
+			MortgageTable:  map[common.Address]*hexutil.Big{writer: (*hexutil.Big)(big.NewInt(100))},
+			AuthorityTable: map[common.Address]int{writer: common.Write},
+			FileID:         fileID,
+			MortgagTotal:   big.NewInt(100),
+			EndTime:        endTime,
+			FromAccount:    account,
+		})
+	}
+	config := *params.TestChainConfig
+	config.Genaro = &params.GenaroConfig{MortgageBlock: big.NewInt(10), OfficialAddress: official.Hex()}
+
+	sync := []byte(fmt.Sprintf(`{"type":"0x7","specialTxTypeMortgageInit":{"fileID":"%s","dataversion":"%s","fromAccount":"%s","sidechain":{"%s":"0x10"}}}`,
+		fileID, fileID, from.Hex(), writer.Hex()))
+	terminate := []byte(fmt.Sprintf(`{"type":"0x6","specialTxTypeMortgageInit":{"fileID":"%s","fromAccount":"%s"}}`, fileID, from.Hex()))
+	unknown := []byte(fmt.Sprintf(`{"type":"0x6","specialTxTypeMortgageInit":{"fileID":"%s","fromAccount":"%s"}}`, fileID, writer.Hex()))
+	// The head is at time zero, but the next block is sealed now
+	expiredSync := []byte(fmt.Sprintf(`{"type":"0x7","specialTxTypeMortgageInit":{"fileID":"%s","dataversion":"%s","fromAccount":"%s","sidechain":{"%s":"0x10"}}}`,
+		fileID, fileID, expired.Hex(), writer.Hex()))
+	expiredTerminate := []byte(fmt.Sprintf(`{"type":"0x6","specialTxTypeMortgageInit":{"fileID":"%s","fromAccount":"%s"}}`, fileID, expired.Hex()))
+	for i, test := range []struct {
+		head   uint64
+		caller common.Address
+		input  []byte
+		want   error
+	}{
+		{8, official, sync, vm.ErrSpecialTxUndefinedType},
+		{9, official, sync, nil},
+		{9, from, sync, vm.ErrInvalidCaller},
+		{9, from, terminate, vm.ErrMortgageNotExpired},
+		{9, official, terminate, nil},
+		{9, official, unknown, vm.ErrMortgageNotFound},
+		{9, official, expiredSync, vm.ErrMortgageExpired},
+		{9, from, expiredTerminate, nil},
+	} {
+		blockchain := &headTestBlockChain{&testBlockChain{statedb, 1000000, new(event.Feed)}, test.head}
+		pool := NewTxPool(testTxPoolConfig, &config, blockchain)
+		if err := pool.dispatchHandlerValidateTx(test.input, test.caller); err != test.want {
+			t.Errorf("test %d: have %v, want %v", i, err, test.want)
+		}
+		pool.Stop()
+	}
+}
+
+// Tests that checking batch special transactions leaves no trace on the state
+// they are checked on, which is copied once per head.
+func TestBatchTxValidation(t *testing.T) {
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(ethdb.NewMemDatabase()))
+	caller := common.HexToAddress("0x1000000000000000000000000000000000000001")
+	balance := new(big.Int).Mul(big.NewInt(10), common.BaseCompany)
+	statedb.AddBalance(caller, balance)
+
+	config := *params.TestChainConfig
+	config.Genaro = &params.GenaroConfig{BatchBlock: big.NewInt(10)}
+	blockchain := &headTestBlockChain{&testBlockChain{statedb, 1000000, new(event.Feed)}, 9}
+	pool := NewTxPool(testTxPoolConfig, &config, blockchain)
+	defer pool.Stop()
+
+	batch := []byte(fmt.Sprintf(`{"type":"0x2d","batch":[{"type":"0x1","address":"%x","stake":1}]}`, caller))
+	for i := 0; i < 2; i++ {
+		if err := pool.dispatchHandlerValidateTx(batch, caller); err != nil {
+			t.Fatalf("check %d: batch rejected: %v", i, err)
+		}
+	}
+	scratch := pool.batchState
+	if scratch == nil || scratch == pool.currentState {
+		t.Fatalf("batch not checked on a copy of the state")
+	}
+	if scratch.GetBalance(caller).Cmp(balance) != 0 || len(scratch.GetCandidates()) != 0 {
+		t.Errorf("check not reverted: balance %v, candidates %x", scratch.GetBalance(caller), scratch.GetCandidates())
+	}
+	if err := pool.dispatchHandlerValidateTx(batch, caller); err != nil {
+		t.Fatalf("batch rejected: %v", err)
+	}
+	if pool.batchState != scratch {
+		t.Errorf("state copied again for the same head")
+	}
+	pool.lockedReset(nil, nil)
+	if pool.batchState != nil {
+		t.Errorf("state copy kept over a reset")
+	}
+}
+
 // Benchmarks the speed of validating the contents of the pending queue of the
 // transaction pool.
 func BenchmarkPendingDemotion100(b *testing.B)   { benchmarkPendingDemotion(b, 100) }
@@ -12,6 +12,7 @@ import (
 
 var _ = (*receiptMarshaling)(nil)
 
+// MarshalJSON marshals as JSON.
 func (r Receipt) MarshalJSON() ([]byte, error) {
 	type Receipt struct {
 		PostState         hexutil.Bytes  `json:"root"`
@@ -22,6 +23,9 @@ func (r Receipt) MarshalJSON() ([]byte, error) {
 		TxHash            common.Hash    `json:"transactionHash" gencodec:"required"`
 		ContractAddress   common.Address `json:"contractAddress"`
 		GasUsed           hexutil.Uint64 `json:"gasUsed" gencodec:"required"`
+		ExtraInfo         string         `json:"extraInfo"`
+		ErrorCode         hexutil.Uint64 `json:"errorCode"`
+		ErrorReason       string         `json:"errorReason"`
 	}
 	var enc Receipt
 	enc.PostState = r.PostState
@@ -32,9 +36,13 @@ func (r Receipt) MarshalJSON() ([]byte, error) {
 	enc.TxHash = r.TxHash
 	enc.ContractAddress = r.ContractAddress
 	enc.GasUsed = hexutil.Uint64(r.GasUsed)
+	enc.ExtraInfo = r.ExtraInfo
+	enc.ErrorCode = hexutil.Uint64(r.ErrorCode)
+	enc.ErrorReason = r.ErrorReason
 	return json.Marshal(&enc)
 }
 
+// UnmarshalJSON unmarshals from JSON.
 func (r *Receipt) UnmarshalJSON(input []byte) error {
 	type Receipt struct {
 		PostState         *hexutil.Bytes  `json:"root"`
@@ -45,6 +53,9 @@ func (r *Receipt) UnmarshalJSON(input []byte) error {
 		TxHash            *common.Hash    `json:"transactionHash" gencodec:"required"`
 		ContractAddress   *common.Address `json:"contractAddress"`
 		GasUsed           *hexutil.Uint64 `json:"gasUsed" gencodec:"required"`
+		ExtraInfo         *string         `json:"extraInfo"`
+		ErrorCode         *hexutil.Uint64 `json:"errorCode"`
+		ErrorReason       *string         `json:"errorReason"`
 	}
 	var dec Receipt
 	if err := json.Unmarshal(input, &dec); err != nil {
@@ -79,5 +90,14 @@ func (r *Receipt) UnmarshalJSON(input []byte) error {
 		return errors.New("missing required field 'gasUsed' for Receipt")
 	}
 	r.GasUsed = uint64(*dec.GasUsed)
+	if dec.ExtraInfo != nil {
+		r.ExtraInfo = *dec.ExtraInfo
+	}
+	if dec.ErrorCode != nil {
+		r.ErrorCode = uint64(*dec.ErrorCode)
+	}
+	if dec.ErrorReason != nil {
+		r.ErrorReason = *dec.ErrorReason
+	}
 	return nil
 }
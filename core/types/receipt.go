@@ -56,6 +56,10 @@ type Receipt struct {
 	ContractAddress common.Address `json:"contractAddress"`
 	GasUsed         uint64         `json:"gasUsed" gencodec:"required"`
 	ExtraInfo       string         `json:"extraInfo"`
+
+	// Failure fields, only kept in the local database
+	ErrorCode   uint64 `json:"errorCode"`
+	ErrorReason string `json:"errorReason"`
 }
 
 type receiptMarshaling struct {
@@ -64,6 +68,7 @@ type receiptMarshaling struct {
 	CumulativeGasUsed hexutil.Uint64
 	GasUsed           hexutil.Uint64
 	ExtraInfo         string
+	ErrorCode         hexutil.Uint64
 }
 
 // receiptRLP is the consensus encoding of a receipt.
@@ -84,6 +89,15 @@ type receiptStorageRLP struct {
 	Logs              []*LogForStorage
 	GasUsed           uint64
 	ExtraInfo         string
+	Failure           []receiptFailureRLP `rlp:"tail"`
+}
+
+// receiptFailureRLP is the storage encoding of the reason a transaction failed.
+// It is appended as an optional tail to receiptStorageRLP so that receipts
+// stored before it was introduced can still be decoded.
+type receiptFailureRLP struct {
+	ErrorCode   uint64
+	ErrorReason string
 }
 
 // NewReceipt creates a barebone transaction receipt, copying the init fields.
@@ -182,6 +196,9 @@ func (r *ReceiptForStorage) EncodeRLP(w io.Writer) error {
 	for i, log := range r.Logs {
 		enc.Logs[i] = (*LogForStorage)(log)
 	}
+	if r.ErrorCode != 0 || r.ErrorReason != "" {
+		enc.Failure = []receiptFailureRLP{{r.ErrorCode, r.ErrorReason}}
+	}
 	return rlp.Encode(w, enc)
 }
 
@@ -204,6 +221,9 @@ func (r *ReceiptForStorage) DecodeRLP(s *rlp.Stream) error {
 	// Assign the implementation fields
 	r.TxHash, r.ContractAddress, r.GasUsed = dec.TxHash, dec.ContractAddress, dec.GasUsed
 	r.ExtraInfo = dec.ExtraInfo
+	if len(dec.Failure) > 0 {
+		r.ErrorCode, r.ErrorReason = dec.Failure[0].ErrorCode, dec.Failure[0].ErrorReason
+	}
 	return nil
 }
 
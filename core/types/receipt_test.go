@@ -0,0 +1,64 @@
+package types
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/GenaroNetwork/GenaroCore/common"
+	"github.com/GenaroNetwork/GenaroCore/rlp"
+)
+
+func TestReceiptStorageFailure(t *testing.T) {
+	receipt := &Receipt{
+		Status:            ReceiptStatusFailed,
+		CumulativeGasUsed: 21000,
+		TxHash:            common.HexToHash("0x01"),
+		GasUsed:           21000,
+		ErrorCode:         102,
+		ErrorReason:       "caller address of this transaction is not invalid",
+	}
+	enc, err := rlp.EncodeToBytes((*ReceiptForStorage)(receipt))
+	if err != nil {
+		t.Fatalf("failed to encode receipt: %v", err)
+	}
+	var dec ReceiptForStorage
+	if err := rlp.DecodeBytes(enc, &dec); err != nil {
+		t.Fatalf("failed to decode receipt: %v", err)
+	}
+	if dec.ErrorCode != receipt.ErrorCode || dec.ErrorReason != receipt.ErrorReason {
+		t.Errorf("failure mismatch: have %d %q, want %d %q", dec.ErrorCode, dec.ErrorReason, receipt.ErrorCode, receipt.ErrorReason)
+	}
+
+	// The failure must not leak into the consensus encoding
+	consensus, _ := rlp.EncodeToBytes(receipt)
+	receipt.ErrorCode, receipt.ErrorReason = 0, ""
+	plain, _ := rlp.EncodeToBytes(receipt)
+	if !bytes.Equal(consensus, plain) {
+		t.Error("failure changed the consensus encoding of the receipt")
+	}
+}
+
+func TestReceiptStorageLegacy(t *testing.T) {
+	// Receipts stored without the failure tail must still decode
+	legacy := struct {
+		PostStateOrStatus []byte
+		CumulativeGasUsed uint64
+		Bloom             Bloom
+		TxHash            common.Hash
+		ContractAddress   common.Address
+		Logs              []*LogForStorage
+		GasUsed           uint64
+		ExtraInfo         string
+	}{receiptStatusSuccessfulRLP, 21000, Bloom{}, common.HexToHash("0x01"), common.Address{}, nil, 21000, "100"}
+	enc, err := rlp.EncodeToBytes(legacy)
+	if err != nil {
+		t.Fatalf("failed to encode receipt: %v", err)
+	}
+	var dec ReceiptForStorage
+	if err := rlp.DecodeBytes(enc, &dec); err != nil {
+		t.Fatalf("failed to decode legacy receipt: %v", err)
+	}
+	if dec.Status != ReceiptStatusSuccessful || dec.ExtraInfo != "100" || dec.ErrorCode != 0 || dec.ErrorReason != "" {
+		t.Errorf("legacy receipt mismatch: %+v", dec)
+	}
+}
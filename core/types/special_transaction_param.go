@@ -5,11 +5,13 @@ import (
 	"github.com/GenaroNetwork/GenaroCore/common"
 	"github.com/GenaroNetwork/GenaroCore/common/hexutil"
 	"github.com/GenaroNetwork/GenaroCore/crypto"
+	"github.com/GenaroNetwork/GenaroCore/params"
 	"github.com/GenaroNetwork/GenaroCore/rlp"
 	"github.com/syndtr/goleveldb/leveldb/errors"
 	"math"
 	"math/big"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -17,22 +19,28 @@ import (
 
 type SpecialTxInput struct {
 	GenaroData
-	Address               string       `json:"address"`
-	NodeID                string       `json:"nodeId"`
-	BucketID              string       `json:"bucketId"`
-	Size                  uint64       `json:"size"`
-	Duration              uint64       `json:"duration"`
-	Type                  *hexutil.Big `json:"type"`
-	BlockNumber           string       `json:"blockNr"`
-	Message               string       `json:"msg"`
-	Sign                  string       `json:"sign"`
-	AddCoin               *hexutil.Big `json:"addCoin"`
-	OrderId               common.Hash  `json:"orderId"`
-	RestoreBlock          uint64       `json:"RestoreBlock"`
-	TxNum                 uint64       `json:"TxNum"`
-	PromissoryNoteTxPrice *hexutil.Big `json:"PromissoryNoteTxPrice"`
-	OptionPrice           *hexutil.Big `json:"OptionPrice"`
-	IsSell                bool         `json:"IsSell"`
+	Address               string           `json:"address"`
+	NodeID                string           `json:"nodeId"`
+	BucketID              string           `json:"bucketId"`
+	Size                  uint64           `json:"size"`
+	Duration              uint64           `json:"duration"`
+	Type                  *hexutil.Big     `json:"type"`
+	BlockNumber           string           `json:"blockNr"`
+	Message               string           `json:"msg"`
+	Sign                  string           `json:"sign"`
+	AddCoin               *hexutil.Big     `json:"addCoin"`
+	OrderId               common.Hash      `json:"orderId"`
+	RestoreBlock          uint64           `json:"RestoreBlock"`
+	TxNum                 uint64           `json:"TxNum"`
+	PromissoryNoteTxPrice *hexutil.Big     `json:"PromissoryNoteTxPrice"`
+	OptionPrice           *hexutil.Big     `json:"OptionPrice"`
+	IsSell                bool             `json:"IsSell"`
+	PublicKeyVersion      uint64           `json:"publicKeyVersion,omitempty"`
+	Batch                 []SpecialTxInput `json:"batch,omitempty"`       // steps of a batch, applied in order
+	Sponsored             *SponsoredTx     `json:"sponsored,omitempty"`   // authorization of a sponsored special tx
+	FromVesting           bool             `json:"fromVesting,omitempty"` // stake the locked GNX of the vesting of the caller
+	StorageRoot           *common.Hash     `json:"storageRoot,omitempty"` // address of a chunk held by a storage node
+	StorageProof          *StorageProof    `json:"storageProof,omitempty"`
 	GenaroPrice
 }
 
@@ -55,12 +63,23 @@ type GenaroPrice struct {
 	ExtraPrice               []byte       `json:"extraPrice"`
 }
 
-func (s SpecialTxInput) SpecialCost(currentPrice *GenaroPrice, bucketsMap map[string]interface{}) big.Int {
+// SpecialCost returns the GNX a special transaction costs on top of its gas at
+// blockNum. A stake paid with the GNX of a vesting costs nothing from the
+// Vesting fork on.
+func (s SpecialTxInput) SpecialCost(currentPrice *GenaroPrice, bucketsMap map[string]interface{}, genaroConfig *params.GenaroConfig, blockNum *big.Int) big.Int {
 
 	switch s.Type.ToInt().Uint64() {
 	case common.SpecialTxTypeStakeSync.Uint64():
+		if s.FromVesting && genaroConfig != nil && genaroConfig.IsVesting(blockNum) {
+			return *big.NewInt(0)
+		}
 		ret := new(big.Int).Mul(new(big.Int).SetUint64(s.Stake), common.BaseCompany)
 		return *ret
+	case common.SpecialTxVestingCreate.Uint64():
+		if s.Vesting == nil || s.Vesting.Total == nil {
+			return *big.NewInt(0)
+		}
+		return *new(big.Int).Set(s.Vesting.Total.ToInt())
 	case common.SpecialTxTypeSpaceApply.Uint64():
 		var totalCost *big.Int = big.NewInt(0)
 		var bucketPrice *big.Int
@@ -157,6 +176,25 @@ func (s SpecialTxInput) SpecialCost(currentPrice *GenaroPrice, bucketsMap map[st
 		timeLimitGas := temp.Mul(temp, common.DefaultOneDayMortgageGes)
 		sumMortgageTable.Add(sumMortgageTable, timeLimitGas)
 		return *sumMortgageTable
+	case common.SpecialTxBatch.Uint64():
+		totalCost := new(big.Int)
+		for _, step := range s.Batch {
+			if step.Type == nil || step.Type.ToInt().Cmp(common.SpecialTxBatch) == 0 {
+				continue
+			}
+			cost := step.SpecialCost(currentPrice, bucketsMap, genaroConfig, blockNum)
+			totalCost.Add(totalCost, &cost)
+		}
+		return *totalCost
+	case common.SpecialTxSponsored.Uint64():
+		if s.Sponsored == nil {
+			return *big.NewInt(0)
+		}
+		input, err := s.Sponsored.Input()
+		if err != nil || input.Type == nil || input.Type.ToInt().Cmp(common.SpecialTxSponsored) == 0 {
+			return *big.NewInt(0)
+		}
+		return input.SpecialCost(currentPrice, bucketsMap, genaroConfig, blockNum)
 	default:
 		return *big.NewInt(0)
 	}
@@ -278,8 +316,46 @@ type GenaroData struct {
 	PromissoryNotes              PromissoryNotes                      `json:"PromissoryNotes"`
 	ProfitAccount                common.Address                       `json:"ProfitAccount"`
 	ShadowAccount                common.Address                       `json:"ShadowAccount"`
+	OutgoingShareKeys            []ShareKeyRef                        `json:"outgoingShareKeys,omitempty"`
+	FileSharePublicKeys          []FileSharePublicKeyVersion          `json:"publicKeys,omitempty"`
+	Vesting                      *Vesting                             `json:"vesting,omitempty"`
+}
+
+// FileSharePublicKeyVersion is one version of the public key an account
+// receives shared keys with. Versions are numbered from 1 in the order they
+// were published.
+type FileSharePublicKeyVersion struct {
+	Version     uint64 `json:"version"`
+	PublicKey   string `json:"publicKey"`
+	ActiveBlock uint64 `json:"activeBlock"`           // block the version became active at, 0 if published before versioning
+	RevokeBlock uint64 `json:"revokeBlock,omitempty"` // block the version was revoked at, 0 if not revoked
+}
+
+// ValidAt returns whether the version was the active key at blockNumber.
+func (v FileSharePublicKeyVersion) ValidAt(blockNumber uint64) bool {
+	return v.ActiveBlock <= blockNumber && (v.RevokeBlock == 0 || v.RevokeBlock > blockNumber)
+}
+
+// FileSharePublicKeyAt returns the public key version that was valid at
+// blockNumber. A newer version supersedes the older ones from its activation
+// block on, even if it is revoked later.
+func FileSharePublicKeyAt(versions []FileSharePublicKeyVersion, blockNumber uint64) (FileSharePublicKeyVersion, bool) {
+	for i := len(versions) - 1; i >= 0; i-- {
+		if versions[i].ActiveBlock <= blockNumber {
+			return versions[i], versions[i].ValidAt(blockNumber)
+		}
+	}
+	return FileSharePublicKeyVersion{}, false
 }
 
+// Status of a shared key offer.
+const (
+	ShareKeyStatusPending   = 0 // offered, waiting for the recipient
+	ShareKeyStatusUnlocked  = 1 // paid and unlocked by the recipient
+	ShareKeyStatusCancelled = 2 // withdrawn by the sharer
+	ShareKeyStatusRejected  = 3 // declined by the recipient
+)
+
 type SynchronizeShareKey struct {
 	ShareKey         string         `json:"shareKey"`
 	Shareprice       *hexutil.Big   `json:"shareprice"`
@@ -289,6 +365,54 @@ type SynchronizeShareKey struct {
 	FromAccount      common.Address `json:"fromAccount"`
 	MailHash         string         `json:"mail_hash"`
 	MailSize         int            `json:"mail_size"`
+	ExpireBlock      uint64         `json:"expireBlock,omitempty"`      // block from which a pending offer can no longer be unlocked, 0 for never
+	CompleteBlock    uint64         `json:"completeBlock,omitempty"`    // block at which the offer left the pending status
+	PublicKeyVersion uint64         `json:"publicKeyVersion,omitempty"` // version of the recipient's public key the share key is encrypted with
+	Commitment       *common.Hash   `json:"commitment,omitempty"`       // hash of the share key delivered off chain, nil if the share key is on chain
+}
+
+// CommitmentHash returns the commitment of the share key, zero if the share
+// key is on chain.
+func (s SynchronizeShareKey) CommitmentHash() common.Hash {
+	if s.Commitment == nil {
+		return common.Hash{}
+	}
+	return *s.Commitment
+}
+
+// IsCommitted returns whether the share key is delivered off chain, with only
+// its commitment on chain.
+func (s SynchronizeShareKey) IsCommitted() bool {
+	return s.CommitmentHash() != (common.Hash{})
+}
+
+// IsExpired returns whether a pending offer expired at blockNumber.
+func (s SynchronizeShareKey) IsExpired(blockNumber uint64) bool {
+	return s.Status == ShareKeyStatusPending && s.ExpireBlock != 0 && blockNumber >= s.ExpireBlock
+}
+
+// StatusName returns the readable status of the offer at blockNumber.
+func (s SynchronizeShareKey) StatusName(blockNumber uint64) string {
+	switch s.Status {
+	case ShareKeyStatusPending:
+		if s.IsExpired(blockNumber) {
+			return "expired"
+		}
+		return "pending"
+	case ShareKeyStatusUnlocked:
+		return "unlocked"
+	case ShareKeyStatusCancelled:
+		return "cancelled"
+	case ShareKeyStatusRejected:
+		return "rejected"
+	}
+	return "unknown"
+}
+
+// ShareKeyRef points from the sharer to an offer stored at the recipient.
+type ShareKeyRef struct {
+	ShareKeyId       string         `json:"shareKeyId"`
+	RecipientAddress common.Address `json:"recipientAddress"`
 }
 
 type BucketPropertie struct {
@@ -300,6 +424,31 @@ type BucketPropertie struct {
 	Backup uint64 `json:"backup"`
 
 	Size uint64 `json:"size"`
+
+	Used uint64 `json:"used,omitempty"` // bytes stored in the bucket, the median of the reports
+
+	UsageReports map[common.Address]uint64 `json:"usageReports,omitempty" rlp:"-"` // reporter -> bytes last reported by it
+}
+
+// ReportUsage records the bytes stored in the bucket as reported by reporter,
+// replacing its earlier report, and sets the used size to the median of the
+// reports of the reporters accepted by valid. Reports of other reporters are
+// dropped.
+func (b *BucketPropertie) ReportUsage(reporter common.Address, used uint64, valid func(common.Address) bool) {
+	if b.UsageReports == nil {
+		b.UsageReports = make(map[common.Address]uint64)
+	}
+	b.UsageReports[reporter] = used
+
+	values := make([]uint64, 0, len(b.UsageReports))
+	for addr, value := range b.UsageReports {
+		if addr != reporter && !valid(addr) {
+			delete(b.UsageReports, addr)
+			continue
+		}
+		values = append(values, value)
+	}
+	b.Used = medianOf(values)
 }
 
 type Sidechain map[common.Address]*hexutil.Big
@@ -318,10 +467,89 @@ type FileIDArr struct {
 	FromAccount     common.Address                             `json:"fromAccount"`
 	Terminate       bool                                       `json:"terminate"`
 	Sidechain       Sidechain                                  `json:"sidechain"`
+	LastDataversion string                                     `json:"lastDataversion,omitempty"` // dataversion of the latest synced sidechain status
+	Dataversions    []string                                   `json:"dataversions,omitempty"`    // dataversions kept in SidechainStatus, oldest first
+	SettleBlock     uint64                                     `json:"settleBlock,omitempty"`     // block the mortgage was settled at
+	Payout          map[common.Address]*hexutil.Big            `json:"payout,omitempty"`          // amounts paid on settlement, including the refund to FromAccount
 }
 
 type SpecialTxTypeMortgageInit FileIDArr
 
+// StatusName returns the readable status of the mortgage at the given unix
+// time: open, expired (past EndTime but not settled yet) or settled.
+func (m SpecialTxTypeMortgageInit) StatusName(now int64) string {
+	if m.Terminate {
+		return "settled"
+	}
+	if now >= m.EndTime {
+		return "expired"
+	}
+	return "open"
+}
+
+// Settlement splits the mortgage according to the latest synced sidechain
+// status. Every account with write authority is paid the amount the status
+// reports for it, capped by its own mortgage, and the rest is refunded to
+// FromAccount.
+func (m SpecialTxTypeMortgageInit) Settlement() (map[common.Address]*big.Int, *big.Int) {
+	payouts := make(map[common.Address]*big.Int)
+	refund := new(big.Int)
+	if m.MortgagTotal != nil {
+		refund.Set(m.MortgagTotal)
+	}
+	for account, value := range m.SidechainStatus[m.LastDataversion] {
+		authority, ok := m.AuthorityTable[account]
+		if !ok || (authority != common.ReadWrite && authority != common.Write) {
+			continue
+		}
+		mortgage := m.MortgageTable[account]
+		if value == nil || value.ToInt().Sign() <= 0 || mortgage == nil {
+			continue
+		}
+		amount := new(big.Int).Set(value.ToInt())
+		if mortgage.ToInt().Cmp(amount) < 0 {
+			amount.Set(mortgage.ToInt())
+		}
+		payouts[account] = amount
+		refund.Sub(refund, amount)
+	}
+	return payouts, refund
+}
+
+// MortgageExpiry locates an open mortgage by the unix time it expires at.
+type MortgageExpiry struct {
+	FromAccount common.Address `json:"fromAccount"`
+	FileID      string         `json:"fileId"`
+	EndTime     int64          `json:"endTime"`
+}
+
+// MortgageExpiries lists the open mortgages by EndTime, so that the expired
+// ones can be settled without going through the accounts. It is stored at
+// common.MortgageSaveAddress.
+type MortgageExpiries []MortgageExpiry
+
+// Add inserts a mortgage after the ones expiring at the same time or earlier.
+func (e *MortgageExpiries) Add(expiry MortgageExpiry) {
+	i := len(*e)
+	for i > 0 && (*e)[i-1].EndTime > expiry.EndTime {
+		i--
+	}
+	*e = append(*e, MortgageExpiry{})
+	copy((*e)[i+1:], (*e)[i:])
+	(*e)[i] = expiry
+}
+
+// Remove drops the mortgage of the file opened by fromAccount.
+func (e *MortgageExpiries) Remove(fromAccount common.Address, fileID string) bool {
+	for i, expiry := range *e {
+		if expiry.FromAccount == fromAccount && expiry.FileID == fileID {
+			*e = append((*e)[:i], (*e)[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
 type LastSynState struct {
 	LastRootStates   map[common.Hash]uint64 `json:"LastRootStates"`
 	LastSynBlockNum  uint64                 `json:"LastSynBlockNum"`
@@ -442,6 +670,119 @@ func (forbidList *ForbidBackStakeList) IsExist(addr common.Address) bool {
 	return false
 }
 
+// HeftOracle is the set of accounts reporting heft together with the reports
+// of the current epoch. It is stored at common.HeftOracleSaveAddress.
+type HeftOracle struct {
+	Reporters []common.Address                             `json:"reporters"`
+	Reports   map[common.Address]map[common.Address]uint64 `json:"reports,omitempty"` // reported account -> reporter -> heft
+	Strikes   map[common.Address]uint64                    `json:"strikes,omitempty"` // consecutive epochs a reporter disagreed with the aggregate
+}
+
+func (o *HeftOracle) IsReporter(addr common.Address) bool {
+	for _, reporter := range o.Reporters {
+		if reporter == addr {
+			return true
+		}
+	}
+	return false
+}
+
+func (o *HeftOracle) AddReporter(addr common.Address) {
+	if !o.IsReporter(addr) {
+		o.Reporters = append(o.Reporters, addr)
+	}
+}
+
+// DelReporter removes a reporter together with its strikes and pending reports.
+func (o *HeftOracle) DelReporter(addr common.Address) {
+	for i, reporter := range o.Reporters {
+		if reporter == addr {
+			o.Reporters = append(o.Reporters[:i], o.Reporters[i+1:]...)
+			break
+		}
+	}
+	delete(o.Strikes, addr)
+	for account, reports := range o.Reports {
+		delete(reports, addr)
+		if len(reports) == 0 {
+			delete(o.Reports, account)
+		}
+	}
+}
+
+// Report records the heft of account reported by reporter, replacing its
+// earlier report of the epoch.
+func (o *HeftOracle) Report(reporter common.Address, account common.Address, heft uint64) {
+	if o.Reports == nil {
+		o.Reports = make(map[common.Address]map[common.Address]uint64)
+	}
+	if o.Reports[account] == nil {
+		o.Reports[account] = make(map[common.Address]uint64)
+	}
+	o.Reports[account][reporter] = heft
+}
+
+// IsFlagged returns whether reporter disagreed with the aggregate for at least
+// strikes epochs in a row.
+func (o *HeftOracle) IsFlagged(reporter common.Address, strikes uint64) bool {
+	return strikes > 0 && o.Strikes[reporter] >= strikes
+}
+
+// Aggregate closes the epoch and returns the median heft of every account
+// reported by more than half of the reporters. A reporter whose report of such
+// an account deviates from the median by more than tolerance, in common.Base
+// units of the median, gets a strike, a reporter whose reports all agreed has
+// its strikes cleared. The pending reports are discarded.
+func (o *HeftOracle) Aggregate(tolerance uint64) map[common.Address]uint64 {
+	hefts := make(map[common.Address]uint64)
+	agreed := make(map[common.Address]bool)
+	for account, reports := range o.Reports {
+		if uint64(len(reports))*2 <= uint64(len(o.Reporters)) {
+			continue
+		}
+		values := make([]uint64, 0, len(reports))
+		for _, heft := range reports {
+			values = append(values, heft)
+		}
+		median := medianOf(values)
+		hefts[account] = median
+
+		limit := new(big.Int).Mul(new(big.Int).SetUint64(median), new(big.Int).SetUint64(tolerance))
+		for reporter, heft := range reports {
+			deviation := new(big.Int).Sub(new(big.Int).SetUint64(heft), new(big.Int).SetUint64(median))
+			deviation.Abs(deviation).Mul(deviation, new(big.Int).SetUint64(common.Base))
+			if deviation.Cmp(limit) > 0 {
+				agreed[reporter] = false
+			} else if _, ok := agreed[reporter]; !ok {
+				agreed[reporter] = true
+			}
+		}
+	}
+	for reporter, ok := range agreed {
+		if ok {
+			delete(o.Strikes, reporter)
+			continue
+		}
+		if o.Strikes == nil {
+			o.Strikes = make(map[common.Address]uint64)
+		}
+		o.Strikes[reporter]++
+	}
+	o.Reports = nil
+	return hefts
+}
+
+// medianOf returns the median of values, which it sorts.
+func medianOf(values []uint64) uint64 {
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+	mid := len(values) / 2
+	if len(values)%2 == 1 {
+		return values[mid]
+	}
+	a, b := values[mid-1], values[mid]
+	return a/2 + b/2 + (a%2+b%2)/2
+}
+
 type RewardsValues struct {
 	CoinActualRewards       *big.Int `json:"CoinActualRewards"`
 	PreCoinActualRewards    *big.Int `json:"PreCoinActualRewards"`
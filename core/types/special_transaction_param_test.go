@@ -148,3 +148,65 @@ func TestAccountName(t *testing.T) {
 		t.Log("test3 name is invalid")
 	}
 }
+
+func TestFileSharePublicKeyAt(t *testing.T) {
+	versions := []FileSharePublicKeyVersion{
+		{Version: 1, PublicKey: "a", ActiveBlock: 0},
+		{Version: 2, PublicKey: "b", ActiveBlock: 10, RevokeBlock: 20},
+		{Version: 3, PublicKey: "c", ActiveBlock: 30},
+	}
+	tests := []struct {
+		block   uint64
+		version uint64
+		valid   bool
+	}{
+		{5, 1, true},
+		{10, 2, true},
+		{19, 2, true},
+		{20, 2, false},
+		{30, 3, true},
+	}
+	for i, test := range tests {
+		version, valid := FileSharePublicKeyAt(versions, test.block)
+		if version.Version != test.version || valid != test.valid {
+			t.Errorf("test %d: have version %d valid %v, want version %d valid %v", i, version.Version, valid, test.version, test.valid)
+		}
+	}
+}
+
+func TestHeftOracleAggregate(t *testing.T) {
+	r1, r2, r3, r4 := common.HexToAddress("0x01"), common.HexToAddress("0x02"), common.HexToAddress("0x03"), common.HexToAddress("0x04")
+	account, other := common.HexToAddress("0x11"), common.HexToAddress("0x12")
+	oracle := HeftOracle{Reporters: []common.Address{r1, r2, r3, r4}}
+
+	oracle.Report(r1, account, 100)
+	oracle.Report(r2, account, 104)
+	oracle.Report(r3, account, 150)
+	oracle.Report(r1, other, 7)
+	oracle.Report(r2, other, 7)
+	hefts := oracle.Aggregate(common.Base / 10)
+	if len(hefts) != 1 || hefts[account] != 104 {
+		t.Fatalf("aggregated hefts mismatch: have %v", hefts)
+	}
+	if oracle.Strikes[r3] != 1 || oracle.Strikes[r1] != 0 || len(oracle.Reports) != 0 {
+		t.Fatalf("oracle mismatch after first epoch: %+v", oracle)
+	}
+
+	// An even number of reports aggregates to the mean of the middle two
+	for i := 0; i < 2; i++ {
+		oracle.Report(r1, account, 100)
+		oracle.Report(r2, account, 101)
+		oracle.Report(r3, account, 10)
+		oracle.Report(r4, account, 103)
+		if hefts := oracle.Aggregate(common.Base / 10); hefts[account] != 100 {
+			t.Fatalf("aggregated heft mismatch: have %d, want 100", hefts[account])
+		}
+	}
+	if !oracle.IsFlagged(r3, 3) || oracle.IsFlagged(r1, 3) {
+		t.Fatalf("flagged reporters mismatch: %v", oracle.Strikes)
+	}
+	oracle.DelReporter(r3)
+	if oracle.IsReporter(r3) || oracle.Strikes[r3] != 0 {
+		t.Fatal("removed reporter still registered")
+	}
+}
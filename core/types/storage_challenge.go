@@ -0,0 +1,158 @@
+package types
+
+import (
+	"strings"
+
+	"github.com/GenaroNetwork/GenaroCore/common"
+	"github.com/GenaroNetwork/GenaroCore/common/hexutil"
+)
+
+// StorageProof is the answer of a storage node to a storage challenge: the
+// challenged segment of the chunk and its BMT inclusion proof. The chunk
+// address is the Keccak256 hash of the span of the chunk followed by its BMT
+// root, as computed by swarm.
+type StorageProof struct {
+	Span    hexutil.Bytes   `json:"span"`    // 8 byte span prefix of the chunk
+	Segment hexutil.Bytes   `json:"segment"` // challenged segment
+	Proof   []hexutil.Bytes `json:"proof"`   // sibling segment and sibling nodes up to the BMT root
+}
+
+// StorageChallenge asks a storage node to prove it holds a segment of a chunk
+// it committed to.
+type StorageChallenge struct {
+	NodeID     string         `json:"nodeId"`
+	Account    common.Address `json:"account"` // staker the node was bound to when challenged
+	Root       common.Hash    `json:"root"`    // address of the challenged chunk
+	Segment    uint64         `json:"segment"` // index of the challenged segment
+	Challenger common.Address `json:"challenger"`
+	Deadline   uint64         `json:"deadline"` // last block the proof is accepted in
+}
+
+// StorageChallenges holds the chunks the bucket owners stored in their buckets,
+// the chunks committed by the storage nodes and the challenges they have to
+// answer. It is stored at common.StorageChallengeSaveAddress.
+type StorageChallenges struct {
+	Buckets  map[string][]common.Hash  `json:"buckets,omitempty"`  // bucket key -> chunk addresses committed by the owner
+	Roots    map[string][]common.Hash  `json:"roots,omitempty"`    // node id -> committed chunk addresses
+	Pending  []StorageChallenge        `json:"pending,omitempty"`  // unanswered challenges, by deadline
+	Failures map[common.Address]uint64 `json:"failures,omitempty"` // challenges failed in the epoch by staker
+}
+
+// BucketKey returns the key of a bucket of owner in StorageChallenges.Buckets.
+func BucketKey(owner common.Address, bucketID string) string {
+	return strings.ToLower(owner.Hex()) + "/" + bucketID
+}
+
+// InBucket returns whether the owner of the bucket committed to the chunk with
+// address root.
+func (c *StorageChallenges) InBucket(bucketKey string, root common.Hash) bool {
+	for _, committed := range c.Buckets[bucketKey] {
+		if committed == root {
+			return true
+		}
+	}
+	return false
+}
+
+// CommitBucket records that the chunk with address root is stored in the bucket.
+func (c *StorageChallenges) CommitBucket(bucketKey string, root common.Hash) bool {
+	if c.InBucket(bucketKey, root) {
+		return false
+	}
+	if c.Buckets == nil {
+		c.Buckets = make(map[string][]common.Hash)
+	}
+	c.Buckets[bucketKey] = append(c.Buckets[bucketKey], root)
+	return true
+}
+
+func (c *StorageChallenges) IsCommitted(nodeID string, root common.Hash) bool {
+	for _, committed := range c.Roots[nodeID] {
+		if committed == root {
+			return true
+		}
+	}
+	return false
+}
+
+// Commit records that the node holds the chunk with address root.
+func (c *StorageChallenges) Commit(nodeID string, root common.Hash) bool {
+	if c.IsCommitted(nodeID, root) {
+		return false
+	}
+	if c.Roots == nil {
+		c.Roots = make(map[string][]common.Hash)
+	}
+	c.Roots[nodeID] = append(c.Roots[nodeID], root)
+	return true
+}
+
+// DropNode forgets the chunks committed by the node. A pending challenge of the
+// node still has to be answered.
+func (c *StorageChallenges) DropNode(nodeID string) {
+	delete(c.Roots, nodeID)
+}
+
+// PendingOf returns the unanswered challenge of the node, nil if there is none.
+func (c *StorageChallenges) PendingOf(nodeID string) *StorageChallenge {
+	for i := range c.Pending {
+		if c.Pending[i].NodeID == nodeID {
+			return &c.Pending[i]
+		}
+	}
+	return nil
+}
+
+// Challenge adds a challenge, failing if the node has one pending already.
+func (c *StorageChallenges) Challenge(challenge StorageChallenge) bool {
+	if c.PendingOf(challenge.NodeID) != nil {
+		return false
+	}
+	i := len(c.Pending)
+	for i > 0 && c.Pending[i-1].Deadline > challenge.Deadline {
+		i--
+	}
+	c.Pending = append(c.Pending, StorageChallenge{})
+	copy(c.Pending[i+1:], c.Pending[i:])
+	c.Pending[i] = challenge
+	return true
+}
+
+// Answer removes the pending challenge of the node.
+func (c *StorageChallenges) Answer(nodeID string) bool {
+	for i := range c.Pending {
+		if c.Pending[i].NodeID == nodeID {
+			c.Pending = append(c.Pending[:i], c.Pending[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Expire removes and returns the challenges whose deadline passed before
+// blockNumber, and counts them as failures of their stakers.
+func (c *StorageChallenges) Expire(blockNumber uint64) []StorageChallenge {
+	n := 0
+	for n < len(c.Pending) && c.Pending[n].Deadline < blockNumber {
+		n++
+	}
+	if n == 0 {
+		return nil
+	}
+	expired := append([]StorageChallenge{}, c.Pending[:n]...)
+	c.Pending = append(c.Pending[:0], c.Pending[n:]...)
+	if c.Failures == nil {
+		c.Failures = make(map[common.Address]uint64)
+	}
+	for _, challenge := range expired {
+		c.Failures[challenge.Account]++
+	}
+	return expired
+}
+
+// TakeFailures returns the failures of the epoch and starts counting over.
+func (c *StorageChallenges) TakeFailures() map[common.Address]uint64 {
+	failures := c.Failures
+	c.Failures = nil
+	return failures
+}
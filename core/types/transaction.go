@@ -28,6 +28,7 @@ import (
 	"github.com/GenaroNetwork/GenaroCore/common"
 	"github.com/GenaroNetwork/GenaroCore/common/hexutil"
 	"github.com/GenaroNetwork/GenaroCore/crypto"
+	"github.com/GenaroNetwork/GenaroCore/params"
 	"github.com/GenaroNetwork/GenaroCore/rlp"
 )
 
@@ -259,9 +260,9 @@ func (tx *Transaction) Cost() *big.Int {
 	return total
 }
 
-// SpecialCost returns total cost for special transaction
+// SpecialCost returns total cost for special transaction at blockNum
 // if current transaction is normal transaction, return zero.
-func (tx *Transaction) SpecialCost(currentPrice *GenaroPrice, bucketsMap map[string]interface{}) *big.Int {
+func (tx *Transaction) SpecialCost(currentPrice *GenaroPrice, bucketsMap map[string]interface{}, genaroConfig *params.GenaroConfig, blockNum *big.Int) *big.Int {
 	var ret = big.NewInt(0)
 	if tx.Data() == nil {
 		return ret
@@ -269,7 +270,7 @@ func (tx *Transaction) SpecialCost(currentPrice *GenaroPrice, bucketsMap map[str
 	var s SpecialTxInput
 	err := json.Unmarshal(tx.Data(), &s)
 	if err == nil {
-		cost := s.SpecialCost(currentPrice, bucketsMap)
+		cost := s.SpecialCost(currentPrice, bucketsMap, genaroConfig, blockNum)
 		ret.Set(&cost)
 		return ret
 	}
@@ -18,11 +18,13 @@ package types
 
 import (
 	"crypto/ecdsa"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"math/big"
 
 	"github.com/GenaroNetwork/GenaroCore/common"
+	"github.com/GenaroNetwork/GenaroCore/common/hexutil"
 	"github.com/GenaroNetwork/GenaroCore/crypto"
 	"github.com/GenaroNetwork/GenaroCore/params"
 )
@@ -62,6 +64,73 @@ func SignTx(tx *Transaction, s Signer, prv *ecdsa.PrivateKey) (*Transaction, err
 	return tx.WithSignature(s, sig)
 }
 
+// SponsoredTx authorizes a sponsor to submit the special transaction Payload on
+// behalf of the signer of the authorization. The sponsor sends it in a special
+// transaction of type common.SpecialTxSponsored and pays both the gas and the
+// special cost of the payload, which is applied as if sent by the signer.
+type SponsoredTx struct {
+	Payload hexutil.Bytes  `json:"payload"` // special tx input applied on behalf of the signer
+	Nonce   hexutil.Uint64 `json:"nonce"`   // account nonce of the signer, consumed by the transaction
+	Sig     hexutil.Bytes  `json:"sig"`     // signature of the signer over SigHash
+}
+
+// SignSponsoredTx authorizes a sponsor to submit the special transaction
+// payload on behalf of the owner of prv, whose account nonce is nonce.
+func SignSponsoredTx(payload []byte, nonce uint64, chainId *big.Int, prv *ecdsa.PrivateKey) (*SponsoredTx, error) {
+	s := &SponsoredTx{Payload: common.CopyBytes(payload), Nonce: hexutil.Uint64(nonce)}
+	h := s.SigHash(chainId)
+	sig, err := crypto.Sign(h[:], prv)
+	if err != nil {
+		return nil, err
+	}
+	s.Sig = sig
+	return s, nil
+}
+
+// SigHash returns the hash signed by the signer of the authorization. It covers
+// the chain id so an authorization can't be replayed on another chain.
+func (s *SponsoredTx) SigHash(chainId *big.Int) common.Hash {
+	if chainId == nil {
+		chainId = new(big.Int)
+	}
+	return rlpHash([]interface{}{"genaro-sponsored", chainId, []byte(s.Payload), uint64(s.Nonce)})
+}
+
+// Signer returns the account the payload is applied on behalf of.
+func (s *SponsoredTx) Signer(chainId *big.Int) (common.Address, error) {
+	if len(s.Sig) != 65 {
+		return common.Address{}, ErrInvalidSig
+	}
+	h := s.SigHash(chainId)
+	pub, err := crypto.Ecrecover(h[:], s.Sig)
+	if err != nil {
+		return common.Address{}, err
+	}
+	var addr common.Address
+	copy(addr[:], crypto.Keccak256(pub[1:])[12:])
+	return addr, nil
+}
+
+// Input decodes the special transaction applied on behalf of the signer.
+func (s *SponsoredTx) Input() (SpecialTxInput, error) {
+	var input SpecialTxInput
+	err := json.Unmarshal(s.Payload, &input)
+	return input, err
+}
+
+// NewSponsoredTransaction creates the unsigned transaction a sponsor sends to
+// submit the authorized special transaction.
+func NewSponsoredTransaction(nonce uint64, sponsored *SponsoredTx, gasLimit uint64, gasPrice *big.Int) (*Transaction, error) {
+	data, err := json.Marshal(struct {
+		Type      *hexutil.Big `json:"type"`
+		Sponsored *SponsoredTx `json:"sponsored"`
+	}{(*hexutil.Big)(common.SpecialTxSponsored), sponsored})
+	if err != nil {
+		return nil, err
+	}
+	return NewTransaction(nonce, common.SpecialSyncAddress, new(big.Int), gasLimit, gasPrice, data), nil
+}
+
 // Sender returns the address derived from the signature (V, R, S) using secp256k1
 // elliptic curve and an error if it failed deriving or upon an incorrect
 // signature.
@@ -17,6 +17,7 @@
 package types
 
 import (
+	"encoding/json"
 	"math/big"
 	"testing"
 
@@ -136,3 +137,35 @@ func TestChainId(t *testing.T) {
 		t.Error("expected no error")
 	}
 }
+
+func TestSponsoredTxSigning(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+
+	payload := []byte(`{"type":"0x4","traffic":10}`)
+	sponsored, err := SignSponsoredTx(payload, 3, big.NewInt(18), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signer, err := sponsored.Signer(big.NewInt(18)); err != nil || signer != addr {
+		t.Errorf("signer mismatch: have %x (%v), want %x", signer, err, addr)
+	}
+	if signer, _ := sponsored.Signer(big.NewInt(19)); signer == addr {
+		t.Errorf("authorization valid on another chain")
+	}
+
+	// The envelope costs what the payload costs
+	tx, err := NewSponsoredTransaction(0, sponsored, 100000, big.NewInt(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var s SpecialTxInput
+	if err := json.Unmarshal(tx.Data(), &s); err != nil || s.Type.ToInt().Cmp(common.SpecialTxSponsored) != 0 || s.Sponsored == nil {
+		t.Fatalf("envelope mismatch: %s (%v)", tx.Data(), err)
+	}
+	input, _ := s.Sponsored.Input()
+	want := input.SpecialCost(nil, nil, nil, nil)
+	if cost := tx.SpecialCost(nil, nil, nil, nil); cost.Sign() == 0 || cost.Cmp(&want) != 0 {
+		t.Errorf("special cost mismatch: have %v, want %v", cost, &want)
+	}
+}
@@ -0,0 +1,112 @@
+package types
+
+import (
+	"math/big"
+
+	"github.com/GenaroNetwork/GenaroCore/common/hexutil"
+)
+
+// Vesting is a grant of GNX locked in an account and released linearly from
+// block Start to block End, nothing being released before block Cliff. The
+// locked GNX are not part of the balance of the account. They can be staked
+// while locked, and are withdrawn to the balance once vested.
+type Vesting struct {
+	Total     *hexutil.Big `json:"total"`               // granted amount
+	Start     uint64       `json:"start"`               // block the linear release starts at
+	Cliff     uint64       `json:"cliff"`               // block nothing is released before
+	End       uint64       `json:"end"`                 // block the whole grant is released at
+	Withdrawn *hexutil.Big `json:"withdrawn,omitempty"` // amount withdrawn to the balance
+	Staked    *hexutil.Big `json:"staked,omitempty"`    // amount staked and not backed yet
+	Slashed   *hexutil.Big `json:"slashed,omitempty"`   // amount lost to punishments of the stake
+}
+
+// Valid returns whether the grant is positive and the schedule well ordered.
+func (v Vesting) Valid() bool {
+	return v.Total != nil && v.Total.ToInt().Sign() > 0 && v.Start <= v.Cliff && v.Cliff <= v.End && v.Start < v.End
+}
+
+// Vested returns the amount of the grant released at blockNumber.
+func (v Vesting) Vested(blockNumber uint64) *big.Int {
+	total := vestingAmount(v.Total)
+	switch {
+	case blockNumber < v.Cliff:
+		return new(big.Int)
+	case blockNumber >= v.End:
+		return total
+	}
+	vested := new(big.Int).Mul(total, new(big.Int).SetUint64(blockNumber-v.Start))
+	return vested.Div(vested, new(big.Int).SetUint64(v.End-v.Start))
+}
+
+// Held returns the amount of the grant still locked in the account, i.e.
+// neither withdrawn, staked nor slashed.
+func (v Vesting) Held() *big.Int {
+	held := vestingAmount(v.Total)
+	held.Sub(held, vestingAmount(v.Withdrawn))
+	held.Sub(held, vestingAmount(v.Staked))
+	held.Sub(held, vestingAmount(v.Slashed))
+	if held.Sign() < 0 {
+		return new(big.Int)
+	}
+	return held
+}
+
+// Withdrawable returns the amount that can be withdrawn to the balance at
+// blockNumber: the released amount not withdrawn yet, as far as it is not
+// staked.
+func (v Vesting) Withdrawable(blockNumber uint64) *big.Int {
+	withdrawable := v.Vested(blockNumber)
+	withdrawable.Sub(withdrawable, vestingAmount(v.Withdrawn))
+	if held := v.Held(); withdrawable.Cmp(held) > 0 {
+		withdrawable = held
+	}
+	if withdrawable.Sign() < 0 {
+		return new(big.Int)
+	}
+	return withdrawable
+}
+
+// Stake moves amount of the held GNX to the stake, it returns false if less is
+// held.
+func (v *Vesting) Stake(amount *big.Int) bool {
+	if v.Held().Cmp(amount) < 0 {
+		return false
+	}
+	v.Staked = (*hexutil.Big)(new(big.Int).Add(vestingAmount(v.Staked), amount))
+	return true
+}
+
+// Withdraw marks the amount withdrawable at blockNumber as withdrawn and
+// returns it.
+func (v *Vesting) Withdraw(blockNumber uint64) *big.Int {
+	amount := v.Withdrawable(blockNumber)
+	v.Withdrawn = (*hexutil.Big)(new(big.Int).Add(vestingAmount(v.Withdrawn), amount))
+	return amount
+}
+
+// BackStake returns amount of backed stake to the grant, up to the amount
+// staked out of it, and returns the rest. Staked GNX that are not returned were
+// lost to punishments and are counted as slashed.
+func (v *Vesting) BackStake(amount *big.Int) *big.Int {
+	staked := vestingAmount(v.Staked)
+	if staked.Sign() == 0 {
+		return amount
+	}
+	restored := amount
+	if restored.Cmp(staked) > 0 {
+		restored = staked
+	}
+	if lost := new(big.Int).Sub(staked, restored); lost.Sign() > 0 {
+		v.Slashed = (*hexutil.Big)(new(big.Int).Add(vestingAmount(v.Slashed), lost))
+	}
+	v.Staked = nil
+	return new(big.Int).Sub(amount, restored)
+}
+
+// vestingAmount returns a copy of an amount of a Vesting, zero if unset.
+func vestingAmount(amount *hexutil.Big) *big.Int {
+	if amount == nil {
+		return new(big.Int)
+	}
+	return new(big.Int).Set(amount.ToInt())
+}
@@ -0,0 +1,58 @@
+package types
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/GenaroNetwork/GenaroCore/common/hexutil"
+)
+
+func TestVestingSchedule(t *testing.T) {
+	v := Vesting{Total: (*hexutil.Big)(big.NewInt(1000)), Start: 100, Cliff: 150, End: 200}
+	if !v.Valid() {
+		t.Fatalf("valid schedule rejected")
+	}
+	for _, tt := range []struct {
+		block  uint64
+		vested int64
+	}{{0, 0}, {149, 0}, {150, 500}, {175, 750}, {200, 1000}, {300, 1000}} {
+		if have := v.Vested(tt.block); have.Cmp(big.NewInt(tt.vested)) != 0 {
+			t.Errorf("vested at %d: have %v, want %d", tt.block, have, tt.vested)
+		}
+	}
+
+	// Staked GNX stay locked even once vested.
+	if !v.Stake(big.NewInt(400)) {
+		t.Fatalf("stake of held GNX rejected")
+	}
+	if v.Stake(big.NewInt(601)) {
+		t.Errorf("stake beyond held GNX accepted")
+	}
+	if have := v.Withdraw(175); have.Cmp(big.NewInt(600)) != 0 {
+		t.Errorf("withdrawn: have %v, want 600", have)
+	}
+	if have := v.Withdrawable(200); have.Sign() != 0 {
+		t.Errorf("withdrawable with everything else staked: have %v, want 0", have)
+	}
+
+	// A punished stake comes back short, the shortfall is slashed.
+	if rest := v.BackStake(big.NewInt(300)); rest.Sign() != 0 {
+		t.Errorf("rest of back stake: have %v, want 0", rest)
+	}
+	if have := v.Withdrawable(200); have.Cmp(big.NewInt(300)) != 0 {
+		t.Errorf("withdrawable after back stake: have %v, want 300", have)
+	}
+	if v.Slashed.ToInt().Cmp(big.NewInt(100)) != 0 || v.Staked != nil {
+		t.Errorf("slashed %v, staked %v, want 100 and none", v.Slashed, v.Staked)
+	}
+
+	for _, invalid := range []Vesting{
+		{Start: 100, Cliff: 150, End: 200},
+		{Total: (*hexutil.Big)(big.NewInt(1)), Start: 100, Cliff: 90, End: 200},
+		{Total: (*hexutil.Big)(big.NewInt(1)), Start: 100, Cliff: 100, End: 100},
+	} {
+		if invalid.Valid() {
+			t.Errorf("invalid schedule %+v accepted", invalid)
+		}
+	}
+}
@@ -3,12 +3,13 @@ package vm
 import (
 	"bytes"
 	"crypto/sha256"
-	"errors"
 	"fmt"
+	"github.com/GenaroNetwork/GenaroCore/bmt"
 	"github.com/GenaroNetwork/GenaroCore/common"
 	"github.com/GenaroNetwork/GenaroCore/common/hexutil"
 	"github.com/GenaroNetwork/GenaroCore/core/types"
 	"github.com/GenaroNetwork/GenaroCore/crypto"
+	"github.com/GenaroNetwork/GenaroCore/crypto/sha3"
 	"github.com/GenaroNetwork/GenaroCore/params"
 	"golang.org/x/crypto/ripemd160"
 	"math/big"
@@ -17,54 +18,66 @@ import (
 	"time"
 )
 
-func isSpecialAddress(address common.Address, optionTxMemorySize uint64) bool {
+// isSpecialAddress reports whether address is reserved for special transactions
+// at block blockNum. Save addresses introduced by a fork are only reserved from
+// that fork on, so that blocks before it replay as they were accepted.
+func isSpecialAddress(address common.Address, genaroConfig *params.GenaroConfig, blockNum *big.Int) bool {
 	for _, v := range common.SpecialAddressList {
 		if bytes.Compare(address.Bytes(), v.Bytes()) == 0 {
 			return true
 		}
 	}
+	if genaroConfig.IsHeftOracle(blockNum) && address == common.HeftOracleSaveAddress {
+		return true
+	}
+	if genaroConfig.IsStorageProof(blockNum) && address == common.StorageChallengeSaveAddress {
+		return true
+	}
+	if genaroConfig.IsMortgage(blockNum) && address == common.MortgageSaveAddress {
+		return true
+	}
 	dist := address.Sub(common.OptionTxBeginSaveAddress)
-	if dist >= 0 && dist < int64(optionTxMemorySize) {
+	if dist >= 0 && dist < int64(genaroConfig.OptionTxMemorySize) {
 		return true
 	}
 	return false
 }
 
-func CheckSpecialTxTypeSyncSidechainStatusParameter(s types.SpecialTxInput, caller common.Address, state StateDB, genaroConfig *params.GenaroConfig) error {
-	if true == isSpecialAddress(s.SpecialTxTypeMortgageInit.FromAccount, genaroConfig.OptionTxMemorySize) {
-		return errors.New("fromAccount error")
+func CheckSpecialTxTypeSyncSidechainStatusParameter(s types.SpecialTxInput, caller common.Address, state StateDB, genaroConfig *params.GenaroConfig, blockNum *big.Int) error {
+	if true == isSpecialAddress(s.SpecialTxTypeMortgageInit.FromAccount, genaroConfig, blockNum) {
+		return ErrSidechainFromAccount
 	}
 
 	if state.IsContract(s.SpecialTxTypeMortgageInit.FromAccount) {
-		return errors.New("Account is Contract")
+		return ErrAccountIsContract
 	}
 
 	OfficialAddress := common.HexToAddress(genaroConfig.OfficialAddress)
 	if caller != OfficialAddress {
-		return errors.New("caller address of this transaction is not invalid")
+		return ErrInvalidCaller
 	}
 
 	if 64 != len(s.SpecialTxTypeMortgageInit.Dataversion) {
-		return errors.New("Parameter Dataversion  error")
+		return ErrDataversion
 	}
 
 	if 64 != len(s.SpecialTxTypeMortgageInit.FileID) {
-		return errors.New("Parameter fileID  error")
+		return ErrSidechainFileID
 	}
 	if 20 != len(s.SpecialTxTypeMortgageInit.FromAccount) {
-		return errors.New("Parameter fromAccount  error")
+		return ErrSidechainFromAccountParam
 	}
 	if 1 < len(s.SpecialTxTypeMortgageInit.Sidechain) {
 		for k, v := range s.SpecialTxTypeMortgageInit.Sidechain {
 			if 20 != len(k) {
-				return errors.New("Parameter mortgage account  error")
+				return ErrMortgageAccount
 			}
 			if v.ToInt().Cmp(big.NewInt(0)) < 0 {
-				return errors.New("Parameter Sidechain")
+				return ErrSidechain
 			}
 		}
 	} else {
-		return errors.New("Parameter side chain length less than zero")
+		return ErrSidechainLength
 	}
 	return nil
 }
@@ -77,58 +90,246 @@ func CheckspecialTxTypeMortgageInitParameter(s types.SpecialTxInput, caller comm
 	if s.SpecialTxTypeMortgageInit.CreateTime > s.SpecialTxTypeMortgageInit.EndTime ||
 		s.SpecialTxTypeMortgageInit.CreateTime > time.Now().Unix() ||
 		s.SpecialTxTypeMortgageInit.EndTime != endTime {
-		return errors.New("Parameter CreateTime or EndTime  error")
+		return ErrMortgageTime
 	}
 	if caller != s.SpecialTxTypeMortgageInit.FromAccount {
-		return errors.New("Parameter FromAccount  error")
+		return ErrMortgageFromAccount
 	}
 	if len(s.SpecialTxTypeMortgageInit.FileID) != 64 {
-		return errors.New("Parameter FileID  error")
+		return ErrMortgageFileID
 	}
 	mortgageTable := s.SpecialTxTypeMortgageInit.MortgageTable
 	authorityTable := s.SpecialTxTypeMortgageInit.AuthorityTable
 	if len(authorityTable) != len(mortgageTable) {
-		return errors.New("Parameter authorityTable != mortgageTable  error")
+		return ErrAuthorityTableMismatch
 	}
 	for k, v := range authorityTable {
 		if v < 0 || v > 3 {
-			return errors.New("Parameter authority type  error")
+			return ErrAuthorityType
 		}
 		if mortgageTable[k].ToInt().Cmp(big.NewInt(0)) < 0 {
-			return errors.New("Parameter mortgage amount is less than zero")
+			return ErrMortgageAmount
+		}
+	}
+	return nil
+}
+
+// CheckMortgageInitTx checks a sidechain mortgage opened once
+// GenaroConfig.MortgageBlock is reached. Times are checked against the unix
+// time now of the block instead of the local clock.
+func CheckMortgageInitTx(caller common.Address, s types.SpecialTxInput, state StateDB, now int64) error {
+	mortgage := s.SpecialTxTypeMortgageInit
+	if caller != mortgage.FromAccount {
+		return ErrMortgageFromAccount
+	}
+	if len(mortgage.FileID) != 64 {
+		return ErrMortgageFileID
+	}
+	if mortgage.TimeLimit == nil || mortgage.TimeLimit.ToInt().Sign() <= 0 {
+		return ErrMortgageTime
+	}
+	endTime := new(big.Int).Mul(mortgage.TimeLimit.ToInt(), big.NewInt(86400))
+	endTime.Add(endTime, big.NewInt(mortgage.CreateTime))
+	if !endTime.IsInt64() || mortgage.EndTime != endTime.Int64() ||
+		mortgage.CreateTime > now || mortgage.EndTime <= now {
+		return ErrMortgageTime
+	}
+	if len(mortgage.MortgageTable) == 0 || len(mortgage.MortgageTable) > 8 {
+		return ErrMortgageTableSize
+	}
+	if len(mortgage.AuthorityTable) != len(mortgage.MortgageTable) {
+		return ErrAuthorityTableMismatch
+	}
+	for k, v := range mortgage.AuthorityTable {
+		if v < 0 || v > 3 {
+			return ErrAuthorityType
+		}
+		if amount, ok := mortgage.MortgageTable[k]; !ok || amount == nil || amount.ToInt().Sign() < 0 {
+			return ErrMortgageAmount
+		}
+	}
+	if _, ok := state.GetMortgage(caller, mortgage.FileID); ok {
+		return ErrMortgageExists
+	}
+	total, fee := mortgageCost(mortgage, state.GetOneDayGesCost())
+	if state.GetBalance(caller).Cmp(new(big.Int).Add(total, fee)) < 0 {
+		return ErrSpecialTxInsufficientBalance
+	}
+	return nil
+}
+
+// CheckSyncSidechainStatusTx checks a sidechain status synced by the official
+// account once GenaroConfig.MortgageBlock is reached. After EndTime only a
+// terminating sync, which settles the mortgage, is accepted.
+func CheckSyncSidechainStatusTx(caller common.Address, s types.SpecialTxInput, state StateDB, genaroConfig *params.GenaroConfig, now int64) error {
+	status := s.SpecialTxTypeMortgageInit
+	if caller != common.HexToAddress(genaroConfig.OfficialAddress) {
+		return ErrInvalidCaller
+	}
+	if len(status.Dataversion) != 64 {
+		return ErrDataversion
+	}
+	if len(status.FileID) != 64 {
+		return ErrSidechainFileID
+	}
+	if len(status.Sidechain) == 0 {
+		return ErrSidechainLength
+	}
+	mortgage, err := checkOpenMortgage(status.FromAccount, status.FileID, state)
+	if err != nil {
+		return err
+	}
+	if now >= mortgage.EndTime && !status.Terminate {
+		return ErrMortgageExpired
+	}
+	for k, v := range status.Sidechain {
+		if _, ok := mortgage.MortgageTable[k]; !ok {
+			return ErrSidechainAccount
+		}
+		if v == nil || v.ToInt().Sign() < 0 {
+			return ErrSidechain
 		}
 	}
 	return nil
 }
 
-func CheckSynchronizeShareKeyParameter(s types.SpecialTxInput, state StateDB, genaroConfig *params.GenaroConfig) error {
+// CheckMortgageTerminateTx checks the settlement of a sidechain mortgage once
+// GenaroConfig.MortgageBlock is reached. Anyone may settle an expired
+// mortgage, the official account may terminate it at any time.
+func CheckMortgageTerminateTx(caller common.Address, s types.SpecialTxInput, state StateDB, genaroConfig *params.GenaroConfig, now int64) error {
+	mortgage, err := checkOpenMortgage(s.SpecialTxTypeMortgageInit.FromAccount, s.SpecialTxTypeMortgageInit.FileID, state)
+	if err != nil {
+		return err
+	}
+	if now < mortgage.EndTime && caller != common.HexToAddress(genaroConfig.OfficialAddress) {
+		return ErrMortgageNotExpired
+	}
+	return nil
+}
 
-	if true == isSpecialAddress(s.SynchronizeShareKey.RecipientAddress, genaroConfig.OptionTxMemorySize) {
-		return errors.New("update  chain SynchronizeShareKey fail")
+func checkOpenMortgage(fromAccount common.Address, fileID string, state StateDB) (types.SpecialTxTypeMortgageInit, error) {
+	mortgage, ok := state.GetMortgage(fromAccount, fileID)
+	if !ok {
+		return mortgage, ErrMortgageNotFound
+	}
+	if mortgage.Terminate {
+		return mortgage, ErrMortgageSettled
+	}
+	return mortgage, nil
+}
+
+func CheckSynchronizeShareKeyParameter(s types.SpecialTxInput, state StateDB, genaroConfig *params.GenaroConfig, blockNum *big.Int) error {
+
+	if true == isSpecialAddress(s.SynchronizeShareKey.RecipientAddress, genaroConfig, blockNum) {
+		return ErrUpdateSynchronizeShareKey
 	}
 
 	if state.IsContract(s.SynchronizeShareKey.RecipientAddress) {
-		return errors.New("Account is Contract")
+		return ErrAccountIsContract
 	}
 
 	if len(s.SynchronizeShareKey.ShareKeyId) == 0 {
-		return errors.New("Parameter ShareKeyId  error")
+		return ErrShareKeyId
 	}
-	if len(s.SynchronizeShareKey.ShareKey) == 0 {
-		return errors.New("Parameter ShareKey  error")
+	if len(s.SynchronizeShareKey.ShareKey) == 0 && !s.SynchronizeShareKey.IsCommitted() {
+		return ErrShareKey
 	}
 	if s.SynchronizeShareKey.Shareprice.ToInt().Cmp(big.NewInt(0)) < 0 {
-		return errors.New("Parameter Shareprice  is less than zero")
+		return ErrSharePrice
 	}
 	if len(s.SynchronizeShareKey.MailHash) > 67 {
-		return errors.New("Parameter MailHash  error")
+		return ErrMailHash
+	}
+	return nil
+}
+
+// CheckShareKeyCommitment checks that a share key delivered off chain leaves
+// no trace of the key or the mail on chain.
+func CheckShareKeyCommitment(s types.SpecialTxInput) error {
+	shareKey := s.SynchronizeShareKey
+	if shareKey.IsCommitted() && (shareKey.ShareKey != "" || shareKey.MailHash != "" || shareKey.MailSize != 0) {
+		return ErrShareKeyCommitted
+	}
+	return nil
+}
+
+// CheckShareKeyOffer checks the rules a share key offer has to follow once
+// GenaroConfig.ShareKeyBlock is reached, on top of CheckSynchronizeShareKeyParameter.
+func CheckShareKeyOffer(s types.SpecialTxInput, state StateDB, blockNum *big.Int) error {
+	shareKey := s.SynchronizeShareKey
+	if shareKey.ExpireBlock != 0 && shareKey.ExpireBlock <= blockNum.Uint64() {
+		return ErrShareKeyExpireBlock
+	}
+	if old, ok := state.GetSynchronizeShareKey(shareKey.RecipientAddress, shareKey.ShareKeyId); ok {
+		if old.Status == types.ShareKeyStatusUnlocked || (old.Status == types.ShareKeyStatusPending && !old.IsExpired(blockNum.Uint64())) {
+			return ErrShareKeyExists
+		}
+	}
+	return nil
+}
+
+// CheckUnlockShareKeyTx replaces CheckUnlockSharedKeyParameter once
+// GenaroConfig.ShareKeyBlock is reached.
+func CheckUnlockShareKeyTx(caller common.Address, s types.SpecialTxInput, state StateDB, blockNum *big.Int) error {
+	if len(s.SynchronizeShareKey.ShareKeyId) == 0 {
+		return ErrShareKeyId
+	}
+	shareKey, ok := state.GetSynchronizeShareKey(caller, s.SynchronizeShareKey.ShareKeyId)
+	if !ok {
+		return ErrShareKeyNotFound
+	}
+	if shareKey.Status != types.ShareKeyStatusPending {
+		return ErrShareKeyNotPending
+	}
+	if shareKey.IsExpired(blockNum.Uint64()) {
+		return ErrShareKeyExpired
+	}
+	// unlocking acknowledges the receipt of the share key delivered off chain
+	if shareKey.CommitmentHash() != s.SynchronizeShareKey.CommitmentHash() {
+		return ErrShareKeyCommitment
+	}
+	if shareKey.Shareprice != nil && state.GetBalance(caller).Cmp(shareKey.Shareprice.ToInt()) < 0 {
+		return ErrSpecialTxInsufficientBalance
+	}
+	return nil
+}
+
+// CheckCancelShareKeyTx checks that the caller withdraws a pending offer it made.
+func CheckCancelShareKeyTx(caller common.Address, s types.SpecialTxInput, state StateDB) error {
+	if len(s.SynchronizeShareKey.ShareKeyId) == 0 {
+		return ErrShareKeyId
+	}
+	shareKey, ok := state.GetSynchronizeShareKey(s.SynchronizeShareKey.RecipientAddress, s.SynchronizeShareKey.ShareKeyId)
+	if !ok {
+		return ErrShareKeyNotFound
+	}
+	if shareKey.FromAccount != caller {
+		return ErrShareKeyNotSharer
+	}
+	if shareKey.Status != types.ShareKeyStatusPending {
+		return ErrShareKeyNotPending
+	}
+	return nil
+}
+
+// CheckRejectShareKeyTx checks that the caller declines a pending offer made to it.
+func CheckRejectShareKeyTx(caller common.Address, s types.SpecialTxInput, state StateDB) error {
+	if len(s.SynchronizeShareKey.ShareKeyId) == 0 {
+		return ErrShareKeyId
+	}
+	shareKey, ok := state.GetSynchronizeShareKey(caller, s.SynchronizeShareKey.ShareKeyId)
+	if !ok {
+		return ErrShareKeyNotFound
+	}
+	if shareKey.Status != types.ShareKeyStatusPending {
+		return ErrShareKeyNotPending
 	}
 	return nil
 }
 
 func CheckUnlockSharedKeyParameter(s types.SpecialTxInput, state StateDB, caller common.Address) error {
 	if len(s.SynchronizeShareKey.ShareKeyId) == 0 {
-		return errors.New("Parameter ShareKeyId  error")
+		return ErrShareKeyId
 	}
 	balance := state.GetBalance(caller)
 	shareKeyId := s.SynchronizeShareKey.ShareKeyId
@@ -138,190 +339,261 @@ func CheckUnlockSharedKeyParameter(s types.SpecialTxInput, state StateDB, caller
 		return nil
 	}
 	if balance.Cmp(getSharedFile.Shareprice.ToInt()) <= 0 {
-		return errors.New("Insufficient balance")
+		return ErrSpecialTxInsufficientBalance
 	}
 	return nil
 }
 
-func CheckStakeTx(s types.SpecialTxInput, state StateDB, genaroConfig *params.GenaroConfig) error {
+func CheckStakeTx(s types.SpecialTxInput, state StateDB, genaroConfig *params.GenaroConfig, blockNum *big.Int) error {
 	if s.Address == "" {
-		return errors.New("param [address] missing or can't be null string")
+		return ErrAddressMissing
 	}
 
 	adress := common.HexToAddress(s.Address)
-	if isSpecialAddress(adress, genaroConfig.OptionTxMemorySize) {
-		return errors.New("param [address] can't be special address")
+	if isSpecialAddress(adress, genaroConfig, blockNum) {
+		return ErrSpecialAddress
 	}
 
 	if state.IsContract(adress) {
-		return errors.New("Account is Contract")
+		return ErrAccountIsContract
 	}
 
 	genaroPrice := state.GetGenaroPrice()
 	if s.Stake < genaroPrice.MinStake {
-		return errors.New("value of stake must larger than MinStake")
+		return ErrStakeTooSmall
 	}
 
 	if state.IsAlreadyBackStake(adress) {
-		return errors.New("account in back stake list")
+		return ErrAccountInBackStakeList
 	}
 	return nil
 }
 
-func CheckSyncHeftTx(caller common.Address, s types.SpecialTxInput, state StateDB, genaroConfig *params.GenaroConfig) error {
+func CheckSyncHeftTx(caller common.Address, s types.SpecialTxInput, state StateDB, genaroConfig *params.GenaroConfig, blockNum *big.Int) error {
 	genaroPrice := state.GetGenaroPrice()
 	heftAccount := common.HexToAddress(genaroPrice.HeftAccount)
 	if caller != heftAccount {
-		return errors.New("caller address of this transaction is not invalid")
+		return ErrInvalidCaller
 	}
 
 	if s.Address == "" {
-		return errors.New("param [address] missing or can't be null string")
+		return ErrAddressMissing
 	}
 
 	adress := common.HexToAddress(s.Address)
-	if isSpecialAddress(adress, genaroConfig.OptionTxMemorySize) {
-		return errors.New("param [address] can't be special address")
+	if isSpecialAddress(adress, genaroConfig, blockNum) {
+		return ErrSpecialAddress
 	}
 
 	if state.IsContract(adress) {
-		return errors.New("Account is Contract")
+		return ErrAccountIsContract
 	}
 
 	if s.Heft <= 0 {
-		return errors.New("value of heft must larger than zero")
+		return ErrHeftTooSmall
+	}
+
+	return nil
+}
+
+// CheckHeftOracleInactive checks that heft can still be synced by the heft
+// account, which is no longer the case once heft reporters are registered after
+// GenaroConfig.HeftOracleBlock.
+func CheckHeftOracleInactive(state StateDB) error {
+	if len(state.GetHeftOracle().Reporters) > 0 {
+		return ErrHeftOracleActive
 	}
+	return nil
+}
+
+// CheckAddHeftReporterTx checks that the official account registers a new heft
+// reporter.
+func CheckAddHeftReporterTx(caller common.Address, s types.SpecialTxInput, state StateDB, genaroConfig *params.GenaroConfig, blockNum *big.Int) error {
+	if caller != common.HexToAddress(genaroConfig.OfficialAddress) {
+		return ErrInvalidCaller
+	}
+	if s.Address == "" {
+		return ErrAddressMissing
+	}
+	reporter := common.HexToAddress(s.Address)
+	if isSpecialAddress(reporter, genaroConfig, blockNum) {
+		return ErrSpecialAddress
+	}
+	if state.IsContract(reporter) {
+		return ErrAccountIsContract
+	}
+	oracle := state.GetHeftOracle()
+	if oracle.IsReporter(reporter) {
+		return ErrHeftReporterExists
+	}
+	return nil
+}
+
+// CheckDelHeftReporterTx checks that the official account removes a heft
+// reporter.
+func CheckDelHeftReporterTx(caller common.Address, s types.SpecialTxInput, state StateDB, genaroConfig *params.GenaroConfig) error {
+	if caller != common.HexToAddress(genaroConfig.OfficialAddress) {
+		return ErrInvalidCaller
+	}
+	if s.Address == "" {
+		return ErrAddressMissing
+	}
+	oracle := state.GetHeftOracle()
+	if !oracle.IsReporter(common.HexToAddress(s.Address)) {
+		return ErrNotHeftReporter
+	}
+	return nil
+}
 
+// CheckReportHeftTx checks a heft report of a registered heft reporter.
+func CheckReportHeftTx(caller common.Address, s types.SpecialTxInput, state StateDB, genaroConfig *params.GenaroConfig, blockNum *big.Int) error {
+	oracle := state.GetHeftOracle()
+	if !oracle.IsReporter(caller) {
+		return ErrNotHeftReporter
+	}
+	if s.Address == "" {
+		return ErrAddressMissing
+	}
+	adress := common.HexToAddress(s.Address)
+	if isSpecialAddress(adress, genaroConfig, blockNum) {
+		return ErrSpecialAddress
+	}
+	if state.IsContract(adress) {
+		return ErrAccountIsContract
+	}
+	if s.Heft <= 0 {
+		return ErrHeftTooSmall
+	}
 	return nil
 }
 
-func CheckApplyBucketTx(s types.SpecialTxInput, state StateDB, genaroConfig *params.GenaroConfig) error {
+func CheckApplyBucketTx(s types.SpecialTxInput, state StateDB, genaroConfig *params.GenaroConfig, blockNum *big.Int) error {
 	if s.Address == "" {
-		return errors.New("param [address] missing or can't be null string")
+		return ErrAddressMissing
 	}
 
 	adress := common.HexToAddress(s.Address)
-	if isSpecialAddress(adress, genaroConfig.OptionTxMemorySize) {
-		return errors.New("param [address] can't be special address")
+	if isSpecialAddress(adress, genaroConfig, blockNum) {
+		return ErrSpecialAddress
 	}
 
 	if state.IsContract(adress) {
-		return errors.New("Account is Contract ")
+		return ErrAccountIsContract
 	}
 
 	bucketMap, _ := state.GetBuckets(adress)
 
 	for _, v := range s.Buckets {
 		if len(v.BucketId) != 64 {
-			return errors.New("length of bucketId must be 64")
+			return ErrBucketIdLength
 		}
 
 		if v.TimeStart == 0 || v.TimeEnd == 0 {
-			return errors.New("param [timeEnd/timeStart] missing or can't be zero ")
+			return ErrBucketTimeMissing
 		}
 
 		if v.TimeEnd <= v.TimeStart {
-			return errors.New("param timeEnd must be larger than param TimeStart")
+			return ErrBucketTimeOrder
 		}
 
 		if v.Backup == 0 {
-			return errors.New("param [backup] missing or can't be zero ")
+			return ErrBucketBackupMissing
 		}
 
 		if v.Size == 0 {
-			return errors.New("param [size] missing or can't be zero ")
+			return ErrBucketSizeMissing
 		}
 
 		if bucketMap != nil {
 			if _, ok := bucketMap[v.BucketId]; ok {
-				return errors.New("param [bucketId] already exists")
+				return ErrBucketIdExists
 			}
 		}
 	}
 	return nil
 }
 
-func CheckBucketSupplement(s types.SpecialTxInput, state StateDB, genaroConfig *params.GenaroConfig) error {
+func CheckBucketSupplement(s types.SpecialTxInput, state StateDB, genaroConfig *params.GenaroConfig, blockNum *big.Int) error {
 
 	if s.Address == "" {
-		return errors.New("param [address] missing or can't be null string")
+		return ErrAddressMissing
 	}
 
 	if s.BucketID == "" {
-		return errors.New("param [bucketId] missing or can't be null string")
+		return ErrBucketIdMissing
 	}
 
 	if s.Size == 0 && s.Duration < 86400 {
-		return errors.New("param [size / duration] missing or must be larger than zero")
+		return ErrSupplementMissing
 	}
 
 	if s.Message == "" {
-		return errors.New("param [ msg ] missing or can't be null")
+		return ErrTimestampMissing
 	}
 
 	timeInt, err := strconv.Atoi(s.Message)
 	if err != nil {
-		return errors.New("param [ msg ] is not timestamp")
+		return ErrTimestampInvalid
 	}
 
 	txTime := time.Unix(int64(timeInt), 0)
 
 	adress := common.HexToAddress(s.Address)
-	if isSpecialAddress(adress, genaroConfig.OptionTxMemorySize) {
-		return errors.New("param [address] can't be special address")
+	if isSpecialAddress(adress, genaroConfig, blockNum) {
+		return ErrSpecialAddress
 	}
 
 	if state.IsContract(adress) {
-		return errors.New("Account is Contract")
+		return ErrAccountIsContract
 	}
 
 	buckets, _ := state.GetBuckets(adress)
 	if buckets == nil {
-		return errors.New("the user does not have the bucket corresponding to the bucketId")
+		return ErrBucketNotFound
 	}
 
 	if b, ok := buckets[s.BucketID]; ok {
 		bucketInDb := b.(types.BucketPropertie)
 		if bucketInDb.TimeEnd <= uint64(txTime.Unix()) {
-			return errors.New("the bucket corresponding to the bucketId has has been expired")
+			return ErrBucketExpired
 		}
 	} else {
-		return errors.New("the user does not have the bucket corresponding to the bucketId")
+		return ErrBucketNotFound
 	}
 
 	return nil
 }
 
-func CheckTrafficTx(s types.SpecialTxInput, state StateDB, genaroConfig *params.GenaroConfig) error {
+func CheckTrafficTx(s types.SpecialTxInput, state StateDB, genaroConfig *params.GenaroConfig, blockNum *big.Int) error {
 
 	if s.Address == "" {
-		return errors.New("param [address] missing or can't be null string")
+		return ErrAddressMissing
 	}
 
 	adress := common.HexToAddress(s.Address)
-	if isSpecialAddress(adress, genaroConfig.OptionTxMemorySize) {
-		return errors.New("param [address] can't be special address")
+	if isSpecialAddress(adress, genaroConfig, blockNum) {
+		return ErrSpecialAddress
 	}
 
 	if state.IsContract(adress) {
-		return errors.New("Account is Contract")
+		return ErrAccountIsContract
 	}
 
 	if s.Traffic <= 0 {
-		return errors.New("param [traffic] missing or must larger than zero")
+		return ErrTrafficMissing
 	}
 	return nil
 }
 
 func CheckSyncNodeTx(caller common.Address, s types.SpecialTxInput, db StateDB) error {
 	if s.Address == "" {
-		return errors.New("param [address] missing or can't be null string")
+		return ErrAddressMissing
 	}
 	if s.NodeID == "" {
-		return errors.New("param [nodeId] missing ")
+		return ErrNodeIdMissing
 	}
 	if s.Sign == "" {
-		return errors.New("param [sign] missing ")
+		return ErrSignMissing
 	}
 
 	stake, _ := db.GetStake(caller)
@@ -329,35 +601,35 @@ func CheckSyncNodeTx(caller common.Address, s types.SpecialTxInput, db StateDB)
 	stakeVlauePerNode := db.GetStakePerNodePrice()
 
 	if len(s.NodeID) == 0 {
-		return errors.New("length of nodeId must larger then 0")
+		return ErrNodeIdEmpty
 	}
 
 	paramAddress := common.HexToAddress(s.Address)
 	if caller != paramAddress {
-		return errors.New("address in param is not equal with callerAddress of this Tx")
+		return ErrAddressNotCaller
 	}
 
 	if db.GetAddressByNode(s.NodeID) != "" {
-		return errors.New("the input node have been bound by themselves or others")
+		return ErrNodeAlreadyBound
 	}
 
 	msg := s.NodeID + s.Address
 
 	sig, err := hexutil.Decode(s.Sign)
 	if err != nil {
-		return errors.New("sign without 0x prefix")
+		return ErrSignWithoutPrefix
 	}
 
 	recoveredPub, err := crypto.SigToPub(crypto.Keccak256([]byte(msg)), sig)
 	if err != nil {
-		return errors.New("ECRecover error when valid sign")
+		return ErrSignRecover
 	}
 
 	pubKey := crypto.CompressPubkey(recoveredPub)
 
 	genNodeID := generateNodeId(pubKey)
 	if genNodeID != s.NodeID {
-		return errors.New("sign valid error, nodeId mismatch")
+		return ErrSignNodeIdMismatch
 	}
 
 	var nodeNum int = 1
@@ -371,7 +643,7 @@ func CheckSyncNodeTx(caller common.Address, s types.SpecialTxInput, db StateDB)
 	currentStake := new(big.Int).Mul(new(big.Int).SetUint64(stake), common.BaseCompany)
 
 	if needStakeVale.Cmp(currentStake) == 1 {
-		return errors.New("none enough stake to synchronize node")
+		return ErrStakeNotEnoughForNode
 	}
 	return nil
 }
@@ -385,27 +657,27 @@ func generateNodeId(b []byte) string {
 	return nodeId
 }
 
-func CheckPunishmentTx(caller common.Address, s types.SpecialTxInput, state StateDB, genaroConfig *params.GenaroConfig) error {
+func CheckPunishmentTx(caller common.Address, s types.SpecialTxInput, state StateDB, genaroConfig *params.GenaroConfig, blockNum *big.Int) error {
 	if s.Address == "" {
-		return errors.New("param [address] missing or can't be null string")
+		return ErrAddressMissing
 	}
 
 	if s.Stake == 0 {
-		return errors.New("param [stake] missing or must be larger than zero")
+		return ErrStakeMissing
 	}
 
 	adress := common.HexToAddress(s.Address)
-	if isSpecialAddress(adress, genaroConfig.OptionTxMemorySize) {
-		return errors.New("param [address] can't be special address")
+	if isSpecialAddress(adress, genaroConfig, blockNum) {
+		return ErrSpecialAddress
 	}
 
 	if state.IsContract(adress) {
-		return errors.New("Account is Contract")
+		return ErrAccountIsContract
 	}
 
 	OfficialAddress := common.HexToAddress(genaroConfig.OfficialAddress)
 	if caller != OfficialAddress {
-		return errors.New("caller address of this transaction is not invalid")
+		return ErrInvalidCaller
 	}
 	return nil
 }
@@ -413,20 +685,20 @@ func CheckPunishmentTx(caller common.Address, s types.SpecialTxInput, state Stat
 func CheckBackStakeTx(caller common.Address, state StateDB) error {
 	ok, backStakeList := state.GetAlreadyBackStakeList()
 	if !ok {
-		return errors.New("userBackStake fail")
+		return ErrBackStake
 	}
 	genaroPrice := state.GetGenaroPrice()
 	if len(backStakeList) > int(genaroPrice.BackStackListMax) {
-		return errors.New("BackStackList too long")
+		return ErrBackStakeListTooLong
 	}
 	if state.IsBindingAccount(caller) {
-		return errors.New("account is binding")
+		return ErrAccountIsBinding
 	}
 	if state.IsAlreadyBackStake(caller) {
-		return errors.New("account in back stake list")
+		return ErrAccountInBackStakeList
 	}
 	if state.IsAccountExistInForbidBackStakeList(caller) {
-		return errors.New("account in forbid backstake list")
+		return ErrAccountInForbidBackStakeList
 	}
 	return nil
 }
@@ -435,38 +707,79 @@ func CheckSynStateTx(caller common.Address, state StateDB) error {
 	genaroPrice := state.GetGenaroPrice()
 	synStateAccount := common.HexToAddress(genaroPrice.SynStateAccount)
 	if caller != synStateAccount {
-		return errors.New("caller address of this transaction is not invalid")
+		return ErrInvalidCaller
 	}
 	return nil
 }
 
-func CheckSyncFileSharePublicKeyTx(s types.SpecialTxInput, state StateDB, genaroConfig *params.GenaroConfig) error {
+func CheckSyncFileSharePublicKeyTx(s types.SpecialTxInput, state StateDB, genaroConfig *params.GenaroConfig, blockNum *big.Int) error {
 	if s.Address == "" {
-		return errors.New("param [address] missing or can't be null string")
+		return ErrAddressMissing
 	}
 
 	adress := common.HexToAddress(s.Address)
-	if isSpecialAddress(adress, genaroConfig.OptionTxMemorySize) {
-		return errors.New("param [address] can't be special address")
+	if isSpecialAddress(adress, genaroConfig, blockNum) {
+		return ErrSpecialAddress
 	}
 
 	if state.IsContract(adress) {
-		return errors.New("Account is Contract")
+		return ErrAccountIsContract
 	}
 
 	if s.FileSharePublicKey == "" {
-		return errors.New("public key for file share can't be null")
+		return ErrFileSharePublicKeyMissing
+	}
+	return nil
+}
+
+// CheckRevokeFileSharePublicKeyTx checks that the caller revokes a version of
+// its own public key that is still valid. Version 0 stands for the latest one.
+func CheckRevokeFileSharePublicKeyTx(caller common.Address, s types.SpecialTxInput, state StateDB) error {
+	if s.Address == "" {
+		return ErrAddressMissing
+	}
+	adress := common.HexToAddress(s.Address)
+	if adress != caller {
+		return ErrAddressNotCaller
+	}
+	versions := state.GetFileSharePublicKeys(adress)
+	version := s.PublicKeyVersion
+	if version == 0 {
+		version = uint64(len(versions))
+	}
+	if version == 0 || version > uint64(len(versions)) {
+		return ErrPublicKeyVersionNotFound
+	}
+	if versions[version-1].RevokeBlock != 0 {
+		return ErrPublicKeyVersionRevoked
+	}
+	return nil
+}
+
+// CheckShareKeyPublicKeyVersion checks that a share key targets a version of
+// the recipient's public key that is not revoked.
+func CheckShareKeyPublicKeyVersion(s types.SpecialTxInput, state StateDB) error {
+	version := s.SynchronizeShareKey.PublicKeyVersion
+	if version == 0 {
+		return nil
+	}
+	versions := state.GetFileSharePublicKeys(s.SynchronizeShareKey.RecipientAddress)
+	if version > uint64(len(versions)) {
+		return ErrPublicKeyVersionNotFound
+	}
+	if versions[version-1].RevokeBlock != 0 {
+		return ErrPublicKeyVersionRevoked
 	}
 	return nil
 }
 
 func CheckPriceRegulation(caller common.Address, s types.SpecialTxInput) error {
 	if caller != common.GenaroPriceAddress {
-		return errors.New("caller address of this transaction is not invalid")
+		return ErrInvalidCaller
 	}
 
 	if s.StakeValuePerNode == nil && s.BucketApplyGasPerGPerDay == nil && s.TrafficApplyGasPerG == nil && s.OneDayMortgageGes == nil && s.OneDaySyncLogGsaCost == nil {
-		return errors.New("none price to update")
+		return ErrNonePrice
 	}
 
 	return nil
@@ -474,11 +787,11 @@ func CheckPriceRegulation(caller common.Address, s types.SpecialTxInput) error {
 
 func CheckUnbindNodeTx(caller common.Address, s types.SpecialTxInput, existNodes []string) error {
 	if existNodes == nil {
-		return errors.New("none node of this account need to unbind")
+		return ErrNoNodeToUnbind
 	}
 
 	if s.NodeID == "" {
-		return errors.New("param [nodeId] is null or missing")
+		return ErrNodeIdNull
 	}
 
 	for _, v := range existNodes {
@@ -486,37 +799,37 @@ func CheckUnbindNodeTx(caller common.Address, s types.SpecialTxInput, existNodes
 			return nil
 		}
 	}
-	return errors.New("this node does not belong to this account")
+	return ErrNodeNotOwned
 }
 
 func CheckAccountBindingTx(caller common.Address, s types.SpecialTxInput, state StateDB) error {
 	genaroPrice := state.GetGenaroPrice()
 	bindingAccount := common.HexToAddress(genaroPrice.BindingAccount)
 	if caller != bindingAccount {
-		return errors.New("caller address of this transaction is not invalid")
+		return ErrInvalidCaller
 	}
 
 	mainAccount := common.HexToAddress(s.Address)
 	subAccount := common.HexToAddress(s.Message)
 	if bytes.EqualFold(mainAccount.Bytes(), subAccount.Bytes()) {
-		return errors.New("same account")
+		return ErrSameAccount
 	}
 	if !state.IsCandidateExist(mainAccount) {
-		return errors.New("mainAddr is not a candidate")
+		return ErrMainAccountNotCandidate
 	}
 	if state.GetSubAccountsCount(mainAccount) > int(genaroPrice.MaxBinding) {
-		return errors.New("binding enough")
+		return ErrBindingEnough
 	}
 	if state.IsBindingMainAccount(subAccount) {
-		return errors.New("sub account is a main account")
+		return ErrSubAccountIsMain
 	}
 	thisMainAccount := state.GetMainAccount(subAccount)
 	if !state.IsCandidateExist(subAccount) && thisMainAccount == nil {
-		return errors.New("subAddr is not a candidate")
+		return ErrSubAccountNotCandidate
 	}
 
 	if thisMainAccount != nil && bytes.Compare(thisMainAccount.Bytes(), mainAccount.Bytes()) == 0 {
-		return errors.New("has binding")
+		return ErrHasBinding
 	}
 
 	return nil
@@ -533,17 +846,17 @@ func CheckAccountCancelBindingTx(caller common.Address, s types.SpecialTxInput,
 				if thisMainAccount != nil && bytes.EqualFold(thisMainAccount.Bytes(), caller.Bytes()) {
 					t = 3
 				} else {
-					err = errors.New("not binding account")
+					err = ErrNotBindingAccount
 				}
 			} else {
-				err = errors.New("not binding account")
+				err = ErrNotBindingAccount
 			}
 		}
 
 	} else if state.IsBindingSubAccount(caller) {
 		t = 2
 	} else {
-		err = errors.New("not binding account")
+		err = ErrNotBindingAccount
 	}
 	return
 }
@@ -551,7 +864,7 @@ func CheckAccountCancelBindingTx(caller common.Address, s types.SpecialTxInput,
 func CheckAddAccountInForbidBackStakeListTx(caller common.Address, s types.SpecialTxInput, state StateDB, genaroConfig *params.GenaroConfig) error {
 	OfficialAddress := common.HexToAddress(genaroConfig.OfficialAddress)
 	if caller != OfficialAddress {
-		return errors.New("caller address of this transaction is not invalid")
+		return ErrInvalidCaller
 	}
 	account := common.HexToAddress(s.Address)
 	stake, err := state.GetStake(account)
@@ -559,10 +872,10 @@ func CheckAddAccountInForbidBackStakeListTx(caller common.Address, s types.Speci
 		return err
 	}
 	if stake == 0 {
-		return errors.New("account stake is zero")
+		return ErrAccountStakeZero
 	}
 	if state.IsAccountExistInForbidBackStakeList(account) {
-		return errors.New("account is in forbid list")
+		return ErrAccountInForbidList
 	}
 	return nil
 }
@@ -570,12 +883,12 @@ func CheckAddAccountInForbidBackStakeListTx(caller common.Address, s types.Speci
 func CheckDelAccountInForbidBackStakeListTx(caller common.Address, s types.SpecialTxInput, state StateDB, genaroConfig *params.GenaroConfig) error {
 	OfficialAddress := common.HexToAddress(genaroConfig.OfficialAddress)
 	if caller != OfficialAddress {
-		return errors.New("caller address of this transaction is not invalid")
+		return ErrInvalidCaller
 	}
 	account := common.HexToAddress(s.Address)
 	ok := state.IsAccountExistInForbidBackStakeList(account)
 	if !ok {
-		return errors.New("account is not in forbid list")
+		return ErrAccountNotInForbidList
 	}
 	return nil
 }
@@ -583,11 +896,11 @@ func CheckDelAccountInForbidBackStakeListTx(caller common.Address, s types.Speci
 func CheckSetGlobalVar(caller common.Address, s types.SpecialTxInput, genaroConfig *params.GenaroConfig) error {
 	OfficialAddress := common.HexToAddress(genaroConfig.OfficialAddress)
 	if caller != OfficialAddress {
-		return errors.New("caller address of this transaction is not invalid")
+		return ErrInvalidCaller
 	}
 
 	if s.RatioPerYear >= 100 || s.CoinRewardsRatio >= 100 || s.StorageRewardsRatio >= 100 {
-		return errors.New("Ratio is not invalid")
+		return ErrRatio
 	}
 
 	return nil
@@ -596,37 +909,37 @@ func CheckSetGlobalVar(caller common.Address, s types.SpecialTxInput, genaroConf
 func CheckAddCoinpool(caller common.Address, s types.SpecialTxInput, state StateDB) error {
 	balance := state.GetBalance(caller)
 	if s.AddCoin.ToInt().Cmp(big.NewInt(0)) <= 0 {
-		return errors.New("Value is not invalid")
+		return ErrCoinpoolValue
 	}
 	if balance.Cmp(s.AddCoin.ToInt()) < 0 {
-		return errors.New("Balance is not enough")
+		return ErrCoinpoolBalance
 	}
 	return nil
 }
 
 func CheckPromissoryNoteRevoke(caller common.Address, s types.SpecialTxInput, state StateDB, blockNum *big.Int, optionTxMemorySize uint64) error {
 	if (s.OrderId == common.Hash{}) {
-		return errors.New("param [OrderId] Missing")
+		return ErrOrderIdMissing
 	}
 
 	optionTxTable := state.GetOptionTxTable(s.OrderId, optionTxMemorySize)
 	if optionTxTable == nil {
-		return errors.New("None promissory note tx with this hash ")
+		return ErrOptionTxNotFound
 	}
 
 	var promissoryNotesOptionTx types.PromissoryNotesOptionTx
 	var ok bool
 	if promissoryNotesOptionTx, ok = (*optionTxTable)[s.OrderId]; !ok {
-		return errors.New("None promissory note tx with this hash ")
+		return ErrOptionTxNotFound
 	}
 
 	if promissoryNotesOptionTx.PromissoryNotesOwner != caller {
-		return errors.New("You can't revoke someone else's options trading，check the order id ")
+		return ErrOptionNotOwned
 	}
 
 	if (common.Address{} != promissoryNotesOptionTx.OptionOwner) {
 		if promissoryNotesOptionTx.RestoreBlock <= blockNum.Uint64() {
-			return errors.New("You can't revoke this options trading, current options have been purchased ")
+			return ErrOptionPurchased
 		}
 	}
 
@@ -635,23 +948,23 @@ func CheckPromissoryNoteRevoke(caller common.Address, s types.SpecialTxInput, st
 
 func CheckPublishOption(caller common.Address, s types.SpecialTxInput, state StateDB, blockNum *big.Int) error {
 	if s.RestoreBlock == 0 {
-		return errors.New("param [restoreBlock] must be larger than zero")
+		return ErrRestoreBlockMissing
 	}
 
 	if s.RestoreBlock <= blockNum.Uint64() {
-		return errors.New("param [restoreBlock] must be larger than current block number ")
+		return ErrRestoreBlockPassed
 	}
 
 	if s.TxNum == 0 {
-		return errors.New("param [txNum] must be larger than zero")
+		return ErrTxNumMissing
 	}
 
 	if s.PromissoryNoteTxPrice == nil {
-		return errors.New("param [PromissoryNoteTxPrice] Missing")
+		return ErrPromissoryNoteTxPriceMissing
 	}
 
 	if s.OptionPrice == nil {
-		return errors.New("param [OptionPrice] Missing")
+		return ErrOptionPriceMissing
 	}
 
 	promissoryNotes := state.GetPromissoryNotes(caller)
@@ -660,32 +973,32 @@ func CheckPublishOption(caller common.Address, s types.SpecialTxInput, state Sta
 			return nil
 		}
 	}
-	return errors.New("None enough promissory notes to sell ")
+	return ErrPromissoryNotesNotEnough
 }
 
 func CheckSetOptionTxStatus(caller common.Address, s types.SpecialTxInput, state StateDB, optionTxMemorySize uint64) error {
 	if (s.OrderId == common.Hash{}) {
-		return errors.New("param [OrderId] Missing")
+		return ErrOrderIdMissing
 	}
 
 	optionTxTable := state.GetOptionTxTable(s.OrderId, optionTxMemorySize)
 	if optionTxTable == nil {
-		return errors.New("None promissory note tx with this hash ")
+		return ErrOptionTxNotFound
 	}
 
 	var promissoryNotesOptionTx types.PromissoryNotesOptionTx
 	var ok bool
 	if promissoryNotesOptionTx, ok = (*optionTxTable)[s.OrderId]; !ok {
-		return errors.New("None promissory note tx with this hash ")
+		return ErrOptionTxNotFound
 	}
 
 	if (common.Address{} == promissoryNotesOptionTx.OptionOwner) {
 		if promissoryNotesOptionTx.PromissoryNotesOwner != caller {
-			return errors.New("You can't revoke someone else's options trading，check the order id ")
+			return ErrOptionNotOwned
 		}
 	} else {
 		if promissoryNotesOptionTx.OptionOwner != caller {
-			return errors.New("You can't revoke someone else's options trading，check the order id ")
+			return ErrOptionNotOwned
 		}
 	}
 	return nil
@@ -694,19 +1007,19 @@ func CheckSetOptionTxStatus(caller common.Address, s types.SpecialTxInput, state
 func CheckBuyPromissoryNotes(caller common.Address, s types.SpecialTxInput, state StateDB, optionTxMemorySize uint64) error {
 	optionTxTable := state.GetOptionTxTable(s.OrderId, optionTxMemorySize)
 	if optionTxTable == nil {
-		return errors.New("None promissory note tx with this hash ")
+		return ErrOptionTxNotFound
 	}
 	var promissoryNotesOptionTx types.PromissoryNotesOptionTx
 	var ok bool
 	if promissoryNotesOptionTx, ok = (*optionTxTable)[s.OrderId]; !ok {
-		return errors.New("None promissory note tx with this hash ")
+		return ErrOptionTxNotFound
 	}
 	if true != promissoryNotesOptionTx.IsSell {
-		return errors.New("Go to permission to buy promissory None")
+		return ErrNoBuyPermission
 	}
 	balance := state.GetBalance(caller)
 	if balance.Cmp(promissoryNotesOptionTx.OptionPrice) <= 0 {
-		return errors.New("Insufficient balance")
+		return ErrSpecialTxInsufficientBalance
 	}
 	return nil
 }
@@ -714,20 +1027,20 @@ func CheckBuyPromissoryNotes(caller common.Address, s types.SpecialTxInput, stat
 func CheckCarriedOutPromissoryNotes(caller common.Address, s types.SpecialTxInput, state StateDB, optionTxMemorySize uint64) error {
 	optionTxTable := state.GetOptionTxTable(s.OrderId, optionTxMemorySize)
 	if optionTxTable == nil {
-		return errors.New("None promissory note tx with this hash ")
+		return ErrOptionTxNotFound
 	}
 	var promissoryNotesOptionTx types.PromissoryNotesOptionTx
 	var ok bool
 	if promissoryNotesOptionTx, ok = (*optionTxTable)[s.OrderId]; !ok {
-		return errors.New("None promissory note tx with this hash ")
+		return ErrOptionTxNotFound
 	}
 	if caller != promissoryNotesOptionTx.OptionOwner {
-		return errors.New("No right turn buy promissoryNotes ")
+		return ErrNoTurnBuyPermission
 	}
 	balance := state.GetBalance(caller)
 	promissoryNotesOptionTx.PromissoryNoteTxPrice.Mul(promissoryNotesOptionTx.PromissoryNoteTxPrice, big.NewInt(int64(promissoryNotesOptionTx.TxNum)))
 	if balance.Cmp(promissoryNotesOptionTx.PromissoryNoteTxPrice) <= 0 {
-		return errors.New("Insufficient balance")
+		return ErrSpecialTxInsufficientBalance
 	}
 	return nil
 }
@@ -735,16 +1048,16 @@ func CheckCarriedOutPromissoryNotes(caller common.Address, s types.SpecialTxInpu
 func CheckTurnBuyPromissoryNotes(caller common.Address, s types.SpecialTxInput, state StateDB, optionTxMemorySize uint64) error {
 	optionTxTable := state.GetOptionTxTable(s.OrderId, optionTxMemorySize)
 	if optionTxTable == nil {
-		return errors.New("None promissory note tx with this hash ")
+		return ErrOptionTxNotFound
 	}
 
 	var promissoryNotesOptionTx types.PromissoryNotesOptionTx
 	var ok bool
 	if promissoryNotesOptionTx, ok = (*optionTxTable)[s.OrderId]; !ok {
-		return errors.New("None promissory note tx with this hash ")
+		return ErrOptionTxNotFound
 	}
 	if caller != promissoryNotesOptionTx.OptionOwner {
-		return errors.New("No right turn buy promissoryNotes ")
+		return ErrNoTurnBuyPermission
 	}
 	return nil
 }
@@ -752,24 +1065,24 @@ func CheckTurnBuyPromissoryNotes(caller common.Address, s types.SpecialTxInput,
 func WithdrawCash(caller common.Address, state StateDB, blockNum *big.Int) error {
 	beforPromissoryNotesNum := state.GetBeforPromissoryNotesNum(caller, blockNum.Uint64())
 	if beforPromissoryNotesNum <= 0 {
-		return errors.New("The number of cashable notes available is 0")
+		return ErrNoCashableNotes
 	}
 	return nil
 }
 
 func CheckSetNameTxStatus(caller common.Address, s types.SpecialTxInput, state StateDB) error {
 	if len(s.Message) == 0 {
-		return errors.New("name is null")
+		return ErrNameNull
 	}
 	if len(s.Message) > common.HashLength {
-		return errors.New("name is too long")
+		return ErrNameTooLong
 	}
 	exist, err := state.IsNameAccountExist(s.Message)
 	if err != nil {
 		return err
 	}
 	if exist {
-		return errors.New("name is exist")
+		return ErrNameExists
 	}
 
 	var name types.AccountName
@@ -778,7 +1091,7 @@ func CheckSetNameTxStatus(caller common.Address, s types.SpecialTxInput, state S
 
 	balance := state.GetBalance(caller)
 	if priceBig.Cmp(balance) > 0 {
-		return errors.New("There is not enough balance")
+		return ErrNameBalance
 	}
 
 	return nil
@@ -786,19 +1099,19 @@ func CheckSetNameTxStatus(caller common.Address, s types.SpecialTxInput, state S
 
 func CheckTransferNameTxStatus(caller common.Address, s types.SpecialTxInput, state StateDB) error {
 	if len(s.Message) == 0 {
-		return errors.New("name is null")
+		return ErrNameNull
 	}
 
 	if len(s.Message) > common.HashLength {
-		return errors.New("name is too long")
+		return ErrNameTooLong
 	}
 
 	if s.Address == "" {
-		return errors.New("param [address] missing or can't be null string")
+		return ErrAddressMissing
 	}
 
 	if !state.HasName(caller, s.Message) {
-		return errors.New("name is not belong to you")
+		return ErrNameNotOwned
 	}
 
 	return nil
@@ -806,15 +1119,15 @@ func CheckTransferNameTxStatus(caller common.Address, s types.SpecialTxInput, st
 
 func CheckUnsubscribeNameTxStatus(caller common.Address, s types.SpecialTxInput, state StateDB) error {
 	if len(s.Message) == 0 {
-		return errors.New("name is null")
+		return ErrNameNull
 	}
 
 	if len(s.Message) > common.HashLength {
-		return errors.New("name is too long")
+		return ErrNameTooLong
 	}
 
 	if !state.HasName(caller, s.Message) {
-		return errors.New("name is not belong to you")
+		return ErrNameNotOwned
 	}
 
 	return nil
@@ -822,14 +1135,226 @@ func CheckUnsubscribeNameTxStatus(caller common.Address, s types.SpecialTxInput,
 
 func CheckSetProfitAccount(caller common.Address, s types.SpecialTxInput, state StateDB) error {
 	if s.Address == "" {
-		return errors.New("param [address] missing or can't be null string")
+		return ErrAddressMissing
 	}
 	return nil
 }
 
 func CheckSetShadowAccount(caller common.Address, s types.SpecialTxInput, state StateDB) error {
 	if s.Address == "" {
-		return errors.New("param [address] missing or can't be null string")
+		return ErrAddressMissing
+	}
+	return nil
+}
+
+// CheckVestingCreateTx checks a vesting grant. An account holds a single
+// vesting, so grants lock at least common.MinVestingTotal GNX to keep anyone
+// from blocking a beneficiary with a worthless one.
+func CheckVestingCreateTx(caller common.Address, s types.SpecialTxInput, state StateDB, genaroConfig *params.GenaroConfig, blockNum *big.Int) error {
+	if s.Address == "" {
+		return ErrAddressMissing
+	}
+	beneficiary := common.HexToAddress(s.Address)
+	if isSpecialAddress(beneficiary, genaroConfig, blockNum) {
+		return ErrSpecialAddress
+	}
+	if state.IsContract(beneficiary) {
+		return ErrAccountIsContract
+	}
+	if s.Vesting == nil || !s.Vesting.Valid() {
+		return ErrVestingInvalid
+	}
+	if min := new(big.Int).Mul(new(big.Int).SetUint64(common.MinVestingTotal), common.BaseCompany); s.Vesting.Total.ToInt().Cmp(min) < 0 {
+		return ErrVestingTooSmall
+	}
+	if state.GetVesting(beneficiary) != nil {
+		return ErrVestingExists
+	}
+	if state.GetBalance(caller).Cmp(s.Vesting.Total.ToInt()) < 0 {
+		return ErrSpecialTxInsufficientBalance
+	}
+	return nil
+}
+
+func CheckVestingWithdrawTx(caller common.Address, state StateDB, blockNumber uint64) error {
+	vesting := state.GetVesting(caller)
+	if vesting == nil {
+		return ErrVestingNotFound
+	}
+	if vesting.Withdrawable(blockNumber).Sign() == 0 {
+		return ErrNothingVested
+	}
+	return nil
+}
+
+// CheckVestingStakeTx checks a stake paid with the GNX locked in the vesting of
+// the caller. Such a stake can only be made for the caller itself, so that it
+// goes back to the vesting when backed.
+func CheckVestingStakeTx(caller common.Address, s types.SpecialTxInput, state StateDB) error {
+	if common.HexToAddress(s.Address) != caller {
+		return ErrAddressNotCaller
+	}
+	vesting := state.GetVesting(caller)
+	if vesting == nil {
+		return ErrVestingNotFound
+	}
+	amount := new(big.Int).Mul(new(big.Int).SetUint64(s.Stake), common.BaseCompany)
+	if vesting.Held().Cmp(amount) < 0 {
+		return ErrVestingInsufficient
+	}
+	return nil
+}
+
+// CheckBucketRootCommitTx checks that the caller commits a chunk to one of its
+// own buckets.
+func CheckBucketRootCommitTx(caller common.Address, s types.SpecialTxInput, state StateDB) error {
+	if s.BucketID == "" {
+		return ErrBucketIdMissing
+	}
+	buckets, _ := state.GetBuckets(caller)
+	if _, ok := buckets[s.BucketID]; !ok {
+		return ErrBucketNotFound
+	}
+	if s.StorageRoot == nil || *s.StorageRoot == (common.Hash{}) {
+		return ErrStorageRootMissing
+	}
+	challenges := state.GetStorageChallenges()
+	key := types.BucketKey(caller, s.BucketID)
+	if challenges.InBucket(key, *s.StorageRoot) {
+		return ErrBucketRootCommitted
+	}
+	if uint64(len(challenges.Buckets[key])) >= common.MaxStorageRoots {
+		return ErrBucketRootsFull
+	}
+	return nil
+}
+
+// CheckStorageCommitTx checks that a node of the caller commits to a chunk its
+// bucket owner committed to, given by the address and bucketId params.
+func CheckStorageCommitTx(caller common.Address, s types.SpecialTxInput, state StateDB) error {
+	if s.NodeID == "" {
+		return ErrNodeIdNull
+	}
+	owned := false
+	for _, node := range state.GetStorageNodes(caller) {
+		owned = owned || node == s.NodeID
+	}
+	if !owned {
+		return ErrNodeNotOwned
+	}
+	if s.StorageRoot == nil || *s.StorageRoot == (common.Hash{}) {
+		return ErrStorageRootMissing
+	}
+	if s.Address == "" {
+		return ErrAddressMissing
+	}
+	if s.BucketID == "" {
+		return ErrBucketIdMissing
+	}
+	challenges := state.GetStorageChallenges()
+	if !challenges.InBucket(types.BucketKey(common.HexToAddress(s.Address), s.BucketID), *s.StorageRoot) {
+		return ErrBucketRootNotCommitted
+	}
+	if challenges.IsCommitted(s.NodeID, *s.StorageRoot) {
+		return ErrStorageRootCommitted
+	}
+	if uint64(len(challenges.Roots[s.NodeID])) >= common.MaxStorageRoots {
+		return ErrStorageRootsFull
+	}
+	return nil
+}
+
+// CheckStorageChallengeTx checks a challenge of a storage node, which can be
+// sent by the official account and the heft reporters.
+func CheckStorageChallengeTx(caller common.Address, s types.SpecialTxInput, state StateDB, genaroConfig *params.GenaroConfig) error {
+	oracle := state.GetHeftOracle()
+	if caller != common.HexToAddress(genaroConfig.OfficialAddress) && !oracle.IsReporter(caller) {
+		return ErrInvalidCaller
+	}
+	if s.NodeID == "" {
+		return ErrNodeIdNull
+	}
+	if state.GetAddressByNode(s.NodeID) == "" {
+		return ErrNodeNotBound
+	}
+	challenges := state.GetStorageChallenges()
+	if len(challenges.Roots[s.NodeID]) == 0 {
+		return ErrStorageRootNotCommitted
+	}
+	if s.StorageRoot != nil && !challenges.IsCommitted(s.NodeID, *s.StorageRoot) {
+		return ErrStorageRootNotCommitted
+	}
+	if challenges.PendingOf(s.NodeID) != nil {
+		return ErrStorageChallengePending
+	}
+	return nil
+}
+
+// CheckStorageProofTx checks the answer to the pending challenge of a storage
+// node included in block blockNumber.
+func CheckStorageProofTx(caller common.Address, s types.SpecialTxInput, state StateDB, blockNumber uint64) error {
+	if s.NodeID == "" {
+		return ErrNodeIdNull
+	}
+	challenges := state.GetStorageChallenges()
+	challenge := challenges.PendingOf(s.NodeID)
+	if challenge == nil {
+		return ErrStorageChallengeNotFound
+	}
+	if challenge.Account != caller {
+		return ErrNodeNotOwned
+	}
+	if blockNumber > challenge.Deadline {
+		return ErrStorageChallengeExpired
+	}
+	if !verifyStorageProof(*challenge, s.StorageProof) {
+		return ErrStorageProofInvalid
+	}
+	return nil
+}
+
+// storageProofLength is the length of the BMT inclusion proof of a segment of a
+// full chunk, log2(bmt.DefaultSegmentCount).
+const storageProofLength = 7
+
+// verifyStorageProof returns whether proof proves the challenged segment of a
+// full swarm chunk, whose address is Keccak256(span | BMT root).
+func verifyStorageProof(challenge types.StorageChallenge, proof *types.StorageProof) bool {
+	if proof == nil || len(proof.Span) != 8 || len(proof.Proof) != storageProofLength {
+		return false
+	}
+	siblings := make([][]byte, len(proof.Proof))
+	for i, sibling := range proof.Proof {
+		siblings[i] = sibling
+	}
+	root := bmt.ProofRoot(sha3.NewKeccak256, proof.Segment, int(challenge.Segment), siblings)
+	if root == nil {
+		return false
+	}
+	return crypto.Keccak256Hash(proof.Span, root) == challenge.Root
+}
+
+// CheckBucketUsageTx checks a report of the bytes stored in a bucket, which can
+// be sent by the official account and the heft reporters.
+func CheckBucketUsageTx(caller common.Address, s types.SpecialTxInput, state StateDB, genaroConfig *params.GenaroConfig) error {
+	oracle := state.GetHeftOracle()
+	if caller != common.HexToAddress(genaroConfig.OfficialAddress) && !oracle.IsReporter(caller) {
+		return ErrInvalidCaller
+	}
+	if s.Address == "" {
+		return ErrAddressMissing
+	}
+	if s.BucketID == "" {
+		return ErrBucketIdMissing
+	}
+	buckets, _ := state.GetBuckets(common.HexToAddress(s.Address))
+	b, ok := buckets[s.BucketID]
+	if !ok {
+		return ErrBucketNotFound
+	}
+	bucket := b.(types.BucketPropertie)
+	if s.Size > bucket.Size*common.BucketSizeUnit {
+		return ErrBucketUsageExceeded
 	}
 	return nil
 }
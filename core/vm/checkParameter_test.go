@@ -0,0 +1,33 @@
+package vm
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/GenaroNetwork/GenaroCore/common"
+	"github.com/GenaroNetwork/GenaroCore/params"
+)
+
+func TestIsSpecialAddressForks(t *testing.T) {
+	config := &params.GenaroConfig{HeftOracleBlock: big.NewInt(10), StorageProofBlock: big.NewInt(20), MortgageBlock: big.NewInt(30)}
+
+	tests := []struct {
+		address common.Address
+		number  int64
+		special bool
+	}{
+		{common.CandidateSaveAddress, 0, true},
+		{common.HexToAddress("0x1000000000000000000000000000000000000001"), 10, false},
+		{common.HeftOracleSaveAddress, 9, false},
+		{common.HeftOracleSaveAddress, 10, true},
+		{common.StorageChallengeSaveAddress, 19, false},
+		{common.StorageChallengeSaveAddress, 20, true},
+		{common.MortgageSaveAddress, 29, false},
+		{common.MortgageSaveAddress, 30, true},
+	}
+	for i, test := range tests {
+		if special := isSpecialAddress(test.address, config, big.NewInt(test.number)); special != test.special {
+			t.Errorf("test %d: %x at block %d: have special %v, want %v", i, test.address, test.number, special, test.special)
+		}
+	}
+}
@@ -26,3 +26,222 @@ var (
 	ErrInsufficientBalance      = errors.New("insufficient balance for transfer")
 	ErrContractAddressCollision = errors.New("contract address collision")
 )
+
+// SpecialTxError is an error raised while checking or applying a special
+// transaction. Code identifies the failure independently of the message, it is
+// recorded in the receipt and returned by the RPC so clients can tell why a
+// special transaction was rejected or failed.
+type SpecialTxError struct {
+	Code    uint64
+	Message string
+}
+
+func newSpecialTxError(code uint64, message string) *SpecialTxError {
+	return &SpecialTxError{Code: code, Message: message}
+}
+
+func (e *SpecialTxError) Error() string {
+	return e.Message
+}
+
+// SpecialTxErrorCode returns the code of a special transaction error, or zero if
+// err did not originate from a special transaction.
+func SpecialTxErrorCode(err error) uint64 {
+	if e, ok := err.(*SpecialTxError); ok {
+		return e.Code
+	}
+	return 0
+}
+
+// Error codes of special transactions. Codes are part of the RPC interface and
+// must never be reused or renumbered.
+var (
+	// ErrSpecialTxFailed wraps failures that have no dedicated code.
+	ErrSpecialTxFailed = newSpecialTxError(1, "special transaction failed")
+
+	// Generic special transaction errors
+	ErrSpecialTxInvalidInput        = newSpecialTxError(100, "special tx error： the extraData parameters of the wrong format")
+	ErrSpecialTxUndefinedType       = newSpecialTxError(101, "undefined type of special transaction")
+	ErrInvalidCaller                = newSpecialTxError(102, "caller address of this transaction is not invalid")
+	ErrAccountIsContract            = newSpecialTxError(103, "Account is Contract")
+	ErrAddressMissing               = newSpecialTxError(104, "param [address] missing or can't be null string")
+	ErrSpecialAddress               = newSpecialTxError(105, "param [address] can't be special address")
+	ErrAddressNotCaller             = newSpecialTxError(106, "address in param is not equal with callerAddress of this Tx")
+	ErrSpecialTxInsufficientBalance = newSpecialTxError(107, "Insufficient balance")
+	ErrSpecialTxTypeMissing         = newSpecialTxError(108, "special tx error: miss param [type]")
+	ErrBatchEmpty                   = newSpecialTxError(109, "param [batch] missing or empty")
+	ErrBatchTooLong                 = newSpecialTxError(110, "param [batch] has too many steps")
+	ErrBatchNested                  = newSpecialTxError(111, "a batch can't contain another batch")
+	ErrSponsoredSig                 = newSpecialTxError(112, "invalid signature of the sponsored transaction")
+	ErrSponsoredNonce               = newSpecialTxError(113, "nonce of the sponsored transaction mismatch")
+	ErrSponsoredNested              = newSpecialTxError(114, "a sponsored transaction can't sponsor another one")
+
+	// Stake, heft, node and punishment errors
+	ErrStakeTooSmall          = newSpecialTxError(200, "value of stake must larger than MinStake")
+	ErrStakeMissing           = newSpecialTxError(201, "param [stake] missing or must be larger than zero")
+	ErrAccountInBackStakeList = newSpecialTxError(202, "account in back stake list")
+	ErrHeftTooSmall           = newSpecialTxError(203, "value of heft must larger than zero")
+	ErrNodeIdMissing          = newSpecialTxError(204, "param [nodeId] missing")
+	ErrSignMissing            = newSpecialTxError(205, "param [sign] missing")
+	ErrNodeIdEmpty            = newSpecialTxError(206, "length of nodeId must larger then 0")
+	ErrNodeAlreadyBound       = newSpecialTxError(207, "the input node have been bound by themselves or others")
+	ErrSignWithoutPrefix      = newSpecialTxError(208, "sign without 0x prefix")
+	ErrSignRecover            = newSpecialTxError(209, "ECRecover error when valid sign")
+	ErrSignNodeIdMismatch     = newSpecialTxError(210, "sign valid error, nodeId mismatch")
+	ErrStakeNotEnoughForNode  = newSpecialTxError(211, "none enough stake to synchronize node")
+	ErrNoNodeToUnbind         = newSpecialTxError(212, "none node of this account need to unbind")
+	ErrNodeIdNull             = newSpecialTxError(213, "param [nodeId] is null or missing")
+	ErrNodeNotOwned           = newSpecialTxError(214, "this node does not belong to this account")
+	ErrUpdateHeft             = newSpecialTxError(215, "update user's heft fail")
+	ErrUpdateStake            = newSpecialTxError(216, "update sentinel's stake fail")
+	ErrAddCandidate           = newSpecialTxError(217, "add candidate fail")
+	ErrDeleteStake            = newSpecialTxError(218, "delete user's stake fail")
+	ErrHeftReporterExists     = newSpecialTxError(219, "account is already a heft reporter")
+	ErrNotHeftReporter        = newSpecialTxError(220, "account is not a heft reporter")
+	ErrHeftOracleActive       = newSpecialTxError(221, "heft is reported by the heft reporters")
+
+	// Bucket and traffic errors
+	ErrBucketIdLength      = newSpecialTxError(300, "length of bucketId must be 64")
+	ErrBucketTimeMissing   = newSpecialTxError(301, "param [timeEnd/timeStart] missing or can't be zero")
+	ErrBucketTimeOrder     = newSpecialTxError(302, "param timeEnd must be larger than param TimeStart")
+	ErrBucketBackupMissing = newSpecialTxError(303, "param [backup] missing or can't be zero")
+	ErrBucketSizeMissing   = newSpecialTxError(304, "param [size] missing or can't be zero")
+	ErrBucketIdExists      = newSpecialTxError(305, "param [bucketId] already exists")
+	ErrBucketIdMissing     = newSpecialTxError(306, "param [bucketId] missing or can't be null string")
+	ErrSupplementMissing   = newSpecialTxError(307, "param [size / duration] missing or must be larger than zero")
+	ErrBucketNotFound      = newSpecialTxError(308, "the user does not have the bucket corresponding to the bucketId")
+	ErrBucketExpired       = newSpecialTxError(309, "the bucket corresponding to the bucketId has has been expired")
+	ErrTrafficMissing      = newSpecialTxError(310, "param [traffic] missing or must larger than zero")
+	ErrBucketEndTime       = newSpecialTxError(311, "endTime must larger then startTime")
+	ErrUpdateBucket        = newSpecialTxError(312, "update user's bucket fail")
+	ErrUpdateTraffic       = newSpecialTxError(313, "update user's teraffic fail")
+	ErrTimestampMissing    = newSpecialTxError(314, "param [ msg ] missing or can't be null")
+	ErrTimestampInvalid    = newSpecialTxError(315, "param [ msg ] is not timestamp")
+	ErrBucketUsageExceeded = newSpecialTxError(316, "param [size] exceeds the size of the bucket")
+
+	// File share and mortgage errors
+	ErrSidechainFromAccount         = newSpecialTxError(400, "fromAccount error")
+	ErrDataversion                  = newSpecialTxError(401, "Parameter Dataversion  error")
+	ErrSidechainFileID              = newSpecialTxError(402, "Parameter fileID  error")
+	ErrSidechainFromAccountParam    = newSpecialTxError(403, "Parameter fromAccount  error")
+	ErrMortgageAccount              = newSpecialTxError(404, "Parameter mortgage account  error")
+	ErrSidechain                    = newSpecialTxError(405, "Parameter Sidechain")
+	ErrSidechainLength              = newSpecialTxError(406, "Parameter side chain length less than zero")
+	ErrMortgageTime                 = newSpecialTxError(407, "Parameter CreateTime or EndTime  error")
+	ErrMortgageFromAccount          = newSpecialTxError(408, "Parameter FromAccount  error")
+	ErrMortgageFileID               = newSpecialTxError(409, "Parameter FileID  error")
+	ErrAuthorityTableMismatch       = newSpecialTxError(410, "Parameter authorityTable != mortgageTable  error")
+	ErrAuthorityType                = newSpecialTxError(411, "Parameter authority type  error")
+	ErrMortgageAmount               = newSpecialTxError(412, "Parameter mortgage amount is less than zero")
+	ErrShareKeyId                   = newSpecialTxError(413, "Parameter ShareKeyId  error")
+	ErrShareKey                     = newSpecialTxError(414, "Parameter ShareKey  error")
+	ErrSharePrice                   = newSpecialTxError(415, "Parameter Shareprice  is less than zero")
+	ErrMailHash                     = newSpecialTxError(416, "Parameter MailHash  error")
+	ErrFileSharePublicKeyMissing    = newSpecialTxError(417, "public key for file share can't be null")
+	ErrUpdateSynchronizeShareKey    = newSpecialTxError(418, "update  chain SynchronizeShareKey fail")
+	ErrUpdateUnlockSharedKey        = newSpecialTxError(419, "update  chain UnlockSharedKey fail")
+	ErrUpdateFileSharePublicKey     = newSpecialTxError(420, "update user's public key fail")
+	ErrUpdateCrossChainMortgageInit = newSpecialTxError(421, "update cross chain SpecialTxTypeMortgageInit fail")
+	ErrUpdateMortgageInit           = newSpecialTxError(422, "update  chain SpecialTxTypeMortgageInit fail")
+	ErrShareKeyExists               = newSpecialTxError(423, "share key with this id is already offered to the recipient")
+	ErrShareKeyNotFound             = newSpecialTxError(424, "share key not found")
+	ErrShareKeyNotPending           = newSpecialTxError(425, "share key is not pending")
+	ErrShareKeyExpired              = newSpecialTxError(426, "share key has expired")
+	ErrShareKeyNotSharer            = newSpecialTxError(427, "share key was not offered by the caller")
+	ErrShareKeyExpireBlock          = newSpecialTxError(428, "param [expireBlock] must be larger than current block number")
+	ErrPublicKeyVersionNotFound     = newSpecialTxError(429, "public key version for file share not found")
+	ErrPublicKeyVersionRevoked      = newSpecialTxError(430, "public key version for file share is revoked")
+	ErrMortgageExists               = newSpecialTxError(431, "mortgage for this file already exists")
+	ErrMortgageNotFound             = newSpecialTxError(432, "mortgage not found")
+	ErrMortgageSettled              = newSpecialTxError(433, "mortgage is already settled")
+	ErrMortgageExpired              = newSpecialTxError(434, "mortgage has expired, only a terminating sync is allowed")
+	ErrMortgageNotExpired           = newSpecialTxError(435, "mortgage has not expired yet")
+	ErrSidechainAccount             = newSpecialTxError(436, "sidechain account is not in the mortgage table")
+	ErrMortgageTableSize            = newSpecialTxError(437, "param [mortgage] must have between 1 and 8 accounts")
+	ErrShareKeyCommitted            = newSpecialTxError(438, "param [shareKey], [mail_hash] and [mail_size] must be empty for a committed share key")
+	ErrShareKeyCommitment           = newSpecialTxError(439, "param [commitment] does not match the committed share key")
+
+	// Back stake, binding and forbid list errors
+	ErrBackStake                    = newSpecialTxError(500, "userBackStake fail")
+	ErrBackStakeListTooLong         = newSpecialTxError(501, "BackStackList too long")
+	ErrAccountIsBinding             = newSpecialTxError(502, "account is binding")
+	ErrAccountInForbidBackStakeList = newSpecialTxError(503, "account in forbid backstake list")
+	ErrSameAccount                  = newSpecialTxError(504, "same account")
+	ErrMainAccountNotCandidate      = newSpecialTxError(505, "mainAddr is not a candidate")
+	ErrBindingEnough                = newSpecialTxError(506, "binding enough")
+	ErrSubAccountIsMain             = newSpecialTxError(507, "sub account is a main account")
+	ErrSubAccountNotCandidate       = newSpecialTxError(508, "subAddr is not a candidate")
+	ErrHasBinding                   = newSpecialTxError(509, "has binding")
+	ErrNotBindingAccount            = newSpecialTxError(510, "not binding account")
+	ErrAccountStakeZero             = newSpecialTxError(511, "account stake is zero")
+	ErrAccountInForbidList          = newSpecialTxError(512, "account is in forbid list")
+	ErrAccountNotInForbidList       = newSpecialTxError(513, "account is not in forbid list")
+	ErrBinding                      = newSpecialTxError(514, "binding failed")
+	ErrDelCandidate                 = newSpecialTxError(515, "DelCandidate failed")
+	ErrCancelBinding                = newSpecialTxError(516, "Account Cancel Binding failed")
+	ErrAddForbidBackStake           = newSpecialTxError(517, "Add Account In Forbid BackStake List failed")
+	ErrDelForbidBackStake           = newSpecialTxError(518, "Delete Account In Forbid BackStake List failed")
+
+	// Price, global variable and sync state errors
+	ErrNonePrice                  = newSpecialTxError(600, "none price to update")
+	ErrRatio                      = newSpecialTxError(601, "Ratio is not invalid")
+	ErrCoinpoolValue              = newSpecialTxError(602, "Value is not invalid")
+	ErrCoinpoolBalance            = newSpecialTxError(603, "Balance is not enough")
+	ErrUpdateStakePerNodePrice    = newSpecialTxError(604, "update the price of stakePerNode fail")
+	ErrUpdateBucketApplyPrice     = newSpecialTxError(605, "update the price of bucketApply fail")
+	ErrUpdateTrafficApplyPrice    = newSpecialTxError(606, "update the price of trafficApply fail")
+	ErrUpdateOneDayGesCost        = newSpecialTxError(607, "update the price of OneDayGesCost fail")
+	ErrUpdateOneDaySyncLogGsaCost = newSpecialTxError(608, "update the price of OneDaySyncLogGsaCost fail")
+	ErrAddCoinpool                = newSpecialTxError(609, "addCoinpool fail")
+	ErrSetGlobalVar               = newSpecialTxError(610, "setGlobalVar fail")
+	ErrSynState                   = newSpecialTxError(611, "SynState fail")
+
+	// Name errors
+	ErrNameNull     = newSpecialTxError(700, "name is null")
+	ErrNameTooLong  = newSpecialTxError(701, "name is too long")
+	ErrNameExists   = newSpecialTxError(702, "name is exist")
+	ErrNameBalance  = newSpecialTxError(703, "There is not enough balance")
+	ErrNameNotOwned = newSpecialTxError(704, "name is not belong to you")
+
+	// Promissory note and option errors
+	ErrOrderIdMissing               = newSpecialTxError(800, "param [OrderId] Missing")
+	ErrOptionTxNotFound             = newSpecialTxError(801, "None promissory note tx with this hash")
+	ErrOptionNotOwned               = newSpecialTxError(802, "You can't revoke someone else's options trading，check the order id")
+	ErrOptionPurchased              = newSpecialTxError(803, "You can't revoke this options trading, current options have been purchased")
+	ErrRestoreBlockMissing          = newSpecialTxError(804, "param [restoreBlock] must be larger than zero")
+	ErrRestoreBlockPassed           = newSpecialTxError(805, "param [restoreBlock] must be larger than current block number")
+	ErrTxNumMissing                 = newSpecialTxError(806, "param [txNum] must be larger than zero")
+	ErrPromissoryNoteTxPriceMissing = newSpecialTxError(807, "param [PromissoryNoteTxPrice] Missing")
+	ErrOptionPriceMissing           = newSpecialTxError(808, "param [OptionPrice] Missing")
+	ErrPromissoryNotesNotEnough     = newSpecialTxError(809, "None enough promissory notes to sell")
+	ErrNoBuyPermission              = newSpecialTxError(810, "Go to permission to buy promissory None")
+	ErrNoTurnBuyPermission          = newSpecialTxError(811, "No right turn buy promissoryNotes")
+	ErrNoCashableNotes              = newSpecialTxError(812, "The number of cashable notes available is 0")
+	ErrWithdrawCash                 = newSpecialTxError(813, "WithdrawCash error")
+
+	// Profit and shadow account errors
+	ErrSetProfitAccount = newSpecialTxError(900, "Set Profit Account failed")
+	ErrSetShadowAccount = newSpecialTxError(901, "Set Shadow Account failed")
+
+	// Vesting errors
+	ErrVestingInvalid      = newSpecialTxError(1000, "param [vesting] missing or invalid, total must be larger than zero and start <= cliff <= end, start < end")
+	ErrVestingExists       = newSpecialTxError(1001, "account already has a vesting")
+	ErrVestingNotFound     = newSpecialTxError(1002, "account has no vesting")
+	ErrNothingVested       = newSpecialTxError(1003, "nothing vested to withdraw")
+	ErrVestingInsufficient = newSpecialTxError(1004, "not enough GNX locked in the vesting")
+	ErrVestingTooSmall     = newSpecialTxError(1005, "vesting total below the minimum")
+
+	// Storage challenge errors
+	ErrStorageRootMissing       = newSpecialTxError(1100, "param [storageRoot] missing or can't be zero")
+	ErrStorageRootCommitted     = newSpecialTxError(1101, "chunk already committed by the node")
+	ErrStorageRootsFull         = newSpecialTxError(1102, "the node committed to too many chunks")
+	ErrStorageRootNotCommitted  = newSpecialTxError(1103, "chunk not committed by the node")
+	ErrNodeNotBound             = newSpecialTxError(1104, "the node is not bound to any account")
+	ErrStorageChallengePending  = newSpecialTxError(1105, "the node has a pending storage challenge")
+	ErrStorageChallengeNotFound = newSpecialTxError(1106, "the node has no pending storage challenge")
+	ErrStorageChallengeExpired  = newSpecialTxError(1107, "the deadline of the storage challenge has passed")
+	ErrStorageProofInvalid      = newSpecialTxError(1108, "param [storageProof] missing or does not prove the challenged segment")
+	ErrBucketRootCommitted      = newSpecialTxError(1109, "chunk already committed to the bucket")
+	ErrBucketRootsFull          = newSpecialTxError(1110, "the bucket has too many committed chunks")
+	ErrBucketRootNotCommitted   = newSpecialTxError(1111, "chunk not committed to the bucket by its owner")
+)
@@ -0,0 +1,35 @@
+package vm
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/GenaroNetwork/GenaroCore/common"
+	"github.com/GenaroNetwork/GenaroCore/core/state"
+	"github.com/GenaroNetwork/GenaroCore/ethdb"
+	"github.com/GenaroNetwork/GenaroCore/params"
+)
+
+func TestDispatchHandlerErrorCode(t *testing.T) {
+	db, _ := state.New(common.Hash{}, state.NewDatabase(ethdb.NewMemDatabase()))
+	evm := NewEVM(Context{}, db, &params.ChainConfig{Genaro: &params.GenaroConfig{}}, Config{})
+	caller := common.HexToAddress("0x1000000000000000000000000000000000000001")
+
+	tests := []struct {
+		input string
+		code  uint64
+	}{
+		{`not json`, ErrSpecialTxInvalidInput.Code},
+		{`{"type":"0x3e7"}`, ErrSpecialTxUndefinedType.Code},
+		{`{"type":"0x2"}`, ErrInvalidCaller.Code},
+	}
+	for i, test := range tests {
+		err := dispatchHandler(evm, caller, []byte(test.input))
+		if code := SpecialTxErrorCode(err); code != test.code {
+			t.Errorf("test %d: error code mismatch: have %d (%v), want %d", i, code, err, test.code)
+		}
+	}
+	if code := SpecialTxErrorCode(errors.New("plain")); code != 0 {
+		t.Errorf("plain error has code %d", code)
+	}
+}
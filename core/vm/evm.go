@@ -17,13 +17,16 @@
 package vm
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"math/big"
+	"sort"
 	"sync/atomic"
 	"time"
 
 	"fmt"
+	"github.com/GenaroNetwork/GenaroCore/bmt"
 	"github.com/GenaroNetwork/GenaroCore/common"
 	"github.com/GenaroNetwork/GenaroCore/core/types"
 	"github.com/GenaroNetwork/GenaroCore/crypto"
@@ -115,6 +118,9 @@ type EVM struct {
 	// available gas is calculated in gasCall* according to the 63/64 rule and later
 	// applied in opCall*.
 	callGasTemp uint64
+	// specialLogs holds the logs of the special transaction being applied until
+	// its handler succeeds.
+	specialLogs []*types.Log
 }
 
 // NewEVM returns a new EVM. The returned EVM is not thread safe and should
@@ -127,6 +133,11 @@ func NewEVM(ctx Context, statedb StateDB, chainConfig *params.ChainConfig, vmCon
 		chainConfig: chainConfig,
 		chainRules:  chainConfig.Rules(ctx.BlockNumber),
 	}
+	// Failing calls only revert the Genaro data from the batch and sponsored
+	// forks on.
+	if statedb != nil && chainConfig.Genaro != nil {
+		statedb.SetGenaroJournal(chainConfig.Genaro.IsBatch(ctx.BlockNumber) || chainConfig.Genaro.IsSponsored(ctx.BlockNumber))
+	}
 
 	evm.interpreter = NewInterpreter(evm, vmConfig)
 	return evm
@@ -222,8 +233,28 @@ func dispatchHandler(evm *EVM, caller common.Address, input []byte) error {
 	var s types.SpecialTxInput
 	err = json.Unmarshal(input, &s)
 	if err != nil {
-		return errors.New("special tx error： the extraData parameters of the wrong format")
+		return ErrSpecialTxInvalidInput
+	}
+	evm.specialLogs = nil
+	err = dispatchSpecialTx(evm, s, caller)
+	if err == nil {
+		flushSpecialTxLogs(evm)
+	}
+
+	if err != nil && common.SpecialTxSynState.Uint64() != s.Type.ToInt().Uint64() {
+		log.Info(fmt.Sprintf("special transaction error: %s", err))
+		log.Info(fmt.Sprintf("special transaction param：%s", string(input)))
+	}
+	if _, ok := err.(*SpecialTxError); err != nil && !ok {
+		err = &SpecialTxError{Code: ErrSpecialTxFailed.Code, Message: err.Error()}
 	}
+	return err
+}
+
+// dispatchSpecialTx applies the special transaction s sent by caller according
+// to its type.
+func dispatchSpecialTx(evm *EVM, s types.SpecialTxInput, caller common.Address) error {
+	var err error
 	switch s.Type.ToInt().Uint64() {
 	case common.SpecialTxTypeStakeSync.Uint64():
 		err = updateStake(evm, s, caller)
@@ -233,12 +264,44 @@ func dispatchHandler(evm *EVM, caller common.Address, input []byte) error {
 		err = updateStorageProperties(evm, s, caller)
 	case common.SpecialTxBucketSupplement.Uint64():
 		err = bucketSupplement(evm, s, caller)
-	//case common.SpecialTxTypeMortgageInit.Uint64():
-	//	err = specialTxTypeMortgageInit(evm, s, caller)
-	//case common.SpecialTxTypeSyncSidechainStatus.Uint64():
-	//	err = SpecialTxTypeSyncSidechainStatus(evm, s, caller)
+	case common.SpecialTxTypeMortgageInit.Uint64():
+		if evm.chainConfig.Genaro.IsMortgage(evm.BlockNumber) {
+			err = initMortgage(evm, s, caller)
+		} else {
+			err = ErrSpecialTxUndefinedType
+		}
+	case common.SpecialTxTypeMortgageTerminate.Uint64():
+		if evm.chainConfig.Genaro.IsMortgage(evm.BlockNumber) {
+			err = terminateMortgage(evm, s, caller)
+		} else {
+			err = ErrSpecialTxUndefinedType
+		}
+	case common.SpecialTxTypeSyncSidechainStatus.Uint64():
+		if evm.chainConfig.Genaro.IsMortgage(evm.BlockNumber) {
+			err = syncSidechainStatus(evm, s, caller)
+		} else {
+			err = ErrSpecialTxUndefinedType
+		}
 	case common.SpecialTxTypeTrafficApply.Uint64():
 		err = updateTraffic(evm, s, caller)
+	case common.SpecialTxAddHeftReporter.Uint64():
+		if evm.chainConfig.Genaro.IsHeftOracle(evm.BlockNumber) {
+			err = addHeftReporter(evm, s, caller)
+		} else {
+			err = ErrSpecialTxUndefinedType
+		}
+	case common.SpecialTxDelHeftReporter.Uint64():
+		if evm.chainConfig.Genaro.IsHeftOracle(evm.BlockNumber) {
+			err = delHeftReporter(evm, s, caller)
+		} else {
+			err = ErrSpecialTxUndefinedType
+		}
+	case common.SpecialTxReportHeft.Uint64():
+		if evm.chainConfig.Genaro.IsHeftOracle(evm.BlockNumber) {
+			err = reportHeft(evm, s, caller)
+		} else {
+			err = ErrSpecialTxUndefinedType
+		}
 	case common.SpecialTxTypeSyncNode.Uint64():
 		err = updateStakeNode(evm, s, caller)
 	case common.SynchronizeShareKey.Uint64():
@@ -247,6 +310,24 @@ func dispatchHandler(evm *EVM, caller common.Address, input []byte) error {
 		err = updateFileShareSecretKey(evm, s, caller)
 	case common.UnlockSharedKey.Uint64():
 		err = UnlockSharedKey(evm, s, caller)
+	case common.SpecialTxCancelShareKey.Uint64():
+		if evm.chainConfig.Genaro.IsShareKey(evm.BlockNumber) {
+			err = cancelShareKey(evm, s, caller)
+		} else {
+			err = ErrSpecialTxUndefinedType
+		}
+	case common.SpecialTxRejectShareKey.Uint64():
+		if evm.chainConfig.Genaro.IsShareKey(evm.BlockNumber) {
+			err = rejectShareKey(evm, s, caller)
+		} else {
+			err = ErrSpecialTxUndefinedType
+		}
+	case common.SpecialTxRevokeFileSharePublicKey.Uint64():
+		if evm.chainConfig.Genaro.IsFileShareKey(evm.BlockNumber) {
+			err = revokeFileSharePublicKey(evm, s, caller)
+		} else {
+			err = ErrSpecialTxUndefinedType
+		}
 	case common.SpecialTxTypePunishment.Uint64():
 		err = userPunishment(evm, s, caller)
 	case common.SpecialTxTypeBackStake.Uint64():
@@ -293,13 +374,56 @@ func dispatchHandler(evm *EVM, caller common.Address, input []byte) error {
 		err = setProfitAccount(evm, s, caller)
 	case common.SpecialTxSetShadowAccount.Uint64(): 
 		err = setShadowAccount(evm, s, caller)
+	case common.SpecialTxBatch.Uint64():
+		if evm.chainConfig.Genaro.IsBatch(evm.BlockNumber) {
+			err = applyBatch(evm, s, caller)
+		} else {
+			err = ErrSpecialTxUndefinedType
+		}
+	case common.SpecialTxVestingCreate.Uint64():
+		if evm.chainConfig.Genaro.IsVesting(evm.BlockNumber) {
+			err = createVesting(evm, s, caller)
+		} else {
+			err = ErrSpecialTxUndefinedType
+		}
+	case common.SpecialTxVestingWithdraw.Uint64():
+		if evm.chainConfig.Genaro.IsVesting(evm.BlockNumber) {
+			err = withdrawVesting(evm, caller)
+		} else {
+			err = ErrSpecialTxUndefinedType
+		}
+	case common.SpecialTxStorageCommit.Uint64():
+		if evm.chainConfig.Genaro.IsStorageProof(evm.BlockNumber) {
+			err = commitStorageRoot(evm, s, caller)
+		} else {
+			err = ErrSpecialTxUndefinedType
+		}
+	case common.SpecialTxBucketRootCommit.Uint64():
+		if evm.chainConfig.Genaro.IsStorageProof(evm.BlockNumber) {
+			err = commitBucketRoot(evm, s, caller)
+		} else {
+			err = ErrSpecialTxUndefinedType
+		}
+	case common.SpecialTxStorageChallenge.Uint64():
+		if evm.chainConfig.Genaro.IsStorageProof(evm.BlockNumber) {
+			err = challengeStorage(evm, s, caller)
+		} else {
+			err = ErrSpecialTxUndefinedType
+		}
+	case common.SpecialTxStorageProof.Uint64():
+		if evm.chainConfig.Genaro.IsStorageProof(evm.BlockNumber) {
+			err = proveStorage(evm, s, caller)
+		} else {
+			err = ErrSpecialTxUndefinedType
+		}
+	case common.SpecialTxBucketUsage.Uint64():
+		if evm.chainConfig.Genaro.IsBucketUsage(evm.BlockNumber) {
+			err = reportBucketUsage(evm, s, caller)
+		} else {
+			err = ErrSpecialTxUndefinedType
+		}
 	default:
-		err = errors.New("undefined type of special transaction")
-	}
-
-	if err != nil && common.SpecialTxSynState.Uint64() != s.Type.ToInt().Uint64() {
-		log.Info(fmt.Sprintf("special transaction error: %s", err))
-		log.Info(fmt.Sprintf("special transaction param：%s", string(input)))
+		err = ErrSpecialTxUndefinedType
 	}
 	return err
 }
@@ -311,8 +435,9 @@ func setShadowAccount(evm *EVM, s types.SpecialTxInput, caller common.Address) e
 	shadowAccount := common.HexToAddress(s.Address)
 	ok := (*evm).StateDB.SetShadowAccount(caller, shadowAccount)
 	if !ok {
-		return errors.New("Set Shadow Account failed")
+		return ErrSetShadowAccount
 	}
+	addSpecialTxLog(evm, "ShadowAccountSet", []common.Hash{addressTopic(caller), addressTopic(shadowAccount)})
 	return nil
 }
 
@@ -323,8 +448,9 @@ func setProfitAccount(evm *EVM, s types.SpecialTxInput, caller common.Address) e
 	profitAccount := common.HexToAddress(s.Address)
 	ok := (*evm).StateDB.SetProfitAccount(caller, profitAccount)
 	if !ok {
-		return errors.New("Set Profit Account failed")
+		return ErrSetProfitAccount
 	}
+	addSpecialTxLog(evm, "ProfitAccountSet", []common.Hash{addressTopic(caller), addressTopic(profitAccount)})
 	return nil
 }
 
@@ -346,6 +472,7 @@ func registerName(evm *EVM, s types.SpecialTxInput, caller common.Address) error
 	OfficialAddress := common.HexToAddress(evm.chainConfig.Genaro.OfficialAddress)
 	(*evm).StateDB.AddBalance(OfficialAddress, priceBig)
 
+	addSpecialTxLog(evm, "NameRegister", []common.Hash{addressTopic(caller), name.ToHash()}, priceBig)
 	return nil
 }
 
@@ -360,6 +487,9 @@ func transferNameTxStatus(evm *EVM, s types.SpecialTxInput, caller common.Addres
 		return err
 	}
 
+	var name types.AccountName
+	name.SetString(s.Message)
+	addSpecialTxLog(evm, "NameTransfer", []common.Hash{addressTopic(caller), addressTopic(transferTarget), name.ToHash()})
 	return nil
 }
 
@@ -373,6 +503,9 @@ func unsubscribeNameTxStatus(evm *EVM, s types.SpecialTxInput, caller common.Add
 		return err
 	}
 
+	var name types.AccountName
+	name.SetString(s.Message)
+	addSpecialTxLog(evm, "NameUnsubscribe", []common.Hash{addressTopic(caller), name.ToHash()})
 	return nil
 }
 
@@ -385,6 +518,7 @@ func setOptionTxStatus(evm *EVM, s types.SpecialTxInput, caller common.Address)
 
 	(*evm).StateDB.SetTxStatusInOptionTxTable(s.OrderId, s.IsSell, optionTxMemorySize)
 
+	addSpecialTxLog(evm, "OptionTxStatusSet", []common.Hash{addressTopic(caller), s.OrderId}, s.IsSell)
 	return nil
 }
 
@@ -408,6 +542,8 @@ func publishOption(evm *EVM, s types.SpecialTxInput, caller common.Address) erro
 		promissoryNotesOptionTx.PromissoryNoteTxPrice = s.PromissoryNoteTxPrice.ToInt()
 		promissoryNotesOptionTx.OptionPrice = s.OptionPrice.ToInt()
 		(*evm).StateDB.AddTxInOptionTxTable(optionHash, promissoryNotesOptionTx, optionTxMemorySize)
+		addSpecialTxLog(evm, "OptionPublish", []common.Hash{addressTopic(caller), optionHash},
+			s.RestoreBlock, s.TxNum, promissoryNotesOptionTx.PromissoryNoteTxPrice, promissoryNotesOptionTx.OptionPrice)
 	}
 
 	return nil
@@ -428,6 +564,7 @@ func revokePromissoryNotesTx(evm *EVM, s types.SpecialTxInput, caller common.Add
 		promissoryNote.Num = promissoryNotesOptionTx.TxNum
 		promissoryNote.RestoreBlock = promissoryNotesOptionTx.RestoreBlock
 		(*evm).StateDB.AddPromissoryNote(caller, promissoryNote)
+		addSpecialTxLog(evm, "PromissoryNotesRevoke", []common.Hash{addressTopic(caller), s.OrderId})
 	}
 
 	return nil
@@ -440,8 +577,9 @@ func delAccountInForbidBackStakeList(evm *EVM, s types.SpecialTxInput, caller co
 	account := common.HexToAddress(s.Address)
 	ok := (*evm).StateDB.DelAccountInForbidBackStakeList(account)
 	if !ok {
-		return errors.New("Delete Account In Forbid BackStake List failed")
+		return ErrDelForbidBackStake
 	}
+	addSpecialTxLog(evm, "ForbidBackStakeDel", []common.Hash{addressTopic(account)})
 	return nil
 }
 
@@ -452,8 +590,9 @@ func addAccountInForbidBackStakeList(evm *EVM, s types.SpecialTxInput, caller co
 	account := common.HexToAddress(s.Address)
 	ok := (*evm).StateDB.AddAccountInForbidBackStakeList(account)
 	if !ok {
-		return errors.New("Add Account In Forbid BackStake List failed")
+		return ErrAddForbidBackStake
 	}
+	addSpecialTxLog(evm, "ForbidBackStakeAdd", []common.Hash{addressTopic(account)})
 	return nil
 }
 
@@ -469,6 +608,10 @@ func unbindNode(evm *EVM, s types.SpecialTxInput, caller common.Address) error {
 	if err == nil {
 		node2UserAccountIndexAddress := common.StakeNode2StakeAddress
 		(*evm).StateDB.UbindNode2Address(node2UserAccountIndexAddress, s.NodeID)
+		if evm.chainConfig.Genaro.IsStorageProof(evm.BlockNumber) {
+			(*evm).StateDB.DropStorageNode(s.NodeID)
+		}
+		addSpecialTxLog(evm, "NodeUnbind", []common.Hash{addressTopic(caller)}, s.NodeID)
 	}
 
 	return nil
@@ -483,13 +626,14 @@ func accountBinding(evm *EVM, s types.SpecialTxInput, caller common.Address) err
 	mainAddr := common.HexToAddress(s.Address)
 	subAddr := common.HexToAddress(s.Message)
 	if !(*evm).StateDB.UpdateAccountBinding(mainAddr, subAddr) {
-		return errors.New("binding failed")
+		return ErrBinding
 	}
 
 	if !(*evm).StateDB.DelCandidate(subAddr) {
-		return errors.New("DelCandidate failed")
+		return ErrDelCandidate
 	}
 
+	addSpecialTxLog(evm, "AccountBinding", []common.Hash{addressTopic(mainAddr), addressTopic(subAddr)})
 	return nil
 }
 
@@ -504,20 +648,26 @@ func accountCancelBinding(evm *EVM, s types.SpecialTxInput, caller common.Addres
 		subAccounts := (*evm).StateDB.DelMainAccountBinding(caller)
 		for _, subAccount := range subAccounts {
 			(*evm).StateDB.AddCandidate(subAccount)
+			addSpecialTxLog(evm, "AccountCancelBinding", []common.Hash{addressTopic(caller), addressTopic(subAccount)})
 		}
 	case 2:
+		mainAccount := (*evm).StateDB.GetMainAccount(caller)
 		ok := (*evm).StateDB.DelSubAccountBinding(caller)
 		if ok {
 			(*evm).StateDB.AddCandidate(caller)
+			if mainAccount != nil {
+				addSpecialTxLog(evm, "AccountCancelBinding", []common.Hash{addressTopic(*mainAccount), addressTopic(caller)})
+			}
 		}
 	case 3:
 		subAddr := common.HexToAddress(s.Address)
 		ok := (*evm).StateDB.DelSubAccountBinding(subAddr)
 		if ok {
 			(*evm).StateDB.AddCandidate(subAddr)
+			addSpecialTxLog(evm, "AccountCancelBinding", []common.Hash{addressTopic(caller), addressTopic(subAddr)})
 		}
 	default:
-		return errors.New("Account Cancel Binding failed")
+		return ErrCancelBinding
 	}
 	return nil
 }
@@ -528,39 +678,40 @@ func genaroPriceRegulation(evm *EVM, s types.SpecialTxInput, caller common.Addre
 	}
 
 	if caller != common.GenaroPriceAddress {
-		return errors.New("caller address of this transaction is not invalid")
+		return ErrInvalidCaller
 	}
 
 	if s.StakeValuePerNode != nil {
 		if ok := (*evm).StateDB.UpdateStakePerNodePrice(caller, s.StakeValuePerNode); !ok {
-			return errors.New("update the price of stakePerNode fail")
+			return ErrUpdateStakePerNodePrice
 		}
 	}
 
 	if s.BucketApplyGasPerGPerDay != nil {
 		if ok := (*evm).StateDB.UpdateBucketApplyPrice(caller, s.BucketApplyGasPerGPerDay); !ok {
-			return errors.New("update the price of bucketApply fail")
+			return ErrUpdateBucketApplyPrice
 		}
 	}
 
 	if s.TrafficApplyGasPerG != nil {
 		if ok := (*evm).StateDB.UpdateTrafficApplyPrice(caller, s.TrafficApplyGasPerG); !ok {
-			return errors.New("update the price of trafficApply fail")
+			return ErrUpdateTrafficApplyPrice
 		}
 	}
 
 	if s.OneDayMortgageGes != nil {
 		if ok := (*evm).StateDB.UpdateOneDayGesCost(caller, s.OneDayMortgageGes); !ok {
-			return errors.New("update the price of OneDayGesCost fail")
+			return ErrUpdateOneDayGesCost
 		}
 	}
 
 	if s.OneDaySyncLogGsaCost != nil {
 		if ok := (*evm).StateDB.UpdateOneDaySyncLogGsaCost(caller, s.OneDaySyncLogGsaCost); !ok {
-			return errors.New("update the price of OneDaySyncLogGsaCost fail")
+			return ErrUpdateOneDaySyncLogGsaCost
 		}
 	}
 
+	addSpecialTxLog(evm, "PriceRegulation", []common.Hash{addressTopic(caller)})
 	return nil
 }
 
@@ -573,9 +724,10 @@ func addCoinpool(evm *EVM, s types.SpecialTxInput, caller common.Address) error
 	ok := (*evm).StateDB.SetRewardsValues(*rewardsValues)
 	if ok {
 		(*evm).StateDB.SubBalance(caller, s.AddCoin.ToInt())
+		addSpecialTxLog(evm, "CoinpoolAdd", []common.Hash{addressTopic(caller)}, s.AddCoin.ToInt())
 		return nil
 	}
-	return errors.New("addCoinpool fail")
+	return ErrAddCoinpool
 }
 
 func setGlobalVar(evm *EVM, s types.SpecialTxInput, caller common.Address) error {
@@ -618,8 +770,9 @@ func setGlobalVar(evm *EVM, s types.SpecialTxInput, caller common.Address) error
 
 	ok := (*evm).StateDB.SetGenaroPrice(*genaroPrice)
 	if !ok {
-		return errors.New("setGlobalVar fail")
+		return ErrSetGlobalVar
 	}
+	addSpecialTxLog(evm, "GlobalVarSet", []common.Hash{addressTopic(caller)})
 	return nil
 }
 
@@ -633,9 +786,10 @@ func SynState(evm *EVM, s types.SpecialTxInput, caller common.Address) error {
 	blockNum, ok := lastSynState.LastRootStates[blockHash]
 	if ok {
 		(*evm).StateDB.SetLastSynBlock(blockNum, blockHash)
+		addSpecialTxLog(evm, "SynState", []common.Hash{blockHash}, blockNum)
 		return nil
 	} else {
-		return errors.New("SynState fail")
+		return ErrSynState
 	}
 }
 
@@ -651,62 +805,178 @@ func userBackStake(evm *EVM, caller common.Address) error {
 	}
 	ok := (*evm).StateDB.AddAlreadyBackStack(backStake)
 	if !ok {
-		return errors.New("userBackStake fail")
+		return ErrBackStake
 	}
 	ok = (*evm).StateDB.DelCandidate(caller)
 	if !ok {
-		return errors.New("DelCandidate fail")
+		return ErrDelCandidate
 	}
+	addSpecialTxLog(evm, "BackStakeApply", []common.Hash{addressTopic(caller)}, backStake.BackBlockNumber)
 	return nil
 }
 
 func userPunishment(evm *EVM, s types.SpecialTxInput, caller common.Address) error {
 
-	if err := CheckPunishmentTx(caller, s, evm.StateDB, evm.chainConfig.Genaro); err != nil {
+	if err := CheckPunishmentTx(caller, s, evm.StateDB, evm.chainConfig.Genaro, evm.BlockNumber); err != nil {
 		return err
 	}
 	adress := common.HexToAddress(s.Address)
 	var actualPunishment uint64
 	var ok bool
 	if ok, actualPunishment = (*evm).StateDB.DeleteStake(adress, s.Stake, evm.BlockNumber.Uint64()); !ok {
-		return errors.New("delete user's stake fail")
+		return ErrDeleteStake
 	}
 	amount := new(big.Int).Mul(common.BaseCompany, new(big.Int).SetUint64(actualPunishment))
 	OfficialAddress := common.HexToAddress(evm.chainConfig.Genaro.OfficialAddress)
 	(*evm).StateDB.AddBalance(OfficialAddress, amount)
+	addSpecialTxLog(evm, "Punishment", []common.Hash{addressTopic(adress)}, actualPunishment)
 	return nil
 }
 
 func UnlockSharedKey(evm *EVM, s types.SpecialTxInput, caller common.Address) error {
+	if evm.chainConfig.Genaro.IsShareKey(evm.BlockNumber) {
+		return unlockShareKey(evm, s, caller)
+	}
 	if err := CheckUnlockSharedKeyParameter(s, (*evm).StateDB, caller); nil != err {
 		return err
 	}
 	if !(*evm).StateDB.UnlockSharedKey(caller, s.SynchronizeShareKey.ShareKeyId) {
-		return errors.New("update  chain UnlockSharedKey fail")
+		return ErrUpdateUnlockSharedKey
+	}
+	// GetSharedFile marks the share as unlocked, so only read it back afterwards
+	sharedFile := (*evm).StateDB.GetSharedFile(caller, s.SynchronizeShareKey.ShareKeyId)
+	price := new(big.Int)
+	if sharedFile.Shareprice != nil {
+		price.Set(sharedFile.Shareprice.ToInt())
+	}
+	addSpecialTxLog(evm, "ShareKeyUnlock", []common.Hash{addressTopic(caller), addressTopic(sharedFile.FromAccount)}, s.SynchronizeShareKey.ShareKeyId, price)
+	return nil
+}
+
+// unlockShareKey pays the sharer and unlocks the offer once
+// GenaroConfig.ShareKeyBlock is reached.
+func unlockShareKey(evm *EVM, s types.SpecialTxInput, caller common.Address) error {
+	if !evm.chainConfig.Genaro.IsShareCommit(evm.BlockNumber) {
+		s.SynchronizeShareKey.Commitment = nil
+	}
+	if err := CheckUnlockShareKeyTx(caller, s, evm.StateDB, evm.BlockNumber); err != nil {
+		return err
+	}
+	shareKey, _ := (*evm).StateDB.GetSynchronizeShareKey(caller, s.SynchronizeShareKey.ShareKeyId)
+	price := new(big.Int)
+	if shareKey.Shareprice != nil {
+		price.Set(shareKey.Shareprice.ToInt())
+	}
+	if !(*evm).StateDB.SetShareKeyStatus(caller, shareKey.ShareKeyId, types.ShareKeyStatusUnlocked, evm.BlockNumber.Uint64()) {
+		return ErrUpdateUnlockSharedKey
+	}
+	(*evm).StateDB.SubBalance(caller, price)
+	(*evm).StateDB.AddBalance(shareKey.FromAccount, price)
+	addSpecialTxLog(evm, "ShareKeyUnlock", []common.Hash{addressTopic(caller), addressTopic(shareKey.FromAccount)}, shareKey.ShareKeyId, price)
+	return nil
+}
+
+func cancelShareKey(evm *EVM, s types.SpecialTxInput, caller common.Address) error {
+	if err := CheckCancelShareKeyTx(caller, s, evm.StateDB); err != nil {
+		return err
+	}
+	recipient := s.SynchronizeShareKey.RecipientAddress
+	if !(*evm).StateDB.SetShareKeyStatus(recipient, s.SynchronizeShareKey.ShareKeyId, types.ShareKeyStatusCancelled, evm.BlockNumber.Uint64()) {
+		return ErrShareKeyNotPending
+	}
+	addSpecialTxLog(evm, "ShareKeyCancel", []common.Hash{addressTopic(caller), addressTopic(recipient)}, s.SynchronizeShareKey.ShareKeyId)
+	return nil
+}
+
+func rejectShareKey(evm *EVM, s types.SpecialTxInput, caller common.Address) error {
+	if err := CheckRejectShareKeyTx(caller, s, evm.StateDB); err != nil {
+		return err
+	}
+	shareKey, _ := (*evm).StateDB.GetSynchronizeShareKey(caller, s.SynchronizeShareKey.ShareKeyId)
+	if !(*evm).StateDB.SetShareKeyStatus(caller, shareKey.ShareKeyId, types.ShareKeyStatusRejected, evm.BlockNumber.Uint64()) {
+		return ErrShareKeyNotPending
 	}
+	addSpecialTxLog(evm, "ShareKeyReject", []common.Hash{addressTopic(caller), addressTopic(shareKey.FromAccount)}, shareKey.ShareKeyId)
 	return nil
 }
 
 func SynchronizeShareKey(evm *EVM, s types.SpecialTxInput, caller common.Address) error {
-	if err := CheckSynchronizeShareKeyParameter(s, evm.StateDB, evm.chainConfig.Genaro); err != nil {
+	if !evm.chainConfig.Genaro.IsShareCommit(evm.BlockNumber) || !s.SynchronizeShareKey.IsCommitted() {
+		s.SynchronizeShareKey.Commitment = nil
+	}
+	if err := CheckSynchronizeShareKeyParameter(s, evm.StateDB, evm.chainConfig.Genaro, evm.BlockNumber); err != nil {
+		return err
+	}
+	if err := CheckShareKeyCommitment(s); err != nil {
 		return err
 	}
 	s.SynchronizeShareKey.Status = 0
 	s.SynchronizeShareKey.FromAccount = caller
-	if !(*evm).StateDB.SynchronizeShareKey(s.SynchronizeShareKey.RecipientAddress, s.SynchronizeShareKey) {
-		return errors.New("update  chain SynchronizeShareKey fail")
+	s.SynchronizeShareKey.CompleteBlock = 0
+	if evm.chainConfig.Genaro.IsFileShareKey(evm.BlockNumber) {
+		if err := CheckShareKeyPublicKeyVersion(s, evm.StateDB); err != nil {
+			return err
+		}
+		// Default to the recipient's current public key
+		if s.SynchronizeShareKey.PublicKeyVersion == 0 {
+			versions := (*evm).StateDB.GetFileSharePublicKeys(s.SynchronizeShareKey.RecipientAddress)
+			if n := len(versions); n > 0 && versions[n-1].RevokeBlock == 0 {
+				s.SynchronizeShareKey.PublicKeyVersion = versions[n-1].Version
+			}
+		}
+	} else {
+		s.SynchronizeShareKey.PublicKeyVersion = 0
+	}
+	if evm.chainConfig.Genaro.IsShareKey(evm.BlockNumber) {
+		if err := CheckShareKeyOffer(s, evm.StateDB, evm.BlockNumber); err != nil {
+			return err
+		}
+		if !(*evm).StateDB.AddShareKey(s.SynchronizeShareKey, evm.BlockNumber.Uint64()) {
+			return ErrUpdateSynchronizeShareKey
+		}
+	} else {
+		s.SynchronizeShareKey.ExpireBlock = 0
+		if !(*evm).StateDB.SynchronizeShareKey(s.SynchronizeShareKey.RecipientAddress, s.SynchronizeShareKey) {
+			return ErrUpdateSynchronizeShareKey
+		}
+	}
+	addSpecialTxLog(evm, "ShareKeySync", []common.Hash{addressTopic(caller), addressTopic(s.SynchronizeShareKey.RecipientAddress)},
+		s.SynchronizeShareKey.ShareKeyId, s.SynchronizeShareKey.Shareprice.ToInt())
+	if s.SynchronizeShareKey.IsCommitted() {
+		addSpecialTxLog(evm, "ShareKeyCommit", []common.Hash{addressTopic(caller), addressTopic(s.SynchronizeShareKey.RecipientAddress), *s.SynchronizeShareKey.Commitment},
+			s.SynchronizeShareKey.ShareKeyId)
 	}
 	return nil
 }
 
 func updateFileShareSecretKey(evm *EVM, s types.SpecialTxInput, caller common.Address) error {
-	if err := CheckSyncFileSharePublicKeyTx(s, evm.StateDB, evm.chainConfig.Genaro); nil != err {
+	if err := CheckSyncFileSharePublicKeyTx(s, evm.StateDB, evm.chainConfig.Genaro, evm.BlockNumber); nil != err {
 		return err
 	}
 	adress := common.HexToAddress(s.Address)
-	if !(*evm).StateDB.UpdateFileSharePublicKey(adress, s.FileSharePublicKey) {
-		return errors.New("update user's public key fail")
+	if evm.chainConfig.Genaro.IsFileShareKey(evm.BlockNumber) {
+		if !(*evm).StateDB.AddFileSharePublicKey(adress, s.FileSharePublicKey, evm.BlockNumber.Uint64()) {
+			return ErrUpdateFileSharePublicKey
+		}
+	} else if !(*evm).StateDB.UpdateFileSharePublicKey(adress, s.FileSharePublicKey) {
+		return ErrUpdateFileSharePublicKey
 	}
+	addSpecialTxLog(evm, "FileSharePublicKeySync", []common.Hash{addressTopic(adress)}, s.FileSharePublicKey)
+	return nil
+}
+
+func revokeFileSharePublicKey(evm *EVM, s types.SpecialTxInput, caller common.Address) error {
+	if err := CheckRevokeFileSharePublicKeyTx(caller, s, evm.StateDB); err != nil {
+		return err
+	}
+	version := s.PublicKeyVersion
+	if version == 0 {
+		version = uint64(len((*evm).StateDB.GetFileSharePublicKeys(caller)))
+	}
+	if !(*evm).StateDB.RevokeFileSharePublicKey(caller, version, evm.BlockNumber.Uint64()) {
+		return ErrUpdateFileSharePublicKey
+	}
+	addSpecialTxLog(evm, "FileSharePublicKeyRevoke", []common.Hash{addressTopic(caller)}, version)
 	return nil
 }
 
@@ -722,19 +992,20 @@ func updateStakeNode(evm *EVM, s types.SpecialTxInput, caller common.Address) er
 	if err == nil {
 		node2UserAccountIndexAddress := common.StakeNode2StakeAddress
 		(*evm).StateDB.SyncNode2Address(node2UserAccountIndexAddress, s.NodeID, caller.String())
+		addSpecialTxLog(evm, "NodeSync", []common.Hash{addressTopic(caller)}, s.NodeID)
 	}
 
 	return err
 }
 
 func SpecialTxTypeSyncSidechainStatus(evm *EVM, s types.SpecialTxInput, caller common.Address) error {
-	if err := CheckSpecialTxTypeSyncSidechainStatusParameter(s, caller, evm.StateDB, evm.chainConfig.Genaro); nil != err {
+	if err := CheckSpecialTxTypeSyncSidechainStatusParameter(s, caller, evm.StateDB, evm.chainConfig.Genaro, evm.BlockNumber); nil != err {
 		return err
 	}
 
 	restlt, flag := (*evm).StateDB.SpecialTxTypeSyncSidechainStatus(s.SpecialTxTypeMortgageInit.FromAccount, s.SpecialTxTypeMortgageInit)
 	if false == flag {
-		return errors.New("update cross chain SpecialTxTypeMortgageInit fail")
+		return ErrUpdateCrossChainMortgageInit
 	}
 	for k, v := range restlt {
 		(*evm).StateDB.AddBalance(k, v)
@@ -744,26 +1015,26 @@ func SpecialTxTypeSyncSidechainStatus(evm *EVM, s types.SpecialTxInput, caller c
 
 func specialTxTypeMortgageInit(evm *EVM, s types.SpecialTxInput, caller common.Address) error {
 	if err := CheckspecialTxTypeMortgageInitParameter(s, caller); nil != err {
-		return errors.New("update  chain SpecialTxTypeMortgageInit fail")
+		return ErrUpdateMortgageInit
 	}
 	sumMortgageTable := new(big.Int)
 	mortgageTable := s.SpecialTxTypeMortgageInit.MortgageTable
 	if len(mortgageTable) > 8 {
-		return errors.New("update  chain SpecialTxTypeMortgageInit fail")
+		return ErrUpdateMortgageInit
 	}
 	zero := big.NewInt(0)
 	for _, v := range mortgageTable {
 		if v.ToInt().Cmp(zero) < 0 {
-			return errors.New("update  chain SpecialTxTypeMortgageInit fail")
+			return ErrUpdateMortgageInit
 		}
 		sumMortgageTable = sumMortgageTable.Add(sumMortgageTable, v.ToInt())
 	}
 	s.SpecialTxTypeMortgageInit.MortgagTotal = sumMortgageTable
 	if !(*evm).StateDB.SpecialTxTypeMortgageInit(caller, s.SpecialTxTypeMortgageInit) {
-		return errors.New("update  chain SpecialTxTypeMortgageInit fail")
+		return ErrUpdateMortgageInit
 	}
 	if s.SpecialTxTypeMortgageInit.TimeLimit.ToInt().Cmp(zero) < 0 {
-		return errors.New("update  chain SpecialTxTypeMortgageInit fail")
+		return ErrUpdateMortgageInit
 	}
 	temp := s.SpecialTxTypeMortgageInit.TimeLimit.ToInt().Mul(s.SpecialTxTypeMortgageInit.TimeLimit.ToInt(), big.NewInt(int64(len(mortgageTable))))
 	timeLimitGas := temp.Mul(temp, (*evm).StateDB.GetOneDayGesCost())
@@ -776,15 +1047,105 @@ func specialTxTypeMortgageInit(evm *EVM, s types.SpecialTxInput, caller common.A
 	return nil
 }
 
+// mortgageCost returns the amount held by a mortgage and the fee charged for
+// syncing it over its time limit.
+func mortgageCost(mortgage types.SpecialTxTypeMortgageInit, oneDayCost *big.Int) (*big.Int, *big.Int) {
+	total := new(big.Int)
+	for _, v := range mortgage.MortgageTable {
+		if v != nil {
+			total.Add(total, v.ToInt())
+		}
+	}
+	fee := new(big.Int)
+	if mortgage.TimeLimit != nil && oneDayCost != nil {
+		fee.Mul(mortgage.TimeLimit.ToInt(), big.NewInt(int64(len(mortgage.MortgageTable))))
+		fee.Mul(fee, oneDayCost)
+	}
+	return total, fee
+}
+
+// initMortgage opens a sidechain mortgage once GenaroConfig.MortgageBlock is
+// reached. The mortgage is held until it is settled, the sync fee goes to the
+// official account.
+func initMortgage(evm *EVM, s types.SpecialTxInput, caller common.Address) error {
+	if err := CheckMortgageInitTx(caller, s, evm.StateDB, evm.Time.Int64()); err != nil {
+		return err
+	}
+	input := s.SpecialTxTypeMortgageInit
+	total, fee := mortgageCost(input, (*evm).StateDB.GetOneDayGesCost())
+	mortgage := types.SpecialTxTypeMortgageInit{
+		MortgageTable:  input.MortgageTable,
+		AuthorityTable: input.AuthorityTable,
+		FileID:         input.FileID,
+		MortgagTotal:   total,
+		TimeLimit:      input.TimeLimit,
+		CreateTime:     input.CreateTime,
+		EndTime:        input.EndTime,
+		FromAccount:    caller,
+	}
+	if !(*evm).StateDB.OpenMortgage(mortgage) {
+		return ErrMortgageExists
+	}
+	(*evm).StateDB.SubBalance(caller, new(big.Int).Add(total, fee))
+	OfficialAddress := common.HexToAddress(evm.chainConfig.Genaro.OfficialAddress)
+	(*evm).StateDB.AddBalance(OfficialAddress, fee)
+	addSpecialTxLog(evm, "MortgageInit", []common.Hash{addressTopic(caller)}, mortgage.FileID, total, uint64(mortgage.EndTime))
+	return nil
+}
+
+// syncSidechainStatus records the latest sidechain status of a mortgage once
+// GenaroConfig.MortgageBlock is reached, settling it if the sync terminates it.
+func syncSidechainStatus(evm *EVM, s types.SpecialTxInput, caller common.Address) error {
+	if err := CheckSyncSidechainStatusTx(caller, s, evm.StateDB, evm.chainConfig.Genaro, evm.Time.Int64()); err != nil {
+		return err
+	}
+	status := s.SpecialTxTypeMortgageInit
+	if !(*evm).StateDB.SyncMortgageStatus(status.FromAccount, status.FileID, status.Dataversion, status.Sidechain) {
+		return ErrMortgageSettled
+	}
+	addSpecialTxLog(evm, "SidechainStatusSync", []common.Hash{addressTopic(status.FromAccount)}, status.FileID, status.Dataversion)
+	if status.Terminate {
+		return settleMortgage(evm, status.FromAccount, status.FileID)
+	}
+	return nil
+}
+
+// terminateMortgage settles a mortgage once GenaroConfig.MortgageBlock is
+// reached.
+func terminateMortgage(evm *EVM, s types.SpecialTxInput, caller common.Address) error {
+	if err := CheckMortgageTerminateTx(caller, s, evm.StateDB, evm.chainConfig.Genaro, evm.Time.Int64()); err != nil {
+		return err
+	}
+	return settleMortgage(evm, s.SpecialTxTypeMortgageInit.FromAccount, s.SpecialTxTypeMortgageInit.FileID)
+}
+
+func settleMortgage(evm *EVM, fromAccount common.Address, fileID string) error {
+	payouts, ok := (*evm).StateDB.SettleMortgage(fromAccount, fileID, evm.BlockNumber.Uint64())
+	if !ok {
+		return ErrMortgageSettled
+	}
+	accounts := make([]common.Address, 0, len(payouts))
+	for account := range payouts {
+		accounts = append(accounts, account)
+	}
+	sort.Slice(accounts, func(i, j int) bool { return bytes.Compare(accounts[i][:], accounts[j][:]) < 0 })
+	for _, account := range accounts {
+		(*evm).StateDB.AddBalance(account, payouts[account])
+		addSpecialTxLog(evm, "MortgagePayout", []common.Hash{addressTopic(fromAccount), addressTopic(account)}, fileID, payouts[account])
+	}
+	addSpecialTxLog(evm, "MortgageSettle", []common.Hash{addressTopic(fromAccount)}, fileID)
+	return nil
+}
+
 func bucketSupplement(evm *EVM, s types.SpecialTxInput, caller common.Address) error {
-	if err := CheckBucketSupplement(s, (*evm).StateDB, (*evm).chainConfig.Genaro); err != nil {
+	if err := CheckBucketSupplement(s, (*evm).StateDB, (*evm).chainConfig.Genaro, evm.BlockNumber); err != nil {
 		return err
 	}
 
 	address := common.HexToAddress(s.Address)
 	bucketsMap, _ := (*evm).StateDB.GetBuckets(address)
 	currentPrice := (*evm).StateDB.GetGenaroPrice()
-	currentCost := s.SpecialCost(currentPrice, bucketsMap)
+	currentCost := s.SpecialCost(currentPrice, bucketsMap, evm.chainConfig.Genaro, evm.BlockNumber)
 	totalGas := new(big.Int).Set(&currentCost)
 	log.Info(fmt.Sprintf("evm bucketSupplement cost:%s", totalGas.String()))
 
@@ -793,32 +1154,64 @@ func bucketSupplement(evm *EVM, s types.SpecialTxInput, caller common.Address) e
 		return ErrInsufficientBalance
 	}
 
-	var bucket types.BucketPropertie
+	// Start from the stored bucket to keep its reported usage
 	b, _ := bucketsMap[s.BucketID]
-	bucketInDb := b.(types.BucketPropertie)
-
-	bucket.BucketId = bucketInDb.BucketId
-	bucket.Backup = bucketInDb.Backup
-	bucket.TimeStart = bucketInDb.TimeStart
-	bucket.Size = bucketInDb.Size + s.Size
-	bucket.TimeEnd = bucketInDb.TimeEnd + s.Duration
+	bucket := b.(types.BucketPropertie)
+	bucket.Size += s.Size
+	bucket.TimeEnd += s.Duration
 
 	if (*evm).StateDB.UpdateBucket(address, bucket) {
 		(*evm).StateDB.SubBalance(caller, totalGas)
 		OfficialAddress := common.HexToAddress(evm.chainConfig.Genaro.OfficialAddress)
 		(*evm).StateDB.AddBalance(OfficialAddress, totalGas)
+		addSpecialTxLog(evm, "BucketSupplement", []common.Hash{addressTopic(address)}, bucket.BucketId, bucket.Size, bucket.TimeEnd, totalGas)
 	}
 	return nil
 }
 
+// SpecialTxBuckets returns the buckets SpecialCost needs to price s: the ones
+// of the address of a bucket supplement, or of every bucket supplement of a
+// batch or sponsored special transaction.
+func SpecialTxBuckets(s types.SpecialTxInput, db StateDB) map[string]interface{} {
+	bucketsMap := make(map[string]interface{})
+	addSpecialTxBuckets(s, db, bucketsMap)
+	return bucketsMap
+}
+
+func addSpecialTxBuckets(s types.SpecialTxInput, db StateDB, bucketsMap map[string]interface{}) {
+	if s.Type == nil {
+		return
+	}
+	switch s.Type.ToInt().Uint64() {
+	case common.SpecialTxBucketSupplement.Uint64():
+		buckets, _ := db.GetBuckets(common.HexToAddress(s.Address))
+		for id, bucket := range buckets {
+			bucketsMap[id] = bucket
+		}
+	case common.SpecialTxBatch.Uint64():
+		for _, step := range s.Batch {
+			if step.Type != nil && step.Type.ToInt().Cmp(common.SpecialTxBatch) != 0 {
+				addSpecialTxBuckets(step, db, bucketsMap)
+			}
+		}
+	case common.SpecialTxSponsored.Uint64():
+		if s.Sponsored == nil {
+			return
+		}
+		if input, err := s.Sponsored.Input(); err == nil && input.Type != nil && input.Type.ToInt().Cmp(common.SpecialTxSponsored) != 0 {
+			addSpecialTxBuckets(input, db, bucketsMap)
+		}
+	}
+}
+
 func updateStorageProperties(evm *EVM, s types.SpecialTxInput, caller common.Address) error {
-	if err := CheckApplyBucketTx(s, evm.StateDB, (*evm).chainConfig.Genaro); err != nil {
+	if err := CheckApplyBucketTx(s, evm.StateDB, (*evm).chainConfig.Genaro, evm.BlockNumber); err != nil {
 		return err
 	}
 	adress := common.HexToAddress(s.Address)
 
 	currentPrice := (*evm).StateDB.GetGenaroPrice()
-	currentCost := s.SpecialCost(currentPrice, nil)
+	currentCost := s.SpecialCost(currentPrice, nil, evm.chainConfig.Genaro, evm.BlockNumber)
 	totalGas := new(big.Int).Set(&currentCost)
 	log.Info(fmt.Sprintf("evm bucketApply cost:%s", totalGas.String()))
 
@@ -830,16 +1223,17 @@ func updateStorageProperties(evm *EVM, s types.SpecialTxInput, caller common.Add
 	for _, b := range s.Buckets {
 		bucketId := b.BucketId
 		if len(bucketId) != 64 {
-			return errors.New("the length of bucketId must be 64")
+			return ErrBucketIdLength
 		}
 
 		if b.TimeStart >= b.TimeEnd {
-			return errors.New("endTime must larger then startTime")
+			return ErrBucketEndTime
 		}
 
 		if !(*evm).StateDB.UpdateBucketProperties(adress, bucketId, b.Size, b.Backup, b.TimeStart, b.TimeEnd) {
-			return errors.New("update user's bucket fail")
+			return ErrUpdateBucket
 		}
+		addSpecialTxLog(evm, "BucketApply", []common.Hash{addressTopic(adress)}, bucketId, b.Size, b.Backup, b.TimeStart, b.TimeEnd)
 	}
 
 	(*evm).StateDB.SubBalance(caller, totalGas)
@@ -850,27 +1244,71 @@ func updateStorageProperties(evm *EVM, s types.SpecialTxInput, caller common.Add
 }
 
 func updateHeft(evm *EVM, s types.SpecialTxInput, caller common.Address) error {
-	if err := CheckSyncHeftTx(caller, s, evm.StateDB, evm.chainConfig.Genaro); err != nil {
+	if err := CheckSyncHeftTx(caller, s, evm.StateDB, evm.chainConfig.Genaro, evm.BlockNumber); err != nil {
 		return err
 	}
+	if evm.chainConfig.Genaro.IsHeftOracle(evm.BlockNumber) {
+		if err := CheckHeftOracleInactive(evm.StateDB); err != nil {
+			return err
+		}
+	}
 
 	adress := common.HexToAddress(s.Address)
 	if !(evm.StateDB).UpdateHeft(adress, s.Heft, evm.BlockNumber.Uint64()) {
-		return errors.New("update user's heft fail")
+		return ErrUpdateHeft
+	}
+	addSpecialTxLog(evm, "HeftSync", []common.Hash{addressTopic(adress)}, s.Heft)
+	return nil
+}
+
+func addHeftReporter(evm *EVM, s types.SpecialTxInput, caller common.Address) error {
+	if err := CheckAddHeftReporterTx(caller, s, evm.StateDB, evm.chainConfig.Genaro, evm.BlockNumber); err != nil {
+		return err
+	}
+	reporter := common.HexToAddress(s.Address)
+	if !(*evm).StateDB.AddHeftReporter(reporter) {
+		return ErrHeftReporterExists
+	}
+	addSpecialTxLog(evm, "HeftReporterAdd", []common.Hash{addressTopic(reporter)})
+	return nil
+}
+
+func delHeftReporter(evm *EVM, s types.SpecialTxInput, caller common.Address) error {
+	if err := CheckDelHeftReporterTx(caller, s, evm.StateDB, evm.chainConfig.Genaro); err != nil {
+		return err
+	}
+	reporter := common.HexToAddress(s.Address)
+	if !(*evm).StateDB.DelHeftReporter(reporter) {
+		return ErrNotHeftReporter
+	}
+	addSpecialTxLog(evm, "HeftReporterDel", []common.Hash{addressTopic(reporter)})
+	return nil
+}
+
+// reportHeft records a heft report, which is aggregated by the consensus
+// engine at the end of the epoch.
+func reportHeft(evm *EVM, s types.SpecialTxInput, caller common.Address) error {
+	if err := CheckReportHeftTx(caller, s, evm.StateDB, evm.chainConfig.Genaro, evm.BlockNumber); err != nil {
+		return err
 	}
+	adress := common.HexToAddress(s.Address)
+	if !(*evm).StateDB.ReportHeft(caller, adress, s.Heft) {
+		return ErrNotHeftReporter
+	}
+	addSpecialTxLog(evm, "HeftReport", []common.Hash{addressTopic(caller), addressTopic(adress)}, s.Heft)
 	return nil
 }
 
 func updateTraffic(evm *EVM, s types.SpecialTxInput, caller common.Address) error {
 
-	if err := CheckTrafficTx(s, evm.StateDB, evm.chainConfig.Genaro); err != nil {
+	if err := CheckTrafficTx(s, evm.StateDB, evm.chainConfig.Genaro, evm.BlockNumber); err != nil {
 		return err
 	}
 
 	adress := common.HexToAddress(s.Address)
 
 	currentPrice := (*evm).StateDB.GetGenaroPrice()
-	currentCost := s.SpecialCost(currentPrice, nil)
+	currentCost := s.SpecialCost(currentPrice, nil, evm.chainConfig.Genaro, evm.BlockNumber)
 	totalGas := new(big.Int).Set(&currentCost)
 	log.Info(fmt.Sprintf("evm trafficApply cost:%s", totalGas.String()))
 
@@ -880,40 +1318,177 @@ func updateTraffic(evm *EVM, s types.SpecialTxInput, caller common.Address) erro
 	}
 
 	if !(*evm).StateDB.UpdateTraffic(adress, s.Traffic) {
-		return errors.New("update user's teraffic fail")
+		return ErrUpdateTraffic
 	}
 
 	(*evm).StateDB.SubBalance(caller, totalGas)
 	OfficialAddress := common.HexToAddress(evm.chainConfig.Genaro.OfficialAddress)
 	(*evm).StateDB.AddBalance(OfficialAddress, totalGas)
 
+	addSpecialTxLog(evm, "TrafficApply", []common.Hash{addressTopic(adress)}, s.Traffic, totalGas)
 	return nil
 }
 
 func updateStake(evm *EVM, s types.SpecialTxInput, caller common.Address) error {
-	if err := CheckStakeTx(s, evm.StateDB, evm.chainConfig.Genaro); err != nil {
+	if err := CheckStakeTx(s, evm.StateDB, evm.chainConfig.Genaro, evm.BlockNumber); err != nil {
 		return err
 	}
+	fromVesting := s.FromVesting && evm.chainConfig.Genaro.IsVesting(evm.BlockNumber)
+	if fromVesting {
+		if err := CheckVestingStakeTx(caller, s, evm.StateDB); err != nil {
+			return err
+		}
+	}
 
 	// the unit of stake is GNX， one stake means one GNX
-	currentCost := s.SpecialCost(nil, nil)
-	amount := new(big.Int).Set(&currentCost)
+	amount := new(big.Int).Mul(new(big.Int).SetUint64(s.Stake), common.BaseCompany)
 
 	// judge if there is enough balance to stake（balance must larger than stake value)
-	if !evm.Context.CanTransfer(evm.StateDB, caller, amount) {
+	if !fromVesting && !evm.Context.CanTransfer(evm.StateDB, caller, amount) {
 		return ErrInsufficientBalance
 	}
 
 	adress := common.HexToAddress(s.Address)
 	if !(*evm).StateDB.UpdateStake(adress, s.Stake, evm.BlockNumber.Uint64()) {
-		return errors.New("update sentinel's stake fail")
+		return ErrUpdateStake
 
 	}
 	// 加入候选名单
 	if !(*evm).StateDB.AddCandidate(adress) {
-		return errors.New("add candidate fail")
+		return ErrAddCandidate
+	}
+	if fromVesting {
+		if !(*evm).StateDB.StakeVesting(caller, amount) {
+			return ErrVestingInsufficient
+		}
+	} else {
+		(*evm).StateDB.SubBalance(caller, amount)
 	}
-	(*evm).StateDB.SubBalance(caller, amount)
+	addSpecialTxLog(evm, "StakeSync", []common.Hash{addressTopic(caller), addressTopic(adress)}, s.Stake)
+	return nil
+}
+
+func createVesting(evm *EVM, s types.SpecialTxInput, caller common.Address) error {
+	if err := CheckVestingCreateTx(caller, s, evm.StateDB, evm.chainConfig.Genaro, evm.BlockNumber); err != nil {
+		return err
+	}
+	beneficiary := common.HexToAddress(s.Address)
+	vesting := types.Vesting{Total: s.Vesting.Total, Start: s.Vesting.Start, Cliff: s.Vesting.Cliff, End: s.Vesting.End}
+	if !(*evm).StateDB.SetVesting(beneficiary, vesting) {
+		return ErrVestingExists
+	}
+	total := vesting.Total.ToInt()
+	(*evm).StateDB.SubBalance(caller, total)
+	addSpecialTxLog(evm, "VestingCreate", []common.Hash{addressTopic(caller), addressTopic(beneficiary)}, total, vesting.Start, vesting.Cliff, vesting.End)
+	return nil
+}
+
+func withdrawVesting(evm *EVM, caller common.Address) error {
+	blockNumber := evm.BlockNumber.Uint64()
+	if err := CheckVestingWithdrawTx(caller, evm.StateDB, blockNumber); err != nil {
+		return err
+	}
+	amount := (*evm).StateDB.WithdrawVesting(caller, blockNumber)
+	addSpecialTxLog(evm, "VestingWithdraw", []common.Hash{addressTopic(caller)}, amount)
+	return nil
+}
+
+// commitBucketRoot records that a chunk of the caller is stored in one of its
+// buckets. Storage nodes can only commit to chunks committed this way.
+func commitBucketRoot(evm *EVM, s types.SpecialTxInput, caller common.Address) error {
+	if err := CheckBucketRootCommitTx(caller, s, evm.StateDB); err != nil {
+		return err
+	}
+	if !(*evm).StateDB.CommitBucketRoot(caller, s.BucketID, *s.StorageRoot) {
+		return ErrBucketRootCommitted
+	}
+	addSpecialTxLog(evm, "BucketRootCommit", []common.Hash{addressTopic(caller), *s.StorageRoot}, s.BucketID)
+	return nil
+}
+
+// commitStorageRoot records that a node of the caller holds a chunk of a
+// bucket, which makes the node liable to storage challenges.
+func commitStorageRoot(evm *EVM, s types.SpecialTxInput, caller common.Address) error {
+	if err := CheckStorageCommitTx(caller, s, evm.StateDB); err != nil {
+		return err
+	}
+	if !(*evm).StateDB.CommitStorageRoot(s.NodeID, *s.StorageRoot) {
+		return ErrStorageRootCommitted
+	}
+	addSpecialTxLog(evm, "StorageCommit", []common.Hash{addressTopic(caller), *s.StorageRoot}, s.NodeID, common.HexToAddress(s.Address), s.BucketID)
+	return nil
+}
+
+// challengeStorage asks a node to prove it holds a random segment of one of
+// the chunks it committed to within common.StorageProofWindow blocks. The
+// segment, and the chunk unless given, are drawn from the hash of the parent of
+// the block including the challenge. The challenger usually knows that hash
+// when sending, but the node cannot know which segment it will have to prove
+// before the parent block is sealed.
+func challengeStorage(evm *EVM, s types.SpecialTxInput, caller common.Address) error {
+	if err := CheckStorageChallengeTx(caller, s, evm.StateDB, evm.chainConfig.Genaro); err != nil {
+		return err
+	}
+	seed := crypto.Keccak256(evm.GetHash(evm.BlockNumber.Uint64()-1).Bytes(), []byte(s.NodeID))
+	root := s.StorageRoot
+	if root == nil {
+		roots := (*evm).StateDB.GetStorageChallenges().Roots[s.NodeID]
+		index := new(big.Int).Mod(new(big.Int).SetBytes(seed), big.NewInt(int64(len(roots))))
+		root = &roots[index.Int64()]
+	}
+	segment := new(big.Int).SetBytes(crypto.Keccak256(seed, root.Bytes()))
+	segment.Mod(segment, big.NewInt(bmt.DefaultSegmentCount))
+
+	challenge := types.StorageChallenge{
+		NodeID:     s.NodeID,
+		Account:    common.HexToAddress((*evm).StateDB.GetAddressByNode(s.NodeID)),
+		Root:       *root,
+		Segment:    segment.Uint64(),
+		Challenger: caller,
+		Deadline:   evm.BlockNumber.Uint64() + common.StorageProofWindow,
+	}
+	if !(*evm).StateDB.AddStorageChallenge(challenge) {
+		return ErrStorageChallengePending
+	}
+	addSpecialTxLog(evm, "StorageChallenge", []common.Hash{addressTopic(challenge.Account), challenge.Root},
+		challenge.NodeID, caller, challenge.Segment, challenge.Deadline)
+	return nil
+}
+
+// proveStorage answers the pending challenge of a node of the caller. An
+// unanswered challenge is punished by the consensus engine once expired.
+func proveStorage(evm *EVM, s types.SpecialTxInput, caller common.Address) error {
+	if err := CheckStorageProofTx(caller, s, evm.StateDB, evm.BlockNumber.Uint64()); err != nil {
+		return err
+	}
+	challenges := (*evm).StateDB.GetStorageChallenges()
+	challenge := *challenges.PendingOf(s.NodeID)
+	if !(*evm).StateDB.AnswerStorageChallenge(s.NodeID) {
+		return ErrStorageChallengeNotFound
+	}
+	addSpecialTxLog(evm, "StorageProof", []common.Hash{addressTopic(caller), challenge.Root}, s.NodeID, challenge.Segment)
+	return nil
+}
+
+// reportBucketUsage records the bytes stored in a bucket by the storage layer.
+// The used size of the bucket is the median of the reports of the official
+// account and the heft reporters, so no single reporter sets it.
+func reportBucketUsage(evm *EVM, s types.SpecialTxInput, caller common.Address) error {
+	if err := CheckBucketUsageTx(caller, s, evm.StateDB, evm.chainConfig.Genaro); err != nil {
+		return err
+	}
+	address := common.HexToAddress(s.Address)
+	buckets, _ := (*evm).StateDB.GetBuckets(address)
+	bucket := buckets[s.BucketID].(types.BucketPropertie)
+	official := common.HexToAddress(evm.chainConfig.Genaro.OfficialAddress)
+	oracle := (*evm).StateDB.GetHeftOracle()
+	bucket.ReportUsage(caller, s.Size, func(reporter common.Address) bool {
+		return reporter == official || oracle.IsReporter(reporter)
+	})
+	if !(*evm).StateDB.UpdateBucket(address, bucket) {
+		return ErrUpdateBucket
+	}
+	addSpecialTxLog(evm, "BucketUsage", []common.Hash{addressTopic(address), addressTopic(caller)}, s.BucketID, s.Size)
 	return nil
 }
 
@@ -921,11 +1496,12 @@ func PromissoryNotesWithdrawCash(evm *EVM, caller common.Address) error {
 	blockNumber := evm.BlockNumber.Uint64()
 	withdrawCashNum := (*evm).StateDB.PromissoryNotesWithdrawCash(caller, blockNumber)
 	if withdrawCashNum <= 0 {
-		return errors.New("WithdrawCash error")
+		return ErrWithdrawCash
 	}
 	promissoryPrice := big.NewInt(int64(evm.chainConfig.Genaro.PromissoryNotePrice * withdrawCashNum))
 	promissoryPrice.Mul(promissoryPrice, common.BaseCompany)
 	(*evm).StateDB.AddBalance(caller, promissoryPrice)
+	addSpecialTxLog(evm, "PromissoryNotesWithdrawCash", []common.Hash{addressTopic(caller)}, withdrawCashNum, promissoryPrice)
 	return nil
 }
 
@@ -939,6 +1515,7 @@ func buyPromissoryNotes(evm *EVM, s types.SpecialTxInput, caller common.Address)
 		//result.OptionPrice.Mul(result.OptionPrice,common.BaseCompany)
 		(*evm).StateDB.AddBalance(result.PromissoryNotesOwner, result.OptionPrice)
 		(*evm).StateDB.SubBalance(caller, result.OptionPrice)
+		addSpecialTxLog(evm, "PromissoryNotesBuy", []common.Hash{addressTopic(caller), s.OrderId}, result.OptionPrice)
 	}
 	return nil
 }
@@ -953,6 +1530,7 @@ func CarriedOutPromissoryNotes(evm *EVM, s types.SpecialTxInput, caller common.A
 		//result.PromissoryNoteTxPrice.Mul(result.PromissoryNoteTxPrice,common.BaseCompany)
 		(*evm).StateDB.AddBalance(result.PromissoryNotesOwner, result.OptionPrice)
 		(*evm).StateDB.SubBalance(caller, result.OptionPrice)
+		addSpecialTxLog(evm, "PromissoryNotesCarriedOut", []common.Hash{addressTopic(caller), s.OrderId}, result.TxNum)
 	}
 	return nil
 }
@@ -964,6 +1542,8 @@ func turnBuyPromissoryNotes(evm *EVM, s types.SpecialTxInput, caller common.Addr
 	result := (*evm).StateDB.TurnBuyPromissoryNotes(s.OrderId, s.OptionPrice, caller, optionTxMemorySize)
 	if false == result {
 		errors.New("update error")
+	} else {
+		addSpecialTxLog(evm, "PromissoryNotesTurnBuy", []common.Hash{addressTopic(caller), s.OrderId}, s.OptionPrice.ToInt())
 	}
 	return nil
 }
@@ -60,6 +60,7 @@ type StateDB interface {
 
 	RevertToSnapshot(int)
 	Snapshot() int
+	SetGenaroJournal(bool)
 
 	AddLog(*types.Log)
 	AddPreimage(common.Hash, []byte)
@@ -91,6 +92,10 @@ type StateDB interface {
 	GetStorageGas(common.Address, [32]byte) (uint64, error)
 	SpecialTxTypeMortgageInit(common.Address, types.SpecialTxTypeMortgageInit) bool
 	SpecialTxTypeSyncSidechainStatus(common.Address, types.SpecialTxTypeMortgageInit) (map[common.Address]*big.Int, bool)
+	GetMortgage(common.Address, string) (types.SpecialTxTypeMortgageInit, bool)
+	OpenMortgage(types.SpecialTxTypeMortgageInit) bool
+	SyncMortgageStatus(common.Address, string, string, types.Sidechain) bool
+	SettleMortgage(common.Address, string, uint64) (map[common.Address]*big.Int, bool)
 	UpdateTraffic(common.Address, uint64) bool
 
 	GetTraffic(common.Address) uint64
@@ -115,8 +120,24 @@ type StateDB interface {
 	SynchronizeShareKey(common.Address, types.SynchronizeShareKey) bool
 
 	UpdateFileSharePublicKey(common.Address, string) bool
+	GetFileSharePublicKeys(common.Address) []types.FileSharePublicKeyVersion
+	AddFileSharePublicKey(common.Address, string, uint64) bool
+	RevokeFileSharePublicKey(common.Address, uint64, uint64) bool
+	GetVesting(common.Address) *types.Vesting
+	SetVesting(common.Address, types.Vesting) bool
+	StakeVesting(common.Address, *big.Int) bool
+	WithdrawVesting(common.Address, uint64) *big.Int
+	GetStorageChallenges() types.StorageChallenges
+	CommitBucketRoot(common.Address, string, common.Hash) bool
+	CommitStorageRoot(string, common.Hash) bool
+	AddStorageChallenge(types.StorageChallenge) bool
+	AnswerStorageChallenge(string) bool
+	DropStorageNode(string) bool
 	UnlockSharedKey(common.Address, string) bool
 	GetSharedFile(common.Address, string) types.SynchronizeShareKey
+	GetSynchronizeShareKey(common.Address, string) (types.SynchronizeShareKey, bool)
+	AddShareKey(types.SynchronizeShareKey, uint64) bool
+	SetShareKeyStatus(common.Address, string, int, uint64) bool
 	UpdateBucketApplyPrice(common.Address, *hexutil.Big) bool
 	GetBucketApplyPrice() *big.Int
 
@@ -153,6 +174,11 @@ type StateDB interface {
 	IsAccountExistInForbidBackStakeList(address common.Address) bool
 	GetForbidBackStakeList() types.ForbidBackStakeList
 
+	GetHeftOracle() types.HeftOracle
+	AddHeftReporter(common.Address) bool
+	DelHeftReporter(common.Address) bool
+	ReportHeft(common.Address, common.Address, uint64) bool
+
 	UnbindNode(common.Address, string) error
 	UbindNode2Address(common.Address, string) error
 
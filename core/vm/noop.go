@@ -65,6 +65,7 @@ func (NoopStateDB) Exist(common.Address) bool
 func (NoopStateDB) Empty(common.Address) bool                                          { return false }
 func (NoopStateDB) RevertToSnapshot(int)                                               {}
 func (NoopStateDB) Snapshot() int                                                      { return 0 }
+func (NoopStateDB) SetGenaroJournal(bool)                                              {}
 func (NoopStateDB) AddLog(*types.Log)                                                  {}
 func (NoopStateDB) AddPreimage(common.Hash, []byte)                                    {}
 func (NoopStateDB) ForEachStorage(common.Address, func(common.Hash, common.Hash) bool) {}
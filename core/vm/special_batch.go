@@ -0,0 +1,66 @@
+package vm
+
+import (
+	"fmt"
+
+	"github.com/GenaroNetwork/GenaroCore/common"
+	"github.com/GenaroNetwork/GenaroCore/core/types"
+)
+
+// maxBatchSteps is the maximum number of steps of a batch special transaction.
+const maxBatchSteps = 16
+
+// CheckBatchTx checks the shape of a batch special transaction. The steps are
+// checked as they are applied, since a step may depend on the steps before it.
+func CheckBatchTx(s types.SpecialTxInput) error {
+	if len(s.Batch) == 0 {
+		return ErrBatchEmpty
+	}
+	if len(s.Batch) > maxBatchSteps {
+		return ErrBatchTooLong
+	}
+	for _, step := range s.Batch {
+		if step.Type == nil {
+			return ErrSpecialTxTypeMissing
+		}
+		if step.Type.ToInt().Cmp(common.SpecialTxBatch) == 0 {
+			return ErrBatchNested
+		}
+	}
+	return nil
+}
+
+// CheckBatchSteps applies the steps of a batch on the state of evm and returns
+// the error of the first failing step. The steps stay applied if they succeed,
+// so callers validating a batch revert the state of evm afterwards.
+func CheckBatchSteps(evm *EVM, s types.SpecialTxInput, caller common.Address) error {
+	return applyBatch(evm, s, caller)
+}
+
+// applyBatch applies the steps of a batch in order under a single state
+// snapshot. If a step fails, the state is reverted to the snapshot so either
+// every step takes effect or none does. Each step charges its own cost, the
+// batch as a whole costs the sum of them.
+func applyBatch(evm *EVM, s types.SpecialTxInput, caller common.Address) error {
+	if err := CheckBatchTx(s); err != nil {
+		return err
+	}
+	snapshot := evm.StateDB.Snapshot()
+	for i, step := range s.Batch {
+		if err := dispatchSpecialTx(evm, step, caller); err != nil {
+			evm.StateDB.RevertToSnapshot(snapshot)
+			return batchStepError(i, err)
+		}
+	}
+	return nil
+}
+
+// batchStepError reports the failure of step i of a batch under the code of the
+// step error.
+func batchStepError(i int, err error) error {
+	code := ErrSpecialTxFailed.Code
+	if e, ok := err.(*SpecialTxError); ok {
+		code = e.Code
+	}
+	return newSpecialTxError(code, fmt.Sprintf("batch step %d: %v", i, err))
+}
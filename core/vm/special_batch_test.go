@@ -0,0 +1,103 @@
+package vm
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/GenaroNetwork/GenaroCore/common"
+	"github.com/GenaroNetwork/GenaroCore/core/state"
+	"github.com/GenaroNetwork/GenaroCore/ethdb"
+	"github.com/GenaroNetwork/GenaroCore/params"
+)
+
+func TestBatchSpecialTx(t *testing.T) {
+	db, _ := state.New(common.Hash{}, state.NewDatabase(ethdb.NewMemDatabase()))
+	caller := common.HexToAddress("0x1000000000000000000000000000000000000001")
+	profit := common.HexToAddress("0x1000000000000000000000000000000000000002")
+	balance := new(big.Int).Mul(big.NewInt(10), common.BaseCompany)
+	db.AddBalance(caller, balance)
+	txHash := common.HexToHash("0x01")
+	db.Prepare(txHash, common.Hash{}, 0)
+
+	config := &params.ChainConfig{Genaro: &params.GenaroConfig{BatchBlock: big.NewInt(10), SpecialLogBlock: big.NewInt(0)}}
+	context := Context{
+		CanTransfer: func(db StateDB, addr common.Address, amount *big.Int) bool {
+			return db.GetBalance(addr).Cmp(amount) >= 0
+		},
+		BlockNumber: big.NewInt(10),
+	}
+	stake := fmt.Sprintf(`{"type":"0x1","address":"%x","stake":1}`, caller)
+	batch := func(steps ...string) []byte {
+		input := `{"type":"0x2d","batch":[`
+		for i, step := range steps {
+			if i > 0 {
+				input += ","
+			}
+			input += step
+		}
+		return []byte(input + `]}`)
+	}
+
+	// Before the fork batches are undefined.
+	evm := NewEVM(Context{BlockNumber: big.NewInt(9)}, db, config, Config{})
+	if err := dispatchHandler(evm, caller, batch(stake)); err != ErrSpecialTxUndefinedType {
+		t.Fatalf("batch before fork: have %v, want %v", err, ErrSpecialTxUndefinedType)
+	}
+
+	// A failing step reverts the steps before it.
+	evm = NewEVM(context, db, config, Config{})
+	err := dispatchHandler(evm, caller, batch(stake, `{"type":"0x32"}`))
+	if code := SpecialTxErrorCode(err); code != ErrAddressMissing.Code {
+		t.Fatalf("failing batch: have %v (code %d), want code %d", err, code, ErrAddressMissing.Code)
+	}
+	if db.GetBalance(caller).Cmp(balance) != 0 || len(db.GetCandidates()) != 0 {
+		t.Errorf("failing batch not reverted: balance %v, candidates %x", db.GetBalance(caller), db.GetCandidates())
+	}
+	if logs := db.GetLogs(txHash); len(logs) != 0 {
+		t.Errorf("failing batch emitted %d logs", len(logs))
+	}
+
+	// All steps apply and their costs add up.
+	profitStep := fmt.Sprintf(`{"type":"0x32","address":"%x"}`, profit)
+	if err := dispatchHandler(evm, caller, batch(stake, profitStep)); err != nil {
+		t.Fatalf("batch failed: %v", err)
+	}
+	if have, _ := db.GetStake(caller); have != 1 {
+		t.Errorf("stake mismatch: have %d, want 1", have)
+	}
+	if have := db.GetProfitAccount(caller); have == nil || *have != profit {
+		t.Errorf("profit account mismatch: have %v, want %x", have, profit)
+	}
+	if want := new(big.Int).Sub(balance, common.BaseCompany); db.GetBalance(caller).Cmp(want) != 0 {
+		t.Errorf("balance mismatch: have %v, want %v", db.GetBalance(caller), want)
+	}
+	if logs := db.GetLogs(txHash); len(logs) != 2 {
+		t.Errorf("log count mismatch: have %d, want 2", len(logs))
+	}
+
+	for _, input := range []string{`{"type":"0x2d"}`, string(batch(string(batch(stake)))), string(batch(`{"address":"0x01"}`))} {
+		if err := dispatchHandler(evm, caller, []byte(input)); err == nil {
+			t.Errorf("malformed batch %s accepted", input)
+		}
+	}
+}
+
+// Tests that failing calls only revert the Genaro data from the batch fork on.
+func TestGenaroJournalFork(t *testing.T) {
+	config := &params.ChainConfig{Genaro: &params.GenaroConfig{BatchBlock: big.NewInt(10)}}
+	addr := common.HexToAddress("0x1000000000000000000000000000000000000001")
+
+	for number, want := range map[int64]uint64{9: 1, 10: 0} {
+		db, _ := state.New(common.Hash{}, state.NewDatabase(ethdb.NewMemDatabase()))
+		db.AddBalance(addr, big.NewInt(1))
+		NewEVM(Context{BlockNumber: big.NewInt(number)}, db, config, Config{})
+
+		snapshot := db.Snapshot()
+		db.UpdateStake(addr, 1, 1)
+		db.RevertToSnapshot(snapshot)
+		if have, _ := db.GetStake(addr); have != want {
+			t.Errorf("block %d: stake mismatch after revert: have %d, want %d", number, have, want)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package vm
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/GenaroNetwork/GenaroCore/common"
+	"github.com/GenaroNetwork/GenaroCore/core/state"
+	"github.com/GenaroNetwork/GenaroCore/core/types"
+	"github.com/GenaroNetwork/GenaroCore/ethdb"
+	"github.com/GenaroNetwork/GenaroCore/params"
+)
+
+func TestBucketUsageSpecialTx(t *testing.T) {
+	db, _ := state.New(common.Hash{}, state.NewDatabase(ethdb.NewMemDatabase()))
+	official := common.HexToAddress("0x1000000000000000000000000000000000000001")
+	user := common.HexToAddress("0x1000000000000000000000000000000000000002")
+	db.UpdateBucketProperties(user, "bucket", 2, 1, 0, 100)
+
+	config := &params.ChainConfig{Genaro: &params.GenaroConfig{BucketUsageBlock: big.NewInt(10), OfficialAddress: official.Hex()}}
+	evmAt := func(number int64) *EVM {
+		context := Context{
+			CanTransfer: func(db StateDB, addr common.Address, amount *big.Int) bool {
+				return db.GetBalance(addr).Cmp(amount) >= 0
+			},
+			BlockNumber: big.NewInt(number),
+		}
+		return NewEVM(context, db, config, Config{})
+	}
+	report := func(bucketID string, used uint64) []byte {
+		return []byte(fmt.Sprintf(`{"type":"0x36","address":"%s","bucketId":"%s","size":%d}`, user.Hex(), bucketID, used))
+	}
+	used := func() uint64 {
+		buckets, _ := db.GetBuckets(user)
+		return buckets["bucket"].(types.BucketPropertie).Used
+	}
+
+	if err := dispatchHandler(evmAt(9), official, report("bucket", 1)); err != ErrSpecialTxUndefinedType {
+		t.Fatalf("report before fork: have %v, want %v", err, ErrSpecialTxUndefinedType)
+	}
+	if err := dispatchHandler(evmAt(10), user, report("bucket", 1)); SpecialTxErrorCode(err) != ErrInvalidCaller.Code {
+		t.Errorf("report by bucket owner: have %v, want %v", err, ErrInvalidCaller)
+	}
+	if err := dispatchHandler(evmAt(10), official, report("missing", 1)); SpecialTxErrorCode(err) != ErrBucketNotFound.Code {
+		t.Errorf("report of missing bucket: have %v, want %v", err, ErrBucketNotFound)
+	}
+	if err := dispatchHandler(evmAt(10), official, report("bucket", 2*common.BucketSizeUnit+1)); SpecialTxErrorCode(err) != ErrBucketUsageExceeded.Code {
+		t.Errorf("report beyond size: have %v, want %v", err, ErrBucketUsageExceeded)
+	}
+	if err := dispatchHandler(evmAt(10), official, report("bucket", 2*common.BucketSizeUnit)); err != nil {
+		t.Fatalf("report failed: %v", err)
+	}
+	if have := used(); have != 2*common.BucketSizeUnit {
+		t.Errorf("used size mismatch: have %d, want %d", have, 2*common.BucketSizeUnit)
+	}
+	if err := dispatchHandler(evmAt(11), official, report("bucket", 5)); err != nil {
+		t.Fatalf("second report failed: %v", err)
+	}
+	if have := used(); have != 5 {
+		t.Errorf("used size mismatch: have %d, want 5", have)
+	}
+
+	// Reports of several reporters are combined into their median.
+	reporter1 := common.HexToAddress("0x1000000000000000000000000000000000000003")
+	reporter2 := common.HexToAddress("0x1000000000000000000000000000000000000004")
+	oracle := types.HeftOracle{Reporters: []common.Address{reporter1, reporter2}}
+	db.SetHeftOracle(oracle)
+	if err := dispatchHandler(evmAt(12), reporter1, report("bucket", 7)); err != nil {
+		t.Fatalf("reporter report failed: %v", err)
+	}
+	if have := used(); have != 6 {
+		t.Errorf("used size mismatch: have %d, want 6", have)
+	}
+	if err := dispatchHandler(evmAt(12), reporter2, report("bucket", 100)); err != nil {
+		t.Fatalf("reporter report failed: %v", err)
+	}
+	if have := used(); have != 7 {
+		t.Errorf("used size mismatch: have %d, want 7", have)
+	}
+	// Reports of removed reporters are dropped with the next report.
+	oracle.DelReporter(reporter2)
+	db.SetHeftOracle(oracle)
+	if err := dispatchHandler(evmAt(13), official, report("bucket", 5)); err != nil {
+		t.Fatalf("report failed: %v", err)
+	}
+	if have := used(); have != 6 {
+		t.Errorf("used size mismatch: have %d, want 6", have)
+	}
+
+	// A supplement keeps the reported usage of the bucket.
+	db.AddBalance(user, new(big.Int).Mul(big.NewInt(1000), common.BaseCompany))
+	supplement := []byte(fmt.Sprintf(`{"type":"0x29","address":"%s","bucketId":"bucket","size":1,"msg":"50"}`, user.Hex()))
+	if err := dispatchHandler(evmAt(14), user, supplement); err != nil {
+		t.Fatalf("supplement failed: %v", err)
+	}
+	buckets, _ := db.GetBuckets(user)
+	bucket := buckets["bucket"].(types.BucketPropertie)
+	if bucket.Size != 3 || bucket.Used != 6 || len(bucket.UsageReports) != 2 {
+		t.Errorf("supplemented bucket mismatch: size %d, used %d, %d reports, want 3, 6, 2", bucket.Size, bucket.Used, len(bucket.UsageReports))
+	}
+}
@@ -0,0 +1,132 @@
+package vm
+
+import (
+	"strings"
+
+	"github.com/GenaroNetwork/GenaroCore/accounts/abi"
+	"github.com/GenaroNetwork/GenaroCore/common"
+	"github.com/GenaroNetwork/GenaroCore/core/types"
+	"github.com/GenaroNetwork/GenaroCore/log"
+)
+
+// SpecialTxEventsABI is the ABI description of the event logs emitted by the
+// special transaction handlers once GenaroConfig.SpecialLogBlock is reached.
+//
+// Every log is emitted from common.SpecialSyncAddress. Topic 0 is the keccak256
+// hash of the event signature, e.g. keccak256("StakeSync(address,address,uint64)"),
+// followed by the indexed arguments in declaration order (addresses are left
+// padded to 32 bytes, names are their 32 byte AccountName form). The remaining
+// arguments are packed into the log data with the solidity ABI encoding, so the
+// definition below can be fed to abi.JSON or abigen to decode the logs.
+const SpecialTxEventsABI = `[
+	{"type":"event","name":"StakeSync","inputs":[{"name":"caller","type":"address","indexed":true},{"name":"account","type":"address","indexed":true},{"name":"stake","type":"uint64","indexed":false}]},
+	{"type":"event","name":"HeftSync","inputs":[{"name":"account","type":"address","indexed":true},{"name":"heft","type":"uint64","indexed":false}]},
+	{"type":"event","name":"HeftReporterAdd","inputs":[{"name":"reporter","type":"address","indexed":true}]},
+	{"type":"event","name":"HeftReporterDel","inputs":[{"name":"reporter","type":"address","indexed":true}]},
+	{"type":"event","name":"HeftReport","inputs":[{"name":"reporter","type":"address","indexed":true},{"name":"account","type":"address","indexed":true},{"name":"heft","type":"uint64","indexed":false}]},
+	{"type":"event","name":"BucketApply","inputs":[{"name":"account","type":"address","indexed":true},{"name":"bucketId","type":"string","indexed":false},{"name":"size","type":"uint64","indexed":false},{"name":"backup","type":"uint64","indexed":false},{"name":"timeStart","type":"uint64","indexed":false},{"name":"timeEnd","type":"uint64","indexed":false}]},
+	{"type":"event","name":"BucketSupplement","inputs":[{"name":"account","type":"address","indexed":true},{"name":"bucketId","type":"string","indexed":false},{"name":"size","type":"uint64","indexed":false},{"name":"timeEnd","type":"uint64","indexed":false},{"name":"cost","type":"uint256","indexed":false}]},
+	{"type":"event","name":"TrafficApply","inputs":[{"name":"account","type":"address","indexed":true},{"name":"traffic","type":"uint64","indexed":false},{"name":"cost","type":"uint256","indexed":false}]},
+	{"type":"event","name":"NodeSync","inputs":[{"name":"account","type":"address","indexed":true},{"name":"nodeId","type":"string","indexed":false}]},
+	{"type":"event","name":"NodeUnbind","inputs":[{"name":"account","type":"address","indexed":true},{"name":"nodeId","type":"string","indexed":false}]},
+	{"type":"event","name":"FileSharePublicKeySync","inputs":[{"name":"account","type":"address","indexed":true},{"name":"publicKey","type":"string","indexed":false}]},
+	{"type":"event","name":"FileSharePublicKeyRevoke","inputs":[{"name":"account","type":"address","indexed":true},{"name":"version","type":"uint64","indexed":false}]},
+	{"type":"event","name":"ShareKeySync","inputs":[{"name":"from","type":"address","indexed":true},{"name":"recipient","type":"address","indexed":true},{"name":"shareKeyId","type":"string","indexed":false},{"name":"price","type":"uint256","indexed":false}]},
+	{"type":"event","name":"ShareKeyCommit","inputs":[{"name":"from","type":"address","indexed":true},{"name":"recipient","type":"address","indexed":true},{"name":"commitment","type":"bytes32","indexed":true},{"name":"shareKeyId","type":"string","indexed":false}]},
+	{"type":"event","name":"ShareKeyCancel","inputs":[{"name":"from","type":"address","indexed":true},{"name":"recipient","type":"address","indexed":true},{"name":"shareKeyId","type":"string","indexed":false}]},
+	{"type":"event","name":"ShareKeyReject","inputs":[{"name":"recipient","type":"address","indexed":true},{"name":"from","type":"address","indexed":true},{"name":"shareKeyId","type":"string","indexed":false}]},
+	{"type":"event","name":"ShareKeyUnlock","inputs":[{"name":"recipient","type":"address","indexed":true},{"name":"from","type":"address","indexed":true},{"name":"shareKeyId","type":"string","indexed":false},{"name":"price","type":"uint256","indexed":false}]},
+	{"type":"event","name":"MortgageInit","inputs":[{"name":"from","type":"address","indexed":true},{"name":"fileId","type":"string","indexed":false},{"name":"total","type":"uint256","indexed":false},{"name":"endTime","type":"uint64","indexed":false}]},
+	{"type":"event","name":"SidechainStatusSync","inputs":[{"name":"from","type":"address","indexed":true},{"name":"fileId","type":"string","indexed":false},{"name":"dataversion","type":"string","indexed":false}]},
+	{"type":"event","name":"MortgagePayout","inputs":[{"name":"from","type":"address","indexed":true},{"name":"account","type":"address","indexed":true},{"name":"fileId","type":"string","indexed":false},{"name":"amount","type":"uint256","indexed":false}]},
+	{"type":"event","name":"MortgageSettle","inputs":[{"name":"from","type":"address","indexed":true},{"name":"fileId","type":"string","indexed":false}]},
+	{"type":"event","name":"Punishment","inputs":[{"name":"account","type":"address","indexed":true},{"name":"stake","type":"uint64","indexed":false}]},
+	{"type":"event","name":"BackStakeApply","inputs":[{"name":"account","type":"address","indexed":true},{"name":"blockNumber","type":"uint64","indexed":false}]},
+	{"type":"event","name":"PriceRegulation","inputs":[{"name":"caller","type":"address","indexed":true}]},
+	{"type":"event","name":"SynState","inputs":[{"name":"blockHash","type":"bytes32","indexed":true},{"name":"blockNumber","type":"uint64","indexed":false}]},
+	{"type":"event","name":"AccountBinding","inputs":[{"name":"mainAccount","type":"address","indexed":true},{"name":"subAccount","type":"address","indexed":true}]},
+	{"type":"event","name":"AccountCancelBinding","inputs":[{"name":"mainAccount","type":"address","indexed":true},{"name":"subAccount","type":"address","indexed":true}]},
+	{"type":"event","name":"ForbidBackStakeAdd","inputs":[{"name":"account","type":"address","indexed":true}]},
+	{"type":"event","name":"ForbidBackStakeDel","inputs":[{"name":"account","type":"address","indexed":true}]},
+	{"type":"event","name":"GlobalVarSet","inputs":[{"name":"caller","type":"address","indexed":true}]},
+	{"type":"event","name":"CoinpoolAdd","inputs":[{"name":"caller","type":"address","indexed":true},{"name":"amount","type":"uint256","indexed":false}]},
+	{"type":"event","name":"NameRegister","inputs":[{"name":"owner","type":"address","indexed":true},{"name":"name","type":"bytes32","indexed":true},{"name":"price","type":"uint256","indexed":false}]},
+	{"type":"event","name":"NameTransfer","inputs":[{"name":"from","type":"address","indexed":true},{"name":"to","type":"address","indexed":true},{"name":"name","type":"bytes32","indexed":true}]},
+	{"type":"event","name":"NameUnsubscribe","inputs":[{"name":"owner","type":"address","indexed":true},{"name":"name","type":"bytes32","indexed":true}]},
+	{"type":"event","name":"PromissoryNotesRevoke","inputs":[{"name":"owner","type":"address","indexed":true},{"name":"orderId","type":"bytes32","indexed":true}]},
+	{"type":"event","name":"PromissoryNotesWithdrawCash","inputs":[{"name":"account","type":"address","indexed":true},{"name":"num","type":"uint64","indexed":false},{"name":"amount","type":"uint256","indexed":false}]},
+	{"type":"event","name":"OptionPublish","inputs":[{"name":"owner","type":"address","indexed":true},{"name":"orderId","type":"bytes32","indexed":true},{"name":"restoreBlock","type":"uint64","indexed":false},{"name":"txNum","type":"uint64","indexed":false},{"name":"promissoryNoteTxPrice","type":"uint256","indexed":false},{"name":"optionPrice","type":"uint256","indexed":false}]},
+	{"type":"event","name":"OptionTxStatusSet","inputs":[{"name":"caller","type":"address","indexed":true},{"name":"orderId","type":"bytes32","indexed":true},{"name":"isSell","type":"bool","indexed":false}]},
+	{"type":"event","name":"PromissoryNotesBuy","inputs":[{"name":"buyer","type":"address","indexed":true},{"name":"orderId","type":"bytes32","indexed":true},{"name":"optionPrice","type":"uint256","indexed":false}]},
+	{"type":"event","name":"PromissoryNotesCarriedOut","inputs":[{"name":"caller","type":"address","indexed":true},{"name":"orderId","type":"bytes32","indexed":true},{"name":"txNum","type":"uint64","indexed":false}]},
+	{"type":"event","name":"PromissoryNotesTurnBuy","inputs":[{"name":"caller","type":"address","indexed":true},{"name":"orderId","type":"bytes32","indexed":true},{"name":"optionPrice","type":"uint256","indexed":false}]},
+	{"type":"event","name":"ProfitAccountSet","inputs":[{"name":"account","type":"address","indexed":true},{"name":"profitAccount","type":"address","indexed":true}]},
+	{"type":"event","name":"ShadowAccountSet","inputs":[{"name":"account","type":"address","indexed":true},{"name":"shadowAccount","type":"address","indexed":true}]},
+	{"type":"event","name":"VestingCreate","inputs":[{"name":"grantor","type":"address","indexed":true},{"name":"beneficiary","type":"address","indexed":true},{"name":"total","type":"uint256","indexed":false},{"name":"start","type":"uint64","indexed":false},{"name":"cliff","type":"uint64","indexed":false},{"name":"end","type":"uint64","indexed":false}]},
+	{"type":"event","name":"VestingWithdraw","inputs":[{"name":"beneficiary","type":"address","indexed":true},{"name":"amount","type":"uint256","indexed":false}]},
+	{"type":"event","name":"BucketRootCommit","inputs":[{"name":"account","type":"address","indexed":true},{"name":"root","type":"bytes32","indexed":true},{"name":"bucketId","type":"string","indexed":false}]},
+	{"type":"event","name":"StorageCommit","inputs":[{"name":"account","type":"address","indexed":true},{"name":"root","type":"bytes32","indexed":true},{"name":"nodeId","type":"string","indexed":false},{"name":"owner","type":"address","indexed":false},{"name":"bucketId","type":"string","indexed":false}]},
+	{"type":"event","name":"StorageChallenge","inputs":[{"name":"account","type":"address","indexed":true},{"name":"root","type":"bytes32","indexed":true},{"name":"nodeId","type":"string","indexed":false},{"name":"challenger","type":"address","indexed":false},{"name":"segment","type":"uint64","indexed":false},{"name":"deadline","type":"uint64","indexed":false}]},
+	{"type":"event","name":"StorageProof","inputs":[{"name":"account","type":"address","indexed":true},{"name":"root","type":"bytes32","indexed":true},{"name":"nodeId","type":"string","indexed":false},{"name":"segment","type":"uint64","indexed":false}]},
+	{"type":"event","name":"BucketUsage","inputs":[{"name":"account","type":"address","indexed":true},{"name":"reporter","type":"address","indexed":true},{"name":"bucketId","type":"string","indexed":false},{"name":"used","type":"uint64","indexed":false}]}
+]`
+
+// specialTxEvents is the parsed form of SpecialTxEventsABI.
+var specialTxEvents abi.ABI
+
+func init() {
+	parsed, err := abi.JSON(strings.NewReader(SpecialTxEventsABI))
+	if err != nil {
+		panic(err)
+	}
+	specialTxEvents = parsed
+}
+
+// SpecialTxEvents returns the events that special transactions may emit,
+// keyed by event name.
+func SpecialTxEvents() map[string]abi.Event {
+	return specialTxEvents.Events
+}
+
+// addressTopic converts an address into an indexed log topic.
+func addressTopic(addr common.Address) common.Hash {
+	return common.BytesToHash(addr.Bytes())
+}
+
+// addSpecialTxLog records the event with the given name so that it ends up in
+// the receipt of the special transaction. indexed holds the topics of the
+// indexed arguments, args the values of the remaining ones. The log is held back
+// until the handler succeeds, since a failing handler does not revert the state.
+func addSpecialTxLog(evm *EVM, name string, indexed []common.Hash, args ...interface{}) {
+	genaroConfig := evm.chainConfig.Genaro
+	if genaroConfig == nil || !genaroConfig.IsSpecialLog(evm.BlockNumber) {
+		return
+	}
+	event, ok := specialTxEvents.Events[name]
+	if !ok {
+		log.Error("undefined special transaction event", "name", name)
+		return
+	}
+	data, err := event.Inputs.NonIndexed().Pack(args...)
+	if err != nil {
+		log.Error("special transaction event pack failed", "name", name, "err", err)
+		return
+	}
+	topics := make([]common.Hash, 0, len(indexed)+1)
+	topics = append(topics, event.Id())
+	topics = append(topics, indexed...)
+	evm.specialLogs = append(evm.specialLogs, &types.Log{
+		Address:     common.SpecialSyncAddress,
+		Topics:      topics,
+		Data:        data,
+		BlockNumber: evm.BlockNumber.Uint64(),
+	})
+}
+
+// flushSpecialTxLogs adds the logs held back by addSpecialTxLog to the state.
+func flushSpecialTxLogs(evm *EVM) {
+	for _, l := range evm.specialLogs {
+		evm.StateDB.AddLog(l)
+	}
+	evm.specialLogs = nil
+}
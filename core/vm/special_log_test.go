@@ -0,0 +1,68 @@
+package vm
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/GenaroNetwork/GenaroCore/common"
+	"github.com/GenaroNetwork/GenaroCore/core/state"
+	"github.com/GenaroNetwork/GenaroCore/ethdb"
+	"github.com/GenaroNetwork/GenaroCore/params"
+)
+
+func TestSpecialTxEventIds(t *testing.T) {
+	ids := make(map[common.Hash]string)
+	for name, event := range SpecialTxEvents() {
+		if other, ok := ids[event.Id()]; ok {
+			t.Fatalf("event %s and %s share the same id", name, other)
+		}
+		ids[event.Id()] = name
+	}
+}
+
+func TestAddSpecialTxLog(t *testing.T) {
+	db, _ := state.New(common.Hash{}, state.NewDatabase(ethdb.NewMemDatabase()))
+	txHash := common.HexToHash("0x01")
+	db.Prepare(txHash, common.Hash{}, 0)
+
+	caller := common.HexToAddress("0x1000000000000000000000000000000000000001")
+	config := &params.ChainConfig{Genaro: &params.GenaroConfig{SpecialLogBlock: big.NewInt(10)}}
+
+	evm := NewEVM(Context{BlockNumber: big.NewInt(9)}, db, config, Config{})
+	addSpecialTxLog(evm, "HeftSync", []common.Hash{addressTopic(caller)}, uint64(7))
+	flushSpecialTxLogs(evm)
+	if logs := db.GetLogs(txHash); len(logs) != 0 {
+		t.Fatalf("log emitted before fork: have %d logs", len(logs))
+	}
+
+	evm = NewEVM(Context{BlockNumber: big.NewInt(10)}, db, config, Config{})
+	addSpecialTxLog(evm, "HeftSync", []common.Hash{addressTopic(caller)}, uint64(7))
+	if logs := db.GetLogs(txHash); len(logs) != 0 {
+		t.Fatalf("log emitted before the handler succeeded: have %d logs", len(logs))
+	}
+	flushSpecialTxLogs(evm)
+	logs := db.GetLogs(txHash)
+	if len(logs) != 1 {
+		t.Fatalf("log count mismatch: have %d, want 1", len(logs))
+	}
+	event := SpecialTxEvents()["HeftSync"]
+	if logs[0].Address != common.SpecialSyncAddress {
+		t.Errorf("log address mismatch: have %x", logs[0].Address)
+	}
+	if len(logs[0].Topics) != 2 || logs[0].Topics[0] != event.Id() || logs[0].Topics[1] != addressTopic(caller) {
+		t.Errorf("log topics mismatch: have %x", logs[0].Topics)
+	}
+	values, err := event.Inputs.NonIndexed().UnpackValues(logs[0].Data)
+	if err != nil {
+		t.Fatalf("failed to unpack log data: %v", err)
+	}
+	if len(values) != 1 || values[0].(uint64) != 7 {
+		t.Errorf("log data mismatch: have %v", values)
+	}
+
+	addSpecialTxLog(evm, "UnknownEvent", nil)
+	flushSpecialTxLogs(evm)
+	if logs := db.GetLogs(txHash); len(logs) != 1 {
+		t.Fatalf("unknown event emitted a log: have %d logs", len(logs))
+	}
+}
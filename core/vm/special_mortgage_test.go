@@ -0,0 +1,93 @@
+package vm
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/GenaroNetwork/GenaroCore/common"
+	"github.com/GenaroNetwork/GenaroCore/core/state"
+	"github.com/GenaroNetwork/GenaroCore/ethdb"
+	"github.com/GenaroNetwork/GenaroCore/params"
+)
+
+func TestMortgageSpecialTx(t *testing.T) {
+	db, _ := state.New(common.Hash{}, state.NewDatabase(ethdb.NewMemDatabase()))
+	official := common.HexToAddress("0x1000000000000000000000000000000000000001")
+	from := common.HexToAddress("0x1000000000000000000000000000000000000002")
+	writer := common.HexToAddress("0x1000000000000000000000000000000000000003")
+	reader := common.HexToAddress("0x1000000000000000000000000000000000000004")
+	db.AddBalance(from, new(big.Int).Mul(big.NewInt(10), common.BaseCompany))
+	db.Prepare(common.HexToHash("0x01"), common.Hash{}, 0)
+
+	config := &params.ChainConfig{Genaro: &params.GenaroConfig{MortgageBlock: big.NewInt(10), SpecialLogBlock: big.NewInt(0), OfficialAddress: official.Hex()}}
+	evmAt := func(number int64, time int64) *EVM {
+		context := Context{BlockNumber: big.NewInt(number), Time: big.NewInt(time)}
+		return NewEVM(context, db, config, Config{})
+	}
+	fileID := strings.Repeat("f", 64)
+	init := []byte(fmt.Sprintf(`{"type":"0x5","specialTxTypeMortgageInit":{"mortgage":{"%s":"0x64","%s":"0x32"},"authority":{"%s":2,"%s":1},"fileID":"%s","timeLimit":"0x1","createTime":0,"endTime":86400,"fromAccount":"%s"}}`,
+		writer.Hex(), reader.Hex(), writer.Hex(), reader.Hex(), fileID, from.Hex()))
+	sync := func(version int, paid int64, terminate bool) []byte {
+		return []byte(fmt.Sprintf(`{"type":"0x7","specialTxTypeMortgageInit":{"fileID":"%s","dataversion":"%064x","fromAccount":"%s","sidechain":{"%s":"0x%x"},"terminate":%v}}`,
+			fileID, version, from.Hex(), writer.Hex(), paid, terminate))
+	}
+	terminate := []byte(fmt.Sprintf(`{"type":"0x6","specialTxTypeMortgageInit":{"fileID":"%s","fromAccount":"%s"}}`, fileID, from.Hex()))
+
+	// Before the fork mortgages are undefined.
+	if err := dispatchHandler(evmAt(9, 1), from, init); err != ErrSpecialTxUndefinedType {
+		t.Fatalf("init before fork: have %v, want %v", err, ErrSpecialTxUndefinedType)
+	}
+	if err := dispatchHandler(evmAt(10, 1), from, init); err != nil {
+		t.Fatalf("init failed: %v", err)
+	}
+	if expiries := db.GetMortgageExpiries(); len(expiries) != 1 || expiries[0].FromAccount != from || expiries[0].EndTime != 86400 {
+		t.Fatalf("mortgage expiries mismatch: %+v", expiries)
+	}
+
+	// Only the official account syncs, and only the latest statuses are kept.
+	if err := dispatchHandler(evmAt(11, 2), from, sync(0, 10, false)); SpecialTxErrorCode(err) != ErrInvalidCaller.Code {
+		t.Errorf("sync by owner: have %v, want %v", err, ErrInvalidCaller)
+	}
+	for version := 0; version < int(common.MortgageHistory)+4; version++ {
+		if err := dispatchHandler(evmAt(11, 2), official, sync(version, int64(version), false)); err != nil {
+			t.Fatalf("sync %d failed: %v", version, err)
+		}
+	}
+	mortgage, _ := db.GetMortgage(from, fileID)
+	if uint64(len(mortgage.SidechainStatus)) != common.MortgageHistory || uint64(len(mortgage.Dataversions)) != common.MortgageHistory {
+		t.Fatalf("status history not capped: %d statuses, %d dataversions", len(mortgage.SidechainStatus), len(mortgage.Dataversions))
+	}
+	if mortgage.LastDataversion != mortgage.Dataversions[len(mortgage.Dataversions)-1] {
+		t.Errorf("latest dataversion mismatch: have %s, want %s", mortgage.Dataversions[len(mortgage.Dataversions)-1], mortgage.LastDataversion)
+	}
+
+	// Expired mortgages only take terminating syncs and can be settled by anyone.
+	if err := dispatchHandler(evmAt(12, 3), from, terminate); SpecialTxErrorCode(err) != ErrMortgageNotExpired.Code {
+		t.Errorf("early terminate: have %v, want %v", err, ErrMortgageNotExpired)
+	}
+	if err := dispatchHandler(evmAt(12, 86400), official, sync(99, 40, false)); SpecialTxErrorCode(err) != ErrMortgageExpired.Code {
+		t.Errorf("sync after expiry: have %v, want %v", err, ErrMortgageExpired)
+	}
+	balance := db.GetBalance(from)
+	if err := dispatchHandler(evmAt(12, 86400), reader, terminate); err != nil {
+		t.Fatalf("terminate failed: %v", err)
+	}
+	paid := int64(common.MortgageHistory) + 3
+	if have := db.GetBalance(writer); have.Int64() != paid {
+		t.Errorf("writer payout mismatch: have %v, want %d", have, paid)
+	}
+	if have := new(big.Int).Sub(db.GetBalance(from), balance); have.Int64() != 150-paid {
+		t.Errorf("refund mismatch: have %v, want %d", have, 150-paid)
+	}
+	if expiries := db.GetMortgageExpiries(); len(expiries) != 0 {
+		t.Errorf("settled mortgage still expiring: %+v", expiries)
+	}
+	if logs := db.GetLogs(common.HexToHash("0x01")); len(logs) == 0 || logs[len(logs)-1].Topics[0] != SpecialTxEvents()["MortgageSettle"].Id() {
+		t.Errorf("settlement not logged: %d logs", len(logs))
+	}
+	if err := dispatchHandler(evmAt(13, 86400), reader, terminate); SpecialTxErrorCode(err) != ErrMortgageSettled.Code {
+		t.Errorf("second terminate: have %v, want %v", err, ErrMortgageSettled)
+	}
+}
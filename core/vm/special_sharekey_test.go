@@ -0,0 +1,165 @@
+package vm
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/GenaroNetwork/GenaroCore/common"
+	"github.com/GenaroNetwork/GenaroCore/core/state"
+	"github.com/GenaroNetwork/GenaroCore/core/types"
+	"github.com/GenaroNetwork/GenaroCore/ethdb"
+	"github.com/GenaroNetwork/GenaroCore/params"
+)
+
+func TestShareKeySpecialTx(t *testing.T) {
+	db, _ := state.New(common.Hash{}, state.NewDatabase(ethdb.NewMemDatabase()))
+	sharer := common.HexToAddress("0x1000000000000000000000000000000000000001")
+	recipient := common.HexToAddress("0x1000000000000000000000000000000000000002")
+	stranger := common.HexToAddress("0x1000000000000000000000000000000000000003")
+	db.AddBalance(recipient, big.NewInt(100))
+
+	config := &params.ChainConfig{Genaro: &params.GenaroConfig{ShareKeyBlock: big.NewInt(10)}}
+	evmAt := func(number uint64) *EVM {
+		return NewEVM(Context{BlockNumber: new(big.Int).SetUint64(number)}, db, config, Config{})
+	}
+	offer := func(id string, expire uint64) []byte {
+		return []byte(fmt.Sprintf(`{"type":"0xf","synchronizeShareKey":{"shareKeyId":"%s","shareKey":"key","shareprice":"0x1e","recipientAddress":"%s","expireBlock":%d}}`,
+			id, recipient.Hex(), expire))
+	}
+	action := func(txType uint64, id string) []byte {
+		return []byte(fmt.Sprintf(`{"type":"0x%x","synchronizeShareKey":{"shareKeyId":"%s","recipientAddress":"%s"}}`, txType, id, recipient.Hex()))
+	}
+	cancel := func(id string) []byte { return action(common.SpecialTxCancelShareKey.Uint64(), id) }
+	reject := func(id string) []byte { return action(common.SpecialTxRejectShareKey.Uint64(), id) }
+	unlock := func(id string) []byte { return action(common.UnlockSharedKey.Uint64(), id) }
+	status := func(id string) int {
+		shareKey, ok := db.GetSynchronizeShareKey(recipient, id)
+		if !ok {
+			return -1
+		}
+		return shareKey.Status
+	}
+
+	if err := dispatchHandler(evmAt(9), sharer, cancel("a")); err != ErrSpecialTxUndefinedType {
+		t.Fatalf("cancel before fork: have %v, want %v", err, ErrSpecialTxUndefinedType)
+	}
+	if err := dispatchHandler(evmAt(10), sharer, offer("a", 10)); SpecialTxErrorCode(err) != ErrShareKeyExpireBlock.Code {
+		t.Errorf("offer expiring in its own block: have %v, want %v", err, ErrShareKeyExpireBlock)
+	}
+	for _, id := range []string{"a", "b", "c"} {
+		if err := dispatchHandler(evmAt(10), sharer, offer(id, 20)); err != nil {
+			t.Fatalf("offer %s failed: %v", id, err)
+		}
+	}
+	if err := dispatchHandler(evmAt(11), sharer, offer("a", 20)); SpecialTxErrorCode(err) != ErrShareKeyExists.Code {
+		t.Errorf("offer of a pending id: have %v, want %v", err, ErrShareKeyExists)
+	}
+
+	// The sharer withdraws a, the recipient declines b.
+	if err := dispatchHandler(evmAt(11), stranger, cancel("a")); SpecialTxErrorCode(err) != ErrShareKeyNotSharer.Code {
+		t.Errorf("cancel by a stranger: have %v, want %v", err, ErrShareKeyNotSharer)
+	}
+	if err := dispatchHandler(evmAt(11), sharer, cancel("a")); err != nil {
+		t.Fatalf("cancel failed: %v", err)
+	}
+	if err := dispatchHandler(evmAt(11), sharer, cancel("a")); SpecialTxErrorCode(err) != ErrShareKeyNotPending.Code {
+		t.Errorf("second cancel: have %v, want %v", err, ErrShareKeyNotPending)
+	}
+	if err := dispatchHandler(evmAt(11), recipient, reject("b")); err != nil {
+		t.Fatalf("reject failed: %v", err)
+	}
+	if err := dispatchHandler(evmAt(12), recipient, unlock("b")); SpecialTxErrorCode(err) != ErrShareKeyNotPending.Code {
+		t.Errorf("unlock of a declined offer: have %v, want %v", err, ErrShareKeyNotPending)
+	}
+	if have := status("a"); have != types.ShareKeyStatusCancelled {
+		t.Errorf("status of a: have %d, want %d", have, types.ShareKeyStatusCancelled)
+	}
+	if have := status("b"); have != types.ShareKeyStatusRejected {
+		t.Errorf("status of b: have %d, want %d", have, types.ShareKeyStatusRejected)
+	}
+
+	// c expires unpaid and can be offered again.
+	if err := dispatchHandler(evmAt(20), recipient, unlock("c")); SpecialTxErrorCode(err) != ErrShareKeyExpired.Code {
+		t.Errorf("unlock of an expired offer: have %v, want %v", err, ErrShareKeyExpired)
+	}
+	if balance := db.GetBalance(sharer); balance.Sign() != 0 {
+		t.Errorf("sharer paid for unfinished offers: %v", balance)
+	}
+	if err := dispatchHandler(evmAt(20), sharer, offer("c", 0)); err != nil {
+		t.Fatalf("offer of an expired id failed: %v", err)
+	}
+
+	// Finished offers are collected once the retention period is over.
+	if err := dispatchHandler(evmAt(11+common.ShareKeyRetention), sharer, offer("d", 0)); err != nil {
+		t.Fatalf("offer d failed: %v", err)
+	}
+	if have := status("a"); have != -1 {
+		t.Errorf("cancelled offer not collected: status %d", have)
+	}
+	if have := status("b"); have != -1 {
+		t.Errorf("declined offer not collected: status %d", have)
+	}
+	if have := status("c"); have != types.ShareKeyStatusPending {
+		t.Errorf("pending offer collected: status %d", have)
+	}
+	refs := db.GetOutgoingShareKeys(sharer)
+	if len(refs) != 2 || refs[0].ShareKeyId != "c" || refs[1].ShareKeyId != "d" {
+		t.Errorf("outgoing offers mismatch: %+v", refs)
+	}
+}
+
+func TestShareKeyCommitSpecialTx(t *testing.T) {
+	db, _ := state.New(common.Hash{}, state.NewDatabase(ethdb.NewMemDatabase()))
+	sharer := common.HexToAddress("0x1000000000000000000000000000000000000001")
+	recipient := common.HexToAddress("0x1000000000000000000000000000000000000002")
+	db.AddBalance(recipient, big.NewInt(100))
+	commitment := common.HexToHash("0x01")
+
+	config := &params.ChainConfig{Genaro: &params.GenaroConfig{ShareKeyBlock: big.NewInt(0), ShareCommitBlock: big.NewInt(10)}}
+	evmAt := func(number int64) *EVM {
+		return NewEVM(Context{BlockNumber: big.NewInt(number)}, db, config, Config{})
+	}
+	offer := func(id, shareKey string) []byte {
+		return []byte(fmt.Sprintf(`{"type":"0xf","synchronizeShareKey":{"shareKeyId":"%s","shareKey":"%s","shareprice":"0x1e","recipientAddress":"%s","commitment":"%s"}}`,
+			id, shareKey, recipient.Hex(), commitment.Hex()))
+	}
+	unlock := func(id string, commitment common.Hash) []byte {
+		return []byte(fmt.Sprintf(`{"type":"0x14","synchronizeShareKey":{"shareKeyId":"%s","commitment":"%s"}}`, id, commitment.Hex()))
+	}
+
+	// Before the fork the commitment is ignored and the share key required.
+	if err := dispatchHandler(evmAt(9), sharer, offer("early", "")); SpecialTxErrorCode(err) != ErrShareKey.Code {
+		t.Errorf("commitment before fork: have %v, want %v", err, ErrShareKey)
+	}
+	if err := dispatchHandler(evmAt(9), sharer, offer("early", "key")); err != nil {
+		t.Fatalf("offer before fork failed: %v", err)
+	}
+	if shareKey, _ := db.GetSynchronizeShareKey(recipient, "early"); shareKey.IsCommitted() {
+		t.Errorf("commitment stored before fork")
+	}
+
+	if err := dispatchHandler(evmAt(10), sharer, offer("committed", "key")); SpecialTxErrorCode(err) != ErrShareKeyCommitted.Code {
+		t.Errorf("committed offer with share key: have %v, want %v", err, ErrShareKeyCommitted)
+	}
+	if err := dispatchHandler(evmAt(10), sharer, offer("committed", "")); err != nil {
+		t.Fatalf("committed offer failed: %v", err)
+	}
+	if shareKey, _ := db.GetSynchronizeShareKey(recipient, "committed"); shareKey.CommitmentHash() != commitment || shareKey.ShareKey != "" {
+		t.Fatalf("committed offer mismatch: %+v", shareKey)
+	}
+
+	// The recipient pays when acknowledging the delivery it received.
+	if err := dispatchHandler(evmAt(11), recipient, unlock("committed", common.HexToHash("0x02"))); SpecialTxErrorCode(err) != ErrShareKeyCommitment.Code {
+		t.Errorf("unlock with another commitment: have %v, want %v", err, ErrShareKeyCommitment)
+	}
+	if err := dispatchHandler(evmAt(11), recipient, unlock("committed", commitment)); err != nil {
+		t.Fatalf("unlock failed: %v", err)
+	}
+	if shareKey, _ := db.GetSynchronizeShareKey(recipient, "committed"); shareKey.Status != types.ShareKeyStatusUnlocked {
+		t.Errorf("offer not unlocked: %+v", shareKey)
+	}
+	if balance := db.GetBalance(sharer); balance.Cmp(big.NewInt(30)) != 0 {
+		t.Errorf("sharer balance mismatch: have %v, want 30", balance)
+	}
+}
@@ -0,0 +1,36 @@
+package vm
+
+import (
+	"math/big"
+
+	"github.com/GenaroNetwork/GenaroCore/common"
+	"github.com/GenaroNetwork/GenaroCore/core/types"
+)
+
+// CheckSponsoredTx checks the authorization carried by a sponsored special
+// transaction. It returns the account the payload is applied on behalf of and
+// the decoded payload, which the caller checks or applies as sent by that
+// account.
+func CheckSponsoredTx(s types.SpecialTxInput, state StateDB, chainId *big.Int) (common.Address, types.SpecialTxInput, error) {
+	if s.Sponsored == nil {
+		return common.Address{}, types.SpecialTxInput{}, ErrSpecialTxInvalidInput
+	}
+	user, err := s.Sponsored.Signer(chainId)
+	if err != nil {
+		return common.Address{}, types.SpecialTxInput{}, ErrSponsoredSig
+	}
+	if state.GetNonce(user) != uint64(s.Sponsored.Nonce) {
+		return common.Address{}, types.SpecialTxInput{}, ErrSponsoredNonce
+	}
+	input, err := s.Sponsored.Input()
+	if err != nil {
+		return common.Address{}, types.SpecialTxInput{}, ErrSpecialTxInvalidInput
+	}
+	if input.Type == nil {
+		return common.Address{}, types.SpecialTxInput{}, ErrSpecialTxTypeMissing
+	}
+	if input.Type.ToInt().Cmp(common.SpecialTxSponsored) == 0 {
+		return common.Address{}, types.SpecialTxInput{}, ErrSponsoredNested
+	}
+	return user, input, nil
+}
@@ -0,0 +1,141 @@
+package vm
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/GenaroNetwork/GenaroCore/bmt"
+	"github.com/GenaroNetwork/GenaroCore/common"
+	"github.com/GenaroNetwork/GenaroCore/common/hexutil"
+	"github.com/GenaroNetwork/GenaroCore/core/state"
+	"github.com/GenaroNetwork/GenaroCore/core/types"
+	"github.com/GenaroNetwork/GenaroCore/crypto"
+	"github.com/GenaroNetwork/GenaroCore/crypto/sha3"
+	"github.com/GenaroNetwork/GenaroCore/ethdb"
+	"github.com/GenaroNetwork/GenaroCore/params"
+)
+
+func TestStorageChallengeSpecialTx(t *testing.T) {
+	db, _ := state.New(common.Hash{}, state.NewDatabase(ethdb.NewMemDatabase()))
+	official := common.HexToAddress("0x1000000000000000000000000000000000000001")
+	owner := common.HexToAddress("0x1000000000000000000000000000000000000002")
+	stranger := common.HexToAddress("0x1000000000000000000000000000000000000003")
+	user := common.HexToAddress("0x1000000000000000000000000000000000000004")
+	node := "storage-node"
+	db.SyncStakeNode(owner, node)
+	db.SyncNode2Address(common.StakeNode2StakeAddress, node, owner.String())
+	db.Prepare(common.HexToHash("0x01"), common.Hash{}, 0)
+
+	// A full chunk and its swarm address
+	chunk := make([]byte, bmt.DefaultSegmentCount*32)
+	for i := range chunk {
+		chunk[i] = byte(i * 7)
+	}
+	span := make([]byte, 8)
+	binary.LittleEndian.PutUint64(span, uint64(len(chunk)))
+	root := crypto.Keccak256Hash(span, bmt.NewRefHasher(sha3.NewKeccak256, bmt.DefaultSegmentCount).Hash(chunk))
+
+	config := &params.ChainConfig{Genaro: &params.GenaroConfig{StorageProofBlock: big.NewInt(10), SpecialLogBlock: big.NewInt(0), OfficialAddress: official.Hex()}}
+	evmAt := func(number int64) *EVM {
+		context := Context{
+			BlockNumber: big.NewInt(number),
+			GetHash:     func(n uint64) common.Hash { return common.BigToHash(new(big.Int).SetUint64(n)) },
+		}
+		return NewEVM(context, db, config, Config{})
+	}
+	bucketID := "bucket"
+	db.UpdateBucketProperties(user, bucketID, 1, 1, 0, 1)
+	file := []byte(fmt.Sprintf(`{"type":"0x37","bucketId":"%s","storageRoot":"%s"}`, bucketID, root.Hex()))
+	commit := []byte(fmt.Sprintf(`{"type":"0x31","nodeId":"%s","address":"%s","bucketId":"%s","storageRoot":"%s"}`, node, user.Hex(), bucketID, root.Hex()))
+	challenge := []byte(fmt.Sprintf(`{"type":"0x34","nodeId":"%s"}`, node))
+	prove := func(segment uint64, tamper bool) []byte {
+		proof, _ := bmt.Proof(sha3.NewKeccak256, chunk, int(segment))
+		answer := &types.StorageProof{Span: span, Segment: append([]byte{}, chunk[segment*32:(segment+1)*32]...)}
+		for _, sibling := range proof {
+			answer.Proof = append(answer.Proof, hexutil.Bytes(sibling))
+		}
+		if tamper {
+			answer.Segment[0]++
+		}
+		input, _ := json.Marshal(types.SpecialTxInput{Type: (*hexutil.Big)(common.SpecialTxStorageProof), NodeID: node, StorageProof: answer})
+		return input
+	}
+
+	// Before the fork storage challenges are undefined.
+	if err := dispatchHandler(evmAt(9), owner, commit); err != ErrSpecialTxUndefinedType {
+		t.Fatalf("commit before fork: have %v, want %v", err, ErrSpecialTxUndefinedType)
+	}
+	if err := dispatchHandler(evmAt(10), stranger, commit); SpecialTxErrorCode(err) != ErrNodeNotOwned.Code {
+		t.Errorf("commit of foreign node: have %v, want %v", err, ErrNodeNotOwned)
+	}
+	if err := dispatchHandler(evmAt(10), stranger, challenge); SpecialTxErrorCode(err) != ErrInvalidCaller.Code {
+		t.Errorf("challenge by stranger: have %v, want %v", err, ErrInvalidCaller)
+	}
+	if err := dispatchHandler(evmAt(10), official, challenge); SpecialTxErrorCode(err) != ErrStorageRootNotCommitted.Code {
+		t.Errorf("challenge without commitment: have %v, want %v", err, ErrStorageRootNotCommitted)
+	}
+	if err := dispatchHandler(evmAt(10), owner, commit); SpecialTxErrorCode(err) != ErrBucketRootNotCommitted.Code {
+		t.Errorf("commit of chunk outside any bucket: have %v, want %v", err, ErrBucketRootNotCommitted)
+	}
+	if err := dispatchHandler(evmAt(10), stranger, file); SpecialTxErrorCode(err) != ErrBucketNotFound.Code {
+		t.Errorf("commit to foreign bucket: have %v, want %v", err, ErrBucketNotFound)
+	}
+	if err := dispatchHandler(evmAt(10), user, file); err != nil {
+		t.Fatalf("bucket commit failed: %v", err)
+	}
+	if err := dispatchHandler(evmAt(10), user, file); SpecialTxErrorCode(err) != ErrBucketRootCommitted.Code {
+		t.Errorf("second bucket commit: have %v, want %v", err, ErrBucketRootCommitted)
+	}
+	if err := dispatchHandler(evmAt(10), owner, commit); err != nil {
+		t.Fatalf("commit failed: %v", err)
+	}
+	logs := db.GetLogs(common.HexToHash("0x01"))
+	if len(logs) != 2 || logs[0].Topics[0] != SpecialTxEvents()["BucketRootCommit"].Id() || logs[1].Topics[0] != SpecialTxEvents()["StorageCommit"].Id() {
+		t.Fatalf("commit logs mismatch: %v", logs)
+	}
+	if values, err := SpecialTxEvents()["StorageCommit"].Inputs.NonIndexed().UnpackValues(logs[1].Data); err != nil || values[1] != user || values[2] != bucketID {
+		t.Errorf("storage commit log mismatch: have %v (%v)", values, err)
+	}
+	if err := dispatchHandler(evmAt(10), owner, commit); SpecialTxErrorCode(err) != ErrStorageRootCommitted.Code {
+		t.Errorf("second commit: have %v, want %v", err, ErrStorageRootCommitted)
+	}
+
+	// Challenge the node and answer it.
+	if err := dispatchHandler(evmAt(11), official, challenge); err != nil {
+		t.Fatalf("challenge failed: %v", err)
+	}
+	if err := dispatchHandler(evmAt(11), official, challenge); SpecialTxErrorCode(err) != ErrStorageChallengePending.Code {
+		t.Errorf("second challenge: have %v, want %v", err, ErrStorageChallengePending)
+	}
+	challenges := db.GetStorageChallenges()
+	pending := challenges.PendingOf(node)
+	if pending == nil || pending.Root != root || pending.Account != owner || pending.Deadline != 11+common.StorageProofWindow {
+		t.Fatalf("pending challenge mismatch: %+v", pending)
+	}
+	segment := pending.Segment
+	if err := dispatchHandler(evmAt(12), owner, prove(segment, true)); SpecialTxErrorCode(err) != ErrStorageProofInvalid.Code {
+		t.Errorf("tampered proof: have %v, want %v", err, ErrStorageProofInvalid)
+	}
+	if err := dispatchHandler(evmAt(12), owner, prove((segment+1)%bmt.DefaultSegmentCount, false)); SpecialTxErrorCode(err) != ErrStorageProofInvalid.Code {
+		t.Errorf("proof of another segment: have %v, want %v", err, ErrStorageProofInvalid)
+	}
+	if err := dispatchHandler(evmAt(12), stranger, prove(segment, false)); SpecialTxErrorCode(err) != ErrNodeNotOwned.Code {
+		t.Errorf("proof by stranger: have %v, want %v", err, ErrNodeNotOwned)
+	}
+	if err := dispatchHandler(evmAt(int64(pending.Deadline)+1), owner, prove(segment, false)); SpecialTxErrorCode(err) != ErrStorageChallengeExpired.Code {
+		t.Errorf("late proof: have %v, want %v", err, ErrStorageChallengeExpired)
+	}
+	if err := dispatchHandler(evmAt(int64(pending.Deadline)), owner, prove(segment, false)); err != nil {
+		t.Fatalf("proof failed: %v", err)
+	}
+	challenges = db.GetStorageChallenges()
+	if challenges.PendingOf(node) != nil {
+		t.Errorf("answered challenge still pending")
+	}
+	if err := dispatchHandler(evmAt(12), owner, prove(segment, false)); SpecialTxErrorCode(err) != ErrStorageChallengeNotFound.Code {
+		t.Errorf("proof without challenge: have %v, want %v", err, ErrStorageChallengeNotFound)
+	}
+}
@@ -0,0 +1,96 @@
+package vm
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/GenaroNetwork/GenaroCore/common"
+	"github.com/GenaroNetwork/GenaroCore/core/state"
+	"github.com/GenaroNetwork/GenaroCore/core/types"
+	"github.com/GenaroNetwork/GenaroCore/ethdb"
+	"github.com/GenaroNetwork/GenaroCore/params"
+)
+
+func TestVestingSpecialTx(t *testing.T) {
+	db, _ := state.New(common.Hash{}, state.NewDatabase(ethdb.NewMemDatabase()))
+	grantor := common.HexToAddress("0x1000000000000000000000000000000000000001")
+	beneficiary := common.HexToAddress("0x1000000000000000000000000000000000000002")
+	balance := new(big.Int).Mul(big.NewInt(10000), common.BaseCompany)
+	total := new(big.Int).Mul(big.NewInt(4000), common.BaseCompany)
+	db.AddBalance(grantor, balance)
+
+	config := &params.ChainConfig{Genaro: &params.GenaroConfig{VestingBlock: big.NewInt(10)}}
+	evmAt := func(number int64) *EVM {
+		context := Context{
+			CanTransfer: func(db StateDB, addr common.Address, amount *big.Int) bool {
+				return db.GetBalance(addr).Cmp(amount) >= 0
+			},
+			BlockNumber: big.NewInt(number),
+		}
+		return NewEVM(context, db, config, Config{})
+	}
+	create := []byte(fmt.Sprintf(`{"type":"0x2f","address":"%x","vesting":{"total":"0x%x","start":10,"cliff":20,"end":30}}`, beneficiary, total))
+	stake := []byte(fmt.Sprintf(`{"type":"0x1","address":"%x","stake":1000,"fromVesting":true}`, beneficiary))
+	withdraw := []byte(`{"type":"0x30"}`)
+
+	// Before the fork vestings are undefined.
+	if err := dispatchHandler(evmAt(9), grantor, create); err != ErrSpecialTxUndefinedType {
+		t.Fatalf("vesting before fork: have %v, want %v", err, ErrSpecialTxUndefinedType)
+	}
+
+	small := []byte(fmt.Sprintf(`{"type":"0x2f","address":"%x","vesting":{"total":"0x%x","start":10,"cliff":20,"end":30}}`, beneficiary, common.BaseCompany))
+	if err := dispatchHandler(evmAt(10), grantor, small); SpecialTxErrorCode(err) != ErrVestingTooSmall.Code {
+		t.Errorf("vesting below the minimum: have %v, want %v", err, ErrVestingTooSmall)
+	}
+	if err := dispatchHandler(evmAt(10), grantor, create); err != nil {
+		t.Fatalf("vesting creation failed: %v", err)
+	}
+	if want := new(big.Int).Sub(balance, total); db.GetBalance(grantor).Cmp(want) != 0 {
+		t.Errorf("grantor balance mismatch: have %v, want %v", db.GetBalance(grantor), want)
+	}
+	if err := dispatchHandler(evmAt(10), grantor, create); SpecialTxErrorCode(err) != ErrVestingExists.Code {
+		t.Errorf("second vesting: have %v, want %v", err, ErrVestingExists)
+	}
+
+	// The locked GNX can be staked without any balance.
+	if err := dispatchHandler(evmAt(11), beneficiary, stake); err != nil {
+		t.Fatalf("stake from vesting failed: %v", err)
+	}
+	if have, _ := db.GetStake(beneficiary); have != 1000 {
+		t.Errorf("stake mismatch: have %d, want 1000", have)
+	}
+	if err := dispatchHandler(evmAt(15), beneficiary, withdraw); SpecialTxErrorCode(err) != ErrNothingVested.Code {
+		t.Errorf("withdraw before cliff: have %v, want %v", err, ErrNothingVested)
+	}
+
+	// Half of the grant is vested at block 20, the staked part stays locked.
+	if err := dispatchHandler(evmAt(20), beneficiary, withdraw); err != nil {
+		t.Fatalf("withdraw failed: %v", err)
+	}
+	if want := new(big.Int).Mul(big.NewInt(2000), common.BaseCompany); db.GetBalance(beneficiary).Cmp(want) != 0 {
+		t.Errorf("beneficiary balance mismatch: have %v, want %v", db.GetBalance(beneficiary), want)
+	}
+	if err := dispatchHandler(evmAt(30), beneficiary, withdraw); err != nil {
+		t.Fatalf("withdraw failed: %v", err)
+	}
+	if want := new(big.Int).Mul(big.NewInt(3000), common.BaseCompany); db.GetBalance(beneficiary).Cmp(want) != 0 {
+		t.Errorf("beneficiary balance mismatch: have %v, want %v", db.GetBalance(beneficiary), want)
+	}
+	if held := db.GetVesting(beneficiary).Held(); held.Sign() != 0 {
+		t.Errorf("held after withdrawal: have %v, want 0", held)
+	}
+
+	// A stake from a vesting only costs nothing from the fork on.
+	var input types.SpecialTxInput
+	if err := json.Unmarshal(stake, &input); err != nil {
+		t.Fatal(err)
+	}
+	if cost := input.SpecialCost(nil, nil, config.Genaro, big.NewInt(9)); cost.Cmp(new(big.Int).Mul(big.NewInt(1000), common.BaseCompany)) != 0 {
+		t.Errorf("cost before fork: have %v, want the stake", &cost)
+	}
+	if cost := input.SpecialCost(nil, nil, config.Genaro, big.NewInt(10)); cost.Sign() != 0 {
+		t.Errorf("cost after fork: have %v, want 0", &cost)
+	}
+}
@@ -0,0 +1,495 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/GenaroNetwork/GenaroCore/accounts/abi"
+	"github.com/GenaroNetwork/GenaroCore/common"
+	"github.com/GenaroNetwork/GenaroCore/common/hexutil"
+	"github.com/GenaroNetwork/GenaroCore/core"
+	"github.com/GenaroNetwork/GenaroCore/core/types"
+	"github.com/GenaroNetwork/GenaroCore/core/vm"
+	"github.com/GenaroNetwork/GenaroCore/ethdb"
+	"github.com/GenaroNetwork/GenaroCore/internal/ethapi"
+	"github.com/GenaroNetwork/GenaroCore/params"
+	"github.com/GenaroNetwork/GenaroCore/rlp"
+	"github.com/GenaroNetwork/GenaroCore/rpc"
+)
+
+const (
+	// addressIndexSection is the number of blocks indexed at once.
+	addressIndexSection = 4096
+
+	// addressIndexConfirms is the number of confirmation blocks before a
+	// section is indexed.
+	addressIndexConfirms = 12
+
+	// addressIndexThrottling is the time to wait between indexing two
+	// consecutive sections while catching up with the chain.
+	addressIndexThrottling = 20 * time.Millisecond
+
+	// addressIndexPageLimit is the maximum number of transactions returned at once.
+	addressIndexPageLimit = 1000
+
+	// addressIndexScanLimit is the maximum number of blocks past the index
+	// scanned by a single query.
+	addressIndexScanLimit = 2 * addressIndexSection
+)
+
+// addressIndexPrefix is the data table of the address index.
+var addressIndexPrefix = []byte("iA")
+
+var errAddressRange = errors.New("fromBlock can't be larger than toBlock")
+
+// addressTx locates a transaction of an account.
+type addressTx struct {
+	Number uint64
+	Index  uint32
+	Type   uint64 // special transaction type, 0 for other transactions
+}
+
+// addressSectionKey = 'b' + section (uint64 big endian) -> accounts with transactions in the section
+func addressSectionKey(section uint64) []byte {
+	key := make([]byte, 9)
+	key[0] = 'b'
+	binary.BigEndian.PutUint64(key[1:], section)
+	return key
+}
+
+// addressListKey = 'l' + account -> sections with transactions of the account
+func addressListKey(account common.Address) []byte {
+	return append([]byte{'l'}, account.Bytes()...)
+}
+
+// addressTxsKey = 's' + account + section (uint64 big endian) -> transactions of the account in the section
+func addressTxsKey(account common.Address, section uint64) []byte {
+	key := make([]byte, 1+common.AddressLength+8)
+	key[0] = 's'
+	copy(key[1:], account.Bytes())
+	binary.BigEndian.PutUint64(key[1+common.AddressLength:], section)
+	return key
+}
+
+// AddressIndex records the transactions of the canonical chain under their
+// sender, their recipient and, for special transactions, the accounts they
+// act on, so that the transactions of an account are found without scanning
+// the chain.
+type AddressIndex struct {
+	chainDb ethdb.Database // database holding the chain
+	db      ethdb.Database // table the index is stored in
+	config  *params.ChainConfig
+	indexer *core.ChainIndexer
+}
+
+// NewAddressIndex creates the address index of the chain. The index is built
+// in the background from the bodies and receipts of the blocks.
+func NewAddressIndex(db ethdb.Database, config *params.ChainConfig) *AddressIndex {
+	table := ethdb.NewTable(db, string(addressIndexPrefix))
+	backend := &addressIndexer{
+		chainDb: db,
+		db:      table,
+		config:  config,
+	}
+	return &AddressIndex{
+		chainDb: db,
+		db:      table,
+		config:  config,
+		indexer: core.NewChainIndexer(db, table, backend, addressIndexSection, addressIndexConfirms, addressIndexThrottling, "addressindex"),
+	}
+}
+
+// Start starts indexing the chain.
+func (ai *AddressIndex) Start(chain core.ChainIndexerChain) {
+	ai.indexer.Start(chain)
+}
+
+// Close stops indexing the chain.
+func (ai *AddressIndex) Close() error {
+	return ai.indexer.Close()
+}
+
+// Indexed returns the number of the last block indexed, false if none is.
+func (ai *AddressIndex) Indexed() (uint64, bool) {
+	sections, _, _ := ai.indexer.Sections()
+	if sections == 0 {
+		return 0, false
+	}
+	return sections*addressIndexSection - 1, true
+}
+
+func readAddressSections(db ethdb.Database, account common.Address) []uint64 {
+	var list []uint64
+	if blob, err := db.Get(addressListKey(account)); err == nil {
+		rlp.DecodeBytes(blob, &list)
+	}
+	return list
+}
+
+func readAddressTxs(db ethdb.Database, account common.Address, section uint64) ([]addressTx, error) {
+	blob, err := db.Get(addressTxsKey(account, section))
+	if err != nil {
+		return nil, err
+	}
+	var txs []addressTx
+	if err := rlp.DecodeBytes(blob, &txs); err != nil {
+		return nil, err
+	}
+	return txs, nil
+}
+
+// specialTopics maps the id of the events of the special transactions to the
+// topic positions of their indexed address arguments.
+var specialTopics = func() map[common.Hash][]int {
+	topics := make(map[common.Hash][]int)
+	for _, event := range vm.SpecialTxEvents() {
+		position := 0
+		for _, input := range event.Inputs {
+			if !input.Indexed {
+				continue
+			}
+			position++
+			if input.Type.T == abi.AddressTy {
+				topics[event.Id()] = append(topics[event.Id()], position)
+			}
+		}
+	}
+	return topics
+}()
+
+// txSubjects returns the accounts the transaction is indexed under and its
+// special transaction type, 0 if it is not a special transaction.
+func txSubjects(signer types.Signer, tx *types.Transaction, receipt *types.Receipt) ([]common.Address, uint64, error) {
+	from, err := types.Sender(signer, tx)
+	if err != nil {
+		return nil, 0, err
+	}
+	subjects := []common.Address{from}
+	if tx.To() == nil {
+		if receipt != nil && receipt.ContractAddress != (common.Address{}) {
+			subjects = append(subjects, receipt.ContractAddress)
+		}
+		return dedupAddresses(subjects), 0, nil
+	}
+	if *tx.To() != common.SpecialSyncAddress {
+		return dedupAddresses(append(subjects, *tx.To())), 0, nil
+	}
+	// Special transactions act on the accounts named in their input, and on
+	// the ones their logs carry since the special logs fork
+	var txType uint64
+	var s types.SpecialTxInput
+	if err := json.Unmarshal(tx.Data(), &s); err == nil {
+		if s.Type != nil {
+			txType = s.Type.ToInt().Uint64()
+		}
+		subjects = append(subjects, specialInputSubjects(&s)...)
+	}
+	if receipt != nil {
+		for _, log := range receipt.Logs {
+			if log.Address != common.SpecialSyncAddress || len(log.Topics) == 0 {
+				continue
+			}
+			for _, position := range specialTopics[log.Topics[0]] {
+				if position < len(log.Topics) {
+					subjects = append(subjects, common.BytesToAddress(log.Topics[position].Bytes()))
+				}
+			}
+		}
+	}
+	return dedupAddresses(subjects), txType, nil
+}
+
+// specialInputSubjects returns the accounts named in a special transaction
+// input, including the ones of the steps of a batch.
+func specialInputSubjects(s *types.SpecialTxInput) []common.Address {
+	var subjects []common.Address
+	if common.IsHexAddress(s.Address) {
+		subjects = append(subjects, common.HexToAddress(s.Address))
+	}
+	if s.SynchronizeShareKey.RecipientAddress != (common.Address{}) {
+		subjects = append(subjects, s.SynchronizeShareKey.RecipientAddress)
+	}
+	for i := range s.Batch {
+		subjects = append(subjects, specialInputSubjects(&s.Batch[i])...)
+	}
+	return subjects
+}
+
+func dedupAddresses(addrs []common.Address) []common.Address {
+	seen := make(map[common.Address]bool)
+	unique := addrs[:0]
+	for _, addr := range addrs {
+		if !seen[addr] {
+			seen[addr] = true
+			unique = append(unique, addr)
+		}
+	}
+	return unique
+}
+
+// blockAddressTxs returns the transactions of the block by the accounts they
+// are indexed under.
+func blockAddressTxs(db ethdb.Database, config *params.ChainConfig, hash common.Hash, number uint64) (map[common.Address][]addressTx, error) {
+	body := core.GetBody(db, hash, number)
+	if body == nil {
+		return nil, fmt.Errorf("block body #%d [%x…] not found", number, hash[:4])
+	}
+	receipts := core.GetBlockReceipts(db, hash, number)
+	if len(body.Transactions) > 0 && len(receipts) != len(body.Transactions) {
+		return nil, fmt.Errorf("block receipts #%d [%x…] not found", number, hash[:4])
+	}
+	signer := types.MakeSigner(config, new(big.Int).SetUint64(number))
+
+	txs := make(map[common.Address][]addressTx)
+	for i, tx := range body.Transactions {
+		subjects, txType, err := txSubjects(signer, tx, receipts[i])
+		if err != nil {
+			return nil, err
+		}
+		for _, account := range subjects {
+			txs[account] = append(txs[account], addressTx{Number: number, Index: uint32(i), Type: txType})
+		}
+	}
+	return txs, nil
+}
+
+// addressIndexer implements core.ChainIndexerBackend, recording the
+// transactions of every block under the accounts they concern.
+type addressIndexer struct {
+	chainDb ethdb.Database // database holding the chain
+	db      ethdb.Database // table the index is stored in
+	config  *params.ChainConfig
+
+	section uint64
+	txs     map[common.Address][]addressTx // transactions of the section by account
+	err     error                          // first failure of the section, reported on commit
+}
+
+// Reset implements core.ChainIndexerBackend, starting a new section and
+// dropping the transactions a previous chain recorded in it.
+func (b *addressIndexer) Reset(section uint64, prevHead common.Hash) error {
+	b.section, b.err = section, nil
+	b.txs = make(map[common.Address][]addressTx)
+
+	blob, err := b.db.Get(addressSectionKey(section))
+	if err != nil {
+		return nil
+	}
+	var accounts []common.Address
+	if err := rlp.DecodeBytes(blob, &accounts); err != nil {
+		return err
+	}
+	batch := b.db.NewBatch()
+	for _, account := range accounts {
+		if err := b.truncate(batch, account, nil); err != nil {
+			return err
+		}
+		batch.Delete(addressTxsKey(account, section))
+	}
+	batch.Delete(addressSectionKey(section))
+	return batch.Write()
+}
+
+// truncate drops the sections of the account from the current one on, then
+// appends the ones given.
+func (b *addressIndexer) truncate(batch ethdb.Batch, account common.Address, sections []uint64) error {
+	list := readAddressSections(b.db, account)
+	i := sort.Search(len(list), func(i int) bool { return list[i] >= b.section })
+	blob, err := rlp.EncodeToBytes(append(list[:i], sections...))
+	if err != nil {
+		return err
+	}
+	return batch.Put(addressListKey(account), blob)
+}
+
+// Process implements core.ChainIndexerBackend, recording the transactions of
+// the block.
+func (b *addressIndexer) Process(header *types.Header) {
+	if b.err != nil {
+		return
+	}
+	txs, err := blockAddressTxs(b.chainDb, b.config, header.Hash(), header.Number.Uint64())
+	if err != nil {
+		b.err = err
+		return
+	}
+	for account, list := range txs {
+		b.txs[account] = append(b.txs[account], list...)
+	}
+}
+
+// Commit implements core.ChainIndexerBackend, writing the transactions of the
+// section out into the database.
+func (b *addressIndexer) Commit() error {
+	if b.err != nil {
+		return b.err
+	}
+	batch := b.db.NewBatch()
+	accounts := make([]common.Address, 0, len(b.txs))
+	for account, txs := range b.txs {
+		blob, err := rlp.EncodeToBytes(txs)
+		if err != nil {
+			return err
+		}
+		batch.Put(addressTxsKey(account, b.section), blob)
+		if err := b.truncate(batch, account, []uint64{b.section}); err != nil {
+			return err
+		}
+		accounts = append(accounts, account)
+	}
+	sort.Slice(accounts, func(i, j int) bool { return bytes.Compare(accounts[i][:], accounts[j][:]) < 0 })
+	blob, err := rlp.EncodeToBytes(accounts)
+	if err != nil {
+		return err
+	}
+	batch.Put(addressSectionKey(b.section), blob)
+	return batch.Write()
+}
+
+// AddressTransactionsPage is a page of the transactions of an account.
+type AddressTransactionsPage struct {
+	Transactions []*ethapi.RPCTransaction `json:"transactions"`
+	Next         *hexutil.Uint64          `json:"next"`    // block to query the next page from, nil on the last page
+	Indexed      *hexutil.Uint64          `json:"indexed"` // last block indexed, nil if none is yet
+}
+
+// PublicAddressIndexAPI serves the transactions of the accounts recorded by
+// the address index.
+type PublicAddressIndexAPI struct {
+	index *AddressIndex
+}
+
+// NewPublicAddressIndexAPI creates a new address index API.
+func NewPublicAddressIndexAPI(index *AddressIndex) *PublicAddressIndexAPI {
+	return &PublicAddressIndexAPI{index}
+}
+
+// GetTransactionsByAddress returns the transactions in the range sent or
+// received by the account, and the special transactions acting on it, in
+// chain order. With txType set, only the special transactions of that type
+// are returned. The transactions of a block are never split across pages.
+//
+// The blocks past the index are scanned, a bounded number of them at a time,
+// so that a page may hold fewer transactions than the limit while the index
+// is catching up with the chain.
+func (api *PublicAddressIndexAPI) GetTransactionsByAddress(address common.Address, fromBlock, toBlock rpc.BlockNumber, txType *hexutil.Uint64, limit *hexutil.Uint64) (*AddressTransactionsPage, error) {
+	ai := api.index
+	hash := core.GetHeadBlockHash(ai.chainDb)
+	header := core.GetHeader(ai.chainDb, hash, core.GetBlockNumber(ai.chainDb, hash))
+	if header == nil {
+		return nil, fmt.Errorf("head block %x not found", hash)
+	}
+	head := header.Number.Uint64()
+	from, to := uint64(fromBlock), uint64(toBlock)
+	if fromBlock < 0 {
+		from = head
+	}
+	if toBlock < 0 || to > head {
+		to = head
+	}
+	if from > to {
+		return nil, errAddressRange
+	}
+	count := addressIndexPageLimit
+	if limit != nil && *limit > 0 && uint64(*limit) < addressIndexPageLimit {
+		count = int(*limit)
+	}
+	match := func(tx addressTx) bool {
+		return tx.Number >= from && tx.Number <= to && (txType == nil || tx.Type == uint64(*txType))
+	}
+	page := &AddressTransactionsPage{Transactions: []*ethapi.RPCTransaction{}}
+
+	// Collect the transactions of the index, then scan the blocks past it
+	var txs []addressTx
+	scanFrom := from
+	if indexed, ok := ai.Indexed(); ok {
+		page.Indexed = (*hexutil.Uint64)(&indexed)
+
+		sections := readAddressSections(ai.db, address)
+		i := sort.Search(len(sections), func(i int) bool { return sections[i] >= from/addressIndexSection })
+		for ; i < len(sections) && sections[i]*addressIndexSection <= to && len(txs) <= count; i++ {
+			list, err := readAddressTxs(ai.db, address, sections[i])
+			if err != nil {
+				return nil, err
+			}
+			for _, tx := range list {
+				if match(tx) {
+					txs = append(txs, tx)
+				}
+			}
+		}
+		if indexed+1 > scanFrom {
+			scanFrom = indexed + 1
+		}
+	}
+	var scanStop *hexutil.Uint64 // first block left unscanned
+	for number := scanFrom; number <= to && len(txs) <= count; number++ {
+		if number-scanFrom == addressIndexScanLimit {
+			scanStop = (*hexutil.Uint64)(&number)
+			break
+		}
+		blockTxs, err := blockAddressTxs(ai.chainDb, ai.config, core.GetCanonicalHash(ai.chainDb, number), number)
+		if err != nil {
+			return nil, err
+		}
+		for _, tx := range blockTxs[address] {
+			if match(tx) {
+				txs = append(txs, tx)
+			}
+		}
+	}
+	// Cut the page before the first block that does not fit in it entirely,
+	// unless a single block holds more transactions than the limit
+	end := len(txs)
+	page.Next = scanStop
+	if len(txs) > count {
+		end = count
+		for end > 0 && txs[end-1].Number == txs[end].Number {
+			end--
+		}
+		for end == 0 || end < len(txs) && txs[end].Number == txs[end-1].Number {
+			end++
+		}
+		next := txs[end-1].Number + 1
+		if end < len(txs) {
+			next = txs[end].Number
+		}
+		page.Next = nil
+		if next <= to {
+			page.Next = (*hexutil.Uint64)(&next)
+		}
+	}
+	var block *types.Block
+	for _, tx := range txs[:end] {
+		if block == nil || block.NumberU64() != tx.Number {
+			hash := core.GetCanonicalHash(ai.chainDb, tx.Number)
+			if block = core.GetBlock(ai.chainDb, hash, tx.Number); block == nil {
+				return nil, fmt.Errorf("block #%d not found", tx.Number)
+			}
+		}
+		page.Transactions = append(page.Transactions, ethapi.NewRPCTransactionFromBlockIndex(block, uint64(tx.Index)))
+	}
+	return page, nil
+}
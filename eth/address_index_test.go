@@ -0,0 +1,156 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/GenaroNetwork/GenaroCore/common"
+	"github.com/GenaroNetwork/GenaroCore/common/hexutil"
+	"github.com/GenaroNetwork/GenaroCore/core"
+	"github.com/GenaroNetwork/GenaroCore/core/types"
+	"github.com/GenaroNetwork/GenaroCore/core/vm"
+	"github.com/GenaroNetwork/GenaroCore/crypto"
+	"github.com/GenaroNetwork/GenaroCore/ethdb"
+	"github.com/GenaroNetwork/GenaroCore/params"
+	"github.com/GenaroNetwork/GenaroCore/rpc"
+)
+
+func TestAddressIndex(t *testing.T) {
+	var (
+		db       = ethdb.NewMemDatabase()
+		config   = params.TestChainConfig
+		key, _   = crypto.GenerateKey()
+		alice    = crypto.PubkeyToAddress(key.PublicKey)
+		bob      = common.HexToAddress("0x1000000000000000000000000000000000000001")
+		carol    = common.HexToAddress("0x1000000000000000000000000000000000000002")
+		dave     = common.HexToAddress("0x1000000000000000000000000000000000000003")
+		nonce    uint64
+		stakeLog = vm.SpecialTxEvents()["StakeSync"].Id()
+	)
+	send := func(number uint64, to common.Address, data string) *types.Transaction {
+		tx := types.NewTransaction(nonce, to, big.NewInt(1), 100000, big.NewInt(1), []byte(data))
+		tx, err := types.SignTx(tx, types.MakeSigner(config, new(big.Int).SetUint64(number)), key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		nonce++
+		return tx
+	}
+	// Alice transfers to Bob, syncs the stake of Carol in a special transaction
+	// whose log names Dave, then sends Bob three transfers in a single block.
+	// The last transfer is past the indexed section.
+	txs := map[uint64]types.Transactions{
+		1: {send(1, bob, "")},
+		2: {send(2, common.SpecialSyncAddress, fmt.Sprintf(`{"type":"0x1","address":"%s"}`, carol.Hex()))},
+		3: {send(3, bob, ""), send(3, bob, ""), send(3, bob, "")},
+	}
+	logs := map[common.Hash][]*types.Log{
+		txs[2][0].Hash(): {{Address: common.SpecialSyncAddress, Topics: []common.Hash{stakeLog, common.BytesToHash(alice.Bytes()), common.BytesToHash(dave.Bytes())}}},
+	}
+	last := uint64(addressIndexSection + addressIndexConfirms + 4)
+	txs[last] = types.Transactions{send(last, bob, "")}
+
+	var headers []*types.Header
+	parent := common.Hash{}
+	for number := uint64(0); number <= last; number++ {
+		header := &types.Header{ParentHash: parent, Number: new(big.Int).SetUint64(number), Difficulty: big.NewInt(1)}
+		block := types.NewBlockWithHeader(header).WithBody(txs[number], nil)
+		receipts := make(types.Receipts, len(txs[number]))
+		for i, tx := range txs[number] {
+			receipts[i] = &types.Receipt{TxHash: tx.Hash(), Logs: logs[tx.Hash()]}
+		}
+		core.WriteBlock(db, block)
+		core.WriteBlockReceipts(db, block.Hash(), number, receipts)
+		core.WriteCanonicalHash(db, block.Hash(), number)
+		headers = append(headers, block.Header())
+		parent = block.Hash()
+	}
+	core.WriteHeadBlockHash(db, parent)
+
+	index := NewAddressIndex(db, config)
+	index.Start(&historyTestChain{head: headers[last]})
+	for start := time.Now(); ; time.Sleep(10 * time.Millisecond) {
+		if indexed, ok := index.Indexed(); ok && indexed == addressIndexSection-1 {
+			break
+		}
+		if time.Since(start) > 5*time.Second {
+			t.Fatalf("chain not indexed")
+		}
+	}
+	index.Close()
+	api := NewPublicAddressIndexAPI(index)
+
+	check := func(name string, address common.Address, from, to rpc.BlockNumber, txType, limit *hexutil.Uint64, want types.Transactions, next *hexutil.Uint64) {
+		page, err := api.GetTransactionsByAddress(address, from, to, txType, limit)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		var hashes []common.Hash
+		for _, tx := range page.Transactions {
+			hashes = append(hashes, tx.Hash)
+		}
+		if len(hashes) != len(want) {
+			t.Fatalf("%s: transactions %x, want %d", name, hashes, len(want))
+		}
+		for i, tx := range want {
+			if hashes[i] != tx.Hash() {
+				t.Errorf("%s: transaction %d is %x, want %x", name, i, hashes[i], tx.Hash())
+			}
+		}
+		if (page.Next == nil) != (next == nil) || next != nil && *page.Next != *next {
+			t.Errorf("%s: next %v, want %v", name, page.Next, next)
+		}
+		if page.Indexed == nil || *page.Indexed != addressIndexSection-1 {
+			t.Errorf("%s: indexed %v", name, page.Indexed)
+		}
+	}
+	u64 := func(n uint64) *hexutil.Uint64 { return (*hexutil.Uint64)(&n) }
+	all := types.Transactions{txs[1][0], txs[2][0], txs[3][0], txs[3][1], txs[3][2], txs[last][0]}
+
+	check("sender", alice, 0, rpc.LatestBlockNumber, nil, nil, all, nil)
+	check("recipient", bob, 0, rpc.LatestBlockNumber, nil, nil, append(types.Transactions{all[0]}, all[2:]...), nil)
+	check("special input", carol, 0, rpc.LatestBlockNumber, nil, nil, txs[2], nil)
+	check("special log", dave, 0, rpc.LatestBlockNumber, nil, nil, txs[2], nil)
+	check("special type", alice, 0, rpc.LatestBlockNumber, u64(1), nil, txs[2], nil)
+	check("other type", alice, 0, rpc.LatestBlockNumber, u64(3), nil, nil, nil)
+	check("plain only", bob, 0, rpc.LatestBlockNumber, u64(0), nil, append(types.Transactions{all[0]}, all[2:]...), nil)
+	check("range", alice, 2, 3, nil, nil, all[1:5], nil)
+	check("unindexed", alice, rpc.BlockNumber(last), rpc.LatestBlockNumber, nil, nil, txs[last], nil)
+
+	// Pages never split the transactions of a block
+	check("first page", alice, 0, rpc.LatestBlockNumber, nil, u64(3), all[:2], u64(3))
+	check("large block", alice, 3, rpc.LatestBlockNumber, nil, u64(2), all[2:5], u64(4))
+	check("last page", alice, rpc.BlockNumber(last), rpc.LatestBlockNumber, nil, u64(2), txs[last], nil)
+
+	// A reorg of the section drops the transactions of the old chain
+	backend := &addressIndexer{chainDb: db, db: index.db, config: config}
+	if err := backend.Reset(0, common.Hash{}); err != nil {
+		t.Fatal(err)
+	}
+	for _, header := range headers[:2] {
+		backend.Process(header)
+	}
+	if err := backend.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	check("sender after reorg", alice, 0, rpc.LatestBlockNumber, nil, nil, types.Transactions{all[0], all[5]}, nil)
+	check("special after reorg", carol, 0, rpc.LatestBlockNumber, nil, nil, nil, nil)
+}
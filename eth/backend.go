@@ -82,6 +82,8 @@ type Ethereum struct {
 
 	bloomRequests chan chan *bloombits.Retrieval // Channel receiving bloom data retrieval requests
 	bloomIndexer  *core.ChainIndexer             // Bloom indexer operating during block imports
+	genaroHistory *GenaroHistory                 // Genaro history indexer, nil if disabled
+	addressIndex  *AddressIndex                  // Address transaction indexer, nil if disabled
 
 	ApiBackend *EthApiBackend
 
@@ -109,6 +111,9 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 	if !config.SyncMode.IsValid() {
 		return nil, fmt.Errorf("invalid sync mode %d", config.SyncMode)
 	}
+	if config.GenaroHistory && !config.NoPruning {
+		return nil, errors.New("the Genaro history requires an archive node")
+	}
 	chainDb, err := CreateDB(ctx, config, "chaindata")
 	if err != nil {
 		return nil, err
@@ -160,6 +165,14 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 		core.WriteChainConfig(chainDb, genesisHash, chainConfig)
 	}
 	eth.bloomIndexer.Start(eth.blockchain)
+	if config.GenaroHistory {
+		eth.genaroHistory = NewGenaroHistory(chainDb, eth.blockchain.StateCache())
+		eth.genaroHistory.Start(eth.blockchain)
+	}
+	if config.AddressIndex {
+		eth.addressIndex = NewAddressIndex(chainDb, eth.chainConfig)
+		eth.addressIndex.Start(eth.blockchain)
+	}
 
 	if config.TxPool.Journal != "" {
 		config.TxPool.Journal = ctx.ResolvePath(config.TxPool.Journal)
@@ -205,7 +218,7 @@ func CreateDB(ctx *node.ServiceContext, config *Config, name string) (ethdb.Data
 	if err != nil {
 		return nil, err
 	}
-	if db, ok := db.(*ethdb.LDBDatabase); ok {
+	if db, ok := db.(ethdb.DiskDatabase); ok {
 		db.Meter("eth/db/chaindata/")
 	}
 	return db, nil
@@ -253,6 +266,22 @@ func (s *Ethereum) APIs() []rpc.API {
 	// Append any APIs exposed explicitly by the consensus engine
 	apis = append(apis, s.engine.APIs(s.BlockChain())...)
 
+	if s.genaroHistory != nil {
+		apis = append(apis, rpc.API{
+			Namespace: "eth",
+			Version:   "1.0",
+			Service:   NewPublicGenaroHistoryAPI(s.genaroHistory),
+			Public:    true,
+		})
+	}
+	if s.addressIndex != nil {
+		apis = append(apis, rpc.API{
+			Namespace: "eth",
+			Version:   "1.0",
+			Service:   NewPublicAddressIndexAPI(s.addressIndex),
+			Public:    true,
+		})
+	}
 	// Append all the local APIs and return
 	return append(apis, []rpc.API{
 		{
@@ -402,6 +431,11 @@ func (s *Ethereum) Start(srvr *p2p.Server) error {
 	// Start the bloom bits servicing goroutines
 	s.startBloomHandlers()
 
+	// Start posting the consensus events of imported blocks
+	if engine, ok := s.engine.(*genaro.Genaro); ok {
+		go s.postGenaroEvents(engine)
+	}
+
 	// Start the RPC service
 	s.netRPCService = ethapi.NewPublicNetAPI(srvr, s.NetVersion())
 
@@ -421,6 +455,23 @@ func (s *Ethereum) Start(srvr *p2p.Server) error {
 	return nil
 }
 
+// postGenaroEvents posts the events the engine collected while finalizing a
+// block once the block is part of the chain. It returns when the chain stops.
+func (s *Ethereum) postGenaroEvents(engine *genaro.Genaro) {
+	events := make(chan core.ChainEvent, chainEventChanSize)
+	sub := s.blockchain.SubscribeChainEvent(events)
+	defer sub.Unsubscribe()
+
+	for {
+		select {
+		case ev := <-events:
+			engine.PostBlockEvents(ev.Block.Header())
+		case <-sub.Err():
+			return
+		}
+	}
+}
+
 // Stop implements node.Service, terminating all internal goroutines used by the
 // Ethereum protocol.
 func (s *Ethereum) Stop() error {
@@ -428,6 +479,12 @@ func (s *Ethereum) Stop() error {
 		s.stopDbUpgrade()
 	}
 	s.bloomIndexer.Close()
+	if s.genaroHistory != nil {
+		s.genaroHistory.Close()
+	}
+	if s.addressIndex != nil {
+		s.addressIndex.Close()
+	}
 	s.blockchain.Stop()
 	s.protocolManager.Stop()
 	if s.lesServer != nil {
@@ -46,6 +46,10 @@ const (
 	bloomRetrievalWait = time.Duration(0)
 )
 
+// IndexPrefixes are the data tables the chain indexers keep in the chain
+// database, none of which is part of the state.
+var IndexPrefixes = [][]byte{core.BloomBitsIndexPrefix, addressIndexPrefix, genaroHistoryPrefix}
+
 // startBloomHandlers starts a batch of goroutines to accept bloom bit database
 // retrievals from possibly a range of filters and serving the data to satisfy.
 func (eth *Ethereum) startBloomHandlers() {
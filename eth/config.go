@@ -83,6 +83,12 @@ type Config struct {
 	SyncMode  downloader.SyncMode
 	NoPruning bool
 
+	// Enables the index of the Genaro data history of the accounts, requires NoPruning
+	GenaroHistory bool `toml:",omitempty"`
+
+	// Enables the index of the transactions of the accounts
+	AddressIndex bool `toml:",omitempty"`
+
 	// Light client options
 	LightServ  int `toml:",omitempty"` // Maximum percentage of time allowed for serving LES requests
 	LightPeers int `toml:",omitempty"` // Maximum number of LES client peers
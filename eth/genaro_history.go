@@ -0,0 +1,487 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/GenaroNetwork/GenaroCore/common"
+	"github.com/GenaroNetwork/GenaroCore/common/hexutil"
+	"github.com/GenaroNetwork/GenaroCore/core"
+	"github.com/GenaroNetwork/GenaroCore/core/state"
+	"github.com/GenaroNetwork/GenaroCore/core/types"
+	"github.com/GenaroNetwork/GenaroCore/ethdb"
+	"github.com/GenaroNetwork/GenaroCore/rlp"
+	"github.com/GenaroNetwork/GenaroCore/rpc"
+	"github.com/GenaroNetwork/GenaroCore/trie"
+)
+
+const (
+	// genaroHistorySection is the number of blocks indexed at once. The states
+	// of all the blocks are read, so the history requires an archive node.
+	genaroHistorySection = 64
+
+	// genaroHistoryConfirms is the number of confirmation blocks before a
+	// section is indexed.
+	genaroHistoryConfirms = 12
+
+	// genaroHistoryThrottling is the time to wait between indexing two
+	// consecutive sections.
+	genaroHistoryThrottling = 100 * time.Millisecond
+
+	// genaroHistoryPageLimit is the maximum number of changes returned at once.
+	genaroHistoryPageLimit = 1000
+)
+
+// genaroHistoryPrefix is the data table of the Genaro history index.
+var genaroHistoryPrefix = []byte("iG")
+
+// The Genaro data of the accounts the history is recorded of.
+const (
+	historyStake byte = iota
+	historyHeft
+	historyTraffic
+	historyBuckets
+)
+
+var errHistoryNotIndexed = errors.New("block range not indexed yet")
+
+// historyChange identifies a change of the Genaro data of an account.
+type historyChange struct {
+	Kind    byte
+	Account common.Address
+}
+
+// historyBlockKey = 'b' + num (uint64 big endian) -> changes of the block
+func historyBlockKey(number uint64) []byte {
+	key := make([]byte, 9)
+	key[0] = 'b'
+	binary.BigEndian.PutUint64(key[1:], number)
+	return key
+}
+
+// historyListKey = 'l' + kind + account -> numbers of the blocks changing the data
+func historyListKey(change historyChange) []byte {
+	return append([]byte{'l', change.Kind}, change.Account.Bytes()...)
+}
+
+// historyValueKey = 'v' + kind + account + num (uint64 big endian) -> data after the block
+func historyValueKey(change historyChange, number uint64) []byte {
+	key := make([]byte, 2+common.AddressLength+8)
+	key[0], key[1] = 'v', change.Kind
+	copy(key[2:], change.Account.Bytes())
+	binary.BigEndian.PutUint64(key[2+common.AddressLength:], number)
+	return key
+}
+
+// GenaroHistory records every change of the stake, heft, traffic and buckets
+// of the accounts in the canonical chain, including the ones the HeftLog and
+// StakeLog of the state no longer hold after BlockLogLenth blocks.
+type GenaroHistory struct {
+	db      ethdb.Database // table the history is stored in
+	indexer *core.ChainIndexer
+}
+
+// NewGenaroHistory creates the history index of the chain, reading the states
+// of the blocks from statedb.
+func NewGenaroHistory(db ethdb.Database, statedb state.Database) *GenaroHistory {
+	table := ethdb.NewTable(db, string(genaroHistoryPrefix))
+	backend := &genaroHistoryIndexer{
+		chainDb: db,
+		db:      table,
+		state:   statedb,
+		size:    genaroHistorySection,
+	}
+	return &GenaroHistory{
+		db:      table,
+		indexer: core.NewChainIndexer(db, table, backend, genaroHistorySection, genaroHistoryConfirms, genaroHistoryThrottling, "genarohistory"),
+	}
+}
+
+// Start starts indexing the chain.
+func (h *GenaroHistory) Start(chain core.ChainIndexerChain) {
+	h.indexer.Start(chain)
+}
+
+// Close stops indexing the chain.
+func (h *GenaroHistory) Close() error {
+	return h.indexer.Close()
+}
+
+// Indexed returns the number of the last block indexed, false if none is.
+func (h *GenaroHistory) Indexed() (uint64, bool) {
+	sections, _, _ := h.indexer.Sections()
+	if sections == 0 {
+		return 0, false
+	}
+	return sections*genaroHistorySection - 1, true
+}
+
+// changes returns the numbers of up to limit blocks in [from, to] changing the
+// data of the account, and the number of the next block changing it in the
+// range if there are more.
+func (h *GenaroHistory) changes(change historyChange, from, to uint64, limit int) ([]uint64, *uint64) {
+	list := readHistoryList(h.db, change)
+	i := sort.Search(len(list), func(i int) bool { return list[i] >= from })
+
+	var numbers []uint64
+	for ; i < len(list) && list[i] <= to; i++ {
+		if len(numbers) == limit {
+			next := list[i]
+			return numbers, &next
+		}
+		numbers = append(numbers, list[i])
+	}
+	return numbers, nil
+}
+
+// value decodes the data of the account after the block into val.
+func (h *GenaroHistory) value(change historyChange, number uint64, val interface{}) error {
+	blob, err := h.db.Get(historyValueKey(change, number))
+	if err != nil {
+		return err
+	}
+	return rlp.DecodeBytes(blob, val)
+}
+
+func readHistoryList(db ethdb.Database, change historyChange) []uint64 {
+	var list []uint64
+	if blob, err := db.Get(historyListKey(change)); err == nil {
+		rlp.DecodeBytes(blob, &list)
+	}
+	return list
+}
+
+// genaroHistoryIndexer implements core.ChainIndexerBackend, recording the
+// Genaro data of the accounts whose state changed in every block.
+type genaroHistoryIndexer struct {
+	chainDb ethdb.Database // database holding the chain
+	db      ethdb.Database // table the history is stored in
+	state   state.Database // database holding the states of the blocks
+	size    uint64
+
+	section    uint64
+	parentRoot common.Hash                // state root of the last block processed
+	batch      ethdb.Batch                // changes of the section
+	appended   map[historyChange][]uint64 // blocks of the section changing the data of an account
+	err        error                      // first failure of the section, reported on commit
+}
+
+// Reset implements core.ChainIndexerBackend, starting a new section and
+// dropping the changes a previous chain recorded in it.
+func (b *genaroHistoryIndexer) Reset(section uint64, prevHead common.Hash) error {
+	b.section, b.parentRoot, b.err = section, common.Hash{}, nil
+	b.batch = b.db.NewBatch()
+	b.appended = make(map[historyChange][]uint64)
+
+	if prevHead != (common.Hash{}) {
+		header := core.GetHeader(b.chainDb, prevHead, core.GetBlockNumber(b.chainDb, prevHead))
+		if header == nil {
+			return fmt.Errorf("section head %x unknown", prevHead)
+		}
+		b.parentRoot = header.Root
+	}
+	batch := b.db.NewBatch()
+	for number := section * b.size; number < (section+1)*b.size; number++ {
+		blob, err := b.db.Get(historyBlockKey(number))
+		if err != nil {
+			continue
+		}
+		var changes []historyChange
+		if err := rlp.DecodeBytes(blob, &changes); err != nil {
+			return err
+		}
+		for _, change := range changes {
+			if err := b.truncate(batch, change, readHistoryList(b.db, change)); err != nil {
+				return err
+			}
+			batch.Delete(historyValueKey(change, number))
+		}
+		batch.Delete(historyBlockKey(number))
+	}
+	return batch.Write()
+}
+
+// truncate drops the blocks of the list from the start of the section on.
+func (b *genaroHistoryIndexer) truncate(batch ethdb.Batch, change historyChange, list []uint64) error {
+	start := b.section * b.size
+	i := sort.Search(len(list), func(i int) bool { return list[i] >= start })
+	blob, err := rlp.EncodeToBytes(list[:i])
+	if err != nil {
+		return err
+	}
+	return batch.Put(historyListKey(change), blob)
+}
+
+// Process implements core.ChainIndexerBackend, recording the Genaro data of
+// the accounts changed by the block.
+func (b *genaroHistoryIndexer) Process(header *types.Header) {
+	if b.err == nil {
+		b.err = b.process(header)
+	}
+	b.parentRoot = header.Root
+}
+
+func (b *genaroHistoryIndexer) process(header *types.Header) error {
+	if header.Root == b.parentRoot {
+		return nil
+	}
+	triedb := b.state.TrieDB()
+	parent, err := trie.New(b.parentRoot, triedb)
+	if err != nil {
+		return err
+	}
+	current, err := trie.New(header.Root, triedb)
+	if err != nil {
+		return err
+	}
+	preimages, err := b.state.OpenTrie(header.Root)
+	if err != nil {
+		return err
+	}
+	// Collect the accounts added, updated or deleted by the block
+	var keys [][]byte
+	seen := make(map[string]bool)
+	for _, pair := range [][2]*trie.Trie{{parent, current}, {current, parent}} {
+		it, _ := trie.NewDifferenceIterator(pair[0].NodeIterator(nil), pair[1].NodeIterator(nil))
+		for it.Next(true) {
+			if it.Leaf() && !seen[string(it.LeafKey())] {
+				seen[string(it.LeafKey())] = true
+				keys = append(keys, common.CopyBytes(it.LeafKey()))
+			}
+		}
+		if err := it.Error(); err != nil {
+			return err
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return bytes.Compare(keys[i], keys[j]) < 0 })
+
+	number := header.Number.Uint64()
+	var changes []historyChange
+	for _, key := range keys {
+		before, err := genaroDataAt(parent, key)
+		if err != nil {
+			return err
+		}
+		after, err := genaroDataAt(current, key)
+		if err != nil {
+			return err
+		}
+		var account common.Address
+		if preimage := preimages.GetKey(key); len(preimage) == common.AddressLength {
+			account = common.BytesToAddress(preimage)
+		} else {
+			return fmt.Errorf("missing preimage of account %x", key)
+		}
+		values := map[byte][2]interface{}{
+			historyStake:   {before.Stake, after.Stake},
+			historyHeft:    {before.Heft, after.Heft},
+			historyTraffic: {before.Traffic, after.Traffic},
+			historyBuckets: {before.Buckets, after.Buckets},
+		}
+		for kind := historyStake; kind <= historyBuckets; kind++ {
+			prev, err := rlp.EncodeToBytes(values[kind][0])
+			if err != nil {
+				return err
+			}
+			next, err := rlp.EncodeToBytes(values[kind][1])
+			if err != nil {
+				return err
+			}
+			if bytes.Equal(prev, next) {
+				continue
+			}
+			change := historyChange{Kind: kind, Account: account}
+			b.batch.Put(historyValueKey(change, number), next)
+			b.appended[change] = append(b.appended[change], number)
+			changes = append(changes, change)
+		}
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+	blob, err := rlp.EncodeToBytes(changes)
+	if err != nil {
+		return err
+	}
+	return b.batch.Put(historyBlockKey(number), blob)
+}
+
+// genaroDataAt returns the Genaro data of the account with the hashed key in
+// the state trie, empty if the account has none.
+func genaroDataAt(t *trie.Trie, key []byte) (types.GenaroData, error) {
+	var data types.GenaroData
+	blob, err := t.TryGet(key)
+	if err != nil || blob == nil {
+		return data, err
+	}
+	var account state.Account
+	if err := rlp.DecodeBytes(blob, &account); err != nil {
+		return data, err
+	}
+	if !state.CheckCodeEmpty(account.CodeHash) {
+		return data, nil
+	}
+	// special accounts hold other data, which leaves the Genaro data empty
+	json.Unmarshal(account.CodeHash, &data)
+	return data, nil
+}
+
+// Commit implements core.ChainIndexerBackend, writing the changes of the
+// section out into the database.
+func (b *genaroHistoryIndexer) Commit() error {
+	if b.err != nil {
+		return b.err
+	}
+	for change, numbers := range b.appended {
+		list := readHistoryList(b.db, change)
+		// blocks of the section left by a previous chain are dropped
+		start := b.section * b.size
+		i := sort.Search(len(list), func(i int) bool { return list[i] >= start })
+		blob, err := rlp.EncodeToBytes(append(list[:i], numbers...))
+		if err != nil {
+			return err
+		}
+		b.batch.Put(historyListKey(change), blob)
+	}
+	return b.batch.Write()
+}
+
+// GenaroHistoryPoint is the value of the Genaro data of an account after the
+// block changing it.
+type GenaroHistoryPoint struct {
+	BlockNumber hexutil.Uint64 `json:"blockNumber"`
+	Value       hexutil.Uint64 `json:"value"`
+}
+
+// GenaroHistoryPage is a page of the changes of the Genaro data of an account.
+type GenaroHistoryPage struct {
+	Points  []GenaroHistoryPoint `json:"points"`
+	Next    *hexutil.Uint64      `json:"next"`    // block to query the next page from, nil on the last page
+	Indexed hexutil.Uint64       `json:"indexed"` // last block indexed
+}
+
+// BucketHistoryPoint is the buckets of an account after the block changing them.
+type BucketHistoryPoint struct {
+	BlockNumber hexutil.Uint64           `json:"blockNumber"`
+	Buckets     []*types.BucketPropertie `json:"buckets"`
+}
+
+// BucketHistoryPage is a page of the changes of the buckets of an account.
+type BucketHistoryPage struct {
+	Points  []BucketHistoryPoint `json:"points"`
+	Next    *hexutil.Uint64      `json:"next"`    // block to query the next page from, nil on the last page
+	Indexed hexutil.Uint64       `json:"indexed"` // last block indexed
+}
+
+// PublicGenaroHistoryAPI serves the history of the Genaro data of the accounts
+// recorded by the Genaro history index.
+type PublicGenaroHistoryAPI struct {
+	history *GenaroHistory
+}
+
+// NewPublicGenaroHistoryAPI creates a new Genaro history API.
+func NewPublicGenaroHistoryAPI(history *GenaroHistory) *PublicGenaroHistoryAPI {
+	return &PublicGenaroHistoryAPI{history}
+}
+
+// GetStakeHistory returns the stake of the account after every block changing
+// it in the range.
+func (api *PublicGenaroHistoryAPI) GetStakeHistory(address common.Address, fromBlock, toBlock rpc.BlockNumber, limit *hexutil.Uint64) (*GenaroHistoryPage, error) {
+	return api.history.page(historyChange{historyStake, address}, fromBlock, toBlock, limit)
+}
+
+// GetHeftHistory returns the heft of the account after every block changing
+// it in the range.
+func (api *PublicGenaroHistoryAPI) GetHeftHistory(address common.Address, fromBlock, toBlock rpc.BlockNumber, limit *hexutil.Uint64) (*GenaroHistoryPage, error) {
+	return api.history.page(historyChange{historyHeft, address}, fromBlock, toBlock, limit)
+}
+
+// GetTrafficHistory returns the traffic of the account after every block
+// changing it in the range.
+func (api *PublicGenaroHistoryAPI) GetTrafficHistory(address common.Address, fromBlock, toBlock rpc.BlockNumber, limit *hexutil.Uint64) (*GenaroHistoryPage, error) {
+	return api.history.page(historyChange{historyTraffic, address}, fromBlock, toBlock, limit)
+}
+
+// GetBucketHistory returns the buckets of the account after every block
+// changing them in the range.
+func (api *PublicGenaroHistoryAPI) GetBucketHistory(address common.Address, fromBlock, toBlock rpc.BlockNumber, limit *hexutil.Uint64) (*BucketHistoryPage, error) {
+	change := historyChange{historyBuckets, address}
+	numbers, next, indexed, err := api.history.pageNumbers(change, fromBlock, toBlock, limit)
+	if err != nil {
+		return nil, err
+	}
+	page := &BucketHistoryPage{Points: []BucketHistoryPoint{}, Next: next, Indexed: hexutil.Uint64(indexed)}
+	for _, number := range numbers {
+		var buckets []*types.BucketPropertie
+		if err := api.history.value(change, number, &buckets); err != nil {
+			return nil, err
+		}
+		page.Points = append(page.Points, BucketHistoryPoint{BlockNumber: hexutil.Uint64(number), Buckets: buckets})
+	}
+	return page, nil
+}
+
+func (h *GenaroHistory) page(change historyChange, fromBlock, toBlock rpc.BlockNumber, limit *hexutil.Uint64) (*GenaroHistoryPage, error) {
+	numbers, next, indexed, err := h.pageNumbers(change, fromBlock, toBlock, limit)
+	if err != nil {
+		return nil, err
+	}
+	page := &GenaroHistoryPage{Points: []GenaroHistoryPoint{}, Next: next, Indexed: hexutil.Uint64(indexed)}
+	for _, number := range numbers {
+		var value uint64
+		if err := h.value(change, number, &value); err != nil {
+			return nil, err
+		}
+		page.Points = append(page.Points, GenaroHistoryPoint{BlockNumber: hexutil.Uint64(number), Value: hexutil.Uint64(value)})
+	}
+	return page, nil
+}
+
+// pageNumbers resolves the range against the indexed blocks and returns the
+// blocks of the page.
+func (h *GenaroHistory) pageNumbers(change historyChange, fromBlock, toBlock rpc.BlockNumber, limit *hexutil.Uint64) ([]uint64, *hexutil.Uint64, uint64, error) {
+	indexed, ok := h.Indexed()
+	if !ok {
+		return nil, nil, 0, errHistoryNotIndexed
+	}
+	from, to := uint64(fromBlock), uint64(toBlock)
+	if fromBlock < 0 {
+		from = indexed
+	}
+	if toBlock < 0 || to > indexed {
+		to = indexed
+	}
+	if from > indexed {
+		return nil, nil, indexed, errHistoryNotIndexed
+	}
+	count := genaroHistoryPageLimit
+	if limit != nil && *limit > 0 && uint64(*limit) < genaroHistoryPageLimit {
+		count = int(*limit)
+	}
+	numbers, next := h.changes(change, from, to, count)
+	if next == nil {
+		return numbers, nil, indexed, nil
+	}
+	return numbers, (*hexutil.Uint64)(next), indexed, nil
+}
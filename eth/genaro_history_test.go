@@ -0,0 +1,219 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/GenaroNetwork/GenaroCore/common"
+	"github.com/GenaroNetwork/GenaroCore/common/hexutil"
+	"github.com/GenaroNetwork/GenaroCore/core"
+	"github.com/GenaroNetwork/GenaroCore/core/state"
+	"github.com/GenaroNetwork/GenaroCore/core/types"
+	"github.com/GenaroNetwork/GenaroCore/ethdb"
+	"github.com/GenaroNetwork/GenaroCore/event"
+	"github.com/GenaroNetwork/GenaroCore/rpc"
+)
+
+// historyTestChain is a chain the Genaro history indexer follows.
+type historyTestChain struct {
+	head *types.Header
+	feed event.Feed
+}
+
+func (c *historyTestChain) CurrentHeader() *types.Header { return c.head }
+
+func (c *historyTestChain) SubscribeChainEvent(ch chan<- core.ChainEvent) event.Subscription {
+	return c.feed.Subscribe(ch)
+}
+
+// writeHistoryTestChain writes canonical headers on top of parent whose states
+// are mutated by the block numbers of mutate.
+func writeHistoryTestChain(t *testing.T, db ethdb.Database, sdb state.Database, parent *types.Header, count int, mutate map[uint64]func(*state.StateDB)) []*types.Header {
+	var headers []*types.Header
+	for i := 0; i < count; i++ {
+		number, root, parentHash := uint64(0), common.Hash{}, common.Hash{}
+		if parent != nil {
+			number, root, parentHash = parent.Number.Uint64()+1, parent.Root, parent.Hash()
+		}
+		statedb, err := state.New(root, sdb)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fn := mutate[number]; fn != nil {
+			fn(statedb)
+		}
+		if root, err = statedb.Commit(true); err != nil {
+			t.Fatal(err)
+		}
+		header := &types.Header{ParentHash: parentHash, Number: new(big.Int).SetUint64(number), Root: root, Difficulty: big.NewInt(1)}
+		core.WriteHeader(db, header)
+		core.WriteCanonicalHash(db, header.Hash(), number)
+		headers = append(headers, header)
+		parent = header
+	}
+	return headers
+}
+
+func TestGenaroHistory(t *testing.T) {
+	var (
+		db      = ethdb.NewMemDatabase()
+		sdb     = state.NewDatabase(db)
+		staker  = common.HexToAddress("0x1000000000000000000000000000000000000001")
+		node    = common.HexToAddress("0x1000000000000000000000000000000000000002")
+		bucket  = types.BucketPropertie{BucketId: "bucket", TimeStart: 1, TimeEnd: 2, Backup: 3, Size: 4}
+		history = NewGenaroHistory(db, sdb)
+		api     = NewPublicGenaroHistoryAPI(history)
+	)
+	headers := writeHistoryTestChain(t, db, sdb, nil, 2*genaroHistorySection+genaroHistoryConfirms, map[uint64]func(*state.StateDB){
+		0: func(s *state.StateDB) { s.UpdateStake(staker, 10, 0) },
+		5: func(s *state.StateDB) { s.UpdateStake(staker, 10, 5); s.UpdateHeft(node, 7, 5) },
+		70: func(s *state.StateDB) {
+			s.UpdateTraffic(staker, 3)
+			s.UpdateBucketProperties(staker, bucket.BucketId, bucket.Size, bucket.Backup, bucket.TimeStart, bucket.TimeEnd)
+		},
+		100: func(s *state.StateDB) { s.UpdateStake(staker, 5, 100); s.AddBalance(node, big.NewInt(1)) },
+	})
+	chain := &historyTestChain{head: headers[len(headers)-1]}
+	history.Start(chain)
+	for start := time.Now(); ; time.Sleep(10 * time.Millisecond) {
+		if indexed, ok := history.Indexed(); ok && indexed == 2*genaroHistorySection-1 {
+			break
+		}
+		if time.Since(start) > 5*time.Second {
+			t.Fatalf("chain not indexed")
+		}
+	}
+	history.Close()
+
+	point := func(number, value uint64) GenaroHistoryPoint {
+		return GenaroHistoryPoint{BlockNumber: hexutil.Uint64(number), Value: hexutil.Uint64(value)}
+	}
+	check := func(name string, page *GenaroHistoryPage, err error, points []GenaroHistoryPoint, next *hexutil.Uint64) {
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !reflect.DeepEqual(page.Points, points) || !reflect.DeepEqual(page.Next, next) {
+			t.Errorf("%s: points %v next %v, want %v next %v", name, page.Points, page.Next, points, next)
+		}
+		if page.Indexed != 2*genaroHistorySection-1 {
+			t.Errorf("%s: indexed %d", name, page.Indexed)
+		}
+	}
+	page, err := api.GetStakeHistory(staker, 0, rpc.LatestBlockNumber, nil)
+	check("stake", page, err, []GenaroHistoryPoint{point(0, 10), point(5, 20), point(100, 25)}, nil)
+
+	limit, next := hexutil.Uint64(2), hexutil.Uint64(100)
+	page, err = api.GetStakeHistory(staker, 0, rpc.LatestBlockNumber, &limit)
+	check("stake first page", page, err, []GenaroHistoryPoint{point(0, 10), point(5, 20)}, &next)
+	page, err = api.GetStakeHistory(staker, rpc.BlockNumber(next), rpc.LatestBlockNumber, &limit)
+	check("stake next page", page, err, []GenaroHistoryPoint{point(100, 25)}, nil)
+	page, err = api.GetStakeHistory(staker, 6, 99, nil)
+	check("stake range", page, err, []GenaroHistoryPoint{}, nil)
+
+	page, err = api.GetHeftHistory(node, 0, rpc.LatestBlockNumber, nil)
+	check("heft", page, err, []GenaroHistoryPoint{point(5, 7)}, nil)
+	page, err = api.GetStakeHistory(node, 0, rpc.LatestBlockNumber, nil)
+	check("balance only", page, err, []GenaroHistoryPoint{}, nil)
+	page, err = api.GetTrafficHistory(staker, 0, rpc.LatestBlockNumber, nil)
+	check("traffic", page, err, []GenaroHistoryPoint{point(70, 3)}, nil)
+
+	buckets, err := api.GetBucketHistory(staker, 0, rpc.LatestBlockNumber, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(buckets.Points) != 1 || buckets.Points[0].BlockNumber != 70 || len(buckets.Points[0].Buckets) != 1 || !reflect.DeepEqual(*buckets.Points[0].Buckets[0], bucket) {
+		t.Errorf("buckets: %+v", buckets.Points)
+	}
+	if _, err := api.GetStakeHistory(staker, 2*genaroHistorySection, rpc.LatestBlockNumber, nil); err != errHistoryNotIndexed {
+		t.Errorf("range beyond the index: %v", err)
+	}
+
+	// A reorg replacing the second section drops the changes of the old chain
+	fork := writeHistoryTestChain(t, db, sdb, headers[genaroHistorySection-1], genaroHistorySection, map[uint64]func(*state.StateDB){
+		90: func(s *state.StateDB) { s.UpdateStake(staker, 1, 90) },
+	})
+	backend := &genaroHistoryIndexer{chainDb: db, db: history.db, state: sdb, size: genaroHistorySection}
+	if err := backend.Reset(1, headers[genaroHistorySection-1].Hash()); err != nil {
+		t.Fatal(err)
+	}
+	for _, header := range fork {
+		backend.Process(header)
+	}
+	if err := backend.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	page, err = api.GetStakeHistory(staker, 0, rpc.LatestBlockNumber, nil)
+	check("stake after reorg", page, err, []GenaroHistoryPoint{point(0, 10), point(5, 20), point(90, 21)}, nil)
+	page, err = api.GetTrafficHistory(staker, 0, rpc.LatestBlockNumber, nil)
+	check("traffic after reorg", page, err, []GenaroHistoryPoint{}, nil)
+}
+
+// Tests that pruning the state keeps the history, whose value entries are as
+// long as the hashes keying the trie nodes.
+func TestGenaroHistoryPrune(t *testing.T) {
+	dir, err := ioutil.TempDir("", "genaro-history")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	db, err := ethdb.NewLDBDatabase(dir, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	var (
+		sdb     = state.NewDatabase(db)
+		staker  = common.HexToAddress("0x1000000000000000000000000000000000000001")
+		history = NewGenaroHistory(db, sdb)
+	)
+	headers := writeHistoryTestChain(t, db, sdb, nil, genaroHistorySection+genaroHistoryConfirms, map[uint64]func(*state.StateDB){
+		5: func(s *state.StateDB) { s.UpdateStake(staker, 10, 5) },
+	})
+	head := headers[len(headers)-1]
+	if err := sdb.TrieDB().Commit(head.Root, false); err != nil {
+		t.Fatal(err)
+	}
+	history.Start(&historyTestChain{head: head})
+	for start := time.Now(); ; time.Sleep(10 * time.Millisecond) {
+		if indexed, ok := history.Indexed(); ok && indexed == genaroHistorySection-1 {
+			break
+		}
+		if time.Since(start) > 5*time.Second {
+			t.Fatalf("chain not indexed")
+		}
+	}
+	history.Close()
+
+	if _, err := state.PruneState(db, []common.Hash{head.Root}, IndexPrefixes, 1024*1024, false); err != nil {
+		t.Fatal(err)
+	}
+	page, err := NewPublicGenaroHistoryAPI(history).GetStakeHistory(staker, 0, rpc.LatestBlockNumber, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []GenaroHistoryPoint{{BlockNumber: 5, Value: 10}}
+	if !reflect.DeepEqual(page.Points, want) {
+		t.Errorf("stake after pruning: %v, want %v", page.Points, want)
+	}
+}
@@ -49,6 +49,9 @@ const (
 	// txChanSize is the size of channel listening to TxPreEvent.
 	// The number is referenced from the size of tx pool.
 	txChanSize = 4096
+
+	// chainEventChanSize is the size of channel listening to ChainEvent.
+	chainEventChanSize = 10
 )
 
 var (
@@ -44,6 +44,26 @@ func newTestLDB() (*ethdb.LDBDatabase, func()) {
 	}
 }
 
+func newTestPebble(t *testing.T) (*ethdb.PebbleDatabase, func()) {
+	dirname, err := ioutil.TempDir(os.TempDir(), "ethdb_test_")
+	if err != nil {
+		panic("failed to create test file: " + err.Error())
+	}
+	db, err := ethdb.NewPebbleDatabase(dirname, 0, 0)
+	if err == ethdb.ErrNoPebble {
+		os.RemoveAll(dirname)
+		t.Skip(err)
+	}
+	if err != nil {
+		panic("failed to create test database: " + err.Error())
+	}
+
+	return db, func() {
+		db.Close()
+		os.RemoveAll(dirname)
+	}
+}
+
 var test_values = []string{"", "a", "1251", "\x00123\x00"}
 
 func TestLDB_PutGet(t *testing.T) {
@@ -52,6 +72,12 @@ func TestLDB_PutGet(t *testing.T) {
 	testPutGet(db, t)
 }
 
+func TestPebble_PutGet(t *testing.T) {
+	db, remove := newTestPebble(t)
+	defer remove()
+	testPutGet(db, t)
+}
+
 func TestMemoryDB_PutGet(t *testing.T) {
 	db := ethdb.NewMemDatabase()
 	testPutGet(db, t)
@@ -130,6 +156,12 @@ func TestLDB_ParallelPutGet(t *testing.T) {
 	testParallelPutGet(db, t)
 }
 
+func TestPebble_ParallelPutGet(t *testing.T) {
+	db, remove := newTestPebble(t)
+	defer remove()
+	testParallelPutGet(db, t)
+}
+
 func TestMemoryDB_ParallelPutGet(t *testing.T) {
 	db := ethdb.NewMemDatabase()
 	testParallelPutGet(db, t)
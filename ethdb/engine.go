@@ -0,0 +1,133 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethdb
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/GenaroNetwork/GenaroCore/common"
+	"github.com/GenaroNetwork/GenaroCore/log"
+)
+
+// The engines a database can be stored on disk with.
+const (
+	EngineLevelDB = "leveldb"
+	EnginePebble  = "pebble"
+)
+
+// ErrNoPebble is returned when opening a Pebble database with a binary built
+// without the pebble build tag.
+var ErrNoPebble = errors.New("Pebble support not compiled in, rebuild with -tags pebble")
+
+// engineFile is the file of the database directory recording its engine.
+const engineFile = "ENGINE"
+
+// DatabaseEngine returns the engine of the database stored in the directory,
+// an empty string if there is none. Databases created before the engine was
+// recorded are LevelDB ones.
+func DatabaseEngine(file string) (string, error) {
+	blob, err := ioutil.ReadFile(filepath.Join(file, engineFile))
+	if err == nil {
+		return strings.TrimSpace(string(blob)), nil
+	}
+	if !os.IsNotExist(err) {
+		return "", err
+	}
+	if _, err := os.Stat(filepath.Join(file, "CURRENT")); err == nil {
+		return EngineLevelDB, nil
+	}
+	return "", nil
+}
+
+// NewDatabase opens the database stored in the directory with its recorded
+// engine, creating it with the given engine if there is none. An empty engine
+// stands for the recorded one, or LevelDB for new databases. Opening a
+// database with another engine than the one it was created with fails.
+func NewDatabase(file string, engine string, cache int, handles int) (DiskDatabase, error) {
+	recorded, err := DatabaseEngine(file)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case engine == "" && recorded == "":
+		engine = EngineLevelDB
+	case engine == "":
+		engine = recorded
+	case recorded != "" && engine != recorded:
+		return nil, fmt.Errorf("database %s is stored with %s, not %s", file, recorded, engine)
+	}
+	var db DiskDatabase
+	switch engine {
+	case EngineLevelDB:
+		db, err = NewLDBDatabase(file, cache, handles)
+	case EnginePebble:
+		db, err = NewPebbleDatabase(file, cache, handles)
+	default:
+		return nil, fmt.Errorf("unknown database engine %q", engine)
+	}
+	if err != nil {
+		return nil, err
+	}
+	// Record the engine of new databases and of the ones predating the record
+	path := filepath.Join(file, engineFile)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		if err := ioutil.WriteFile(path, []byte(engine+"\n"), 0644); err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+	return db, nil
+}
+
+// CopyDatabase copies every entry of src into dst, returning the number of
+// entries and bytes copied.
+func CopyDatabase(dst Database, src Iteratee) (int, int, error) {
+	var (
+		entries, size int
+		batch         = dst.NewBatch()
+		logged        = time.Now()
+	)
+	it := src.NewIteratorWithPrefix(nil)
+	defer it.Release()
+
+	for it.Next() {
+		batch.Put(common.CopyBytes(it.Key()), common.CopyBytes(it.Value()))
+		entries++
+		size += len(it.Key()) + len(it.Value())
+
+		if batch.ValueSize() >= IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				return entries, size, err
+			}
+			batch.Reset()
+		}
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Copying database", "entries", entries, "size", common.StorageSize(size), "key", fmt.Sprintf("%x", it.Key()))
+			logged = time.Now()
+		}
+	}
+	if err := it.Error(); err != nil {
+		return entries, size, err
+	}
+	return entries, size, batch.Write()
+}
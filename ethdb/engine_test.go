@@ -0,0 +1,171 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethdb_test
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/GenaroNetwork/GenaroCore/ethdb"
+)
+
+func TestDatabaseEngine(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ethdb_engine_")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	check := func(path, want string) {
+		engine, err := ethdb.DatabaseEngine(path)
+		if err != nil || engine != want {
+			t.Errorf("%s: engine %q (%v), want %q", filepath.Base(path), engine, err, want)
+		}
+	}
+	// New databases record their engine, LevelDB by default
+	for _, engine := range []string{"", ethdb.EngineLevelDB, ethdb.EnginePebble} {
+		path := filepath.Join(dir, "new"+engine)
+		check(path, "")
+		db, err := ethdb.NewDatabase(path, engine, 0, 0)
+		if err == ethdb.ErrNoPebble {
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		db.Close()
+		if engine == "" {
+			engine = ethdb.EngineLevelDB
+		}
+		check(path, engine)
+
+		// Reopening keeps the engine, whichever is requested
+		if db, err = ethdb.NewDatabase(path, "", 0, 0); err != nil {
+			t.Fatal(err)
+		}
+		db.Close()
+		other := ethdb.EnginePebble
+		if engine == ethdb.EnginePebble {
+			other = ethdb.EngineLevelDB
+		}
+		if _, err := ethdb.NewDatabase(path, other, 0, 0); err == nil {
+			t.Errorf("%s database opened with %s", engine, other)
+		}
+	}
+	// Databases predating the record are LevelDB ones
+	path := filepath.Join(dir, "legacy")
+	ldb, err := ethdb.NewLDBDatabase(path, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ldb.Close()
+	check(path, ethdb.EngineLevelDB)
+	if _, err := ethdb.NewDatabase(path, ethdb.EnginePebble, 0, 0); err == nil {
+		t.Errorf("legacy database opened with pebble")
+	}
+	db, err := ethdb.NewDatabase(path, "", 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+	if _, err := os.Stat(filepath.Join(path, "ENGINE")); err != nil {
+		t.Errorf("engine of the legacy database not recorded: %v", err)
+	}
+	if _, err := ethdb.NewDatabase(filepath.Join(dir, "unknown"), "bolt", 0, 0); err == nil {
+		t.Errorf("database opened with an unknown engine")
+	}
+}
+
+func TestCopyDatabase(t *testing.T) {
+	for _, engines := range [][2]string{{ethdb.EngineLevelDB, ethdb.EnginePebble}, {ethdb.EnginePebble, ethdb.EngineLevelDB}} {
+		dir, err := ioutil.TempDir("", "ethdb_copy_")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+
+		src, err := ethdb.NewDatabase(filepath.Join(dir, "src"), engines[0], 0, 0)
+		if err == ethdb.ErrNoPebble {
+			t.Skip(err)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer src.Close()
+		dst, err := ethdb.NewDatabase(filepath.Join(dir, "dst"), engines[1], 0, 0)
+		if err == ethdb.ErrNoPebble {
+			t.Skip(err)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer dst.Close()
+
+		// Enough entries to span several batches, some of them deleted
+		value := bytes.Repeat([]byte{0xff}, 1024)
+		for i := 0; i < 1000; i++ {
+			src.Put([]byte(fmt.Sprintf("key-%04d", i)), append(value, byte(i)))
+		}
+		src.Put([]byte{0xff, 0xff}, nil)
+		for i := 0; i < 1000; i += 10 {
+			src.Delete([]byte(fmt.Sprintf("key-%04d", i)))
+		}
+		entries, size, err := ethdb.CopyDatabase(dst, src)
+		if err != nil {
+			t.Fatalf("%s to %s: %v", engines[0], engines[1], err)
+		}
+		if entries != 901 || size != 900*(8+1025)+2 {
+			t.Errorf("%s to %s: copied %d entries, %d bytes", engines[0], engines[1], entries, size)
+		}
+		for i := 0; i < 1000; i++ {
+			key := []byte(fmt.Sprintf("key-%04d", i))
+			if ok, _ := dst.Has(key); ok != (i%10 != 0) {
+				t.Errorf("%s to %s: key %s present %v", engines[0], engines[1], key, ok)
+			}
+		}
+		// Both engines iterate the prefixes alike
+		for _, db := range []ethdb.DiskDatabase{src, dst} {
+			it := db.NewIteratorWithPrefix([]byte("key-00"))
+			var keys int
+			for it.Next() {
+				if !bytes.HasPrefix(it.Key(), []byte("key-00")) {
+					t.Errorf("key %q out of the prefix", it.Key())
+				}
+				keys++
+			}
+			it.Release()
+			if keys != 90 {
+				t.Errorf("%s: %d keys of the prefix, want 90", db.Path(), keys)
+			}
+			it = db.NewIteratorWithPrefix([]byte{0xff})
+			if !it.Next() || !bytes.Equal(it.Key(), []byte{0xff, 0xff}) || it.Next() {
+				t.Errorf("%s: wrong iteration of the last prefix", db.Path())
+			}
+			it.Release()
+		}
+		if err := dst.Compact(nil, nil); err != nil {
+			t.Errorf("%s: compaction failed: %v", engines[1], err)
+		}
+		if blob, err := dst.Get([]byte("key-0001")); err != nil || !bytes.Equal(blob, append(value, 1)) {
+			t.Errorf("%s: value lost by the compaction: %v", engines[1], err)
+		}
+	}
+}
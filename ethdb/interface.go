@@ -50,3 +50,36 @@ type Batch interface {
 	// Reset resets the batch for reuse
 	Reset()
 }
+
+// Iterator iterates over the entries of a database in ascending key order. An
+// iterator must be released after use.
+type Iterator interface {
+	Next() bool
+	Key() []byte
+	Value() []byte
+	Release()
+	Error() error
+}
+
+// Iteratee wraps the iteration over the content of a database.
+type Iteratee interface {
+	// NewIteratorWithPrefix returns an iterator over the entries whose key
+	// starts with prefix.
+	NewIteratorWithPrefix(prefix []byte) Iterator
+}
+
+// Compacter wraps the manual compaction of a database.
+type Compacter interface {
+	// Compact flattens the database in the key range [start, limit), nil
+	// standing for the start and the end of the keys.
+	Compact(start []byte, limit []byte) error
+}
+
+// DiskDatabase is a database stored on disk by one of the engines.
+type DiskDatabase interface {
+	Database
+	Iteratee
+	Compacter
+	Path() string
+	Meter(prefix string)
+}
@@ -0,0 +1,394 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+//go:build pebble
+// +build pebble
+
+package ethdb
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/GenaroNetwork/GenaroCore/log"
+	"github.com/GenaroNetwork/GenaroCore/metrics"
+	"github.com/cockroachdb/pebble"
+	"github.com/cockroachdb/pebble/bloom"
+)
+
+// PebbleDatabase is a Pebble wrapped object. Pebble flushes and compacts its
+// memory tables concurrently with the writes, which keeps the write heavy
+// workload of the special transactions from stalling on compactions.
+type PebbleDatabase struct {
+	fn string     // filename for reporting
+	db *pebble.DB // Pebble instance
+
+	compTime       int64 // total time spent in compaction in ns
+	compRead       int64 // data read during compaction in bytes
+	compWrite      int64 // data written during compaction in bytes
+	writeDelayN    int64 // number of write stalls
+	writeDelay     int64 // total time of the write stalls in ns
+	writeStallTime int64 // start of the ongoing write stall in unix ns, 0 if none
+
+	compTimeMeter    metrics.Meter // Meter for measuring the total time spent in database compaction
+	compReadMeter    metrics.Meter // Meter for measuring the data read during compaction
+	compWriteMeter   metrics.Meter // Meter for measuring the data written during compaction
+	writeDelayNMeter metrics.Meter // Meter for measuring the write delay number due to database compaction
+	writeDelayMeter  metrics.Meter // Meter for measuring the write delay duration due to database compaction
+
+	quitLock sync.Mutex      // Mutex protecting the quit channel access
+	quitChan chan chan error // Quit channel to stop the metrics collection before closing the database
+
+	log log.Logger // Contextual logger tracking the database path
+}
+
+// NewPebbleDatabase returns a Pebble wrapped object.
+func NewPebbleDatabase(file string, cache int, handles int) (*PebbleDatabase, error) {
+	logger := log.New("database", file)
+
+	// Ensure we have some minimal caching and file guarantees
+	if cache < 16 {
+		cache = 16
+	}
+	if handles < 16 {
+		handles = 16
+	}
+	logger.Info("Allocated cache and file handles", "cache", cache, "handles", handles)
+
+	// Half of the cache goes to the blocks, the other half to the memory
+	// tables, writes stalling only once all of them wait to be flushed
+	memTableLimit := 4
+	memTableSize := cache * 1024 * 1024 / 2 / memTableLimit
+	if memTableSize > 1<<30 {
+		memTableSize = 1 << 30
+	}
+	db := &PebbleDatabase{fn: file, log: logger}
+	blocks := pebble.NewCache(int64(cache * 1024 * 1024 / 2))
+	defer blocks.Unref()
+
+	opts := &pebble.Options{
+		Cache:                       blocks,
+		MaxOpenFiles:                handles,
+		MemTableSize:                uint64(memTableSize),
+		MemTableStopWritesThreshold: memTableLimit,
+		MaxConcurrentCompactions:    func() int { return runtime.NumCPU() },
+		L0CompactionThreshold:       2,
+		L0StopWritesThreshold:       24,
+		EventListener: &pebble.EventListener{
+			CompactionEnd:   db.onCompactionEnd,
+			WriteStallBegin: db.onWriteStallBegin,
+			WriteStallEnd:   db.onWriteStallEnd,
+		},
+		Logger: pebbleLogger{logger},
+	}
+	opts.Levels = make([]pebble.LevelOptions, 7)
+	for i := range opts.Levels {
+		opts.Levels[i].TargetFileSize = int64(2*1024*1024) << uint(i)
+		opts.Levels[i].FilterPolicy = bloom.FilterPolicy(10)
+	}
+	opts.Levels[6].FilterPolicy = nil // the last level is read once the others missed
+	opts.EnsureDefaults()
+
+	var err error
+	if db.db, err = pebble.Open(file, opts); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+// Path returns the path to the database directory.
+func (db *PebbleDatabase) Path() string {
+	return db.fn
+}
+
+// Put puts the given key / value to the queue
+func (db *PebbleDatabase) Put(key []byte, value []byte) error {
+	return db.db.Set(key, value, pebble.NoSync)
+}
+
+func (db *PebbleDatabase) Has(key []byte) (bool, error) {
+	_, closer, err := db.db.Get(key)
+	if err == pebble.ErrNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	closer.Close()
+	return true, nil
+}
+
+// Get returns the given key if it's present.
+func (db *PebbleDatabase) Get(key []byte) ([]byte, error) {
+	dat, closer, err := db.db.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	defer closer.Close()
+	return append([]byte{}, dat...), nil
+}
+
+// Delete deletes the key from the queue and database
+func (db *PebbleDatabase) Delete(key []byte) error {
+	return db.db.Delete(key, pebble.NoSync)
+}
+
+// NewIteratorWithPrefix returns a iterator to iterate over subset of database content with a particular prefix.
+func (db *PebbleDatabase) NewIteratorWithPrefix(prefix []byte) Iterator {
+	opts := &pebble.IterOptions{LowerBound: prefix, UpperBound: prefixUpperBound(prefix)}
+	it, err := db.db.NewIter(opts)
+	return &pebbleIterator{it: it, err: err}
+}
+
+// Compact flattens the database in the key range [start, limit), nil standing
+// for the start and the end of the keys.
+func (db *PebbleDatabase) Compact(start []byte, limit []byte) error {
+	if limit == nil {
+		// Pebble requires an upper bound, take one past the largest key
+		it, err := db.db.NewIter(nil)
+		if err != nil {
+			return err
+		}
+		if it.Last() {
+			limit = append(append([]byte{}, it.Key()...), 0)
+		}
+		if err := it.Close(); err != nil {
+			return err
+		}
+		if limit == nil {
+			return nil
+		}
+	}
+	return db.db.Compact(start, limit, true)
+}
+
+func (db *PebbleDatabase) Close() {
+	// Stop the metrics collection to avoid internal database races
+	db.quitLock.Lock()
+	defer db.quitLock.Unlock()
+
+	if db.quitChan != nil {
+		errc := make(chan error)
+		db.quitChan <- errc
+		if err := <-errc; err != nil {
+			db.log.Error("Metrics collection failed", "err", err)
+		}
+		db.quitChan = nil
+	}
+	err := db.db.Close()
+	if err == nil {
+		db.log.Info("Database closed")
+	} else {
+		db.log.Error("Failed to close database", "err", err)
+	}
+}
+
+func (db *PebbleDatabase) Pebble() *pebble.DB {
+	return db.db
+}
+
+// Metrics returns the internal metrics of the database.
+func (db *PebbleDatabase) Metrics() string {
+	return db.db.Metrics().String()
+}
+
+func (db *PebbleDatabase) onCompactionEnd(info pebble.CompactionInfo) {
+	atomic.AddInt64(&db.compTime, int64(info.TotalDuration))
+	for _, level := range info.Input {
+		for _, table := range level.Tables {
+			atomic.AddInt64(&db.compRead, int64(table.Size))
+		}
+	}
+	for _, table := range info.Output.Tables {
+		atomic.AddInt64(&db.compWrite, int64(table.Size))
+	}
+}
+
+func (db *PebbleDatabase) onWriteStallBegin(info pebble.WriteStallBeginInfo) {
+	atomic.AddInt64(&db.writeDelayN, 1)
+	atomic.StoreInt64(&db.writeStallTime, time.Now().UnixNano())
+	db.log.Warn("Database writes stalled", "reason", info.Reason)
+}
+
+func (db *PebbleDatabase) onWriteStallEnd() {
+	if start := atomic.SwapInt64(&db.writeStallTime, 0); start != 0 {
+		atomic.AddInt64(&db.writeDelay, time.Now().UnixNano()-start)
+	}
+}
+
+// Meter configures the database metrics collectors and
+func (db *PebbleDatabase) Meter(prefix string) {
+	if metrics.Enabled {
+		// Initialize all the metrics collector at the requested prefix
+		db.compTimeMeter = metrics.NewRegisteredMeter(prefix+"compact/time", nil)
+		db.compReadMeter = metrics.NewRegisteredMeter(prefix+"compact/input", nil)
+		db.compWriteMeter = metrics.NewRegisteredMeter(prefix+"compact/output", nil)
+	}
+	// Initialize write delay metrics no matter we are in metric mode or not.
+	db.writeDelayMeter = metrics.NewRegisteredMeter(prefix+"compact/writedelay/duration", nil)
+	db.writeDelayNMeter = metrics.NewRegisteredMeter(prefix+"compact/writedelay/counter", nil)
+
+	// Create a quit channel for the periodic collector and run it
+	db.quitLock.Lock()
+	db.quitChan = make(chan chan error)
+	db.quitLock.Unlock()
+
+	go db.meter(3 * time.Second)
+}
+
+// meter periodically reports the compaction and write stall counters gathered
+// from the Pebble events to the metrics subsystem.
+func (db *PebbleDatabase) meter(refresh time.Duration) {
+	type counter struct {
+		value *int64
+		meter metrics.Meter
+	}
+	counters := []*counter{
+		{&db.compTime, db.compTimeMeter},
+		{&db.compRead, db.compReadMeter},
+		{&db.compWrite, db.compWriteMeter},
+		{&db.writeDelayN, db.writeDelayNMeter},
+		{&db.writeDelay, db.writeDelayMeter},
+	}
+	prev := make([]int64, len(counters))
+	for {
+		for i, c := range counters {
+			value := atomic.LoadInt64(c.value)
+			if c.meter != nil {
+				c.meter.Mark(value - prev[i])
+			}
+			prev[i] = value
+		}
+		select {
+		case errc := <-db.quitChan:
+			errc <- nil
+			return
+		case <-time.After(refresh):
+		}
+	}
+}
+
+func (db *PebbleDatabase) NewBatch() Batch {
+	return &pebbleBatch{db: db.db, b: db.db.NewBatch()}
+}
+
+type pebbleBatch struct {
+	db   *pebble.DB
+	b    *pebble.Batch
+	size int
+}
+
+func (b *pebbleBatch) Put(key, value []byte) error {
+	b.b.Set(key, value, nil)
+	b.size += len(value)
+	return nil
+}
+
+func (b *pebbleBatch) Delete(key []byte) error {
+	b.b.Delete(key, nil)
+	b.size += 1
+	return nil
+}
+
+func (b *pebbleBatch) Write() error {
+	return b.b.Commit(pebble.NoSync)
+}
+
+func (b *pebbleBatch) ValueSize() int {
+	return b.size
+}
+
+func (b *pebbleBatch) Reset() {
+	b.b.Reset()
+	b.size = 0
+}
+
+// pebbleIterator adapts a Pebble iterator to the Iterator interface.
+type pebbleIterator struct {
+	it    *pebble.Iterator
+	moved bool // whether the iterator was positioned on the first entry
+	err   error
+}
+
+func (it *pebbleIterator) Next() bool {
+	if it.it == nil {
+		return false
+	}
+	if !it.moved {
+		it.moved = true
+		return it.it.First()
+	}
+	return it.it.Next()
+}
+
+func (it *pebbleIterator) Key() []byte {
+	if it.it == nil || !it.it.Valid() {
+		return nil
+	}
+	return it.it.Key()
+}
+
+func (it *pebbleIterator) Value() []byte {
+	if it.it == nil || !it.it.Valid() {
+		return nil
+	}
+	return it.it.Value()
+}
+
+func (it *pebbleIterator) Release() {
+	if it.it != nil {
+		if err := it.it.Close(); err != nil && it.err == nil {
+			it.err = err
+		}
+		it.it = nil
+	}
+}
+
+func (it *pebbleIterator) Error() error {
+	if it.err == nil && it.it != nil {
+		return it.it.Error()
+	}
+	return it.err
+}
+
+// prefixUpperBound returns the smallest key greater than all the keys with the
+// prefix, nil if there is none.
+func prefixUpperBound(prefix []byte) []byte {
+	limit := bytes.TrimRight(prefix, "\xff")
+	if len(limit) == 0 {
+		return nil
+	}
+	limit = append([]byte{}, limit...)
+	limit[len(limit)-1]++
+	return limit
+}
+
+// pebbleLogger forwards the messages of Pebble to the database logger.
+type pebbleLogger struct {
+	log log.Logger
+}
+
+func (l pebbleLogger) Infof(format string, args ...interface{}) {
+	l.log.Debug(fmt.Sprintf(format, args...))
+}
+
+func (l pebbleLogger) Fatalf(format string, args ...interface{}) {
+	l.log.Crit(fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,36 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+//go:build !pebble
+// +build !pebble
+
+package ethdb
+
+// PebbleDatabase stands for the Pebble database in binaries built without the
+// pebble build tag, it can not be opened.
+type PebbleDatabase struct {
+	DiskDatabase
+}
+
+// NewPebbleDatabase fails with ErrNoPebble, Pebble support is not compiled in.
+func NewPebbleDatabase(file string, cache int, handles int) (*PebbleDatabase, error) {
+	return nil, ErrNoPebble
+}
+
+// Metrics returns the internal metrics of the database.
+func (db *PebbleDatabase) Metrics() string {
+	return ""
+}
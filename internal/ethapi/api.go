@@ -22,6 +22,7 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"sort"
 	"strings"
 	"time"
 
@@ -38,13 +39,13 @@ import (
 	"github.com/GenaroNetwork/GenaroCore/core/types"
 	"github.com/GenaroNetwork/GenaroCore/core/vm"
 	"github.com/GenaroNetwork/GenaroCore/crypto"
+	"github.com/GenaroNetwork/GenaroCore/ethdb"
 	"github.com/GenaroNetwork/GenaroCore/log"
 	"github.com/GenaroNetwork/GenaroCore/p2p"
 	"github.com/GenaroNetwork/GenaroCore/params"
 	"github.com/GenaroNetwork/GenaroCore/rlp"
 	"github.com/GenaroNetwork/GenaroCore/rpc"
 	"github.com/syndtr/goleveldb/leveldb"
-	"github.com/syndtr/goleveldb/leveldb/util"
 )
 
 const (
@@ -142,6 +143,13 @@ func (s *PublicTxPoolAPI) Status() map[string]hexutil.Uint {
 	}
 }
 
+// SpecialStatus reports whether the special transactions in the pool are
+// executable and why not, including the ones recently dropped as they became
+// invalid.
+func (s *PublicTxPoolAPI) SpecialStatus() []core.SpecialTxStatus {
+	return s.b.SpecialTxStatus()
+}
+
 // Inspect retrieves the content of the transaction pool and flattens it into an
 // easily inspectable list.
 func (s *PublicTxPoolAPI) Inspect() map[string]map[string]map[string]string {
@@ -228,6 +236,66 @@ func (s *PublicBlockChainAPI) GetMainAccountRank(ctx context.Context, blockNr rp
 	return committees
 }
 
+// CandidateProjection is where a candidate would rank in the committee elected
+// at the next epoch boundary.
+type CandidateProjection struct {
+	Candidate         common.Address   `json:"candidate"`
+	Stake             uint64           `json:"stake"`
+	Heft              uint64           `json:"heft"`
+	Rank              int              `json:"rank"` // -1 if not elected
+	Proportion        uint64           `json:"proportion"`
+	EpochReward       *hexutil.Big     `json:"epochReward"`
+	CommitteeMinStake uint64           `json:"committeeMinStake"`
+	Committee         []common.Address `json:"committee"`
+	CommitteeStakes   []uint64         `json:"committeeStakes"`
+}
+
+// ProjectCandidate elects the committee of the next epoch boundary against the
+// state of the given block changed by the hypothetical deltas, and returns the
+// rank, proportion and per-epoch interest reward candidate would get.
+func (s *PublicBlockChainAPI) ProjectCandidate(ctx context.Context, candidate common.Address, deltas []genaro.CandidateDelta, blockNr rpc.BlockNumber) (*CandidateProjection, error) {
+	state, header, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	config := s.b.ChainConfig().Genaro
+	next := (header.Number.Uint64()/config.Epoch + 1) * config.Epoch
+	candidates, rank, proportion := genaro.ProjectElection(config, state, new(big.Int).SetUint64(next), deltas)
+
+	stakes := make(map[common.Address]uint64, len(candidates))
+	projection := &CandidateProjection{
+		Candidate:         candidate,
+		Rank:              -1,
+		EpochReward:       new(hexutil.Big),
+		CommitteeMinStake: state.GetGenaroPrice().CommitteeMinStake,
+		Committee:         rank,
+		CommitteeStakes:   make([]uint64, len(rank)),
+	}
+	for _, c := range candidates {
+		stakes[c.Signer] = c.Stake
+		if c.Signer == candidate {
+			projection.Stake, projection.Heft = c.Stake, c.Heft
+		}
+	}
+	total := uint64(0)
+	for _, p := range proportion {
+		total += p
+	}
+	for i, member := range rank {
+		projection.CommitteeStakes[i] = stakes[member]
+		if member != candidate {
+			continue
+		}
+		projection.Rank = i
+		if total > 0 {
+			// Proportions are normalized the way the committee snapshot does.
+			projection.Proportion = proportion[i] * uint64(common.Base) / total
+			projection.EpochReward = (*hexutil.Big)(genaro.EpochInterestRewards(config, state, next, projection.Proportion))
+		}
+	}
+	return projection, state.Error()
+}
+
 // PrivateAccountAPI provides an API to access accounts managed by this node.
 // It offers methods to create, (un)lock en list accounts. Some methods accept
 // passwords and are therefore considered private by default.
@@ -688,6 +756,61 @@ func (s *PublicBlockChainAPI) GetStake(ctx context.Context, address common.Addre
 	return
 }
 
+// VestingSchedule is the vesting grant of an account together with the amounts
+// released and locked at the block it was queried at.
+type VestingSchedule struct {
+	Total        *hexutil.Big `json:"total"`
+	Start        uint64       `json:"start"`
+	Cliff        uint64       `json:"cliff"`
+	End          uint64       `json:"end"`
+	Withdrawn    *hexutil.Big `json:"withdrawn"`
+	Staked       *hexutil.Big `json:"staked"`
+	Slashed      *hexutil.Big `json:"slashed"`
+	Vested       *hexutil.Big `json:"vested"`
+	Withdrawable *hexutil.Big `json:"withdrawable"`
+	Locked       *hexutil.Big `json:"locked"`
+}
+
+// GetVesting returns the vesting schedule of the given address in the state of
+// the given block number, nil if the address has no vesting grant.
+func (s *PublicBlockChainAPI) GetVesting(ctx context.Context, address common.Address, blockNr rpc.BlockNumber) (*VestingSchedule, error) {
+	state, header, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	vesting := state.GetVesting(address)
+	if vesting == nil {
+		return nil, state.Error()
+	}
+	number := header.Number.Uint64()
+	vested := vesting.Vested(number)
+	locked := vesting.Held()
+	withdrawable := vesting.Withdrawable(number)
+	locked.Sub(locked, withdrawable)
+	schedule := &VestingSchedule{
+		Total:        (*hexutil.Big)(vesting.Total.ToInt()),
+		Start:        vesting.Start,
+		Cliff:        vesting.Cliff,
+		End:          vesting.End,
+		Withdrawn:    new(hexutil.Big),
+		Staked:       new(hexutil.Big),
+		Slashed:      new(hexutil.Big),
+		Vested:       (*hexutil.Big)(vested),
+		Withdrawable: (*hexutil.Big)(withdrawable),
+		Locked:       (*hexutil.Big)(locked),
+	}
+	if vesting.Withdrawn != nil {
+		schedule.Withdrawn = vesting.Withdrawn
+	}
+	if vesting.Staked != nil {
+		schedule.Staked = vesting.Staked
+	}
+	if vesting.Slashed != nil {
+		schedule.Slashed = vesting.Slashed
+	}
+	return schedule, state.Error()
+}
+
 // getStakeRangeDiff returns the stakeRangeDiff of ether for the given address in the state of the
 // given block number. The rpc.LatestBlockNumber and rpc.PendingBlockNumber meta
 // block numbers are also allowed.
@@ -736,6 +859,50 @@ func (s *PublicBlockChainAPI) GetHeftRangeDiff(ctx context.Context, address comm
 	return
 }
 
+// HeftReporter is a registered heft reporter. Flagged reporters disagreed with
+// the aggregated heft for common.HeftReportStrikes epochs in a row.
+type HeftReporter struct {
+	Address common.Address `json:"address"`
+	Strikes uint64         `json:"strikes"`
+	Flagged bool           `json:"flagged"`
+}
+
+// GetHeftReporters returns the heft reporters in the order they were
+// registered.
+func (s *PublicBlockChainAPI) GetHeftReporters(ctx context.Context, blockNr rpc.BlockNumber) ([]HeftReporter, error) {
+	state, _, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	oracle := state.GetHeftOracle()
+	reporters := make([]HeftReporter, len(oracle.Reporters))
+	for i, reporter := range oracle.Reporters {
+		reporters[i] = HeftReporter{reporter, oracle.Strikes[reporter], oracle.IsFlagged(reporter, common.HeftReportStrikes)}
+	}
+	return reporters, state.Error()
+}
+
+// GetHeftReports returns the heft reports of the current epoch, keyed by the
+// reported account and then by reporter.
+func (s *PublicBlockChainAPI) GetHeftReports(ctx context.Context, blockNr rpc.BlockNumber) (map[common.Address]map[common.Address]uint64, error) {
+	state, _, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	return state.GetHeftOracle().Reports, state.Error()
+}
+
+// GetStorageChallenges returns the chunks committed by the storage nodes, their
+// pending challenges and the challenges failed in the current epoch by staker.
+func (s *PublicBlockChainAPI) GetStorageChallenges(ctx context.Context, blockNr rpc.BlockNumber) (*types.StorageChallenges, error) {
+	state, _, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	challenges := state.GetStorageChallenges()
+	return &challenges, state.Error()
+}
+
 // only use in genaro
 func (s *PublicBlockChainAPI) GetGenaroCodeHash(ctx context.Context, address common.Address, blockNr rpc.BlockNumber) (GenaroCodeHash string) {
 	state, _, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
@@ -863,12 +1030,12 @@ type CallArgs struct {
 	Data     hexutil.Bytes   `json:"data"`
 }
 
-func (s *PublicBlockChainAPI) doCall(ctx context.Context, args CallArgs, blockNr rpc.BlockNumber, vmCfg vm.Config, timeout time.Duration) ([]byte, uint64, bool, error) {
+func (s *PublicBlockChainAPI) doCall(ctx context.Context, args CallArgs, blockNr rpc.BlockNumber, vmCfg vm.Config, timeout time.Duration) ([]byte, uint64, error) {
 	defer func(start time.Time) { log.Debug("Executing EVM call finished", "runtime", time.Since(start)) }(time.Now())
 
 	state, header, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
 	if state == nil || err != nil {
-		return nil, 0, false, err
+		return nil, 0, err
 	}
 	// Set sender address or use a default if none specified
 	addr := args.From
@@ -906,7 +1073,7 @@ func (s *PublicBlockChainAPI) doCall(ctx context.Context, args CallArgs, blockNr
 	// Get a new instance of the EVM.
 	evm, vmError, err := s.b.GetEVM(ctx, msg, state, header, vmCfg)
 	if err != nil {
-		return nil, 0, false, err
+		return nil, 0, err
 	}
 	// Wait for the context to be done and cancel the evm. Even if the
 	// EVM has finished, cancelling may be done (repeatedly)
@@ -918,17 +1085,34 @@ func (s *PublicBlockChainAPI) doCall(ctx context.Context, args CallArgs, blockNr
 	// Setup the gas pool (also for unmetered requests)
 	// and apply the message.
 	gp := new(core.GasPool).AddGas(math.MaxUint64)
-	res, gas, failed, err := core.ApplyMessage(evm, msg, gp)
+	res, gas, err := core.ApplyMessageWithError(evm, msg, gp)
 	if err := vmError(); err != nil {
-		return nil, 0, false, err
+		return nil, 0, err
+	}
+	return res, gas, err
+}
+
+// specialTxError adds the error code to the message of special transaction
+// errors, so RPC clients can tell why a special transaction was rejected or
+// failed. Other errors are returned as is.
+func specialTxError(err error) error {
+	code := vm.SpecialTxErrorCode(err)
+	if code == 0 {
+		return err
 	}
-	return res, gas, failed, err
+	return fmt.Errorf("special transaction error %d: %v", code, err)
 }
 
 // Call executes the given transaction on the state for the given block number.
 // It doesn't make and changes in the state/blockchain and is useful to execute and retrieve values.
 func (s *PublicBlockChainAPI) Call(ctx context.Context, args CallArgs, blockNr rpc.BlockNumber) (hexutil.Bytes, error) {
-	result, _, _, err := s.doCall(ctx, args, blockNr, vm.Config{}, 5*time.Second)
+	result, _, err := s.doCall(ctx, args, blockNr, vm.Config{}, 5*time.Second)
+	if vmerr, ok := err.(*core.ExecutionError); ok {
+		if vm.SpecialTxErrorCode(vmerr.Err) != 0 {
+			return nil, specialTxError(vmerr.Err)
+		}
+		err = nil
+	}
 	return (hexutil.Bytes)(result), err
 }
 
@@ -954,14 +1138,15 @@ func (s *PublicBlockChainAPI) EstimateGas(ctx context.Context, args CallArgs) (h
 	cap = hi
 
 	// Create a helper to check if a gas allowance results in an executable transaction
+	var specialErr error
 	executable := func(gas uint64) bool {
 		args.Gas = hexutil.Uint64(gas)
 
-		_, _, failed, err := s.doCall(ctx, args, rpc.PendingBlockNumber, vm.Config{}, 0)
-		if err != nil || failed {
-			return false
+		_, _, err := s.doCall(ctx, args, rpc.PendingBlockNumber, vm.Config{}, 0)
+		if vmerr, ok := err.(*core.ExecutionError); ok && vm.SpecialTxErrorCode(vmerr.Err) != 0 {
+			specialErr = vmerr.Err
 		}
-		return true
+		return err == nil
 	}
 	// Execute the binary search and hone in on an executable gas limit
 	for lo+1 < hi {
@@ -975,6 +1160,9 @@ func (s *PublicBlockChainAPI) EstimateGas(ctx context.Context, args CallArgs) (h
 	// Reject the transaction as invalid if it still fails at the highest allowance
 	if hi == cap {
 		if !executable(hi) {
+			if specialErr != nil {
+				return 0, specialTxError(specialErr)
+			}
 			return 0, fmt.Errorf("gas required exceeds allowance or always failing transaction")
 		}
 	}
@@ -1018,6 +1206,100 @@ func (s *PublicBlockChainAPI) GetFileSharePublicKey(ctx context.Context, address
 	return nodes, state.Error()
 }
 
+// GetFileSharePublicKeyAt returns the version of the file share public key of
+// address that was valid at the given block, or nil if there was none. The
+// history is read from the latest state, so old blocks can be queried on
+// non-archive nodes as well.
+func (s *PublicBlockChainAPI) GetFileSharePublicKeyAt(ctx context.Context, address common.Address, blockNr rpc.BlockNumber) (*types.FileSharePublicKeyVersion, error) {
+	header, err := s.b.HeaderByNumber(ctx, blockNr)
+	if header == nil || err != nil {
+		return nil, err
+	}
+	state, _, err := s.b.StateAndHeaderByNumber(ctx, rpc.LatestBlockNumber)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	version, ok := types.FileSharePublicKeyAt(state.GetFileSharePublicKeys(address), header.Number.Uint64())
+	if !ok {
+		return nil, state.Error()
+	}
+	return &version, state.Error()
+}
+
+// ShareKeyOffer is a share key offer together with its status at the queried
+// block: pending, expired, unlocked, cancelled or rejected.
+type ShareKeyOffer struct {
+	types.SynchronizeShareKey
+	State string `json:"state"`
+}
+
+// GetIncomingShareKeys returns the share key offers received by address that
+// have not been collected yet, ordered by share key id.
+func (s *PublicBlockChainAPI) GetIncomingShareKeys(ctx context.Context, address common.Address, blockNr rpc.BlockNumber) ([]ShareKeyOffer, error) {
+	state, header, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	offers := make([]ShareKeyOffer, 0)
+	for _, shareKey := range state.GetSynchronizeShareKeys(address) {
+		offers = append(offers, ShareKeyOffer{shareKey, shareKey.StatusName(header.Number.Uint64())})
+	}
+	sort.Slice(offers, func(i, j int) bool { return offers[i].ShareKeyId < offers[j].ShareKeyId })
+	return offers, state.Error()
+}
+
+// GetOutgoingShareKeys returns the share key offers made by address that have
+// not been collected yet, in the order they were made. Offers made before the
+// ShareKeyBlock fork are not indexed and therefore not returned.
+func (s *PublicBlockChainAPI) GetOutgoingShareKeys(ctx context.Context, address common.Address, blockNr rpc.BlockNumber) ([]ShareKeyOffer, error) {
+	state, header, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	offers := make([]ShareKeyOffer, 0)
+	for _, ref := range state.GetOutgoingShareKeys(address) {
+		if shareKey, ok := state.GetSynchronizeShareKey(ref.RecipientAddress, ref.ShareKeyId); ok && shareKey.FromAccount == address {
+			offers = append(offers, ShareKeyOffer{shareKey, shareKey.StatusName(header.Number.Uint64())})
+		}
+	}
+	return offers, state.Error()
+}
+
+// MortgageInfo is a sidechain mortgage together with its status at the queried
+// block: open, expired or settled.
+type MortgageInfo struct {
+	types.SpecialTxTypeMortgageInit
+	State string `json:"state"`
+}
+
+// GetOpenMortgages returns the sidechain mortgages opened by address that have
+// not been settled yet, ordered by file id. Expired mortgages are returned until
+// they are settled.
+func (s *PublicBlockChainAPI) GetOpenMortgages(ctx context.Context, address common.Address, blockNr rpc.BlockNumber) ([]MortgageInfo, error) {
+	return s.getMortgages(ctx, address, blockNr, false)
+}
+
+// GetSettledMortgages returns the settled sidechain mortgages opened by address
+// together with their payout, ordered by file id.
+func (s *PublicBlockChainAPI) GetSettledMortgages(ctx context.Context, address common.Address, blockNr rpc.BlockNumber) ([]MortgageInfo, error) {
+	return s.getMortgages(ctx, address, blockNr, true)
+}
+
+func (s *PublicBlockChainAPI) getMortgages(ctx context.Context, address common.Address, blockNr rpc.BlockNumber, settled bool) ([]MortgageInfo, error) {
+	state, header, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	mortgages := make([]MortgageInfo, 0)
+	for _, mortgage := range state.GetMortgages(address) {
+		if mortgage.Terminate == settled {
+			mortgages = append(mortgages, MortgageInfo{mortgage, mortgage.StatusName(header.Time.Int64())})
+		}
+	}
+	sort.Slice(mortgages, func(i, j int) bool { return mortgages[i].FileID < mortgages[j].FileID })
+	return mortgages, state.Error()
+}
+
 // ExecutionResult groups all structured logs emitted by the EVM
 // while replaying a transaction in debug mode as well as transaction
 // execution status, the amount of gas used and the return value
@@ -1200,6 +1482,12 @@ func newRPCTransactionFromBlockIndex(b *types.Block, index uint64) *RPCTransacti
 	return newRPCTransaction(txs[index], b.Hash(), b.NumberU64(), index)
 }
 
+// NewRPCTransactionFromBlockIndex returns the transaction at index in the block
+// in its RPC representation, nil if there is none.
+func NewRPCTransactionFromBlockIndex(b *types.Block, index uint64) *RPCTransaction {
+	return newRPCTransactionFromBlockIndex(b, index)
+}
+
 // newRPCRawTransactionFromBlockIndex returns the bytes of a transaction given a block and a transaction index.
 func newRPCRawTransactionFromBlockIndex(b *types.Block, index uint64) hexutil.Bytes {
 	txs := b.Transactions()
@@ -1551,6 +1839,10 @@ func (s *PublicTransactionPoolAPI) GetTransactionReceipt(ctx context.Context, ha
 		"logsBloom":         receipt.Bloom,
 		"extraInfo":         receipt.ExtraInfo,
 	}
+	if receipt.ErrorReason != "" {
+		fields["errorCode"] = hexutil.Uint64(receipt.ErrorCode)
+		fields["errorReason"] = receipt.ErrorReason
+	}
 
 	// Assign receipt status or post state.
 	if len(receipt.PostState) > 0 {
@@ -1667,7 +1959,7 @@ func (args *SendTxArgs) toTransaction() *types.Transaction {
 // submitTransaction is a helper function that submits tx to txPool and logs a message.
 func submitTransaction(ctx context.Context, b Backend, tx *types.Transaction) (common.Hash, error) {
 	if err := b.SendTx(ctx, tx); err != nil {
-		return common.Hash{}, err
+		return common.Hash{}, specialTxError(err)
 	}
 	if tx.To() == nil {
 		signer := types.MakeSigner(b.ChainConfig(), b.CurrentBlock().Number())
@@ -1925,15 +2217,13 @@ func (api *PrivateDebugAPI) ChaindbProperty(property string) (string, error) {
 }
 
 func (api *PrivateDebugAPI) ChaindbCompact() error {
-	ldb, ok := api.b.ChainDb().(interface {
-		LDB() *leveldb.DB
-	})
+	db, ok := api.b.ChainDb().(ethdb.Compacter)
 	if !ok {
 		return fmt.Errorf("chaindbCompact does not work for memory databases")
 	}
 	for b := byte(0); b < 255; b++ {
 		log.Info("Compacting chain database", "range", fmt.Sprintf("0x%0.2X-0x%0.2X", b, b+1))
-		err := ldb.LDB().CompactRange(util.Range{Start: []byte{b}, Limit: []byte{b + 1}})
+		err := db.Compact([]byte{b}, []byte{b + 1})
 		if err != nil {
 			log.Error("Database compaction failed", "err", err)
 			return err
@@ -455,12 +455,36 @@ web3._extend({
 			params: 2,
 			inputFormatter: [web3._extend.formatters.inputAddressFormatter,web3._extend.formatters.inputDefaultBlockNumberFormatter]
 		}),
+		new web3._extend.Method({
+			name: 'getVesting',
+			call: 'eth_getVesting',
+			params: 2,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter,web3._extend.formatters.inputDefaultBlockNumberFormatter]
+		}),
 		new web3._extend.Method({
 			name: 'getHeft',
 			call: 'eth_getHeft',
 			params: 2,
 			inputFormatter: [web3._extend.formatters.inputAddressFormatter,web3._extend.formatters.inputDefaultBlockNumberFormatter]
 		}),
+		new web3._extend.Method({
+			name: 'getHeftReporters',
+			call: 'eth_getHeftReporters',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputDefaultBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'getHeftReports',
+			call: 'eth_getHeftReports',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputDefaultBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'getStorageChallenges',
+			call: 'eth_getStorageChallenges',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputDefaultBlockNumberFormatter]
+		}),
 		new web3._extend.Method({
 			name: 'getGenaroPrice',
 			call: 'eth_getGenaroPrice',
@@ -491,6 +515,12 @@ web3._extend({
         	params: 1,
         	inputFormatter: [web3._extend.formatters.inputDefaultBlockNumberFormatter]
 		}),
+		new web3._extend.Method({
+			name: 'projectCandidate',
+			call: 'eth_projectCandidate',
+			params: 3,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, null, web3._extend.formatters.inputDefaultBlockNumberFormatter]
+		}),
 		new web3._extend.Method({
         	name: 'getMainAccountRank',
         	call: 'eth_getMainAccountRank',
@@ -522,6 +552,36 @@ web3._extend({
 			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter,web3._extend.formatters.inputBlockNumberFormatter]
 		}),
 
+		new web3._extend.Method({
+			name: 'getFileSharePublicKeyAt',
+			call: 'eth_getFileSharePublicKeyAt',
+			params: 2,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'getIncomingShareKeys',
+			call: 'eth_getIncomingShareKeys',
+			params: 2,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'getOutgoingShareKeys',
+			call: 'eth_getOutgoingShareKeys',
+			params: 2,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'getOpenMortgages',
+			call: 'eth_getOpenMortgages',
+			params: 2,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'getSettledMortgages',
+			call: 'eth_getSettledMortgages',
+			params: 2,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, web3._extend.formatters.inputBlockNumberFormatter]
+		}),
 		new web3._extend.Method({
 			name: 'getStorageNodes',
 			call: 'eth_getStorageNodes',
@@ -792,6 +852,10 @@ web3._extend({
 				return status;
 			}
 		}),
+		new web3._extend.Property({
+			name: 'specialStatus',
+			getter: 'txpool_specialStatus'
+		}),
 	]
 });
 `
@@ -129,6 +129,12 @@ func (b *LesApiBackend) TxPoolContent() (map[common.Address]types.Transactions,
 	return b.eth.txPool.Content()
 }
 
+// SpecialTxStatus returns nothing, the light pool does not check special
+// transactions.
+func (b *LesApiBackend) SpecialTxStatus() []core.SpecialTxStatus {
+	return nil
+}
+
 func (b *LesApiBackend) SubscribeTxPreEvent(ch chan<- core.TxPreEvent) event.Subscription {
 	return b.eth.txPool.SubscribeTxPreEvent(ch)
 }
@@ -278,7 +278,8 @@ func (self *worker) update() {
 				acc, _ := types.Sender(self.current.signer, ev.Tx)
 				txs := map[common.Address]types.Transactions{acc: {ev.Tx}}
 				txset := types.NewTransactionsByPriceAndNonce(self.current.signer, txs)
-				self.current.commitTransactions(self.mux, txset, self.chain, self.coinbase)
+				gp := new(core.GasPool).AddGas(self.current.header.GasLimit)
+				self.current.commitTransactions(self.mux, txset, self.chain, self.coinbase, gp)
 				self.currentMu.Unlock()
 			} else {
 				// If we're mining, but nothing is being processed, wake on new transactions
@@ -470,8 +471,21 @@ func (self *worker) commitNewWork() error {
 		log.Error("Failed to fetch pending transactions", "err", err)
 		return err
 	}
+	// Transactions of system senders go first, so they are included whatever
+	// the load of the pool
+	system := make(map[common.Address]types.Transactions)
+	for addr, txs := range pending {
+		if self.eth.TxPool().IsSystemSender(addr) {
+			system[addr] = txs
+			delete(pending, addr)
+		}
+	}
+	gp := new(core.GasPool).AddGas(header.GasLimit)
+	if len(system) > 0 {
+		work.commitTransactions(self.mux, types.NewTransactionsByPriceAndNonce(self.current.signer, system), self.chain, self.coinbase, gp)
+	}
 	txs := types.NewTransactionsByPriceAndNonce(self.current.signer, pending)
-	work.commitTransactions(self.mux, txs, self.chain, self.coinbase)
+	work.commitTransactions(self.mux, txs, self.chain, self.coinbase, gp)
 
 	if self.config.Genaro != nil {
 		// check if has Syn State
@@ -540,9 +554,7 @@ func (self *worker) commitUncle(work *Work, uncle *types.Header) error {
 	return nil
 }
 
-func (env *Work) commitTransactions(mux *event.TypeMux, txs *types.TransactionsByPriceAndNonce, bc *core.BlockChain, coinbase common.Address) {
-	gp := new(core.GasPool).AddGas(env.header.GasLimit)
-
+func (env *Work) commitTransactions(mux *event.TypeMux, txs *types.TransactionsByPriceAndNonce, bc *core.BlockChain, coinbase common.Address, gp *core.GasPool) {
 	var coalescedLogs []*types.Log
 
 	for {
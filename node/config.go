@@ -66,6 +66,11 @@ type Config struct {
 	// in memory.
 	DataDir string
 
+	// DBEngine is the engine new databases are stored with, "leveldb" or
+	// "pebble". Existing databases are opened with the engine they were
+	// created with, an engine set here differing from it is an error.
+	DBEngine string `toml:",omitempty"`
+
 	// Configuration of peer-to-peer networking.
 	P2P p2p.Config
 
@@ -181,8 +181,23 @@ type GenaroConfig struct {
 	OptionTxMemorySize  uint64   `json:"optionTxMemorySize"`  //the number of save option tx
 	PromissoryNotePrice uint64   `json:"PromissoryNotePrice"` // Promissory Note Price
 	OfficialAddress     string   `json:"OfficialAddress"`
-	PropBlock           *big.Int `json:"PropBlock,omitempty"` // Prop HF block
-	TurnBlock           *big.Int `json:"TurnBlock,omitempty"` // Turn HF block
+	PropBlock           *big.Int `json:"PropBlock,omitempty"`         // Prop HF block
+	TurnBlock           *big.Int `json:"TurnBlock,omitempty"`         // Turn HF block
+	SpecialLogBlock     *big.Int `json:"SpecialLogBlock,omitempty"`   // special tx event log HF block (nil = no fork)
+	ShareKeyBlock       *big.Int `json:"ShareKeyBlock,omitempty"`     // share key expiry, cancellation and collection HF block (nil = no fork)
+	FileShareKeyBlock   *big.Int `json:"FileShareKeyBlock,omitempty"` // versioned file share public key HF block (nil = no fork)
+	MortgageBlock       *big.Int `json:"MortgageBlock,omitempty"`     // sidechain mortgage settlement HF block (nil = no fork)
+	ElectionBlock       *big.Int `json:"ElectionBlock,omitempty"`     // committee election strategy HF block (nil = no fork)
+	ElectionStrategy    string   `json:"ElectionStrategy,omitempty"`  // election strategy from ElectionBlock on: stake, stakeHeft or capped
+	ProportionCap       uint64   `json:"ProportionCap,omitempty"`     // max proportion of a member under the capped strategy, in common.Base units
+	HeftOracleBlock     *big.Int `json:"HeftOracleBlock,omitempty"`   // heft reporter set and per epoch aggregation HF block (nil = no fork)
+	InturnSeedBlock     *big.Int `json:"InturnSeedBlock,omitempty"`   // seeded in-turn order HF block (nil = no fork)
+	BatchBlock          *big.Int `json:"BatchBlock,omitempty"`        // atomic batch special tx HF block (nil = no fork)
+	SponsoredBlock      *big.Int `json:"SponsoredBlock,omitempty"`    // sponsored special tx HF block (nil = no fork)
+	VestingBlock        *big.Int `json:"VestingBlock,omitempty"`      // vesting accounts HF block (nil = no fork)
+	StorageProofBlock   *big.Int `json:"StorageProofBlock,omitempty"` // storage challenges HF block (nil = no fork)
+	BucketUsageBlock    *big.Int `json:"BucketUsageBlock,omitempty"`  // bucket used size reports HF block (nil = no fork)
+	ShareCommitBlock    *big.Int `json:"ShareCommitBlock,omitempty"`  // off chain share key delivery HF block (nil = no fork)
 }
 
 // String implements the stringer interface, returning the consensus engine details.
@@ -255,6 +270,81 @@ func (g *GenaroConfig) IsTurn(num *big.Int) bool {
 	return isForked(g.TurnBlock, num)
 }
 
+// IsSpecialLog returns whether special transactions emit event logs at num.
+func (g *GenaroConfig) IsSpecialLog(num *big.Int) bool {
+	return isForked(g.SpecialLogBlock, num)
+}
+
+// IsShareKey returns whether share key offers can expire, be cancelled or
+// rejected and are collected once finished at num.
+func (g *GenaroConfig) IsShareKey(num *big.Int) bool {
+	return isForked(g.ShareKeyBlock, num)
+}
+
+// IsFileShareKey returns whether file share public keys are versioned and can
+// be revoked at num.
+func (g *GenaroConfig) IsFileShareKey(num *big.Int) bool {
+	return isForked(g.FileShareKeyBlock, num)
+}
+
+// IsMortgage returns whether sidechain mortgages can be opened, synced and
+// settled at num.
+func (g *GenaroConfig) IsMortgage(num *big.Int) bool {
+	return isForked(g.MortgageBlock, num)
+}
+
+// IsElection returns whether the committee is elected with ElectionStrategy
+// at num.
+func (g *GenaroConfig) IsElection(num *big.Int) bool {
+	return isForked(g.ElectionBlock, num)
+}
+
+// IsHeftOracle returns whether heft is reported by the heft reporter set and
+// aggregated at the epoch boundary at num.
+func (g *GenaroConfig) IsHeftOracle(num *big.Int) bool {
+	return isForked(g.HeftOracleBlock, num)
+}
+
+// IsInturnSeed returns whether blocks carry a seed at num, and whether the
+// in-turn order of a turn starting at num is shuffled with it.
+func (g *GenaroConfig) IsInturnSeed(num *big.Int) bool {
+	return isForked(g.InturnSeedBlock, num)
+}
+
+// IsBatch returns whether batches of special transactions are accepted at num.
+func (g *GenaroConfig) IsBatch(num *big.Int) bool {
+	return isForked(g.BatchBlock, num)
+}
+
+// IsSponsored returns whether special transactions can be sponsored at num.
+func (g *GenaroConfig) IsSponsored(num *big.Int) bool {
+	return isForked(g.SponsoredBlock, num)
+}
+
+// IsVesting returns whether vesting accounts can be created and staked from at
+// num.
+func (g *GenaroConfig) IsVesting(num *big.Int) bool {
+	return isForked(g.VestingBlock, num)
+}
+
+// IsStorageProof returns whether storage nodes can be challenged to prove they
+// hold the chunks they committed to at num.
+func (g *GenaroConfig) IsStorageProof(num *big.Int) bool {
+	return isForked(g.StorageProofBlock, num)
+}
+
+// IsBucketUsage returns whether the used size of buckets can be reported at num.
+func (g *GenaroConfig) IsBucketUsage(num *big.Int) bool {
+	return isForked(g.BucketUsageBlock, num)
+}
+
+// IsShareCommit returns whether share keys can be delivered off chain with
+// only a commitment on chain at num. The offers rely on the share key
+// settlement of ShareKeyBlock, so both forks are required.
+func (g *GenaroConfig) IsShareCommit(num *big.Int) bool {
+	return isForked(g.ShareCommitBlock, num) && g.IsShareKey(num)
+}
+
 // GasTable returns the gas table corresponding to the current phase (homestead or homestead reprice).
 //
 // The returned GasTable's fields shouldn't, under any circumstances, be changed.
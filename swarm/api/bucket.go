@@ -0,0 +1,214 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package api
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/GenaroNetwork/GenaroCore/common"
+	"github.com/GenaroNetwork/GenaroCore/core/types"
+	"github.com/GenaroNetwork/GenaroCore/crypto"
+	"github.com/GenaroNetwork/GenaroCore/log"
+)
+
+// MaxUploadValidity is the longest time an upload authorization can be valid
+// for, which bounds the authorizations remembered against replays.
+const MaxUploadValidity = time.Hour
+
+var (
+	ErrBucketNotFound      = errors.New("bucket not found")
+	ErrBucketExpired       = errors.New("bucket expired")
+	ErrBucketFull          = errors.New("bucket capacity exceeded")
+	ErrUploadUnauthorized  = errors.New("upload not signed by the bucket owner")
+	ErrUploadAuthExpired   = errors.New("upload authorization expired or valid for too long")
+	ErrUploadAuthDuplicate = errors.New("upload authorization already used")
+)
+
+// BucketBackend gives access to the buckets of the chain.
+type BucketBackend interface {
+	// Bucket returns the bucket of owner in the latest state, nil if there
+	// is none.
+	Bucket(ctx context.Context, owner common.Address, bucketID string) (*types.BucketPropertie, error)
+	// ReportUsage reports the bytes stored in the bucket on chain.
+	ReportUsage(ctx context.Context, owner common.Address, bucketID string, used uint64) error
+}
+
+type bucketKey struct {
+	owner    common.Address
+	bucketID string
+}
+
+type bucketUsage struct {
+	used  uint64 // bytes stored or reserved in the bucket
+	dirty bool   // used changed since the last report
+}
+
+// Buckets attributes uploads to buckets, refusing the ones exceeding the size
+// of their bucket, and reports the used size of the buckets on chain.
+type Buckets struct {
+	backend BucketBackend
+	now     func() time.Time
+
+	lock   sync.Mutex
+	usage  map[bucketKey]*bucketUsage
+	auths  map[common.Hash]uint64 // authorizations used, with their expiry
+	pruned uint64                 // unix time expired authorizations were last dropped at
+}
+
+// NewBuckets creates a bucket tracker on top of the chain backend.
+func NewBuckets(backend BucketBackend) *Buckets {
+	return &Buckets{
+		backend: backend,
+		now:     time.Now,
+		usage:   make(map[bucketKey]*bucketUsage),
+		auths:   make(map[common.Hash]uint64),
+	}
+}
+
+// UploadHash returns the hash the owner of a bucket signs to authorize an
+// upload of size bytes to it until expires, a unix time.
+func UploadHash(bucketID string, size uint64, expires uint64) []byte {
+	return crypto.Keccak256([]byte(fmt.Sprintf("genaro upload:%s:%d:%d", bucketID, size, expires)))
+}
+
+// SignUpload authorizes an upload of size bytes to the bucket of the owner of
+// key until expires, a unix time.
+func SignUpload(key *ecdsa.PrivateKey, bucketID string, size uint64, expires uint64) ([]byte, error) {
+	return crypto.Sign(UploadHash(bucketID, size, expires), key)
+}
+
+// Authorize checks that sig authorizes an upload of size bytes to the bucket
+// of owner until expires, see SignUpload. An authorization is accepted once.
+func (self *Buckets) Authorize(owner common.Address, bucketID string, size uint64, expires uint64, sig []byte) error {
+	now := uint64(self.now().Unix())
+	if expires < now || expires > now+uint64(MaxUploadValidity/time.Second) {
+		return ErrUploadAuthExpired
+	}
+	hash := UploadHash(bucketID, size, expires)
+	pub, err := crypto.SigToPub(hash, sig)
+	if err != nil || crypto.PubkeyToAddress(*pub) != owner {
+		return ErrUploadUnauthorized
+	}
+
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	if now >= self.pruned+60 {
+		for auth, expiry := range self.auths {
+			if expiry < now {
+				delete(self.auths, auth)
+			}
+		}
+		self.pruned = now
+	}
+	auth := crypto.Keccak256Hash(owner.Bytes(), hash)
+	if _, ok := self.auths[auth]; ok {
+		return ErrUploadAuthDuplicate
+	}
+	self.auths[auth] = expires
+	return nil
+}
+
+// Reserve reserves size bytes of the bucket for an upload. It fails if the
+// bucket does not exist, has expired or would exceed its size.
+func (self *Buckets) Reserve(ctx context.Context, owner common.Address, bucketID string, size uint64) error {
+	bucket, err := self.backend.Bucket(ctx, owner, bucketID)
+	if err != nil {
+		return err
+	}
+	if bucket == nil {
+		return ErrBucketNotFound
+	}
+	if bucket.TimeEnd <= uint64(self.now().Unix()) {
+		return ErrBucketExpired
+	}
+
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	key := bucketKey{owner, bucketID}
+	usage := self.usage[key]
+	if usage == nil {
+		usage = &bucketUsage{}
+		self.usage[key] = usage
+	}
+	// the chain may know of uploads to other nodes
+	if bucket.Used > usage.used {
+		usage.used = bucket.Used
+	}
+	capacity := bucket.Size * common.BucketSizeUnit
+	if usage.used > capacity || size > capacity-usage.used {
+		return ErrBucketFull
+	}
+	usage.used += size
+	usage.dirty = true
+	return nil
+}
+
+// Release gives back size bytes reserved for an upload that failed.
+func (self *Buckets) Release(owner common.Address, bucketID string, size uint64) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	usage := self.usage[bucketKey{owner, bucketID}]
+	if usage == nil {
+		return
+	}
+	if size > usage.used {
+		size = usage.used
+	}
+	usage.used -= size
+	usage.dirty = true
+}
+
+// Used returns the bytes stored in the bucket known to the tracker.
+func (self *Buckets) Used(owner common.Address, bucketID string) uint64 {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	if usage := self.usage[bucketKey{owner, bucketID}]; usage != nil {
+		return usage.used
+	}
+	return 0
+}
+
+// Report reports the used size of the buckets changed since the last report.
+// Buckets whose report failed are retried on the next call.
+func (self *Buckets) Report(ctx context.Context) error {
+	self.lock.Lock()
+	reports := make(map[bucketKey]uint64)
+	for key, usage := range self.usage {
+		if usage.dirty {
+			reports[key] = usage.used
+			usage.dirty = false
+		}
+	}
+	self.lock.Unlock()
+
+	var failed error
+	for key, used := range reports {
+		if err := self.backend.ReportUsage(ctx, key.owner, key.bucketID, used); err != nil {
+			log.Warn("Failed to report bucket usage", "owner", key.owner, "bucket", key.bucketID, "err", err)
+			self.lock.Lock()
+			self.usage[key].dirty = true
+			self.lock.Unlock()
+			failed = err
+		}
+	}
+	return failed
+}
@@ -0,0 +1,101 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package api
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/GenaroNetwork/GenaroCore/common"
+	"github.com/GenaroNetwork/GenaroCore/core/types"
+)
+
+type testBucketBackend struct {
+	buckets map[string]*types.BucketPropertie
+	reports map[string]uint64
+	fail    bool
+}
+
+func (b *testBucketBackend) Bucket(ctx context.Context, owner common.Address, bucketID string) (*types.BucketPropertie, error) {
+	return b.buckets[bucketID], nil
+}
+
+func (b *testBucketBackend) ReportUsage(ctx context.Context, owner common.Address, bucketID string, used uint64) error {
+	if b.fail {
+		return errors.New("report failed")
+	}
+	b.reports[bucketID] = used
+	return nil
+}
+
+func TestBuckets(t *testing.T) {
+	now := time.Unix(1000, 0)
+	backend := &testBucketBackend{
+		buckets: map[string]*types.BucketPropertie{
+			"live":    {BucketId: "live", Size: 1, TimeEnd: 2000, Used: 100},
+			"expired": {BucketId: "expired", Size: 1, TimeEnd: 1000},
+		},
+		reports: make(map[string]uint64),
+	}
+	buckets := NewBuckets(backend)
+	buckets.now = func() time.Time { return now }
+	owner := common.HexToAddress("0x01")
+	ctx := context.Background()
+
+	if err := buckets.Reserve(ctx, owner, "missing", 1); err != ErrBucketNotFound {
+		t.Errorf("missing bucket: have %v, want %v", err, ErrBucketNotFound)
+	}
+	if err := buckets.Reserve(ctx, owner, "expired", 1); err != ErrBucketExpired {
+		t.Errorf("expired bucket: have %v, want %v", err, ErrBucketExpired)
+	}
+	// the used size on chain counts against the capacity
+	if err := buckets.Reserve(ctx, owner, "live", common.BucketSizeUnit-99); err != ErrBucketFull {
+		t.Errorf("oversized upload: have %v, want %v", err, ErrBucketFull)
+	}
+	if err := buckets.Reserve(ctx, owner, "live", common.BucketSizeUnit-200); err != nil {
+		t.Fatalf("reserve failed: %v", err)
+	}
+	if err := buckets.Reserve(ctx, owner, "live", 101); err != ErrBucketFull {
+		t.Errorf("upload beyond capacity: have %v, want %v", err, ErrBucketFull)
+	}
+	buckets.Release(owner, "live", 1)
+	if err := buckets.Reserve(ctx, owner, "live", 101); err != nil {
+		t.Fatalf("reserve after release failed: %v", err)
+	}
+	if used := buckets.Used(owner, "live"); used != common.BucketSizeUnit {
+		t.Errorf("used mismatch: have %d, want %d", used, common.BucketSizeUnit)
+	}
+
+	// failed reports are retried, reported buckets are not reported again
+	backend.fail = true
+	if err := buckets.Report(ctx); err == nil {
+		t.Fatalf("expected report to fail")
+	}
+	backend.fail = false
+	if err := buckets.Report(ctx); err != nil {
+		t.Fatalf("report failed: %v", err)
+	}
+	if have := backend.reports["live"]; have != common.BucketSizeUnit {
+		t.Errorf("reported usage mismatch: have %d, want %d", have, common.BucketSizeUnit)
+	}
+	delete(backend.reports, "live")
+	if err := buckets.Report(ctx); err != nil || len(backend.reports) != 0 {
+		t.Errorf("unchanged bucket reported again: %v %v", backend.reports, err)
+	}
+}
@@ -58,6 +58,7 @@ type Config struct {
 	SwapEnabled bool
 	SyncEnabled bool
 	SwapApi     string
+	BucketApi   string // uploads are attributed to buckets when set
 	Cors        string
 	BzzAccount  string
 	BootNodes   string
@@ -81,6 +82,7 @@ func NewDefaultConfig() (self *Config) {
 		SwapEnabled:   false,
 		SyncEnabled:   true,
 		SwapApi:       "",
+		BucketApi:     "",
 		BootNodes:     "",
 	}
 
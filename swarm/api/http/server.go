@@ -36,6 +36,7 @@ import (
 	"time"
 
 	"github.com/GenaroNetwork/GenaroCore/common"
+	"github.com/GenaroNetwork/GenaroCore/common/hexutil"
 	"github.com/GenaroNetwork/GenaroCore/log"
 	"github.com/GenaroNetwork/GenaroCore/metrics"
 	"github.com/GenaroNetwork/GenaroCore/swarm/api"
@@ -43,6 +44,16 @@ import (
 	"github.com/rs/cors"
 )
 
+// Headers attributing an upload to a bucket, required when the server
+// enforces bucket capacities. The signature is made by the bucket owner with
+// api.SignUpload over the bucket, the Content-Length and the expiry.
+const (
+	AccountHeader   = "X-Genaro-Account"
+	BucketHeader    = "X-Genaro-Bucket"
+	ExpiresHeader   = "X-Genaro-Expires"   // unix time the authorization expires at
+	SignatureHeader = "X-Genaro-Signature" // hex encoded signature of the bucket owner
+)
+
 //setup metrics
 var (
 	postRawCount     = metrics.NewRegisteredCounter("api.http.post.raw.count", nil)
@@ -71,6 +82,7 @@ var (
 type ServerConfig struct {
 	Addr       string
 	CorsString string
+	Buckets    *api.Buckets // attributes uploads to buckets when set
 }
 
 // browser API for registering bzz url scheme handlers:
@@ -90,17 +102,24 @@ func StartHttpServer(api *api.Api, config *ServerConfig) {
 		MaxAge:         600,
 		AllowedHeaders: []string{"*"},
 	})
-	hdlr := c.Handler(NewServer(api))
+	hdlr := c.Handler(NewBucketServer(api, config.Buckets))
 
 	go http.ListenAndServe(config.Addr, hdlr)
 }
 
 func NewServer(api *api.Api) *Server {
-	return &Server{api}
+	return &Server{api: api}
+}
+
+// NewBucketServer creates a server attributing uploads to the buckets given
+// by their request headers and refusing the ones exceeding the bucket.
+func NewBucketServer(api *api.Api, buckets *api.Buckets) *Server {
+	return &Server{api: api, buckets: buckets}
 }
 
 type Server struct {
-	api *api.Api
+	api     *api.Api
+	buckets *api.Buckets
 }
 
 // Request wraps http.Request and also includes the parsed bzz URI
@@ -678,6 +697,20 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 	s.logDebug("%s request received for %s", r.Method, uri)
 
+	if s.buckets != nil && (r.Method == "POST" || r.Method == "PUT") {
+		release, ok := s.reserveBucket(w, req)
+		if !ok {
+			return
+		}
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		defer func() {
+			if recorder.status >= http.StatusBadRequest {
+				release()
+			}
+		}()
+		w = recorder
+	}
+
 	switch r.Method {
 	case "POST":
 		if uri.Raw() || uri.DeprecatedRaw() {
@@ -730,6 +763,68 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// reserveBucket reserves the size of an upload in the bucket given by the
+// request headers, reporting whether the upload can proceed. The upload has to
+// be signed by the bucket owner. The returned function gives the reservation
+// back.
+func (s *Server) reserveBucket(w http.ResponseWriter, r *Request) (func(), bool) {
+	account, bucketID := r.Header.Get(AccountHeader), r.Header.Get(BucketHeader)
+	if !common.IsHexAddress(account) || bucketID == "" {
+		ShowError(w, r, fmt.Sprintf("Uploads require the %s and %s headers", AccountHeader, BucketHeader), http.StatusForbidden)
+		return nil, false
+	}
+	if r.ContentLength < 0 {
+		ShowError(w, r, "Uploads to a bucket require a Content-Length", http.StatusLengthRequired)
+		return nil, false
+	}
+	owner, size := common.HexToAddress(account), uint64(r.ContentLength)
+	expires, err := strconv.ParseUint(r.Header.Get(ExpiresHeader), 10, 64)
+	if err != nil {
+		ShowError(w, r, fmt.Sprintf("Uploads require the %s header", ExpiresHeader), http.StatusUnauthorized)
+		return nil, false
+	}
+	sig, err := hexutil.Decode(r.Header.Get(SignatureHeader))
+	if err != nil {
+		ShowError(w, r, fmt.Sprintf("Uploads require the %s header", SignatureHeader), http.StatusUnauthorized)
+		return nil, false
+	}
+	if err := s.buckets.Authorize(owner, bucketID, size, expires, sig); err != nil {
+		ShowError(w, r, fmt.Sprintf("Bucket %s of %s: %v", bucketID, account, err), http.StatusUnauthorized)
+		return nil, false
+	}
+	switch err := s.buckets.Reserve(r.Context(), owner, bucketID, size); err {
+	case nil:
+	case api.ErrBucketFull:
+		ShowError(w, r, fmt.Sprintf("Bucket %s of %s: %v", bucketID, account, err), http.StatusRequestEntityTooLarge)
+		return nil, false
+	case api.ErrBucketNotFound, api.ErrBucketExpired:
+		ShowError(w, r, fmt.Sprintf("Bucket %s of %s: %v", bucketID, account, err), http.StatusForbidden)
+		return nil, false
+	default:
+		s.Error(w, r, err)
+		return nil, false
+	}
+	return func() { s.buckets.Release(owner, bucketID, size) }, true
+}
+
+// statusRecorder remembers the status code of a response.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Flush implements http.Flusher, flushing the wrapped writer if it can.
+func (r *statusRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
 func (s *Server) updateManifest(key storage.Key, update func(mw *api.ManifestWriter) error) (storage.Key, error) {
 	mw, err := s.api.NewManifestWriter(key, nil)
 	if err != nil {
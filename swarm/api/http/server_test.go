@@ -18,17 +18,25 @@ package http_test
 
 import (
 	"bytes"
+	"context"
+	"crypto/ecdsa"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"strconv"
 	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/GenaroNetwork/GenaroCore/common"
+	"github.com/GenaroNetwork/GenaroCore/common/hexutil"
+	"github.com/GenaroNetwork/GenaroCore/core/types"
+	"github.com/GenaroNetwork/GenaroCore/crypto"
 	"github.com/GenaroNetwork/GenaroCore/swarm/api"
 	swarm "github.com/GenaroNetwork/GenaroCore/swarm/api/client"
+	httpapi "github.com/GenaroNetwork/GenaroCore/swarm/api/http"
 	"github.com/GenaroNetwork/GenaroCore/swarm/storage"
 	"github.com/GenaroNetwork/GenaroCore/swarm/testutil"
 )
@@ -318,3 +326,92 @@ func TestBzzRootRedirect(t *testing.T) {
 		t.Fatalf("expected response to equal %q, got %q", data, gotData)
 	}
 }
+
+type testBucketBackend map[string]*types.BucketPropertie
+
+func (b testBucketBackend) Bucket(ctx context.Context, owner common.Address, bucketID string) (*types.BucketPropertie, error) {
+	return b[bucketID], nil
+}
+
+func (b testBucketBackend) ReportUsage(ctx context.Context, owner common.Address, bucketID string, used uint64) error {
+	return nil
+}
+
+// Tests that uploads to a bucket server are attributed to a live bucket and
+// refused beyond its capacity.
+func TestBzzBucketUpload(t *testing.T) {
+	timeEnd := uint64(time.Now().Add(time.Hour).Unix())
+	backend := testBucketBackend{
+		"live":    {BucketId: "live", Size: 1, TimeEnd: timeEnd, Used: common.BucketSizeUnit - 10},
+		"expired": {BucketId: "expired", Size: 1, TimeEnd: 1},
+	}
+	buckets := api.NewBuckets(backend)
+	srv := testutil.NewTestSwarmBucketServer(t, buckets)
+	defer srv.Close()
+	key, _ := crypto.GenerateKey()
+	other, _ := crypto.GenerateKey()
+	owner := crypto.PubkeyToAddress(key.PublicKey)
+
+	upload := func(path string, headers map[string]string, data string) int {
+		req, err := http.NewRequest("POST", srv.URL+path, strings.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		return res.StatusCode
+	}
+	expires := uint64(time.Now().Add(time.Minute).Unix())
+	signed := func(signer *ecdsa.PrivateKey, bucketID string, size uint64, expires uint64) map[string]string {
+		sig, err := api.SignUpload(signer, bucketID, size, expires)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return map[string]string{
+			httpapi.AccountHeader:   owner.Hex(),
+			httpapi.BucketHeader:    bucketID,
+			httpapi.ExpiresHeader:   strconv.FormatUint(expires, 10),
+			httpapi.SignatureHeader: hexutil.Encode(sig),
+		}
+	}
+	headers := func(bucketID string, size uint64) map[string]string {
+		return signed(key, bucketID, size, expires)
+	}
+	unsigned := headers("live", 4)
+	delete(unsigned, httpapi.SignatureHeader)
+	replayed := headers("live", 4)
+
+	for _, test := range []struct {
+		name    string
+		path    string
+		headers map[string]string
+		data    string
+		status  int
+		used    uint64
+	}{
+		{"no bucket", "/bzz-raw:/", nil, "data", http.StatusForbidden, 0},
+		{"unsigned", "/bzz-raw:/", unsigned, "0123", http.StatusUnauthorized, 0},
+		{"not signed by owner", "/bzz-raw:/", signed(other, "live", 4, expires), "0123", http.StatusUnauthorized, 0},
+		{"other size signed", "/bzz-raw:/", headers("live", 3), "0123", http.StatusUnauthorized, 0},
+		{"authorization expired", "/bzz-raw:/", signed(key, "live", 4, uint64(time.Now().Add(-time.Minute).Unix())), "0123", http.StatusUnauthorized, 0},
+		{"unknown bucket", "/bzz-raw:/", headers("missing", 4), "data", http.StatusForbidden, 0},
+		{"expired bucket", "/bzz-raw:/", headers("expired", 4), "data", http.StatusForbidden, 0},
+		{"over capacity", "/bzz-raw:/", headers("live", 11), "01234567890", http.StatusRequestEntityTooLarge, common.BucketSizeUnit - 10},
+		{"failed upload", "/bzz-raw:/addr/path", signed(key, "live", 4, expires+1), "0123", http.StatusBadRequest, common.BucketSizeUnit - 10},
+		{"upload", "/bzz-raw:/", replayed, "0123", http.StatusOK, common.BucketSizeUnit - 6},
+		{"replayed", "/bzz-raw:/", replayed, "0123", http.StatusUnauthorized, common.BucketSizeUnit - 6},
+	} {
+		if status := upload(test.path, test.headers, test.data); status != test.status {
+			t.Errorf("%s: status mismatch: have %d, want %d", test.name, status, test.status)
+		}
+		if used := buckets.Used(owner, "live"); used != test.used {
+			t.Errorf("%s: used mismatch: have %d, want %d", test.name, used, test.used)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package swarm
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"time"
+
+	ethereum "github.com/GenaroNetwork/GenaroCore"
+	"github.com/GenaroNetwork/GenaroCore/common"
+	"github.com/GenaroNetwork/GenaroCore/common/hexutil"
+	"github.com/GenaroNetwork/GenaroCore/core/types"
+	"github.com/GenaroNetwork/GenaroCore/crypto"
+	"github.com/GenaroNetwork/GenaroCore/ethclient"
+	"github.com/GenaroNetwork/GenaroCore/log"
+	"github.com/GenaroNetwork/GenaroCore/rpc"
+	"github.com/GenaroNetwork/GenaroCore/swarm/api"
+)
+
+// bucketReportPeriod is the interval the used size of the buckets is reported
+// on chain at.
+var bucketReportPeriod = 10 * time.Minute
+
+// rpcBucketBackend reads buckets from a Genaro node and reports their usage
+// with special transactions signed by the bzz key, which has to be the
+// official account or a heft reporter.
+type rpcBucketBackend struct {
+	client  *rpc.Client
+	eth     *ethclient.Client
+	key     *ecdsa.PrivateKey
+	address common.Address
+}
+
+func newRpcBucketBackend(endpoint string, key *ecdsa.PrivateKey) (*rpcBucketBackend, error) {
+	log.Info("connecting to bucket API", "url", endpoint)
+	client, err := rpc.Dial(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to bucket API %s: %s", endpoint, err)
+	}
+	return &rpcBucketBackend{
+		client:  client,
+		eth:     ethclient.NewClient(client),
+		key:     key,
+		address: crypto.PubkeyToAddress(key.PublicKey),
+	}, nil
+}
+
+func (self *rpcBucketBackend) Bucket(ctx context.Context, owner common.Address, bucketID string) (*types.BucketPropertie, error) {
+	var buckets map[string]*types.BucketPropertie
+	if err := self.client.CallContext(ctx, &buckets, "eth_getBuckets", owner); err != nil {
+		return nil, err
+	}
+	return buckets[bucketID], nil
+}
+
+func (self *rpcBucketBackend) ReportUsage(ctx context.Context, owner common.Address, bucketID string, used uint64) error {
+	data, err := json.Marshal(types.SpecialTxInput{
+		Type:     (*hexutil.Big)(common.SpecialTxBucketUsage),
+		Address:  owner.Hex(),
+		BucketID: bucketID,
+		Size:     used,
+	})
+	if err != nil {
+		return err
+	}
+	nonce, err := self.eth.PendingNonceAt(ctx, self.address)
+	if err != nil {
+		return err
+	}
+	gasPrice, err := self.eth.SuggestGasPrice(ctx)
+	if err != nil {
+		return err
+	}
+	to := common.SpecialSyncAddress
+	gas, err := self.eth.EstimateGas(ctx, ethereum.CallMsg{From: self.address, To: &to, GasPrice: gasPrice, Data: data})
+	if err != nil {
+		return err
+	}
+	tx, err := types.SignTx(types.NewTransaction(nonce, to, new(big.Int), gas, gasPrice, data), types.HomesteadSigner{}, self.key)
+	if err != nil {
+		return err
+	}
+	return self.eth.SendTransaction(ctx, tx)
+}
+
+// periodicallyReportBuckets reports the used size of the buckets until quit
+// is closed.
+func periodicallyReportBuckets(buckets *api.Buckets, quit chan struct{}) {
+	ticker := time.NewTicker(bucketReportPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			buckets.Report(ctx)
+			cancel()
+		case <-quit:
+			return
+		}
+	}
+}
@@ -74,6 +74,8 @@ type Swarm struct {
 	swapEnabled bool
 	lstore      *storage.LocalStore // local store, needs to store for releasing resources after node stopped
 	sfs         *fuse.SwarmFS       // need this to cleanup all the active mounts on node exit
+	buckets     *api.Buckets        // attributes uploads to buckets, nil if uploads are free
+	bucketQuit  chan struct{}       // stops reporting the usage of the buckets
 }
 
 type SwarmAPI struct {
@@ -169,6 +171,15 @@ func NewSwarm(ctx *node.ServiceContext, backend chequebook.Backend, config *api.
 	self.sfs = fuse.NewSwarmFS(self.api)
 	log.Debug("-> Initializing Fuse file system")
 
+	if config.BucketApi != "" {
+		backend, err := newRpcBucketBackend(config.BucketApi, self.privateKey)
+		if err != nil {
+			return nil, err
+		}
+		self.buckets = api.NewBuckets(backend)
+		log.Debug(fmt.Sprintf("-> Uploads attributed to buckets via %v", config.BucketApi))
+	}
+
 	return self, nil
 }
 
@@ -311,6 +322,7 @@ func (self *Swarm) Start(srv *p2p.Server) error {
 		go httpapi.StartHttpServer(self.api, &httpapi.ServerConfig{
 			Addr:       addr,
 			CorsString: self.corsString,
+			Buckets:    self.buckets,
 		})
 		log.Info(fmt.Sprintf("Swarm http proxy started on %v", addr))
 
@@ -319,6 +331,11 @@ func (self *Swarm) Start(srv *p2p.Server) error {
 		}
 	}
 
+	if self.buckets != nil {
+		self.bucketQuit = make(chan struct{})
+		go periodicallyReportBuckets(self.buckets, self.bucketQuit)
+	}
+
 	self.periodicallyUpdateGauges()
 
 	startCounter.Inc(1)
@@ -344,6 +361,10 @@ func (self *Swarm) updateGauges() {
 // implements the node.Service interface
 // stops all component services.
 func (self *Swarm) Stop() error {
+	if self.bucketQuit != nil {
+		close(self.bucketQuit)
+		self.bucketQuit = nil
+	}
 	self.dpa.Stop()
 	err := self.hive.Stop()
 	if ch := self.config.Swap.Chequebook(); ch != nil {
@@ -28,6 +28,12 @@ import (
 )
 
 func NewTestSwarmServer(t *testing.T) *TestSwarmServer {
+	return NewTestSwarmBucketServer(t, nil)
+}
+
+// NewTestSwarmBucketServer starts a test server enforcing the capacity of
+// the buckets, if any.
+func NewTestSwarmBucketServer(t *testing.T, buckets *api.Buckets) *TestSwarmServer {
 	dir, err := ioutil.TempDir("", "swarm-storage-test")
 	if err != nil {
 		t.Fatal(err)
@@ -50,7 +56,7 @@ func NewTestSwarmServer(t *testing.T) *TestSwarmServer {
 	}
 	dpa.Start()
 	a := api.NewApi(dpa, nil)
-	srv := httptest.NewServer(httpapi.NewServer(a))
+	srv := httptest.NewServer(httpapi.NewBucketServer(a, buckets))
 	return &TestSwarmServer{
 		Server: srv,
 		Dpa:    dpa,
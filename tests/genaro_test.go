@@ -0,0 +1,271 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/GenaroNetwork/GenaroCore/accounts"
+	"github.com/GenaroNetwork/GenaroCore/common"
+	"github.com/GenaroNetwork/GenaroCore/common/hexutil"
+	"github.com/GenaroNetwork/GenaroCore/common/math"
+	"github.com/GenaroNetwork/GenaroCore/consensus/genaro"
+	"github.com/GenaroNetwork/GenaroCore/core"
+	"github.com/GenaroNetwork/GenaroCore/core/types"
+	"github.com/GenaroNetwork/GenaroCore/core/vm"
+	"github.com/GenaroNetwork/GenaroCore/crypto"
+	"github.com/GenaroNetwork/GenaroCore/ethdb"
+	"github.com/GenaroNetwork/GenaroCore/params"
+)
+
+func TestGenaroState(t *testing.T) {
+	t.Parallel()
+
+	gt := new(testMatcher)
+	gt.walk(t, genaroTestDir, func(t *testing.T, name string, test *GenaroTest) {
+		if _, err := test.Run(vm.Config{}); err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+// chainSource records block tests out of a local chain.
+type chainSource struct {
+	chain *core.BlockChain
+}
+
+func (s chainSource) BlockByNumber(number uint64) (*types.Block, error) {
+	if block := s.chain.GetBlockByNumber(number); block != nil {
+		return block, nil
+	}
+	return nil, fmt.Errorf("unknown block")
+}
+
+func (s chainSource) Account(addr common.Address, number uint64) (GenaroAccount, error) {
+	statedb, err := s.chain.StateAt(s.chain.GetBlockByNumber(number).Root())
+	if err != nil {
+		return GenaroAccount{}, err
+	}
+	codeHash, _ := hexutil.Decode(statedb.GetGenaroCodeHash(addr))
+	return NewGenaroAccount(statedb.GetBalance(addr), statedb.GetNonce(addr), statedb.GetCode(addr), codeHash), nil
+}
+
+// Tests that a block test recorded from a Genaro chain replays, and fails once
+// its post state is tampered with.
+func TestGenaroBlockRecord(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	signer := crypto.PubkeyToAddress(key.PublicKey)
+	recipient := common.HexToAddress("0x1000000000000000000000000000000000000abc")
+
+	config := &params.ChainConfig{
+		ChainId:        big.NewInt(1),
+		HomesteadBlock: big.NewInt(0),
+		EIP150Block:    big.NewInt(0),
+		EIP155Block:    big.NewInt(0),
+		EIP158Block:    big.NewInt(0),
+		ByzantiumBlock: big.NewInt(0),
+		Genaro:         &params.GenaroConfig{Epoch: 100, Period: 1, BlockInterval: 5, ValidPeriod: 1, ElectionPeriod: 1, CommitteeMaxSize: 10, OptionTxMemorySize: 20},
+	}
+	header := new(types.Header)
+	genaro.SetHeaderCommitteeRankList(header, []common.Address{signer}, []uint64{10000})
+	genesis := &core.Genesis{
+		Config:     config,
+		Timestamp:  1000,
+		ExtraData:  header.Extra,
+		GasLimit:   20000000,
+		Difficulty: big.NewInt(1),
+		Alloc: core.GenesisAlloc{
+			signer:                    {Balance: new(big.Int).Mul(big.NewInt(1000), common.BaseCompany)},
+			common.GenaroPriceAddress: {Balance: new(big.Int), CodeHash: []byte(`{"MinStake":10,"MaxBinding":10,"BackStackListMax":20,"CoinRewardsRatio":2,"StorageRewardsRatio":1,"RatioPerYear":1}`)},
+			common.RewardsSaveAddress: {Balance: new(big.Int), CodeHash: []byte(`{"CoinActualRewards":0,"PreCoinActualRewards":0,"StorageActualRewards":0,"PreStorageActualRewards":0,"TotalActualRewards":0,"SurplusCoin":67500000000000000000000000,"PreSurplusCoin":0}`)},
+		},
+	}
+	db := ethdb.NewMemDatabase()
+	genesis.MustCommit(db)
+	engine := genaro.New(config.Genaro, db)
+	engine.Authorize(signer, func(account accounts.Account, hash []byte) ([]byte, error) {
+		return crypto.Sign(hash, key)
+	})
+	chain, err := core.NewBlockChain(db, nil, config, engine, vm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer chain.Stop()
+
+	txSigner := types.MakeSigner(config, big.NewInt(1))
+	stake, _ := types.SignTx(types.NewTransaction(0, common.SpecialSyncAddress, new(big.Int), 100000, big.NewInt(1), []byte(fmt.Sprintf(`{"type":"0x1","address":"%x","stake":10}`, signer))), txSigner, key)
+	transfer, _ := types.SignTx(types.NewTransaction(1, recipient, big.NewInt(1000), 21000, big.NewInt(1), nil), txSigner, key)
+	for _, txs := range [][]*types.Transaction{{stake}, {transfer}, nil} {
+		parent := chain.CurrentBlock()
+		header := &types.Header{ParentHash: parent.Hash(), Number: new(big.Int).Add(parent.Number(), common.Big1), GasLimit: parent.GasLimit(), Extra: parent.Extra()}
+		if err := engine.Prepare(chain, header); err != nil {
+			t.Fatal(err)
+		}
+		statedb, err := chain.StateAt(parent.Root())
+		if err != nil {
+			t.Fatal(err)
+		}
+		var receipts []*types.Receipt
+		gaspool := new(core.GasPool).AddGas(header.GasLimit)
+		for i, tx := range txs {
+			statedb.Prepare(tx.Hash(), common.Hash{}, i)
+			receipt, _, err := core.ApplyTransaction(config, chain, &header.Coinbase, gaspool, statedb, header, tx, &header.GasUsed, vm.Config{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			receipts = append(receipts, receipt)
+		}
+		block, err := engine.Finalize(chain, header, statedb, txs, nil, receipts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if block, err = engine.Seal(chain, block, nil); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := chain.InsertChain(types.Blocks{block}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	test, err := RecordGenaroBlockTest(chainSource{chain}, genesis, 3, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc, err := json.Marshal(test)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var replay GenaroTest
+	if err := json.Unmarshal(enc, &replay); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := replay.Run(vm.Config{}); err != nil {
+		t.Fatalf("recorded test failed: %v", err)
+	}
+	if account := replay.json.PostState[recipient]; account.Balance == nil || (*big.Int)(account.Balance).Cmp(big.NewInt(1000)) != 0 {
+		t.Errorf("recipient not recorded: %+v", account)
+	}
+	if account := replay.json.PostState[signer]; account.GenaroData == nil {
+		t.Errorf("stake of the signer not recorded")
+	}
+	replay.json.PostState[recipient] = GenaroAccount{Balance: (*math.HexOrDecimal256)(big.NewInt(999))}
+	if _, err := replay.Run(vm.Config{}); err == nil {
+		t.Errorf("tampered post state accepted")
+	}
+}
+
+func TestGenaroElectionRestart(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	signer := crypto.PubkeyToAddress(key.PublicKey)
+
+	config := &params.ChainConfig{
+		ChainId:        big.NewInt(1),
+		HomesteadBlock: big.NewInt(0),
+		EIP150Block:    big.NewInt(0),
+		EIP155Block:    big.NewInt(0),
+		EIP158Block:    big.NewInt(0),
+		ByzantiumBlock: big.NewInt(0),
+		Genaro:         &params.GenaroConfig{Epoch: 100, Period: 1, BlockInterval: 5, ValidPeriod: 1, ElectionPeriod: 1, CommitteeMaxSize: 10, OptionTxMemorySize: 20},
+	}
+	header := new(types.Header)
+	genaro.SetHeaderCommitteeRankList(header, []common.Address{signer}, []uint64{10000})
+	genesis := &core.Genesis{
+		Config:     config,
+		Timestamp:  1000,
+		ExtraData:  header.Extra,
+		GasLimit:   20000000,
+		Difficulty: big.NewInt(1),
+		Alloc: core.GenesisAlloc{
+			signer:                         {Balance: new(big.Int).Mul(big.NewInt(1000), common.BaseCompany)},
+			common.GenaroPriceAddress:      {Balance: new(big.Int), CodeHash: []byte(fmt.Sprintf(`{"MinStake":10,"MaxBinding":10,"BackStackListMax":20,"CoinRewardsRatio":2,"StorageRewardsRatio":1,"RatioPerYear":1,"SynStateAccount":"%s"}`, signer.Hex()))},
+			common.LastSynStateSaveAddress: {Balance: new(big.Int), CodeHash: []byte(`{"LastRootStates":{},"LastSynBlockNum":0}`)},
+			common.RewardsSaveAddress:      {Balance: new(big.Int), CodeHash: []byte(`{"CoinActualRewards":0,"PreCoinActualRewards":0,"StorageActualRewards":0,"PreStorageActualRewards":0,"TotalActualRewards":0,"SurplusCoin":67500000000000000000000000,"PreSurplusCoin":0}`)},
+		},
+	}
+	db := ethdb.NewMemDatabase()
+	genesis.MustCommit(db)
+
+	newChain := func() (*core.BlockChain, *genaro.Genaro) {
+		engine := genaro.New(config.Genaro, db)
+		engine.Authorize(signer, func(account accounts.Account, hash []byte) ([]byte, error) {
+			return crypto.Sign(hash, key)
+		})
+		chain, err := core.NewBlockChain(db, nil, config, engine, vm.Config{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return chain, engine
+	}
+	txSigner := types.MakeSigner(config, big.NewInt(1))
+	extend := func(chain *core.BlockChain, engine *genaro.Genaro, until uint64) {
+		for chain.CurrentBlock().NumberU64() < until {
+			parent := chain.CurrentBlock()
+			header := &types.Header{ParentHash: parent.Hash(), Number: new(big.Int).Add(parent.Number(), common.Big1), GasLimit: parent.GasLimit(), Extra: parent.Extra()}
+			if err := engine.Prepare(chain, header); err != nil {
+				t.Fatal(err)
+			}
+			// Keep the chain in the past so that sealing does not wait
+			header.Time = new(big.Int).Add(parent.Time(), common.Big1)
+			statedb, err := chain.StateAt(parent.Root())
+			if err != nil {
+				t.Fatal(err)
+			}
+			var data []byte
+			switch number := header.Number.Uint64(); {
+			case number == 1:
+				data = []byte(fmt.Sprintf(`{"type":"0x1","address":"%x","stake":10}`, signer))
+			case number > common.SynBlockLen:
+				data = []byte(fmt.Sprintf(`{"type":"0xd","msg":"%s"}`, parent.ParentHash().Hex()))
+			}
+			var (
+				txs      []*types.Transaction
+				receipts []*types.Receipt
+			)
+			if data != nil {
+				tx, _ := types.SignTx(types.NewTransaction(statedb.GetNonce(signer), common.SpecialSyncAddress, new(big.Int), 1000000, big.NewInt(1), data), txSigner, key)
+				statedb.Prepare(tx.Hash(), common.Hash{}, 0)
+				receipt, _, err := core.ApplyTransaction(config, chain, &header.Coinbase, new(core.GasPool).AddGas(header.GasLimit), statedb, header, tx, &header.GasUsed, vm.Config{})
+				if err != nil {
+					t.Fatalf("block %d: %v", header.Number, err)
+				}
+				txs, receipts = types.Transactions{tx}, []*types.Receipt{receipt}
+			}
+			block, err := engine.Finalize(chain, header, statedb, txs, nil, receipts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if block, err = engine.Seal(chain, block, nil); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := chain.InsertChain(types.Blocks{block}); err != nil {
+				t.Fatalf("block %d: %v", block.Number(), err)
+			}
+		}
+	}
+
+	chain, engine := newChain()
+	extend(chain, engine, 301)
+
+	// A node restarted with empty caches keeps verifying and sealing
+	chain.Stop()
+	chain, engine = newChain()
+	defer chain.Stop()
+	extend(chain, engine, 450)
+}
@@ -0,0 +1,411 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+
+	"github.com/GenaroNetwork/GenaroCore/common"
+	"github.com/GenaroNetwork/GenaroCore/common/hexutil"
+	"github.com/GenaroNetwork/GenaroCore/common/math"
+	"github.com/GenaroNetwork/GenaroCore/consensus/genaro"
+	"github.com/GenaroNetwork/GenaroCore/core"
+	"github.com/GenaroNetwork/GenaroCore/core/state"
+	"github.com/GenaroNetwork/GenaroCore/core/types"
+	"github.com/GenaroNetwork/GenaroCore/core/vm"
+	"github.com/GenaroNetwork/GenaroCore/crypto"
+	"github.com/GenaroNetwork/GenaroCore/ethdb"
+	"github.com/GenaroNetwork/GenaroCore/params"
+	"github.com/GenaroNetwork/GenaroCore/rlp"
+)
+
+// GenaroTest checks the processing of Genaro special transactions.
+//
+// A test listing transactions is a state test: the transactions are applied on
+// top of the pre state without block context. A test listing blocks is a block
+// test: the blocks are imported on top of the genesis with the Genaro engine,
+// which also covers the side effects of Finalize (rewards, back stakes and the
+// committee rank in the extra data).
+type GenaroTest struct {
+	json gtJSON
+}
+
+func (t *GenaroTest) UnmarshalJSON(in []byte) error {
+	return json.Unmarshal(in, &t.json)
+}
+
+func (t GenaroTest) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.json)
+}
+
+type gtJSON struct {
+	// State tests
+	Config *params.ChainConfig              `json:"config,omitempty"`
+	Env    *stEnv                           `json:"env,omitempty"`
+	Pre    map[common.Address]GenaroAccount `json:"pre,omitempty"`
+	Txs    []gtTransaction                  `json:"transactions,omitempty"`
+	Post   *gtPostState                     `json:"post,omitempty"`
+
+	// Block tests
+	Genesis   *core.Genesis                    `json:"genesis,omitempty"`
+	Blocks    []gtBlock                        `json:"blocks,omitempty"`
+	BestBlock *common.Hash                     `json:"lastblockhash,omitempty"`
+	PostState map[common.Address]GenaroAccount `json:"postState,omitempty"`
+}
+
+// GenaroAccount is an account of a Genaro test. Unlike in a genesis, its Genaro
+// data is given as plain JSON.
+type GenaroAccount struct {
+	Balance    *math.HexOrDecimal256       `json:"balance"`
+	Nonce      math.HexOrDecimal64         `json:"nonce,omitempty"`
+	Code       hexutil.Bytes               `json:"code,omitempty"`
+	Storage    map[common.Hash]common.Hash `json:"storage,omitempty"`
+	GenaroData json.RawMessage             `json:"genaroData,omitempty"`
+}
+
+// NewGenaroAccount assembles an account of a Genaro test out of its fields in
+// the state. The code hash of an account holds its Genaro data if it is not a
+// hash.
+func NewGenaroAccount(balance *big.Int, nonce uint64, code []byte, codeHash []byte) GenaroAccount {
+	account := GenaroAccount{
+		Balance: (*math.HexOrDecimal256)(balance),
+		Nonce:   math.HexOrDecimal64(nonce),
+		Code:    code,
+	}
+	if len(codeHash) == 0 || len(codeHash) == common.HashLength {
+		return account
+	}
+	account.GenaroData = json.RawMessage(codeHash)
+	return account
+}
+
+type gtTransaction struct {
+	SecretKey hexutil.Bytes         `json:"secretKey"`
+	To        *common.Address       `json:"to,omitempty"` // common.SpecialSyncAddress if not set
+	Nonce     math.HexOrDecimal64   `json:"nonce"`
+	GasLimit  math.HexOrDecimal64   `json:"gasLimit"`
+	GasPrice  *math.HexOrDecimal256 `json:"gasPrice"`
+	Value     *math.HexOrDecimal256 `json:"value,omitempty"`
+	Data      hexutil.Bytes         `json:"data,omitempty"`
+	Input     json.RawMessage       `json:"input,omitempty"` // special transaction input, replaces data
+}
+
+type gtPostState struct {
+	Root     common.Hash                      `json:"root"` // not checked if zero
+	Logs     common.Hash                      `json:"logs"` // not checked if zero
+	Results  []gtResult                       `json:"results"`
+	Accounts map[common.Address]GenaroAccount `json:"accounts"`
+}
+
+// gtResult is the outcome of a transaction of a state test. A special
+// transaction fails with the code of its error.
+type gtResult struct {
+	Failed bool   `json:"failed,omitempty"`
+	Error  uint64 `json:"error,omitempty"`
+}
+
+type gtBlock struct {
+	Rlp           hexutil.Bytes    `json:"rlp"`
+	Hash          common.Hash      `json:"hash"`
+	StateRoot     common.Hash      `json:"stateRoot"`
+	CommitteeRank []common.Address `json:"committeeRank,omitempty"`
+}
+
+// IsBlockTest returns whether the test imports blocks.
+func (t *GenaroTest) IsBlockTest() bool {
+	return len(t.json.Blocks) > 0
+}
+
+// Run executes the test. It returns the post state, if any, to aid debugging.
+func (t *GenaroTest) Run(vmconfig vm.Config) (*state.StateDB, error) {
+	if t.IsBlockTest() {
+		return t.runBlocks(vmconfig)
+	}
+	return t.runTransactions(vmconfig)
+}
+
+func (t *GenaroTest) runTransactions(vmconfig vm.Config) (*state.StateDB, error) {
+	config, env, post := t.json.Config, t.json.Env, t.json.Post
+	if config == nil || config.Genaro == nil {
+		return nil, errors.New("missing Genaro chain config")
+	}
+	if env == nil || post == nil {
+		return nil, errors.New("missing env or post state")
+	}
+	if len(post.Results) != len(t.json.Txs) {
+		return nil, fmt.Errorf("%d results for %d transactions", len(post.Results), len(t.json.Txs))
+	}
+	statedb := MakeGenaroPreState(ethdb.NewMemDatabase(), t.json.Pre)
+	header := &types.Header{
+		Coinbase:   env.Coinbase,
+		Difficulty: env.Difficulty,
+		GasLimit:   env.GasLimit,
+		Number:     new(big.Int).SetUint64(env.Number),
+		Time:       new(big.Int).SetUint64(env.Timestamp),
+	}
+	signer := types.MakeSigner(config, header.Number)
+	gaspool := new(core.GasPool).AddGas(header.GasLimit)
+
+	for i, gtx := range t.json.Txs {
+		tx, err := gtx.toTransaction(signer)
+		if err != nil {
+			return nil, fmt.Errorf("transaction %d: %v", i, err)
+		}
+		msg, err := tx.AsMessage(signer)
+		if err != nil {
+			return nil, fmt.Errorf("transaction %d: %v", i, err)
+		}
+		statedb.Prepare(tx.Hash(), common.Hash{}, i)
+		context := core.NewEVMContext(msg, header, nil, &env.Coinbase)
+		context.GetHash = vmTestBlockHash
+		evm := vm.NewEVM(context, statedb, config, vmconfig)
+
+		var vmerr error
+		_, _, err = core.ApplyMessageWithError(evm, msg, gaspool)
+		if e, ok := err.(*core.ExecutionError); ok {
+			vmerr, err = e.Err, nil
+		}
+		if err != nil {
+			return statedb, fmt.Errorf("transaction %d: %v", i, err)
+		}
+		statedb.Finalise(true)
+		result := gtResult{Failed: vmerr != nil, Error: vm.SpecialTxErrorCode(vmerr)}
+		if result != post.Results[i] {
+			return statedb, fmt.Errorf("transaction %d result mismatch: got %+v (%v), want %+v", i, result, vmerr, post.Results[i])
+		}
+	}
+	if logs := rlpHash(statedb.Logs()); post.Logs != (common.Hash{}) && logs != post.Logs {
+		return statedb, fmt.Errorf("post state logs hash mismatch: got %x, want %x", logs, post.Logs)
+	}
+	root, _ := statedb.Commit(config.IsEIP158(header.Number))
+	if post.Root != (common.Hash{}) && root != post.Root {
+		return statedb, fmt.Errorf("post state root mismatch: got %x, want %x", root, post.Root)
+	}
+	return statedb, validateGenaroAccounts(statedb, post.Accounts)
+}
+
+func (t *GenaroTest) runBlocks(vmconfig vm.Config) (*state.StateDB, error) {
+	genesis := t.json.Genesis
+	if genesis == nil || genesis.Config == nil || genesis.Config.Genaro == nil {
+		return nil, errors.New("missing Genaro genesis")
+	}
+	db := ethdb.NewMemDatabase()
+	if _, err := genesis.Commit(db); err != nil {
+		return nil, err
+	}
+	chain, err := core.NewBlockChain(db, nil, genesis.Config, genaro.New(genesis.Config.Genaro, db), vmconfig)
+	if err != nil {
+		return nil, err
+	}
+	defer chain.Stop()
+
+	for i, b := range t.json.Blocks {
+		block := new(types.Block)
+		if err := rlp.DecodeBytes(b.Rlp, block); err != nil {
+			return nil, fmt.Errorf("block %d: RLP decoding failed: %v", i, err)
+		}
+		if block.Hash() != b.Hash || block.Root() != b.StateRoot {
+			return nil, fmt.Errorf("block %d: decoded block %x with root %x, want %x with root %x", i, block.Hash(), block.Root(), b.Hash, b.StateRoot)
+		}
+		if b.CommitteeRank != nil {
+			if rank, _ := genaro.GetHeaderCommitteeRankList(block.Header()); !reflect.DeepEqual(rank, b.CommitteeRank) {
+				return nil, fmt.Errorf("block #%v: committee rank mismatch: got %x, want %x", block.Number(), rank, b.CommitteeRank)
+			}
+		}
+		if _, err := chain.InsertChain(types.Blocks{block}); err != nil {
+			return nil, fmt.Errorf("block #%v insertion into chain failed: %v", block.Number(), err)
+		}
+	}
+	if head := chain.CurrentBlock().Hash(); t.json.BestBlock != nil && head != *t.json.BestBlock {
+		return nil, fmt.Errorf("last block hash validation mismatch: want: %x, have: %x", *t.json.BestBlock, head)
+	}
+	statedb, err := chain.State()
+	if err != nil {
+		return nil, err
+	}
+	return statedb, validateGenaroAccounts(statedb, t.json.PostState)
+}
+
+// MakeGenaroPreState creates a state containing the given accounts.
+func MakeGenaroPreState(db ethdb.Database, accounts map[common.Address]GenaroAccount) *state.StateDB {
+	sdb := state.NewDatabase(db)
+	statedb, _ := state.New(common.Hash{}, sdb)
+	for addr, a := range accounts {
+		if a.GenaroData != nil {
+			data := new(bytes.Buffer)
+			json.Compact(data, a.GenaroData)
+			statedb.SetCodeHash(addr, data.Bytes())
+		} else {
+			statedb.SetCode(addr, a.Code)
+		}
+		statedb.SetNonce(addr, uint64(a.Nonce))
+		if a.Balance != nil {
+			statedb.SetBalance(addr, (*big.Int)(a.Balance))
+		}
+		for k, v := range a.Storage {
+			statedb.SetState(addr, k, v)
+		}
+	}
+	// Commit and re-open to start with a clean state.
+	root, _ := statedb.Commit(false)
+	statedb, _ = state.New(root, sdb)
+	return statedb
+}
+
+// validateGenaroAccounts checks the given accounts against the state. The
+// Genaro data of an account is compared as JSON.
+func validateGenaroAccounts(statedb *state.StateDB, accounts map[common.Address]GenaroAccount) error {
+	for addr, want := range accounts {
+		codeHash, _ := hexutil.Decode(statedb.GetGenaroCodeHash(addr))
+		have := NewGenaroAccount(statedb.GetBalance(addr), statedb.GetNonce(addr), statedb.GetCode(addr), codeHash)
+		if want.Balance != nil && (*big.Int)(want.Balance).Cmp((*big.Int)(have.Balance)) != 0 {
+			return fmt.Errorf("account %x: balance mismatch: have %v, want %v", addr, (*big.Int)(have.Balance), (*big.Int)(want.Balance))
+		}
+		if want.Nonce != have.Nonce {
+			return fmt.Errorf("account %x: nonce mismatch: have %d, want %d", addr, have.Nonce, want.Nonce)
+		}
+		if !bytes.Equal(want.Code, have.Code) {
+			return fmt.Errorf("account %x: code mismatch: have %x, want %x", addr, have.Code, want.Code)
+		}
+		for k, v := range want.Storage {
+			if value := statedb.GetState(addr, k); value != v {
+				return fmt.Errorf("account %x: storage %x mismatch: have %x, want %x", addr, k, value, v)
+			}
+		}
+		if !jsonEqual(want.GenaroData, have.GenaroData) {
+			return fmt.Errorf("account %x: Genaro data mismatch: have %s, want %s", addr, have.GenaroData, want.GenaroData)
+		}
+	}
+	return nil
+}
+
+// jsonEqual returns whether two JSON documents hold the same values. Missing
+// documents are only equal to each other.
+func jsonEqual(a, b json.RawMessage) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	var va, vb interface{}
+	if json.Unmarshal(a, &va) != nil || json.Unmarshal(b, &vb) != nil {
+		return false
+	}
+	return reflect.DeepEqual(va, vb)
+}
+
+func (tx *gtTransaction) toTransaction(signer types.Signer) (*types.Transaction, error) {
+	key, err := crypto.ToECDSA(tx.SecretKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key: %v", err)
+	}
+	to := common.SpecialSyncAddress
+	if tx.To != nil {
+		to = *tx.To
+	}
+	data := []byte(tx.Data)
+	if tx.Input != nil {
+		buf := new(bytes.Buffer)
+		if err := json.Compact(buf, tx.Input); err != nil {
+			return nil, fmt.Errorf("invalid special transaction input: %v", err)
+		}
+		data = buf.Bytes()
+	}
+	value := new(big.Int)
+	if tx.Value != nil {
+		value = (*big.Int)(tx.Value)
+	}
+	gasPrice := new(big.Int)
+	if tx.GasPrice != nil {
+		gasPrice = (*big.Int)(tx.GasPrice)
+	}
+	return types.SignTx(types.NewTransaction(uint64(tx.Nonce), to, value, uint64(tx.GasLimit), gasPrice, data), signer, key)
+}
+
+// GenaroChainSource is a chain Genaro block tests are recorded from.
+type GenaroChainSource interface {
+	// BlockByNumber returns a block of the canonical chain.
+	BlockByNumber(number uint64) (*types.Block, error)
+
+	// Account returns an account in the state of the given block. Its storage
+	// is not recorded.
+	Account(addr common.Address, number uint64) (GenaroAccount, error)
+}
+
+// RecordGenaroBlockTest records the blocks 1 to last of a chain started from
+// genesis as a block test. The post state covers the genesis allocation, the
+// coinbases, senders and recipients of the blocks, the addresses their special
+// transactions refer to and the given accounts.
+func RecordGenaroBlockTest(src GenaroChainSource, genesis *core.Genesis, last uint64, accounts []common.Address) (*GenaroTest, error) {
+	if genesis.Config == nil || genesis.Config.Genaro == nil {
+		return nil, errors.New("missing Genaro genesis")
+	}
+	touched := make(map[common.Address]struct{})
+	for addr := range genesis.Alloc {
+		touched[addr] = struct{}{}
+	}
+	for _, addr := range accounts {
+		touched[addr] = struct{}{}
+	}
+	test := &GenaroTest{json: gtJSON{Genesis: genesis}}
+	for number := uint64(1); number <= last; number++ {
+		block, err := src.BlockByNumber(number)
+		if err != nil {
+			return nil, fmt.Errorf("block #%d: %v", number, err)
+		}
+		enc, err := rlp.EncodeToBytes(block)
+		if err != nil {
+			return nil, err
+		}
+		rank, _ := genaro.GetHeaderCommitteeRankList(block.Header())
+		test.json.Blocks = append(test.json.Blocks, gtBlock{Rlp: enc, Hash: block.Hash(), StateRoot: block.Root(), CommitteeRank: rank})
+
+		touched[block.Coinbase()] = struct{}{}
+		signer := types.MakeSigner(genesis.Config, block.Number())
+		for _, tx := range block.Transactions() {
+			from, err := types.Sender(signer, tx)
+			if err != nil {
+				return nil, fmt.Errorf("block #%d: %v", number, err)
+			}
+			touched[from] = struct{}{}
+			if tx.To() == nil {
+				continue
+			}
+			touched[*tx.To()] = struct{}{}
+			var s types.SpecialTxInput
+			if *tx.To() == common.SpecialSyncAddress && json.Unmarshal(tx.Data(), &s) == nil && common.IsHexAddress(s.Address) {
+				touched[common.HexToAddress(s.Address)] = struct{}{}
+			}
+		}
+		if number == last {
+			hash := block.Hash()
+			test.json.BestBlock = &hash
+		}
+	}
+	test.json.PostState = make(map[common.Address]GenaroAccount, len(touched))
+	for addr := range touched {
+		account, err := src.Account(addr, last)
+		if err != nil {
+			return nil, fmt.Errorf("account %x: %v", addr, err)
+		}
+		test.json.PostState[addr] = account
+	}
+	return test, nil
+}
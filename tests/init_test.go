@@ -40,6 +40,7 @@ var (
 	vmTestDir          = filepath.Join(baseDir, "VMTests")
 	rlpTestDir         = filepath.Join(baseDir, "RLPTests")
 	difficultyTestDir  = filepath.Join(baseDir, "BasicTests")
+	genaroTestDir      = filepath.Join(".", "genaro")
 )
 
 func readJson(reader io.Reader, value interface{}) error {
@@ -0,0 +1,217 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+/*
+Package sharekey delivers the keys of shared files over whisper.
+
+The sharer sends the encrypted share key to the recipient in a whisper message
+encrypted with the recipient's file share public key, and offers it on chain
+with a SynchronizeShareKey special transaction holding only the commitment of
+the delivery. The recipient acknowledges the delivery by unlocking the offer
+with the same commitment, which pays the sharer.
+*/
+package sharekey
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/GenaroNetwork/GenaroCore/common"
+	"github.com/GenaroNetwork/GenaroCore/common/hexutil"
+	"github.com/GenaroNetwork/GenaroCore/core/types"
+	"github.com/GenaroNetwork/GenaroCore/crypto"
+	"github.com/GenaroNetwork/GenaroCore/log"
+	"github.com/GenaroNetwork/GenaroCore/rlp"
+	whisper "github.com/GenaroNetwork/GenaroCore/whisper/whisperv6"
+)
+
+// Topic is the whisper topic share keys are delivered with.
+var Topic = whisper.BytesToTopic([]byte("gnsk"))
+
+var (
+	ErrInvalidPublicKey = errors.New("invalid file share public key")
+	ErrOfferMismatch    = errors.New("delivery does not match the offer")
+)
+
+// Delivery is a share key delivered off chain.
+type Delivery struct {
+	ShareKeyId string
+	Sharer     common.Address
+	Recipient  common.Address
+	ShareKey   string // share key encrypted with the recipient's file share public key
+	MailHash   string
+	MailSize   uint64
+}
+
+// Commitment returns the hash of the delivery put on chain.
+func (d *Delivery) Commitment() common.Hash {
+	enc, _ := rlp.EncodeToBytes(d)
+	return crypto.Keccak256Hash(enc)
+}
+
+// Offer returns the input of the SynchronizeShareKey special transaction the
+// sharer offers the delivery on chain with.
+func (d *Delivery) Offer(price *big.Int, expireBlock uint64) types.SpecialTxInput {
+	commitment := d.Commitment()
+	return types.SpecialTxInput{
+		Type: (*hexutil.Big)(common.SynchronizeShareKey),
+		SynchronizeShareKey: types.SynchronizeShareKey{
+			ShareKeyId:       d.ShareKeyId,
+			RecipientAddress: d.Recipient,
+			Shareprice:       (*hexutil.Big)(price),
+			ExpireBlock:      expireBlock,
+			Commitment:       &commitment,
+		},
+	}
+}
+
+// Acknowledgement returns the input of the UnlockSharedKey special transaction
+// the recipient acknowledges the delivery and pays the sharer with.
+func (d *Delivery) Acknowledgement() types.SpecialTxInput {
+	commitment := d.Commitment()
+	return types.SpecialTxInput{
+		Type: (*hexutil.Big)(common.UnlockSharedKey),
+		SynchronizeShareKey: types.SynchronizeShareKey{
+			ShareKeyId: d.ShareKeyId,
+			Commitment: &commitment,
+		},
+	}
+}
+
+// Verify checks that the delivery is the one offered on chain.
+func (d *Delivery) Verify(offer types.SynchronizeShareKey) error {
+	if offer.ShareKeyId != d.ShareKeyId || offer.FromAccount != d.Sharer || offer.RecipientAddress != d.Recipient || offer.CommitmentHash() != d.Commitment() {
+		return ErrOfferMismatch
+	}
+	return nil
+}
+
+// RecipientKey parses a file share public key published on chain.
+func RecipientKey(publicKey string) (*ecdsa.PublicKey, error) {
+	raw, err := hexutil.Decode(publicKey)
+	if err != nil {
+		return nil, ErrInvalidPublicKey
+	}
+	key := crypto.ToECDSAPub(raw)
+	if !whisper.ValidatePublicKey(key) {
+		return nil, ErrInvalidPublicKey
+	}
+	return key, nil
+}
+
+// Sender delivers share keys signed with the account key of the sharer.
+type Sender struct {
+	shh *whisper.Whisper
+	key *ecdsa.PrivateKey
+
+	TTL      uint32  // seconds the delivery lives in the whisper network
+	PoW      float64 // proof of work of the delivery
+	WorkTime uint32  // seconds spent on the proof of work at most
+}
+
+// NewSender creates a sender for the account of key.
+func NewSender(shh *whisper.Whisper, key *ecdsa.PrivateKey) *Sender {
+	return &Sender{
+		shh:      shh,
+		key:      key,
+		TTL:      whisper.DefaultTTL,
+		PoW:      whisper.DefaultMinimumPoW,
+		WorkTime: 5,
+	}
+}
+
+// Send delivers the share key to the file share public key of the recipient
+// and returns the commitment to offer it on chain with.
+func (s *Sender) Send(d *Delivery, recipientKey *ecdsa.PublicKey) (common.Hash, error) {
+	if sharer := crypto.PubkeyToAddress(s.key.PublicKey); d.Sharer != sharer {
+		return common.Hash{}, fmt.Errorf("delivery of %x sent by %x", d.Sharer, sharer)
+	}
+	payload, err := rlp.EncodeToBytes(d)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	params := &whisper.MessageParams{
+		TTL:      s.TTL,
+		Src:      s.key,
+		Dst:      recipientKey,
+		Topic:    Topic,
+		WorkTime: s.WorkTime,
+		PoW:      s.PoW,
+		Payload:  payload,
+	}
+	msg, err := whisper.NewSentMessage(params)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	envelope, err := msg.Wrap(params)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if err := s.shh.Send(envelope); err != nil {
+		return common.Hash{}, err
+	}
+	return d.Commitment(), nil
+}
+
+// Receiver collects the share keys delivered to a file share key pair.
+type Receiver struct {
+	shh      *whisper.Whisper
+	filterID string
+}
+
+// NewReceiver starts collecting the share keys delivered to the public key
+// of key.
+func NewReceiver(shh *whisper.Whisper, key *ecdsa.PrivateKey) (*Receiver, error) {
+	filterID, err := shh.Subscribe(&whisper.Filter{
+		KeyAsym:  key,
+		Topics:   [][]byte{Topic[:]},
+		AllowP2P: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Receiver{shh: shh, filterID: filterID}, nil
+}
+
+// Deliveries returns the share keys delivered since the last call. Messages
+// that are not deliveries signed by their sharer are dropped.
+func (r *Receiver) Deliveries() []*Delivery {
+	filter := r.shh.GetFilter(r.filterID)
+	if filter == nil {
+		return nil
+	}
+	var deliveries []*Delivery
+	for _, msg := range filter.Retrieve() {
+		d := new(Delivery)
+		if err := rlp.DecodeBytes(msg.Payload, d); err != nil {
+			log.Debug("Dropped invalid share key delivery", "envelope", msg.EnvelopeHash, "err", err)
+			continue
+		}
+		if msg.Src == nil || crypto.PubkeyToAddress(*msg.Src) != d.Sharer {
+			log.Debug("Dropped share key delivery not signed by its sharer", "envelope", msg.EnvelopeHash, "sharer", d.Sharer)
+			continue
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries
+}
+
+// Close stops collecting share keys.
+func (r *Receiver) Close() error {
+	return r.shh.Unsubscribe(r.filterID)
+}
@@ -0,0 +1,102 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package sharekey
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/GenaroNetwork/GenaroCore/common/hexutil"
+	"github.com/GenaroNetwork/GenaroCore/crypto"
+	whisper "github.com/GenaroNetwork/GenaroCore/whisper/whisperv6"
+)
+
+func TestDelivery(t *testing.T) {
+	shh := whisper.New(&whisper.DefaultConfig)
+	shh.SetMinimumPowTest(0.0000001)
+	defer shh.SetMinimumPowTest(whisper.DefaultMinimumPoW)
+	shh.Start(nil)
+	defer shh.Stop()
+
+	sharerKey, _ := crypto.GenerateKey()
+	forgerKey, _ := crypto.GenerateKey()
+	recipientKey, _ := crypto.GenerateKey()
+	publicKey, err := RecipientKey(hexutil.Encode(crypto.FromECDSAPub(&recipientKey.PublicKey)))
+	if err != nil {
+		t.Fatalf("failed to parse recipient key: %v", err)
+	}
+	if _, err := RecipientKey("0x1234"); err != ErrInvalidPublicKey {
+		t.Errorf("invalid public key: have %v, want %v", err, ErrInvalidPublicKey)
+	}
+
+	receiver, err := NewReceiver(shh, recipientKey)
+	if err != nil {
+		t.Fatalf("failed to create receiver: %v", err)
+	}
+	defer receiver.Close()
+
+	delivery := &Delivery{
+		ShareKeyId: "share-1",
+		Sharer:     crypto.PubkeyToAddress(sharerKey.PublicKey),
+		Recipient:  crypto.PubkeyToAddress(recipientKey.PublicKey),
+		ShareKey:   "encrypted share key",
+		MailHash:   "mail hash",
+		MailSize:   42,
+	}
+	sender := NewSender(shh, sharerKey)
+	sender.PoW = 0.0000001
+	sender.WorkTime = 1
+	if _, err := sender.Send(delivery, publicKey); err != nil {
+		t.Fatalf("failed to send delivery: %v", err)
+	}
+	// deliveries in the name of another sharer are dropped
+	forger := NewSender(shh, forgerKey)
+	forger.PoW = 0.0000001
+	forger.WorkTime = 1
+	if _, err := forger.Send(delivery, publicKey); err == nil {
+		t.Errorf("forged delivery sent")
+	}
+
+	var received []*Delivery
+	for deadline := time.Now().Add(5 * time.Second); len(received) == 0 && time.Now().Before(deadline); {
+		time.Sleep(50 * time.Millisecond)
+		received = receiver.Deliveries()
+	}
+	if len(received) != 1 || !reflect.DeepEqual(received[0], delivery) {
+		t.Fatalf("received deliveries mismatch: have %v, want %v", received, delivery)
+	}
+
+	// only the commitment of the delivery goes on chain
+	offer := delivery.Offer(big.NewInt(10), 100).SynchronizeShareKey
+	if offer.ShareKey != "" || offer.MailHash != "" || offer.MailSize != 0 || offer.CommitmentHash() != delivery.Commitment() {
+		t.Fatalf("offer leaks the delivery: %+v", offer)
+	}
+	offer.FromAccount = delivery.Sharer
+	if err := received[0].Verify(offer); err != nil {
+		t.Errorf("failed to verify delivery: %v", err)
+	}
+	tampered := *received[0]
+	tampered.ShareKey = "another share key"
+	if err := tampered.Verify(offer); err != ErrOfferMismatch {
+		t.Errorf("tampered delivery: have %v, want %v", err, ErrOfferMismatch)
+	}
+	if ack := received[0].Acknowledgement().SynchronizeShareKey; ack.CommitmentHash() != offer.CommitmentHash() || ack.ShareKeyId != offer.ShareKeyId {
+		t.Errorf("acknowledgement mismatch: %+v", ack)
+	}
+}